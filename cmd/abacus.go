@@ -25,6 +25,22 @@ var abacusCmd = &cobra.Command{
 			msg.InputNotFound(errors.New("the combined analysis needs at least 2 result files to work"), "fatal")
 		}
 
+		switch m.Abacus.Normalize {
+		case "median", "quantile", "total", "none":
+		default:
+			msg.Custom(errors.New("the normalize option must be median, quantile, total or none"), "fatal")
+		}
+
+		switch m.Abacus.Impute {
+		case "minprob", "knn", "none":
+		default:
+			msg.Custom(errors.New("the impute option must be minprob, knn or none"), "fatal")
+		}
+
+		if m.Abacus.IRS && !m.Abacus.Labels {
+			msg.Custom(errors.New("the internal reference scaling requires labeled data sets (--labels)"), "fatal")
+		}
+
 		msg.Executing("Abacus", Version)
 		aba.Run(m, args)
 
@@ -54,8 +70,14 @@ func init() {
 		abacusCmd.Flags().BoolVarP(&m.Abacus.Picked, "picked", "", false, "apply the picked FDR algorithm before the protein scoring")
 		abacusCmd.Flags().BoolVarP(&m.Abacus.Unique, "uniqueonly", "", false, "report TMT quantification based on only unique peptides")
 		abacusCmd.Flags().BoolVarP(&m.Abacus.Labels, "labels", "", false, "indicates whether the data sets includes TMT labels or not")
+		abacusCmd.Flags().BoolVarP(&m.Abacus.IRS, "irs", "", false, "scale the channels across data sets with the internal reference scaling of the reference channels marked on the annotation files")
 		abacusCmd.Flags().BoolVarP(&m.Abacus.Reprint, "reprint", "", false, "create abacus reports using the Reprint format")
 		abacusCmd.Flags().BoolVarP(&m.Abacus.Full, "full", "", false, "generates combined tables with extra information")
+		abacusCmd.Flags().StringVarP(&m.Abacus.Normalize, "normalize", "", "none", "normalization of the combined unique+razor and MaxLFQ protein intensities (median, quantile, total, none)")
+		abacusCmd.Flags().StringVarP(&m.Abacus.Impute, "impute", "", "none", "imputation of the missing combined unique+razor and MaxLFQ protein intensities (minprob, knn, none)")
+		abacusCmd.Flags().BoolVarP(&m.Abacus.MBR, "mbr", "", false, "transfer label-free identifications between data sets (match-between-runs)")
+		abacusCmd.Flags().Float64VarP(&m.Abacus.MBRFDR, "mbrfdr", "", 0.01, "FDR threshold for the match-between-runs transfers")
+		abacusCmd.Flags().Float64VarP(&m.Abacus.MBRWin, "mbrwin", "", 1, "retention time window for the match-between-runs transfers, in minutes")
 	}
 
 	RootCmd.AddCommand(abacusCmd)
@@ -0,0 +1,60 @@
+// Package cmd Align top level command
+package cmd
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"philosopher/lib/met"
+	"philosopher/lib/msg"
+	"philosopher/lib/rta"
+	"philosopher/lib/sys"
+
+	"github.com/spf13/cobra"
+)
+
+// alignCmd represents the align command
+var alignCmd = &cobra.Command{
+	Use:   "align",
+	Short: "Retention time alignment between runs",
+	Run: func(cmd *cobra.Command, args []string) {
+
+		m.FunctionInitCheckUp()
+
+		msg.Executing("Retention time alignment ", Version)
+
+		m.Align.Method = strings.ToLower(m.Align.Method)
+		if m.Align.Method != rta.Loess && m.Align.Method != rta.Linear {
+			msg.Custom(errors.New("the method option must be loess or linear"), "fatal")
+		}
+
+		if m.Align.Span <= 0 || m.Align.Span > 1 {
+			msg.Custom(errors.New("the LOESS span must be between 0 and 1"), "fatal")
+		}
+
+		m := rta.Run(m)
+
+		m.Serialize()
+
+		// clean tmp
+		met.CleanTemp(m.Temp)
+
+		msg.Done()
+	},
+}
+
+func init() {
+
+	if len(os.Args) > 1 && os.Args[1] == "align" {
+
+		m.Restore(sys.Meta())
+
+		alignCmd.Flags().StringVarP(&m.Align.Method, "method", "", "loess", "alignment model (loess, linear)")
+		alignCmd.Flags().StringVarP(&m.Align.Reference, "reference", "", "", "name of the reference run (default: the run with the most confident ions)")
+		alignCmd.Flags().Float64VarP(&m.Align.MinProb, "prob", "", 0.99, "minimum ion probability for the ions used in the alignment")
+		alignCmd.Flags().Float64VarP(&m.Align.Span, "span", "", 0.3, "fraction of the ions used by each LOESS local regression")
+	}
+
+	RootCmd.AddCommand(alignCmd)
+}
@@ -0,0 +1,74 @@
+// Package cmd Diaquant top level command
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"philosopher/lib/met"
+	"philosopher/lib/msg"
+	"philosopher/lib/qua"
+	"philosopher/lib/sys"
+
+	"github.com/spf13/cobra"
+)
+
+// diaquantCmd represents the diaquant command
+var diaquantCmd = &cobra.Command{
+	Use:   "diaquant",
+	Short: "DIA fragment-level quantification",
+	Run: func(cmd *cobra.Command, args []string) {
+
+		m.FunctionInitCheckUp()
+
+		m.Quantify.Format = "mzML"
+		if len(m.Quantify.Dir) < 1 {
+			msg.InputNotFound(errors.New("you need to provide the path to the mz files and the correct extension"), "fatal")
+		}
+
+		if m.Quantify.Fragments < 3 {
+			msg.Custom(errors.New("at least 3 fragments are needed to score the co-elution"), "fatal")
+		}
+
+		if len(m.Quantify.Library) > 0 {
+			if _, e := os.Stat(m.Quantify.Library); os.IsNotExist(e) {
+				msg.InputNotFound(errors.New("cannot find the spectral library"), "fatal")
+			}
+		}
+
+		msg.Executing("DIA quantification ", Version)
+
+		//forcing the larger time window to be the same as the smaller one
+		m.Quantify.RTWin = m.Quantify.PTWin
+
+		qua.RunDIAQuantification(m.Quantify)
+
+		// store parameters on meta data
+		m.Serialize()
+
+		// clean tmp
+		met.CleanTemp(m.Temp)
+
+		msg.Done()
+	},
+}
+
+func init() {
+
+	if len(os.Args) > 1 && os.Args[1] == "diaquant" {
+
+		m.Restore(sys.Meta())
+
+		diaquantCmd.Flags().StringVarP(&m.Quantify.Dir, "dir", "", "", "folder path containing the raw files")
+		diaquantCmd.Flags().StringVarP(&m.Quantify.Library, "library", "", "", "tab-separated spectral library with the precursor fragments (default: the fragments of the identifying spectra)")
+		diaquantCmd.Flags().IntVarP(&m.Quantify.Fragments, "fragments", "", 6, "number of top fragments traced for each precursor")
+		diaquantCmd.Flags().Float64VarP(&m.Quantify.MinFragCor, "fragcorr", "", 0.6, "minimum correlation of a fragment with the summed fragment trace, lower fragments are left out of the precursor intensity")
+		diaquantCmd.Flags().Float64VarP(&m.Quantify.Tol, "tol", "", 20, "fragment m/z tolerance in ppm")
+		diaquantCmd.Flags().Float64VarP(&m.Quantify.PTWin, "ptw", "", 0.4, "specify the time windows for the peak (minute)")
+		diaquantCmd.Flags().BoolVarP(&m.Quantify.Raw, "raw", "", false, "read raw files instead of converted XML")
+		diaquantCmd.Flags().BoolVarP(&m.Quantify.Faims, "faims", "", false, "Use FAIMS information for the quantification")
+		diaquantCmd.Flags().Float64VarP(&m.Quantify.IMTol, "imtol", "", 0, "ion mobility (1/K0) tolerance, restricts the traced fragments to the precursor ion mobility (0 disables it)")
+	}
+
+	RootCmd.AddCommand(diaquantCmd)
+}
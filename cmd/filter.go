@@ -4,6 +4,7 @@ package cmd
 import (
 	"errors"
 	"os"
+	"strings"
 
 	"philosopher/lib/fil"
 	"philosopher/lib/met"
@@ -28,11 +29,31 @@ var filterCmd = &cobra.Command{
 		os.RemoveAll(sys.PepBin())
 		os.RemoveAll(sys.IonBin())
 		os.RemoveAll(sys.ProBin())
-		os.RemoveAll(sys.PepxmlBin())
+
+		// the rescored identifications are kept in the workspace
+		if m.Filter.Rescored {
+			if _, e := os.Stat(sys.PepxmlBin()); os.IsNotExist(e) {
+				msg.InputNotFound(errors.New("no rescored identifications found, run the rescore command first"), "fatal")
+			}
+		} else {
+			os.RemoveAll(sys.PepxmlBin())
+		}
 
 		// check file existence
-		if len(m.Filter.Pex) < 1 {
-			msg.InputNotFound(errors.New("you must provide a pepXML file or a folder with one or more files, Run 'philosopher filter --help' for more information"), "fatal")
+		if len(m.Filter.Pex) < 1 && len(m.Filter.Mzid) < 1 && !m.Filter.Rescored {
+			msg.InputNotFound(errors.New("you must provide a pepXML or mzIdentML file or a folder with one or more files, Run 'philosopher filter --help' for more information"), "fatal")
+		}
+
+		if len(m.Filter.Pex) > 0 && len(m.Filter.Mzid) > 0 {
+			msg.Custom(errors.New("the pepXML and mzIdentML inputs cannot be used together"), "fatal")
+		}
+
+		if len(m.Filter.Score) > 0 && !strings.EqualFold(m.Filter.Score, "hyperscore") && !strings.EqualFold(m.Filter.Score, "expect") && !strings.EqualFold(m.Filter.Score, "xcorr") && !strings.EqualFold(m.Filter.Score, "custom") {
+			msg.Custom(errors.New("the score option must be one of hyperscore, expect, xcorr or custom"), "fatal")
+		}
+
+		if strings.EqualFold(m.Filter.Score, "custom") && len(m.Filter.ScoreName) == 0 {
+			msg.Custom(errors.New("the custom score requires the name of a pepXML or mzIdentML search score, use the --scorename parameter"), "fatal")
 		}
 
 		if len(m.Filter.Pox) == 0 && m.Filter.Razor {
@@ -57,11 +78,14 @@ func init() {
 
 		m.Restore(sys.Meta())
 
-		filterCmd.Flags().StringVarP(&m.Filter.Pex, "pepxml", "", "", "pepXML file or directory containing a set of pepXML files")
+		filterCmd.Flags().StringVarP(&m.Filter.Pex, "pepxml", "", "", "pepXML, MSFragger tsv, Comet txt or Percolator pin file, or a directory containing a set of them")
+		filterCmd.Flags().StringVarP(&m.Filter.Mzid, "mzid", "", "", "mzIdentML file or directory containing a set of mzIdentML files")
 		filterCmd.Flags().StringVarP(&m.Filter.Pox, "protxml", "", "", "protXML file path")
 		filterCmd.Flags().StringVarP(&m.Filter.Tag, "tag", "", "rev_", "decoy tag")
 		filterCmd.Flags().StringVarP(&m.Filter.Mods, "mods", "", "", "list of modifications for a stratified FDR filtering")
 		filterCmd.Flags().StringVarP(&m.Filter.RazorBin, "razorbin", "", "", "use a custom razor assignment for the filtering")
+		filterCmd.Flags().StringVarP(&m.Filter.Score, "score", "", "", "rank PSMs by a search engine score using target-decoy competition instead of probabilities (hyperscore, expect, xcorr, custom)")
+		filterCmd.Flags().StringVarP(&m.Filter.ScoreName, "scorename", "", "", "name of the pepXML or mzIdentML search score used by the custom score, higher values are better")
 		filterCmd.Flags().Float64VarP(&m.Filter.IonFDR, "ion", "", 0.01, "peptide ion FDR level")
 		filterCmd.Flags().Float64VarP(&m.Filter.PepFDR, "pep", "", 0.01, "peptide FDR level")
 		filterCmd.Flags().Float64VarP(&m.Filter.PsmFDR, "psm", "", 0.01, "psm FDR level")
@@ -76,6 +100,7 @@ func init() {
 		filterCmd.Flags().BoolVarP(&m.Filter.Razor, "razor", "", false, "use razor peptides for protein FDR scoring")
 		filterCmd.Flags().BoolVarP(&m.Filter.Picked, "picked", "", false, "apply the picked FDR algorithm before the protein scoring")
 		filterCmd.Flags().BoolVarP(&m.Filter.Mapmods, "mapmods", "", false, "map modifications")
+		filterCmd.Flags().BoolVarP(&m.Filter.Rescored, "rescored", "", false, "use the identifications rescored by the rescore command")
 		filterCmd.Flags().BoolVarP(&m.Filter.Inference, "inference", "", false, "extremely fast and efficient protein inference compatible with 2D and Sequential filters")
 		filterCmd.Flags().MarkHidden("mods")
 		filterCmd.Flags().MarkHidden("delta")
@@ -38,8 +38,9 @@ var freequant = &cobra.Command{
 			msg.InputNotFound(errors.New("unknown file format"), "fatal")
 		}
 
-		if m.Quantify.Raw {
-			msg.Custom(errors.New("support for Thermo raw files was temporarily removed, please convert your files to mzML"), "fatal")
+		if m.Quantify.Isotopes > 0 && m.Quantify.Isotopes < 3 {
+			msg.Custom(errors.New("at least 3 isotopic peaks are needed to score the isotope pattern, tracing 3"), "warning")
+			m.Quantify.Isotopes = 3
 		}
 
 		//forcing the larger time window to be the same as the smaller one
@@ -70,6 +71,9 @@ func init() {
 		freequant.Flags().Float64VarP(&m.Quantify.PTWin, "ptw", "", 0.4, "specify the time windows for the peak (minute)")
 		freequant.Flags().BoolVarP(&m.Quantify.Raw, "raw", "", false, "read raw files instead of converted XML")
 		freequant.Flags().BoolVarP(&m.Quantify.Faims, "faims", "", false, "Use FAIMS information for the quantification")
+		freequant.Flags().Float64VarP(&m.Quantify.IMTol, "imtol", "", 0, "ion mobility (1/K0) tolerance, restricts the traced peaks to the PSM ion mobility (0 disables it)")
+		freequant.Flags().IntVarP(&m.Quantify.Isotopes, "isotopes", "", 0, "number of isotopic peaks traced for each ion and scored against the theoretical isotope pattern (0 traces only the monoisotopic peak)")
+		freequant.Flags().Float64VarP(&m.Quantify.MinIsoCorr, "isocorr", "", 0.8, "minimum isotope pattern correlation, features below it are flagged as interfered")
 	}
 
 	RootCmd.AddCommand(freequant)
@@ -28,11 +28,19 @@ var labelquantCmd = &cobra.Command{
 			msg.InputNotFound(errors.New("you need to provide the path to the mz files and the correct extension"), "fatal")
 		}
 
-		if len(m.Quantify.Plex) < 1 {
+		if m.Quantify.MS1 && len(m.Quantify.Labels) < 1 {
+			msg.InputNotFound(errors.New("you need to specify the MS1 label scheme"), "fatal")
+		}
+
+		if !m.Quantify.MS1 && len(m.Quantify.Plex) < 1 {
 			msg.InputNotFound(errors.New("you need to specify the experiment Plex"), "fatal")
 		}
 
-		msg.Executing("Isobaric-label quantification ", Version)
+		if m.Quantify.MS1 {
+			msg.Executing("MS1 label quantification ", Version)
+		} else {
+			msg.Executing("Isobaric-label quantification ", Version)
+		}
 
 		if strings.EqualFold(strings.ToLower(m.Quantify.Format), "mzml") {
 			m.Quantify.Format = "mzML"
@@ -43,12 +51,12 @@ var labelquantCmd = &cobra.Command{
 			msg.InputNotFound(errors.New("unknown file format"), "fatal")
 		}
 
-		if m.Quantify.Raw {
-			msg.Custom(errors.New("support for Thermo raw files was temporarily removed, please convert your files to mzML"), "fatal")
+		if m.Quantify.MS1 {
+			m.Quantify = qua.RunMS1LabelQuantification(m.Quantify)
+		} else {
+			m.Quantify = qua.RunIsobaricLabelQuantification(m.Quantify, m.Filter.Mapmods)
 		}
 
-		m.Quantify = qua.RunIsobaricLabelQuantification(m.Quantify, m.Filter.Mapmods)
-
 		// store parameters on meta data
 		m.Serialize()
 
@@ -65,7 +73,8 @@ func init() {
 
 		m.Restore(sys.Meta())
 
-		labelquantCmd.Flags().StringVarP(&m.Quantify.Annot, "annot", "", "", "annotation file with custom names for the TMT channels")
+		labelquantCmd.Flags().StringVarP(&m.Quantify.Annot, "annot", "", "", "annotation file with custom names for the TMT channels, a third column (reference, bridge or pool) marks the reference channels")
+		labelquantCmd.Flags().StringVarP(&m.Quantify.Impurity, "impurity", "", "", "reagent impurity table with the -2, -1, +1 and +2 percentages of each channel")
 		labelquantCmd.Flags().StringVarP(&m.Quantify.Plex, "plex", "", "", "number of reporter ion channels")
 		labelquantCmd.Flags().StringVarP(&m.Quantify.Dir, "dir", "", "", "folder path containing the raw files")
 		labelquantCmd.Flags().StringVarP(&m.Quantify.Brand, "brand", "", "", "isobaric labeling brand (tmt, itraq)")
@@ -77,6 +86,13 @@ func init() {
 		labelquantCmd.Flags().BoolVarP(&m.Quantify.Unique, "uniqueonly", "", false, "report quantification based only on unique peptides")
 		labelquantCmd.Flags().BoolVarP(&m.Quantify.BestPSM, "bestpsm", "", false, "select the best PSMs for protein quantification")
 		labelquantCmd.Flags().BoolVarP(&m.Quantify.Raw, "raw", "", false, "read raw files instead of converted XML")
+		labelquantCmd.Flags().BoolVarP(&m.Quantify.MS1, "ms1", "", false, "quantify the SILAC or dimethyl label partners on the MS1 spectra")
+		labelquantCmd.Flags().StringVarP(&m.Quantify.Labels, "labels", "", "", "MS1 label scheme (silac-KR8, silac-KR4-KR8, dimethyl-LH, dimethyl-LMH)")
+		labelquantCmd.Flags().StringVarP(&m.Quantify.ChanNorm, "norm", "", "", "channel normalization of all levels (none, total, reference, mean, median, log2-median), by default only the unique+razor protein intensities are normalized to the total")
+		labelquantCmd.Flags().StringVarP(&m.Quantify.Sites, "sites", "", "", "comma separated list of localized modifications quantified at the site level, given as UniMod names or mass differences (e.g. Phospho,Acetyl,114.0429)")
+		labelquantCmd.Flags().Float64VarP(&m.Quantify.SiteProb, "siteprob", "", 0.75, "minimum localization probability of the quantified modification sites")
+		labelquantCmd.Flags().Float64VarP(&m.Quantify.PTWin, "ptw", "", 0.4, "specify the time windows for the MS1 label peaks (minute)")
+		labelquantCmd.Flags().Float64VarP(&m.Quantify.RTWin, "rtw", "", 3, "specify the retention time window for the MS1 label XICs (minute)")
 
 	}
 
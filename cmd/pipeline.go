@@ -103,6 +103,11 @@ var pipelineCmd = &cobra.Command{
 			meta = pip.CombinedProteinList(meta, p, dir, args)
 		}
 
+		// Rescore
+		if p.Steps.PSMRescoring == "yes" {
+			meta = pip.Rescore(meta, p, dir, args)
+		}
+
 		// Filter
 		if p.Steps.FDRFiltering == "yes" {
 			meta = pip.Filter(meta, p, dir, args)
@@ -118,6 +123,16 @@ var pipelineCmd = &cobra.Command{
 			meta = pip.LabelQuant(meta, p, dir, args)
 		}
 
+		// DIAQuant
+		if p.Steps.DIAQuantification == "yes" {
+			meta = pip.DIAQuant(meta, p, dir, args)
+		}
+
+		// Align
+		if p.Steps.RetentionTimeAlignment == "yes" {
+			meta = pip.Align(meta, p, dir, args)
+		}
+
 		// Report
 		if p.Steps.IndividualReports == "yes" {
 			meta = pip.Report(meta, p, dir, args)
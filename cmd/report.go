@@ -6,6 +6,7 @@ import (
 
 	"philosopher/lib/met"
 	"philosopher/lib/msg"
+	"philosopher/lib/qua"
 	"philosopher/lib/rep"
 	"philosopher/lib/sys"
 
@@ -22,6 +23,10 @@ var reportCmd = &cobra.Command{
 
 		msg.Executing("Report ", Version)
 
+		if len(m.Filter.Pox) > 0 || m.Filter.Inference {
+			qua.RunSpectralAbundances()
+		}
+
 		rep.Run(m)
 
 		// store parameters on meta data
@@ -44,6 +49,7 @@ func init() {
 		reportCmd.Flags().BoolVarP(&m.Report.RemoveContam, "removecontam", "", false, "remove contaminant sequences from the reports")
 		reportCmd.Flags().BoolVarP(&m.Report.MSstats, "msstats", "", false, "create an output compatible with MSstats")
 		reportCmd.Flags().BoolVarP(&m.Report.MZID, "mzid", "", false, "create a mzID output")
+		reportCmd.Flags().BoolVarP(&m.Report.MzTab, "mztab", "", false, "create a mzTab output")
 		reportCmd.Flags().BoolVarP(&m.Report.IonMob, "ionmobility", "", false, "forces the printing of the ion mobility column")
 		reportCmd.Flags().BoolVarP(&m.Report.Prefix, "prefix", "", false, "add the project (folder) name as a prefix to the output files")
 	}
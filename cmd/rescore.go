@@ -0,0 +1,67 @@
+// Package cmd Rescore top level command
+package cmd
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"philosopher/lib/met"
+	"philosopher/lib/msg"
+	"philosopher/lib/rsc"
+	"philosopher/lib/sys"
+
+	"github.com/spf13/cobra"
+)
+
+// rescoreCmd represents the rescore command
+var rescoreCmd = &cobra.Command{
+	Use:   "rescore",
+	Short: "Semi-supervised rescoring of the peptide-spectrum matches",
+	Run: func(cmd *cobra.Command, args []string) {
+
+		m.FunctionInitCheckUp()
+
+		msg.Executing("Rescore ", Version)
+
+		if len(m.Rescore.Pex) < 1 {
+			msg.InputNotFound(errors.New("you must provide an identification file or a folder with one or more files, Run 'philosopher rescore --help' for more information"), "fatal")
+		}
+
+		if !strings.EqualFold(m.Rescore.Model, "svm") && !strings.EqualFold(m.Rescore.Model, "logistic") {
+			msg.Custom(errors.New("the model option must be svm or logistic"), "fatal")
+		}
+
+		if m.Rescore.Folds < 2 {
+			msg.Custom(errors.New("the cross-validation requires at least 2 folds"), "fatal")
+		}
+
+		os.RemoveAll(sys.PepxmlBin())
+
+		m := rsc.Run(m)
+
+		m.Serialize()
+
+		// clean tmp
+		met.CleanTemp(m.Temp)
+
+		msg.Done()
+	},
+}
+
+func init() {
+
+	if len(os.Args) > 1 && os.Args[1] == "rescore" {
+
+		m.Restore(sys.Meta())
+
+		rescoreCmd.Flags().StringVarP(&m.Rescore.Pex, "pepxml", "", "", "pepXML, mzIdentML, MSFragger tsv, Comet txt or Percolator pin file, or a directory containing a set of them")
+		rescoreCmd.Flags().StringVarP(&m.Rescore.Tag, "tag", "", "rev_", "decoy tag")
+		rescoreCmd.Flags().StringVarP(&m.Rescore.Model, "model", "", "svm", "discriminant model (svm, logistic)")
+		rescoreCmd.Flags().Float64VarP(&m.Rescore.FDR, "fdr", "", 0.01, "FDR level used for selecting the positive training set")
+		rescoreCmd.Flags().IntVarP(&m.Rescore.Folds, "folds", "", 3, "number of cross-validation folds")
+		rescoreCmd.Flags().IntVarP(&m.Rescore.Iterations, "iterations", "", 10, "number of training iterations")
+	}
+
+	RootCmd.AddCommand(rescoreCmd)
+}
@@ -0,0 +1,204 @@
+// Package aba (Abacus), internal reference scaling
+package aba
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"philosopher/lib/iso"
+	"philosopher/lib/msg"
+	"philosopher/lib/rep"
+	"philosopher/lib/sys"
+	"philosopher/lib/uti"
+
+	"github.com/sirupsen/logrus"
+)
+
+// irsFactors keeps the scaling factors of a protein or peptide for the diagnostics report
+type irsFactors struct {
+	name    string
+	id      string
+	gene    string
+	factors map[string]float64
+}
+
+// checkReferenceChannels verifies that every data set has reference channels marked on its annotation file
+func checkReferenceChannels(references map[string][]string, namesList []string) {
+
+	for _, i := range namesList {
+		if len(references[i]) == 0 {
+			msg.Custom(errors.New("the data set "+i+" has no reference channels, mark the bridge channels on the third column of the annotation file"), "fatal")
+		}
+	}
+}
+
+// irsScale applies the Internal Reference Scaling to the channels of each data set, the
+// channels are scaled so that the reference channels match the geometric mean of the
+// references across data sets. Data sets without reference intensity are reported as missing,
+// their unscaled channels cannot be compared with the scaled ones.
+func irsScale(labels map[string]iso.Labels, references map[string][]string) (map[string]iso.Labels, map[string]float64) {
+
+	var referenceIntensity = make(map[string]float64)
+	var logSum float64
+
+	for k, v := range labels {
+
+		var isReference = make(map[string]struct{})
+		for _, i := range references[k] {
+			isReference[i] = struct{}{}
+		}
+
+		var sum float64
+		var n int
+		for _, i := range v.Channels {
+			if _, ok := isReference[i.Name]; ok {
+				sum += i.Intensity
+				n++
+			}
+		}
+
+		if n > 0 && sum > 0 {
+			referenceIntensity[k] = sum / float64(n)
+			logSum += math.Log(referenceIntensity[k])
+		}
+	}
+
+	var scaled = make(map[string]iso.Labels)
+	var factors = make(map[string]float64)
+
+	if len(referenceIntensity) == 0 {
+		return scaled, factors
+	}
+
+	geometricMean := math.Exp(logSum / float64(len(referenceIntensity)))
+
+	for k, v := range referenceIntensity {
+
+		factors[k] = geometricMean / v
+
+		l := labels[k]
+		l.Channels = append([]iso.Channel{}, l.Channels...)
+		for i := range l.Channels {
+			l.Channels[i].Intensity *= factors[k]
+		}
+
+		scaled[k] = l
+	}
+
+	return scaled, factors
+}
+
+// irsProteinScaling scales the combined protein labels and keeps the unique+razor factors
+func irsProteinScaling(combined rep.CombinedProteinEvidenceList, references map[string][]string) rep.CombinedProteinEvidenceList {
+
+	for i := range combined {
+		combined[i].TotalLabels, _ = irsScale(combined[i].TotalLabels, references)
+		combined[i].UniqueLabels, _ = irsScale(combined[i].UniqueLabels, references)
+		combined[i].URazorLabels, combined[i].IRSFactors = irsScale(combined[i].URazorLabels, references)
+	}
+
+	return combined
+}
+
+// irsPeptideScaling scales the combined peptide labels
+func irsPeptideScaling(combined rep.CombinedPeptideEvidenceList, references map[string][]string) rep.CombinedPeptideEvidenceList {
+
+	for i := range combined {
+		combined[i].Labels, combined[i].IRSFactors = irsScale(combined[i].Labels, references)
+	}
+
+	return combined
+}
+
+// saveIRSDiagnostics writes the scaling factors of each protein or peptide, and a per data set summary
+func saveIRSDiagnostics(session, level string, rows []irsFactors, namesList []string, references map[string][]string) {
+
+	output := fmt.Sprintf("%s%scombined_%s_irs.tsv", session, string(filepath.Separator), level)
+
+	file, e := os.Create(output)
+	if e != nil {
+		msg.WriteFile(e, "fatal")
+	}
+	defer file.Close()
+
+	header := "Name\tID\tGene"
+	for _, i := range namesList {
+		header += fmt.Sprintf("\t%s IRS Factor", i)
+	}
+	header += "\n"
+
+	_, e = io.WriteString(file, header)
+	if e != nil {
+		msg.WriteToFile(e, "fatal")
+	}
+
+	var perDataSet = make(map[string][]float64)
+
+	for _, i := range rows {
+
+		if len(i.factors) == 0 {
+			continue
+		}
+
+		line := fmt.Sprintf("%s\t%s\t%s", i.name, i.id, i.gene)
+		for _, j := range namesList {
+			v, ok := i.factors[j]
+			if ok {
+				line += fmt.Sprintf("\t%.4f", v)
+				perDataSet[j] = append(perDataSet[j], v)
+			} else {
+				line += "\t"
+			}
+		}
+		line += "\n"
+
+		_, e = io.WriteString(file, line)
+		if e != nil {
+			msg.WriteToFile(e, "fatal")
+		}
+	}
+
+	sys.CopyFile(output, filepath.Base(output))
+
+	summary := fmt.Sprintf("%s%scombined_%s_irs_summary.tsv", session, string(filepath.Separator), level)
+
+	sfile, e := os.Create(summary)
+	if e != nil {
+		msg.WriteFile(e, "fatal")
+	}
+	defer sfile.Close()
+
+	_, e = io.WriteString(sfile, "Data Set\tReference Channels\tScaled\tMedian Factor\tMinimum Factor\tMaximum Factor\n")
+	if e != nil {
+		msg.WriteToFile(e, "fatal")
+	}
+
+	for _, i := range namesList {
+
+		factors := perDataSet[i]
+		sort.Float64s(factors)
+
+		var med, low, high float64
+		if len(factors) > 0 {
+			med = uti.Median(factors)
+			low = factors[0]
+			high = factors[len(factors)-1]
+		}
+
+		logrus.Info("IRS ", level, " factors for ", i, ": ", len(factors), " scaled, median ", fmt.Sprintf("%.4f", med))
+
+		line := fmt.Sprintf("%s\t%s\t%d\t%.4f\t%.4f\t%.4f\n", i, strings.Join(references[i], ", "), len(factors), med, low, high)
+		_, e = io.WriteString(sfile, line)
+		if e != nil {
+			msg.WriteToFile(e, "fatal")
+		}
+	}
+
+	sys.CopyFile(summary, filepath.Base(summary))
+}
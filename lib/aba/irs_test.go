@@ -0,0 +1,51 @@
+package aba
+
+import (
+	"math"
+	"testing"
+
+	"philosopher/lib/iso"
+)
+
+func Test_irsScale(t *testing.T) {
+
+	plex := func(values ...float64) iso.Labels {
+		var l iso.Labels
+		for i, j := range values {
+			l.Channels = append(l.Channels, iso.Channel{Name: []string{"126", "127N", "127C"}[i], Intensity: j})
+		}
+		return l
+	}
+
+	// the second plex was measured four times higher, the third has no reference intensity
+	labels := map[string]iso.Labels{
+		"plex1": plex(100, 50, 20),
+		"plex2": plex(400, 200, 80),
+		"plex3": plex(0, 10, 10),
+	}
+	references := map[string][]string{"plex1": {"126"}, "plex2": {"126"}, "plex3": {"126"}}
+
+	scaled, factors := irsScale(labels, references)
+
+	if math.Abs(factors["plex1"]-2) > 1e-9 || math.Abs(factors["plex2"]-0.5) > 1e-9 {
+		t.Errorf("IRS factors are incorrect, got %v", factors)
+	}
+
+	if _, ok := scaled["plex3"]; ok {
+		t.Errorf("Data sets without reference intensity should be reported as missing, got %v", scaled["plex3"])
+	}
+
+	if _, ok := factors["plex3"]; ok {
+		t.Errorf("Data sets without reference intensity should have no factor, got %v", factors)
+	}
+
+	for _, i := range []string{"plex1", "plex2"} {
+		if math.Abs(scaled[i].Channels[1].Intensity-100) > 1e-9 {
+			t.Errorf("Scaled intensities are incorrect, got %v", scaled[i].Channels)
+		}
+	}
+
+	if labels["plex1"].Channels[0].Intensity != 100 {
+		t.Errorf("The original labels should not change, got %v", labels["plex1"].Channels)
+	}
+}
@@ -0,0 +1,176 @@
+// Package aba (Abacus), MaxLFQ protein intensities
+package aba
+
+import (
+	"math"
+	"sort"
+
+	"philosopher/lib/id"
+	"philosopher/lib/qua"
+	"philosopher/lib/rep"
+	"philosopher/lib/uti"
+)
+
+// minRatioCount is the number of ions two data sets must share to compare them
+const minRatioCount = 2
+
+// maxLFQIntensities calculates the MaxLFQ intensity of each protein group from
+// the unique and razor ion intensities of each data set, including the ions
+// transferred by the match-between-runs
+func maxLFQIntensities(combined rep.CombinedProteinEvidenceList, datasets map[string]rep.Evidence, namesList []string, uniqueOnly bool, transfers map[string]map[id.IonFormType]qua.Transfer) rep.CombinedProteinEvidenceList {
+
+	var ions = make(map[string]map[id.IonFormType]float64)
+
+	for _, k := range namesList {
+
+		ions[k] = make(map[id.IonFormType]float64)
+		for _, i := range datasets[k].Ions {
+			if i.Intensity > 0 {
+				ions[k][i.IonForm()] = i.Intensity
+			}
+		}
+
+		for ion, t := range transfers[k] {
+			if _, ok := ions[k][ion]; !ok && t.Peak.Intensity > 0 {
+				ions[k][ion] = t.Peak.Intensity
+			}
+		}
+	}
+
+	for i := range combined {
+
+		var samples = make([]map[id.IonFormType]float64, len(namesList))
+		for j := range samples {
+			samples[j] = make(map[id.IonFormType]float64)
+		}
+
+		for _, j := range combined[i].PeptideIons {
+
+			if uniqueOnly && !j.IsUnique {
+				continue
+			}
+
+			if !j.IsUnique && j.Razor != 1 {
+				continue
+			}
+
+			ion := j.IonForm()
+			for s, k := range namesList {
+				if v, ok := ions[k][ion]; ok {
+					samples[s][ion] = v
+				}
+			}
+		}
+
+		lfq := maxLFQ(samples)
+		for s, k := range namesList {
+			combined[i].MaxLFQIntensity[k] = lfq[s]
+		}
+	}
+
+	return combined
+}
+
+// maxLFQ estimates the protein intensity in each sample. The median log ratio
+// of the shared ions gives the protein ratio between each pair of samples, and
+// the intensities that best fit all ratios are found by least squares on each
+// group of connected samples, scaled to the summed ion intensities of the group
+func maxLFQ(samples []map[id.IonFormType]float64) []float64 {
+
+	n := len(samples)
+
+	var lfq = make([]float64, n)
+	var ratios = make([][]float64, n)
+	var connected = make([][]bool, n)
+	for j := range ratios {
+		ratios[j] = make([]float64, n)
+		connected[j] = make([]bool, n)
+	}
+
+	for j := 0; j < n; j++ {
+		for k := j + 1; k < n; k++ {
+
+			var logRatios []float64
+			for ion, v := range samples[j] {
+				if w, ok := samples[k][ion]; ok {
+					logRatios = append(logRatios, math.Log(v/w))
+				}
+			}
+
+			if len(logRatios) >= minRatioCount {
+				r := uti.Median(logRatios)
+				ratios[j][k], ratios[k][j] = r, -r
+				connected[j][k], connected[k][j] = true, true
+			}
+		}
+	}
+
+	var visited = make([]bool, n)
+	for j := 0; j < n; j++ {
+
+		if visited[j] {
+			continue
+		}
+
+		// collect the samples connected by ratios
+		var group []int
+		var stack = []int{j}
+		visited[j] = true
+		for len(stack) > 0 {
+			s := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			group = append(group, s)
+			for k := 0; k < n; k++ {
+				if connected[s][k] && !visited[k] {
+					visited[k] = true
+					stack = append(stack, k)
+				}
+			}
+		}
+
+		if len(group) < 2 {
+			continue
+		}
+
+		sort.Ints(group)
+
+		// the first sample of the group is fixed to a log intensity of zero
+		m := len(group) - 1
+		var a = make([][]float64, m)
+		var b = make([]float64, m)
+		for r := range a {
+			a[r] = make([]float64, m)
+		}
+
+		for r, s := range group {
+			for c, t := range group {
+				if s == t || !connected[s][t] {
+					continue
+				}
+				if r > 0 {
+					a[r-1][r-1]++
+					b[r-1] += ratios[s][t]
+					if c > 0 {
+						a[r-1][c-1]--
+					}
+				}
+			}
+		}
+
+		z := append([]float64{0}, uti.Solve(a, b)...)
+
+		var summed, profile float64
+		for r, s := range group {
+			for _, v := range samples[s] {
+				summed += v
+			}
+			profile += math.Exp(z[r])
+		}
+
+		for r, s := range group {
+			lfq[s] = math.Exp(z[r]) * summed / profile
+		}
+	}
+
+	return lfq
+}
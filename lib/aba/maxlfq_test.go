@@ -0,0 +1,43 @@
+package aba
+
+import (
+	"math"
+	"testing"
+
+	"philosopher/lib/id"
+)
+
+func Test_maxLFQ(t *testing.T) {
+
+	a := id.IonFormType{Peptide: "PEPTIDEA", AssumedCharge: 2}
+	b := id.IonFormType{Peptide: "PEPTIDEB", AssumedCharge: 2}
+	c := id.IonFormType{Peptide: "PEPTIDEC", AssumedCharge: 3}
+
+	// the second sample has twice the protein amount and misses one ion, the
+	// fourth sample shares a single ion and can not be compared
+	samples := []map[id.IonFormType]float64{
+		{a: 100, b: 1000, c: 10},
+		{a: 200, b: 2000},
+		{a: 50, b: 500, c: 5},
+		{a: 400},
+	}
+
+	lfq := maxLFQ(samples)
+
+	if r := lfq[1] / lfq[0]; math.Abs(r-2) > 1e-6 {
+		t.Errorf("MaxLFQ ratio is incorrect, got %f, want %f", r, 2.0)
+	}
+
+	if r := lfq[2] / lfq[0]; math.Abs(r-0.5) > 1e-6 {
+		t.Errorf("MaxLFQ ratio is incorrect, got %f, want %f", r, 0.5)
+	}
+
+	if lfq[3] != 0 {
+		t.Errorf("MaxLFQ intensity of an unconnected sample should be zero, got %f", lfq[3])
+	}
+
+	// the intensities are scaled to the summed ion intensities
+	if sum := lfq[0] + lfq[1] + lfq[2]; math.Abs(sum-(1110+2200+555)) > 1e-6 {
+		t.Errorf("MaxLFQ summed intensity is incorrect, got %f, want %f", sum, 3865.0)
+	}
+}
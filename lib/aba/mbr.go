@@ -0,0 +1,203 @@
+// Package aba (Abacus), match-between-runs
+package aba
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"philosopher/lib/id"
+	"philosopher/lib/met"
+	"philosopher/lib/msg"
+	"philosopher/lib/qua"
+	"philosopher/lib/rep"
+	"philosopher/lib/sys"
+
+	"github.com/sirupsen/logrus"
+)
+
+// matchBetweenRuns restores the quantification parameters of each data set and
+// transfers the identified ions to the data sets where they were not identified
+func matchBetweenRuns(a met.Abacus, args []string, datasets map[string]rep.Evidence) map[string]map[id.IonFormType]qua.Transfer {
+
+	var runs []qua.Run
+
+	for _, i := range args {
+
+		var d met.Data
+		d.Restore(fmt.Sprintf("%s%s%s", i, string(filepath.Separator), sys.Meta()))
+
+		// the spectra directory is relative to the data set workspace
+		q := d.Quantify
+		if len(q.Dir) > 0 && !filepath.IsAbs(q.Dir) {
+			q.Dir = filepath.Join(i, q.Dir)
+		}
+
+		prjName := i
+		if strings.Contains(prjName, string(filepath.Separator)) {
+			prjName = strings.Replace(filepath.Base(prjName), string(filepath.Separator), "", -1)
+		}
+
+		runs = append(runs, qua.Run{Name: prjName, Evidence: datasets[prjName], Quantify: q})
+	}
+
+	return qua.MatchBetweenRuns(runs, a.MBRWin, a.MBRFDR)
+}
+
+// transferProteinIntensities recalculates the top 3 ion intensities of the
+// proteins with transferred ions, counting the ions identified in the data set
+// and the ones transferred from the others
+func transferProteinIntensities(combined rep.CombinedProteinEvidenceList, datasets map[string]rep.Evidence, transfers map[string]map[id.IonFormType]qua.Transfer) rep.CombinedProteinEvidenceList {
+
+	for k, v := range datasets {
+
+		var ions = make(map[id.IonFormType]float64)
+		for _, i := range v.Ions {
+			ions[i.IonForm()] = i.Intensity
+		}
+
+		for i := range combined {
+
+			if combined[i].TransferredIons == nil {
+				combined[i].TransferredIons = make(map[string]int)
+			}
+
+			var transferred int
+			var totalInt, uniqueInt, razorInt []float64
+			var seen = make(map[id.IonFormType]bool)
+
+			for _, j := range combined[i].PeptideIons {
+
+				ion := j.IonForm()
+				if seen[ion] {
+					continue
+				}
+				seen[ion] = true
+
+				intensity, ok := ions[ion]
+				if !ok {
+					t, ok := transfers[k][ion]
+					if !ok {
+						continue
+					}
+					intensity = t.Peak.Intensity
+					transferred++
+				}
+
+				totalInt = append(totalInt, intensity)
+
+				if j.IsUnique {
+					uniqueInt = append(uniqueInt, intensity)
+				}
+
+				if j.IsUnique || j.Razor == 1 {
+					razorInt = append(razorInt, intensity)
+				}
+			}
+
+			combined[i].TransferredIons[k] = transferred
+
+			if transferred > 0 {
+				combined[i].TotalIntensity[k] = topIntensity(totalInt)
+				combined[i].UniqueIntensity[k] = topIntensity(uniqueInt)
+				combined[i].UrazorIntensity[k] = topIntensity(razorInt)
+			}
+		}
+	}
+
+	return combined
+}
+
+// topIntensity sums the 3 most intense values
+func topIntensity(values []float64) float64 {
+
+	var sum float64
+
+	sort.Float64s(values)
+	for i := len(values) - 1; i >= 0 && i >= len(values)-3; i-- {
+		sum += values[i]
+	}
+
+	return sum
+}
+
+// saveIonAbacusResult creates the combined ion report, flagging the intensities
+// transferred by the match-between-runs
+func saveIonAbacusResult(session string, datasets map[string]rep.Evidence, namesList []string, transfers map[string]map[id.IonFormType]qua.Transfer) {
+
+	var ions = make(map[id.IonFormType]map[string]float64)
+
+	for _, k := range namesList {
+		for _, i := range datasets[k].Ions {
+
+			if i.IsDecoy {
+				continue
+			}
+
+			if _, ok := ions[i.IonForm()]; !ok {
+				ions[i.IonForm()] = make(map[string]float64)
+			}
+			ions[i.IonForm()][k] = i.Intensity
+		}
+	}
+
+	var list []id.IonFormType
+	for k := range ions {
+		list = append(list, k)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Str() < list[j].Str() })
+
+	logrus.Info("Creating combined ion report")
+
+	// create result file
+	output := fmt.Sprintf("%s%scombined_ion.tsv", session, string(filepath.Separator))
+
+	// create result file
+	file, e := os.Create(output)
+	if e != nil {
+		msg.WriteFile(e, "error")
+	}
+	defer file.Close()
+
+	header := "Peptide Sequence\tCharge\tCalculated Peptide Mass"
+
+	for _, i := range namesList {
+		header += fmt.Sprintf("\t%s Intensity\t%s Match Type\t%s MBR Q-Value", i, i, i)
+	}
+
+	header += "\n"
+	_, e = io.WriteString(file, header)
+	if e != nil {
+		msg.WriteToFile(e, "fatal")
+	}
+
+	for _, i := range list {
+
+		line := fmt.Sprintf("%s\t%d\t%.4f", i.Peptide, i.AssumedCharge, i.CalcNeutralPepMass)
+
+		for _, j := range namesList {
+
+			if v, ok := ions[i][j]; ok {
+				line += fmt.Sprintf("\t%6.f\tMS/MS\t", v)
+			} else if t, ok := transfers[j][i]; ok {
+				line += fmt.Sprintf("\t%6.f\tMBR\t%.4f", t.Peak.Intensity, t.Qvalue)
+			} else {
+				line += "\t0\t\t"
+			}
+		}
+
+		line += "\n"
+		_, e := io.WriteString(file, line)
+		if e != nil {
+			msg.WriteToFile(e, "fatal")
+		}
+	}
+
+	// copy to work directory
+	sys.CopyFile(output, filepath.Base(output))
+
+}
@@ -0,0 +1,349 @@
+// Package aba (Abacus), intensity normalization and imputation
+package aba
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+
+	"philosopher/lib/msg"
+	"philosopher/lib/rep"
+	"philosopher/lib/uti"
+)
+
+// minProbQuantile is the quantile of each sample where the MinProb imputation is centered
+const minProbQuantile = 0.01
+
+// imputationSeed makes the MinProb imputation reproducible
+const imputationSeed = 1
+
+// knnNeighbours is the number of proteins averaged by the KNN imputation
+const knnNeighbours = 5
+
+// processProteinIntensities normalizes and imputes the unique+razor and MaxLFQ
+// intensities of the combined proteins, the raw intensities are kept
+func processProteinIntensities(combined rep.CombinedProteinEvidenceList, namesList []string, normalize, impute string) rep.CombinedProteinEvidenceList {
+
+	var intensities = make([][]float64, len(combined))
+	var maxLFQ = make([][]float64, len(combined))
+
+	for i := range combined {
+		intensities[i] = make([]float64, len(namesList))
+		maxLFQ[i] = make([]float64, len(namesList))
+		for j, k := range namesList {
+			intensities[i][j] = combined[i].UrazorIntensity[k]
+			maxLFQ[i][j] = combined[i].MaxLFQIntensity[k]
+		}
+	}
+
+	intensities = imputeIntensities(normalizeIntensities(intensities, normalize), impute)
+	maxLFQ = imputeIntensities(normalizeIntensities(maxLFQ, normalize), impute)
+
+	for i := range combined {
+		combined[i].ProcessedIntensity = make(map[string]float64)
+		combined[i].ProcessedMaxLFQIntensity = make(map[string]float64)
+		for j, k := range namesList {
+			combined[i].ProcessedIntensity[k] = intensities[i][j]
+			combined[i].ProcessedMaxLFQIntensity[k] = maxLFQ[i][j]
+		}
+	}
+
+	return combined
+}
+
+// normalizeIntensities scales each sample (column) of the protein intensities,
+// the missing values are zero and are left out
+func normalizeIntensities(intensities [][]float64, method string) [][]float64 {
+
+	if len(intensities) == 0 {
+		return intensities
+	}
+
+	samples := len(intensities[0])
+
+	var normalized = make([][]float64, len(intensities))
+	for i := range intensities {
+		normalized[i] = make([]float64, samples)
+		copy(normalized[i], intensities[i])
+	}
+
+	switch method {
+	case "none", "":
+		return normalized
+
+	case "median", "total":
+
+		// the scaling factors bring each sample to the average median or total
+		var values = make([]float64, samples)
+		var present = make([]bool, samples)
+		var observed float64
+
+		for j := 0; j < samples; j++ {
+
+			var column []float64
+			for i := range intensities {
+				if intensities[i][j] > 0 {
+					column = append(column, intensities[i][j])
+				}
+			}
+
+			if len(column) == 0 {
+				continue
+			}
+
+			if method == "median" {
+				for k := range column {
+					column[k] = math.Log2(column[k])
+				}
+				values[j] = uti.Median(column)
+			} else {
+				for _, k := range column {
+					values[j] += k
+				}
+				values[j] = math.Log2(values[j])
+			}
+			present[j] = true
+			observed++
+		}
+
+		var average float64
+		for _, v := range values {
+			average += v
+		}
+		average /= observed
+
+		for j := 0; j < samples; j++ {
+			if !present[j] {
+				continue
+			}
+			factor := math.Pow(2, average-values[j])
+			for i := range normalized {
+				normalized[i][j] *= factor
+			}
+		}
+
+	case "quantile":
+
+		// each observed value takes the average of all samples at its quantile
+		var columns = make([][]float64, samples)
+		for j := 0; j < samples; j++ {
+			for i := range intensities {
+				if intensities[i][j] > 0 {
+					columns[j] = append(columns[j], intensities[i][j])
+				}
+			}
+			sort.Float64s(columns[j])
+		}
+
+		for j := 0; j < samples; j++ {
+
+			var rows []int
+			for i := range intensities {
+				if intensities[i][j] > 0 {
+					rows = append(rows, i)
+				}
+			}
+			sort.SliceStable(rows, func(a, b int) bool { return intensities[rows[a]][j] < intensities[rows[b]][j] })
+
+			for r, i := range rows {
+
+				q := 0.5
+				if len(rows) > 1 {
+					q = float64(r) / float64(len(rows)-1)
+				}
+
+				var sum, n float64
+				for _, c := range columns {
+					if len(c) > 0 {
+						sum += quantile(c, q)
+						n++
+					}
+				}
+				normalized[i][j] = sum / n
+			}
+		}
+
+	default:
+		msg.Custom(errors.New("unknown normalization method "+method), "fatal")
+	}
+
+	return normalized
+}
+
+// imputeIntensities fills the missing protein intensities
+func imputeIntensities(intensities [][]float64, method string) [][]float64 {
+
+	if len(intensities) == 0 {
+		return intensities
+	}
+
+	samples := len(intensities[0])
+
+	var imputed = make([][]float64, len(intensities))
+	for i := range intensities {
+		imputed[i] = make([]float64, samples)
+		copy(imputed[i], intensities[i])
+	}
+
+	switch method {
+	case "none", "":
+		return imputed
+
+	case "minprob":
+
+		// draws from a normal distribution centered on a low quantile of the
+		// sample, with the median protein standard deviation
+		var deviations []float64
+		for i := range intensities {
+			var row []float64
+			for _, v := range intensities[i] {
+				if v > 0 {
+					row = append(row, math.Log2(v))
+				}
+			}
+			if len(row) > 1 {
+				deviations = append(deviations, standardDeviation(row))
+			}
+		}
+		sd := uti.Median(deviations)
+
+		random := rand.New(rand.NewSource(imputationSeed))
+
+		for j := 0; j < samples; j++ {
+
+			var column []float64
+			for i := range intensities {
+				if intensities[i][j] > 0 {
+					column = append(column, math.Log2(intensities[i][j]))
+				}
+			}
+
+			if len(column) == 0 {
+				continue
+			}
+
+			sort.Float64s(column)
+			center := quantile(column, minProbQuantile)
+
+			for i := range imputed {
+				if intensities[i][j] == 0 {
+					imputed[i][j] = math.Pow(2, center+random.NormFloat64()*sd)
+				}
+			}
+		}
+
+	case "knn":
+
+		// averages the sample intensity of the closest proteins, by their log
+		// intensity distance on the samples observed in both
+		var logs = make([][]float64, len(intensities))
+		for i := range intensities {
+			logs[i] = make([]float64, samples)
+			for j, v := range intensities[i] {
+				if v > 0 {
+					logs[i][j] = math.Log2(v)
+				}
+			}
+		}
+
+		type neighbour struct {
+			row      int
+			distance float64
+		}
+
+		for i := range intensities {
+
+			var missing bool
+			for _, v := range intensities[i] {
+				if v == 0 {
+					missing = true
+				}
+			}
+
+			if !missing {
+				continue
+			}
+
+			var neighbours []neighbour
+			for k := range intensities {
+
+				if k == i {
+					continue
+				}
+
+				var sum, shared float64
+				for j := 0; j < samples; j++ {
+					if intensities[i][j] > 0 && intensities[k][j] > 0 {
+						sum += (logs[i][j] - logs[k][j]) * (logs[i][j] - logs[k][j])
+						shared++
+					}
+				}
+
+				if shared > 0 {
+					neighbours = append(neighbours, neighbour{k, math.Sqrt(sum / shared)})
+				}
+			}
+
+			sort.SliceStable(neighbours, func(a, b int) bool { return neighbours[a].distance < neighbours[b].distance })
+
+			for j := 0; j < samples; j++ {
+
+				if intensities[i][j] > 0 {
+					continue
+				}
+
+				var sum, n float64
+				for _, k := range neighbours {
+					if intensities[k.row][j] > 0 {
+						sum += logs[k.row][j]
+						n++
+					}
+					if n == knnNeighbours {
+						break
+					}
+				}
+
+				if n > 0 {
+					imputed[i][j] = math.Pow(2, sum/n)
+				}
+			}
+		}
+
+	default:
+		msg.Custom(errors.New("unknown imputation method "+method), "fatal")
+	}
+
+	return imputed
+}
+
+// quantile interpolates the q quantile of the sorted values
+func quantile(sorted []float64, q float64) float64 {
+
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+
+	position := q * float64(len(sorted)-1)
+	low := int(math.Floor(position))
+	high := int(math.Ceil(position))
+
+	return sorted[low] + (sorted[high]-sorted[low])*(position-float64(low))
+}
+
+// standardDeviation returns the sample standard deviation of the values
+func standardDeviation(values []float64) float64 {
+
+	var mean float64
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	var sum float64
+	for _, v := range values {
+		sum += (v - mean) * (v - mean)
+	}
+
+	return math.Sqrt(sum / float64(len(values)-1))
+}
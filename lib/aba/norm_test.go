@@ -0,0 +1,63 @@
+package aba
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_normalizeIntensities(t *testing.T) {
+
+	// the second sample was loaded twice as much
+	intensities := [][]float64{
+		{100, 200},
+		{1000, 2000},
+		{50, 100},
+	}
+
+	for _, method := range []string{"median", "total", "quantile"} {
+
+		normalized := normalizeIntensities(intensities, method)
+
+		if method != "total" && math.Abs(normalized[1][0]-normalized[1][1]) > 1e-6 {
+			t.Errorf("%s normalization is incorrect, got %v", method, normalized[1])
+		}
+
+	}
+
+	// a missing value in the second sample
+	intensities = append(intensities, []float64{10, 0})
+
+	for _, method := range []string{"median", "total", "quantile"} {
+		if normalized := normalizeIntensities(intensities, method); normalized[3][1] != 0 {
+			t.Errorf("%s normalization should keep missing values, got %f", method, normalized[3][1])
+		}
+	}
+
+	if n := normalizeIntensities(intensities, "none"); n[1][1] != 2000 {
+		t.Errorf("Raw intensities should be kept, got %f", n[1][1])
+	}
+}
+
+func Test_imputeIntensities(t *testing.T) {
+
+	intensities := [][]float64{
+		{100, 200, 400},
+		{110, 220, 0},
+		{1000, 2000, 4000},
+		{5000, 10, 20},
+	}
+
+	imputed := imputeIntensities(intensities, "knn")
+	if imputed[1][2] <= 20 || imputed[1][2] >= 4000 {
+		t.Errorf("KNN imputation is incorrect, got %f", imputed[1][2])
+	}
+
+	imputed = imputeIntensities(intensities, "minprob")
+	if imputed[1][2] <= 0 || imputed[1][2] > 400 {
+		t.Errorf("MinProb imputation should be low, got %f", imputed[1][2])
+	}
+
+	if imputed[0][0] != 100 {
+		t.Errorf("Observed intensities should be kept, got %f", imputed[0][0])
+	}
+}
@@ -16,10 +16,13 @@ import (
 
 	"philosopher/lib/fil"
 	"philosopher/lib/id"
+	"philosopher/lib/iso"
 	"philosopher/lib/met"
 	"philosopher/lib/msg"
 	"philosopher/lib/rep"
 	"philosopher/lib/sys"
+	"philosopher/lib/tmt"
+	"philosopher/lib/uti"
 
 	"github.com/sirupsen/logrus"
 )
@@ -31,6 +34,7 @@ func peptideLevelAbacus(m met.Data, args []string) {
 	//var xmlFiles []string
 	var datasets = make(map[string]rep.PSMEvidenceList)
 	var labels = make(map[string]string)
+	var references = make(map[string][]string)
 
 	// restoring combined file
 	logrus.Info("Processing combined file")
@@ -51,6 +55,7 @@ func peptideLevelAbacus(m met.Data, args []string) {
 		rep.RestorePSM(&evi.PSM)
 
 		files, _ := ioutil.ReadDir(i)
+		var referenceChannels []string
 
 		// collect interact full file names
 		for _, f := range files {
@@ -61,6 +66,7 @@ func peptideLevelAbacus(m met.Data, args []string) {
 			//}
 			if strings.Contains(f.Name(), "annotation") {
 				var annot = fmt.Sprintf("%s%s%s", i, string(filepath.Separator), f.Name())
+				referenceChannels = uti.GetReferenceChannels(annot)
 
 				file, e := os.Open(annot)
 				if e != nil {
@@ -93,6 +99,7 @@ func peptideLevelAbacus(m met.Data, args []string) {
 
 		// unique list and map of datasets
 		datasets[prjName] = evi.PSM
+		references[prjName] = referenceChannels
 		names = append(names, prjName)
 	}
 
@@ -108,7 +115,21 @@ func peptideLevelAbacus(m met.Data, args []string) {
 
 	os.Chdir(local)
 
-	savePeptideAbacusResult(m.Temp, evidences, datasets, names, m.Abacus.Unique, false, labels)
+	// internal reference scaling across the labeled data sets
+	if m.Abacus.IRS {
+		logrus.Info("Scaling the channels to the internal references")
+		checkReferenceChannels(references, names)
+		evidences = irsPeptideScaling(evidences, references)
+
+		var rows []irsFactors
+		for _, i := range evidences {
+			rows = append(rows, irsFactors{i.Sequence, i.ProteinID, i.Gene, i.IRSFactors})
+		}
+		saveIRSDiagnostics(m.Temp, "peptide", rows, names, references)
+	}
+
+	// the peptide channels are reported for the scaled data sets only
+	savePeptideAbacusResult(m.Temp, m.Abacus.Plex, evidences, datasets, names, m.Abacus.Unique, m.Abacus.IRS, labels)
 
 }
 
@@ -124,7 +145,7 @@ func processPeptideCombinedFile(a met.Abacus) {
 		var pep id.PepXML
 		pep.DecoyTag = a.Tag
 
-		pepID, _ := id.ReadPepXMLInput("combined.pep.xml", a.Tag, sys.GetTemp(), false)
+		pepID, _ := id.ReadPepXMLInput("combined.pep.xml", a.Tag, "", sys.GetTemp(), false)
 		//uniqPsms := fil.GetUniquePSMs(pepID)
 		uniqPeps := fil.GetUniquePeptides(pepID)
 
@@ -149,6 +170,7 @@ func collectPeptideDatafromExperiments(datasets map[string]rep.PSMEvidenceList,
 			var e rep.CombinedPeptideEvidence
 			e.Spc = make(map[string]int)
 			e.Intensity = make(map[string]float64)
+			e.Labels = make(map[string]iso.Labels)
 			e.AssignedMassDiffs = make(map[string]uint8)
 			e.ChargeStates = make(map[uint8]uint8)
 
@@ -177,6 +199,7 @@ func SummarizeAttributes(evidences rep.CombinedPeptideEvidenceList, datasets map
 
 		SpcMap := make(map[string]int)
 		IntMap := make(map[string]float64)
+		LabelMap := make(map[string]iso.Labels)
 		ModsMap := make(map[string][]string)
 
 		protIDMap := make(map[string]string)
@@ -196,6 +219,10 @@ func SummarizeAttributes(evidences rep.CombinedPeptideEvidenceList, datasets map
 			SpcMap[j.Sequence] = j.Spc
 			IntMap[j.Sequence] = j.Intensity
 
+			if j.Labels != nil {
+				LabelMap[j.Sequence] = *j.Labels
+			}
+
 			protIDMap[j.Sequence] = j.ProteinID
 			protMap[j.Sequence] = j.Protein
 			protDescMap[j.Sequence] = j.ProteinDescription
@@ -221,6 +248,10 @@ func SummarizeAttributes(evidences rep.CombinedPeptideEvidenceList, datasets map
 			if ok {
 				evidences[i].Intensity[k] = it
 			}
+			l, ok := LabelMap[evidences[i].Sequence]
+			if ok {
+				evidences[i].Labels[k] = l
+			}
 			m, ok := ModsMap[evidences[i].Sequence]
 			if ok {
 				for _, l := range m {
@@ -264,7 +295,7 @@ func SummarizeAttributes(evidences rep.CombinedPeptideEvidenceList, datasets map
 }
 
 // savePeptideAbacusResult creates a single report using 1 or more philosopher result files
-func savePeptideAbacusResult(session string, evidences rep.CombinedPeptideEvidenceList, datasets map[string]rep.PSMEvidenceList, namesList []string, uniqueOnly, hasTMT bool, labelsList map[string]string) {
+func savePeptideAbacusResult(session, plex string, evidences rep.CombinedPeptideEvidenceList, datasets map[string]rep.PSMEvidenceList, namesList []string, uniqueOnly, hasTMT bool, labelsList map[string]string) {
 
 	// create result file
 	output := fmt.Sprintf("%s%scombined_peptide.tsv", session, string(filepath.Separator))
@@ -283,6 +314,26 @@ func savePeptideAbacusResult(session string, evidences rep.CombinedPeptideEviden
 		line += fmt.Sprintf("%s Intensity\t", i)
 	}
 
+	var kit iso.Kit
+	if hasTMT {
+		var e error
+		kit, e = tmt.Kit(plex)
+		if e != nil {
+			msg.Custom(e, "fatal")
+		}
+
+		for _, i := range namesList {
+			for _, j := range kit.Names() {
+				v, ok := labelsList[fmt.Sprintf("%s %s", i, j)]
+				if ok {
+					line += fmt.Sprintf("%s\t", v)
+				} else {
+					line += fmt.Sprintf("%s %s\t", i, j)
+				}
+			}
+		}
+	}
+
 	line += "\n"
 	_, e = io.WriteString(file, line)
 	if e != nil {
@@ -325,6 +376,15 @@ func savePeptideAbacusResult(session string, evidences rep.CombinedPeptideEviden
 			line += fmt.Sprintf("%d\t%.4f\t", i.Spc[j], i.Intensity[j])
 		}
 
+		if hasTMT {
+			for _, j := range namesList {
+				l := i.Labels[j]
+				for _, k := range kit.Select(&l) {
+					line += fmt.Sprintf("%.4f\t", k.Intensity)
+				}
+			}
+		}
+
 		line += "\n"
 		_, e = io.WriteString(file, line)
 		if e != nil {
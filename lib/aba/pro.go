@@ -20,8 +20,10 @@ import (
 	"philosopher/lib/fil"
 	"philosopher/lib/id"
 	"philosopher/lib/met"
+	"philosopher/lib/qua"
 	"philosopher/lib/rep"
 	"philosopher/lib/sys"
+	"philosopher/lib/tmt"
 
 	"github.com/sirupsen/logrus"
 )
@@ -34,6 +36,7 @@ func proteinLevelAbacus(m met.Data, args []string) {
 	var datasets = make(map[string]rep.Evidence)
 
 	var labels = make(map[string]string)
+	var references = make(map[string][]string)
 
 	// restore database
 	database = dat.Base{}
@@ -51,12 +54,16 @@ func proteinLevelAbacus(m met.Data, args []string) {
 		// restoring the database
 		var e rep.Evidence
 		e.RestoreGranularWithPath(i)
+		e.RestoreSearchWithPath(i)
+		e = qua.CalculateSpectralAbundances(e)
 
 		// collect interact full file names
+		var referenceChannels []string
 		files, _ := ioutil.ReadDir(i)
 		for _, f := range files {
 			if strings.Contains(f.Name(), "annotation") {
 				var annot = fmt.Sprintf("%s%s%s", i, string(filepath.Separator), f.Name())
+				referenceChannels = uti.GetReferenceChannels(annot)
 
 				file, e := os.Open(annot)
 				if e != nil {
@@ -87,6 +94,7 @@ func proteinLevelAbacus(m met.Data, args []string) {
 
 		// unique list and map of datasets
 		datasets[prjName] = e
+		references[prjName] = referenceChannels
 		names = append(names, prjName)
 	}
 
@@ -131,11 +139,45 @@ func proteinLevelAbacus(m met.Data, args []string) {
 	logrus.Info("Processing intensities")
 	evidences = sumProteinIntensities(evidences, datasets)
 
+	// transfer identifications between data sets
+	var transfers map[string]map[id.IonFormType]qua.Transfer
+	if m.Abacus.MBR {
+		logrus.Info("Matching between runs")
+		transfers = matchBetweenRuns(m.Abacus, args, datasets)
+		evidences = transferProteinIntensities(evidences, datasets, transfers)
+		saveIonAbacusResult(m.Temp, datasets, names, transfers)
+	}
+
+	// the MaxLFQ intensities are label-free, the labeled data sets report their channels
+	if !m.Abacus.Labels {
+		logrus.Info("Processing MaxLFQ intensities")
+		evidences = maxLFQIntensities(evidences, datasets, names, m.Abacus.Unique, transfers)
+	}
+
+	// normalize and impute the protein intensities
+	if (len(m.Abacus.Normalize) > 0 && m.Abacus.Normalize != "none") || (len(m.Abacus.Impute) > 0 && m.Abacus.Impute != "none") {
+		logrus.Info("Normalizing and imputing intensities")
+		evidences = processProteinIntensities(evidences, names, m.Abacus.Normalize, m.Abacus.Impute)
+	}
+
 	// collect TMT labels
 	if m.Abacus.Labels {
 		evidences = getProteinLabelIntensities(evidences, datasets, m.Abacus.Tag)
 	}
 
+	// internal reference scaling across the labeled data sets
+	if m.Abacus.IRS {
+		logrus.Info("Scaling the channels to the internal references")
+		checkReferenceChannels(references, names)
+		evidences = irsProteinScaling(evidences, references)
+
+		var rows []irsFactors
+		for _, i := range evidences {
+			rows = append(rows, irsFactors{i.ProteinName, i.ProteinID, i.GeneNames, i.IRSFactors})
+		}
+		saveIRSDiagnostics(m.Temp, "protein", rows, names, references)
+	}
+
 	if m.Abacus.Labels {
 		saveProteinAbacusResult(m.Temp, m.Abacus.Plex, evidences, datasets, names, m.Abacus.Unique, true, m.Abacus.Full, labels)
 	} else {
@@ -191,6 +233,9 @@ func processProteinCombinedFile(a met.Abacus, database dat.Base) rep.CombinedPro
 				ce.TotalSpc = make(map[string]int)
 				ce.UniqueSpc = make(map[string]int)
 				ce.UrazorSpc = make(map[string]int)
+				ce.NSAF = make(map[string]float64)
+				ce.DNSAF = make(map[string]float64)
+				ce.EmPAI = make(map[string]float64)
 
 				ce.TotalPeptides = make(map[string]map[string]bool)
 				ce.UniquePeptides = make(map[string]map[string]bool)
@@ -199,6 +244,7 @@ func processProteinCombinedFile(a met.Abacus, database dat.Base) rep.CombinedPro
 				ce.TotalIntensity = make(map[string]float64)
 				ce.UniqueIntensity = make(map[string]float64)
 				ce.UrazorIntensity = make(map[string]float64)
+				ce.MaxLFQIntensity = make(map[string]float64)
 
 				ce.TotalLabels = make(map[string]iso.Labels)
 				ce.UniqueLabels = make(map[string]iso.Labels)
@@ -251,6 +297,9 @@ func getProteinSpectralCounts(combined rep.CombinedProteinEvidenceList, datasets
 					combined[i].UniqueSpc[k] = j.UniqueSpC
 					combined[i].TotalSpc[k] = j.TotalSpC
 					combined[i].UrazorSpc[k] = j.URazorSpC
+					combined[i].NSAF[k] = j.NSAF
+					combined[i].DNSAF[k] = j.DNSAF
+					combined[i].EmPAI[k] = j.EmPAI
 					break
 				}
 			}
@@ -372,6 +421,10 @@ func saveProteinAbacusResult(session, plex string, evidences rep.CombinedProtein
 	var uniquePeptides = make(map[string][]string)
 	var razorPeptides = make(map[string][]string)
 
+	var hasTransfers bool
+	var hasProcessed bool
+	var hasAbundances bool
+
 	// organize by group number
 	sort.Sort(evidences)
 
@@ -401,6 +454,20 @@ func saveProteinAbacusResult(session, plex string, evidences rep.CombinedProtein
 		totalPeptides[i.ProteinID] = uti.RemoveDuplicateStrings(totalPeptides[i.ProteinID])
 		uniquePeptides[i.ProteinID] = uti.RemoveDuplicateStrings(uniquePeptides[i.ProteinID])
 		razorPeptides[i.ProteinID] = uti.RemoveDuplicateStrings(razorPeptides[i.ProteinID])
+
+		if len(i.TransferredIons) > 0 {
+			hasTransfers = true
+		}
+
+		if len(i.ProcessedIntensity) > 0 {
+			hasProcessed = true
+		}
+
+		for _, j := range i.NSAF {
+			if j > 0 {
+				hasAbundances = true
+			}
+		}
 	}
 
 	// create result file
@@ -434,6 +501,19 @@ func saveProteinAbacusResult(session, plex string, evidences rep.CombinedProtein
 		}
 	}
 
+	// Add spectral count abundances
+	if hasAbundances {
+		for _, i := range namesList {
+			header += fmt.Sprintf("\t%s NSAF", i)
+		}
+		for _, i := range namesList {
+			header += fmt.Sprintf("\t%s dNSAF", i)
+		}
+		for _, i := range namesList {
+			header += fmt.Sprintf("\t%s emPAI", i)
+		}
+	}
+
 	// Add Unique+Razor Intensity
 	for _, i := range namesList {
 		header += fmt.Sprintf("\t%s Intensity", i)
@@ -453,23 +533,42 @@ func saveProteinAbacusResult(session, plex string, evidences rep.CombinedProtein
 		}
 	}
 
-	var chs []string
+	// Add MaxLFQ Intensity
+	if !hasLabels {
+		for _, i := range namesList {
+			header += fmt.Sprintf("\t%s MaxLFQ Intensity", i)
+		}
+	}
 
-	if plex == "10" {
-		chs = append(chs, "126", "127N", "127C", "128N", "128C", "129N", "129C", "130N", "130C", "131N")
-	} else if plex == "11" {
-		chs = append(chs, "126", "127N", "127C", "128N", "128C", "129N", "129C", "130N", "130C", "131N", "131C")
-	} else if plex == "16" {
-		chs = append(chs, "126", "127N", "127C", "128N", "128C", "129N", "129C", "130N", "130C", "131N", "131C", "132N", "132C", "133N", "133C", "134N")
-	} else if plex == "18" {
-		chs = append(chs, "126", "127N", "127C", "128N", "128C", "129N", "129C", "130N", "130C", "131N", "131C", "132N", "132C", "133N", "133C", "134N", "134C", "135N")
-	} else {
-		msg.Custom(errors.New("unsupported number of labels"), "fatal")
+	// Add normalized and imputed intensities
+	if hasProcessed {
+		for _, i := range namesList {
+			header += fmt.Sprintf("\t%s Razor Processed Intensity", i)
+		}
+		if !hasLabels {
+			for _, i := range namesList {
+				header += fmt.Sprintf("\t%s Processed MaxLFQ Intensity", i)
+			}
+		}
 	}
 
+	// Add transferred ions
+	if hasTransfers {
+		for _, i := range namesList {
+			header += fmt.Sprintf("\t%s MBR Ions", i)
+		}
+	}
+
+	var kit iso.Kit
 	if hasLabels {
+		var e error
+		kit, e = tmt.Kit(plex)
+		if e != nil {
+			msg.Custom(e, "fatal")
+		}
+
 		for _, i := range namesList {
-			for _, j := range chs {
+			for _, j := range kit.Names() {
 				l := fmt.Sprintf("%s %s", i, j)
 				v, ok := labelsList[l]
 				if ok {
@@ -542,6 +641,19 @@ func saveProteinAbacusResult(session, plex string, evidences rep.CombinedProtein
 				}
 			}
 
+			// Add spectral count abundances
+			if hasAbundances {
+				for _, j := range namesList {
+					line += fmt.Sprintf("%.6f\t", i.NSAF[j])
+				}
+				for _, j := range namesList {
+					line += fmt.Sprintf("%.6f\t", i.DNSAF[j])
+				}
+				for _, j := range namesList {
+					line += fmt.Sprintf("%.4f\t", i.EmPAI[j])
+				}
+			}
+
 			// Add Unique+Razor Int
 			for _, j := range namesList {
 				line += fmt.Sprintf("%6.f\t", i.UrazorIntensity[j])
@@ -561,66 +673,37 @@ func saveProteinAbacusResult(session, plex string, evidences rep.CombinedProtein
 				}
 			}
 
-			if hasLabels {
-				switch plex {
-				case "10":
-					for _, j := range namesList {
-						line += fmt.Sprintf("%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t",
-							i.URazorLabels[j].Channel1.Intensity,
-							i.URazorLabels[j].Channel2.Intensity,
-							i.URazorLabels[j].Channel3.Intensity,
-							i.URazorLabels[j].Channel4.Intensity,
-							i.URazorLabels[j].Channel5.Intensity,
-							i.URazorLabels[j].Channel6.Intensity,
-							i.URazorLabels[j].Channel7.Intensity,
-							i.URazorLabels[j].Channel8.Intensity,
-							i.URazorLabels[j].Channel9.Intensity,
-							i.URazorLabels[j].Channel10.Intensity,
-						)
-					}
-				case "16":
+			// Add MaxLFQ Int
+			if !hasLabels {
+				for _, j := range namesList {
+					line += fmt.Sprintf("%6.f\t", i.MaxLFQIntensity[j])
+				}
+			}
+
+			// Add normalized and imputed Int
+			if hasProcessed {
+				for _, j := range namesList {
+					line += fmt.Sprintf("%6.f\t", i.ProcessedIntensity[j])
+				}
+				if !hasLabels {
 					for _, j := range namesList {
-						line += fmt.Sprintf("%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t",
-							i.URazorLabels[j].Channel1.Intensity,
-							i.URazorLabels[j].Channel2.Intensity,
-							i.URazorLabels[j].Channel3.Intensity,
-							i.URazorLabels[j].Channel4.Intensity,
-							i.URazorLabels[j].Channel5.Intensity,
-							i.URazorLabels[j].Channel6.Intensity,
-							i.URazorLabels[j].Channel7.Intensity,
-							i.URazorLabels[j].Channel8.Intensity,
-							i.URazorLabels[j].Channel9.Intensity,
-							i.URazorLabels[j].Channel10.Intensity,
-							i.URazorLabels[j].Channel11.Intensity,
-							i.URazorLabels[j].Channel12.Intensity,
-							i.URazorLabels[j].Channel13.Intensity,
-							i.URazorLabels[j].Channel14.Intensity,
-							i.URazorLabels[j].Channel15.Intensity,
-							i.URazorLabels[j].Channel16.Intensity,
-						)
+						line += fmt.Sprintf("%6.f\t", i.ProcessedMaxLFQIntensity[j])
 					}
-				case "18":
-					for _, j := range namesList {
-						line += fmt.Sprintf("%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t",
-							i.URazorLabels[j].Channel1.Intensity,
-							i.URazorLabels[j].Channel2.Intensity,
-							i.URazorLabels[j].Channel3.Intensity,
-							i.URazorLabels[j].Channel4.Intensity,
-							i.URazorLabels[j].Channel5.Intensity,
-							i.URazorLabels[j].Channel6.Intensity,
-							i.URazorLabels[j].Channel7.Intensity,
-							i.URazorLabels[j].Channel8.Intensity,
-							i.URazorLabels[j].Channel9.Intensity,
-							i.URazorLabels[j].Channel10.Intensity,
-							i.URazorLabels[j].Channel11.Intensity,
-							i.URazorLabels[j].Channel12.Intensity,
-							i.URazorLabels[j].Channel13.Intensity,
-							i.URazorLabels[j].Channel14.Intensity,
-							i.URazorLabels[j].Channel15.Intensity,
-							i.URazorLabels[j].Channel16.Intensity,
-							i.URazorLabels[j].Channel17.Intensity,
-							i.URazorLabels[j].Channel18.Intensity,
-						)
+				}
+			}
+
+			// Add transferred ions
+			if hasTransfers {
+				for _, j := range namesList {
+					line += fmt.Sprintf("%d\t", i.TransferredIons[j])
+				}
+			}
+
+			if hasLabels {
+				for _, j := range namesList {
+					l := i.URazorLabels[j]
+					for _, k := range kit.Select(&l) {
+						line += fmt.Sprintf("%.4f\t", k.Intensity)
 					}
 				}
 			}
@@ -13,6 +13,7 @@ import (
 	"philosopher/lib/msg"
 	"philosopher/lib/rep"
 	"philosopher/lib/sys"
+	"philosopher/lib/tmt"
 	"sort"
 	"strings"
 
@@ -133,23 +134,16 @@ func savePSMAbacusResult(session, plex string, evidences rep.CombinedPSMEvidence
 		header += fmt.Sprintf("\t%s", i)
 	}
 
-	var chs []string
-
-	if plex == "10" {
-		chs = append(chs, "126", "127N", "127C", "128N", "128C", "129N", "129C", "130N", "130C", "131N")
-	} else if plex == "11" {
-		chs = append(chs, "126", "127N", "127C", "128N", "128C", "129N", "129C", "130N", "130C", "131N", "131C")
-	} else if plex == "16" {
-		chs = append(chs, "126", "127N", "127C", "128N", "128C", "129N", "129C", "130N", "130C", "131N", "131C", "132N", "132C", "133N", "133C", "134N")
-	} else if plex == "18" {
-		chs = append(chs, "126", "127N", "127C", "128N", "128C", "129N", "129C", "130N", "130C", "131N", "131C", "132N", "132C", "133N", "133C", "134N", "134C", "135N")
-	} else {
-		msg.Custom(errors.New("unsupported number of labels"), "fatal")
-	}
-
+	var kit iso.Kit
 	if hasLabels {
+		var e error
+		kit, e = tmt.Kit(plex)
+		if e != nil {
+			msg.Custom(e, "fatal")
+		}
+
 		for _, i := range namesList {
-			for _, j := range chs {
+			for _, j := range kit.Names() {
 				l := fmt.Sprintf("%s %s", i, j)
 				v, ok := labelsList[l]
 				if ok {
@@ -201,65 +195,10 @@ func savePSMAbacusResult(session, plex string, evidences rep.CombinedPSMEvidence
 		}
 
 		if hasLabels {
-			switch plex {
-			case "10":
-				for _, j := range namesList {
-					line += fmt.Sprintf("%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t",
-						i.Labels[j].Channel1.Intensity,
-						i.Labels[j].Channel2.Intensity,
-						i.Labels[j].Channel3.Intensity,
-						i.Labels[j].Channel4.Intensity,
-						i.Labels[j].Channel5.Intensity,
-						i.Labels[j].Channel6.Intensity,
-						i.Labels[j].Channel7.Intensity,
-						i.Labels[j].Channel8.Intensity,
-						i.Labels[j].Channel9.Intensity,
-						i.Labels[j].Channel10.Intensity,
-					)
-				}
-			case "16":
-				for _, j := range namesList {
-					line += fmt.Sprintf("%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t",
-						i.Labels[j].Channel1.Intensity,
-						i.Labels[j].Channel2.Intensity,
-						i.Labels[j].Channel3.Intensity,
-						i.Labels[j].Channel4.Intensity,
-						i.Labels[j].Channel5.Intensity,
-						i.Labels[j].Channel6.Intensity,
-						i.Labels[j].Channel7.Intensity,
-						i.Labels[j].Channel8.Intensity,
-						i.Labels[j].Channel9.Intensity,
-						i.Labels[j].Channel10.Intensity,
-						i.Labels[j].Channel11.Intensity,
-						i.Labels[j].Channel12.Intensity,
-						i.Labels[j].Channel13.Intensity,
-						i.Labels[j].Channel14.Intensity,
-						i.Labels[j].Channel15.Intensity,
-						i.Labels[j].Channel16.Intensity,
-					)
-				}
-			case "18":
-				for _, j := range namesList {
-					line += fmt.Sprintf("%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t",
-						i.Labels[j].Channel1.Intensity,
-						i.Labels[j].Channel2.Intensity,
-						i.Labels[j].Channel3.Intensity,
-						i.Labels[j].Channel4.Intensity,
-						i.Labels[j].Channel5.Intensity,
-						i.Labels[j].Channel6.Intensity,
-						i.Labels[j].Channel7.Intensity,
-						i.Labels[j].Channel8.Intensity,
-						i.Labels[j].Channel9.Intensity,
-						i.Labels[j].Channel10.Intensity,
-						i.Labels[j].Channel11.Intensity,
-						i.Labels[j].Channel12.Intensity,
-						i.Labels[j].Channel13.Intensity,
-						i.Labels[j].Channel14.Intensity,
-						i.Labels[j].Channel15.Intensity,
-						i.Labels[j].Channel16.Intensity,
-						i.Labels[j].Channel17.Intensity,
-						i.Labels[j].Channel18.Intensity,
-					)
+			for _, j := range namesList {
+				l := i.Labels[j]
+				for _, k := range kit.Select(&l) {
+					line += fmt.Sprintf("%.4f\t", k.Intensity)
 				}
 			}
 		}
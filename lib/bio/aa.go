@@ -72,3 +72,20 @@ func New(name string) AminoAcid {
 
 	return aa
 }
+
+// NewFromCode return the amino acid information for the given one letter code
+func NewFromCode(code string) AminoAcid {
+
+	names := []string{"Alanine", "Arginine", "Asparagine", "Aspartic Acid", "Cysteine", "Glutamine", "Glutamic Acid", "Glycine", "Histidine", "Isoleucine", "Leucine", "Lysine", "Methionine", "Phenylalanine", "Proline", "Serine", "Threonine", "Tryptophan", "Tyrosine", "Valine"}
+
+	for _, i := range names {
+		aa := New(i)
+		if aa.Code == code {
+			return aa
+		}
+	}
+
+	msg.Custom(errors.New("amino acid code "+code+" not found"), "warning")
+
+	return AminoAcid{}
+}
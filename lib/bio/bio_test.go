@@ -145,3 +145,43 @@ func TestEnzymes(t *testing.T) {
 		t.Errorf("Enzyme is incorrect, got %s, want %s", e.Name, "glu_c")
 	}
 }
+
+func TestDigest(t *testing.T) {
+
+	var e Enzyme
+	e.Synth("trypsin")
+
+	peptides := e.Digest("MAGKPEPTIDERLLKAAAAAAK", 3, 50)
+
+	want := []string{"MAGKPEPTIDER", "LLK", "AAAAAAK"}
+	if len(peptides) != len(want) {
+		t.Fatalf("Digested peptides are incorrect, got %v, want %v", peptides, want)
+	}
+
+	for i := range want {
+		if peptides[i] != want[i] {
+			t.Errorf("Digested peptide is incorrect, got %s, want %s", peptides[i], want[i])
+		}
+	}
+
+	if peptides = e.Digest("MAGKPEPTIDERLLKAAAAAAK", 7, 10); len(peptides) != 1 {
+		t.Errorf("Peptides outside the length range should be removed, got %v", peptides)
+	}
+
+	// lys_n cleaves before the lysines
+	e = Enzyme{}
+	e.Synth("lys_n")
+
+	peptides = e.Digest("MAGKPEPTIDERLLKAAAAAAK", 1, 50)
+
+	want = []string{"MAG", "KPEPTIDERLL", "KAAAAAA", "K"}
+	if len(peptides) != len(want) {
+		t.Fatalf("Digested lys_n peptides are incorrect, got %v, want %v", peptides, want)
+	}
+
+	for i := range want {
+		if peptides[i] != want[i] {
+			t.Errorf("Digested lys_n peptide is incorrect, got %s, want %s", peptides[i], want[i])
+		}
+	}
+}
@@ -3,4 +3,10 @@ package bio
 const (
 	// Proton mass
 	Proton = 1.007276467
+
+	// Hydrogen monoisotopic mass
+	Hydrogen = 1.00782503207
+
+	// Oxygen monoisotopic mass
+	Oxygen = 15.99491461956
 )
@@ -12,6 +12,7 @@ type Enzyme struct {
 	Name    string
 	Pattern string
 	Join    string
+	NTerm   bool // cleaves before the residues instead of after them
 }
 
 // Synth is an enzyme builder
@@ -29,6 +30,7 @@ func (e *Enzyme) Synth(t string) {
 		e.Name = "lys_n"
 		e.Pattern = "K"
 		e.Join = "K"
+		e.NTerm = true
 	} else if strings.EqualFold(strings.ToLower(t), "chymotrypsin") {
 		e.Name = "chymotrypsin"
 		e.Pattern = "FWYL[^P]"
@@ -42,3 +44,45 @@ func (e *Enzyme) Synth(t string) {
 	}
 
 }
+
+// Digest cleaves the protein sequence in silico without missed cleavages, the
+// enzyme pattern lists the cleaved residues and the residues that block the
+// cleavage after them (KR[^P]). The N-terminal enzymes cleave before the listed
+// residues instead. Only the peptides inside the length range are kept
+func (e Enzyme) Digest(sequence string, minLength, maxLength int) []string {
+
+	var peptides []string
+
+	sites := e.Pattern
+	var blocked string
+	if i := strings.Index(e.Pattern, "[^"); i >= 0 {
+		sites = e.Pattern[:i]
+		blocked = strings.TrimSuffix(e.Pattern[i+2:], "]")
+	}
+
+	if len(sites) == 0 {
+		return peptides
+	}
+
+	var start int
+	for i := 0; i < len(sequence); i++ {
+
+		last := i == len(sequence)-1
+
+		var cleaved bool
+		if e.NTerm {
+			cleaved = !last && strings.IndexByte(sites, sequence[i+1]) >= 0 && strings.IndexByte(blocked, sequence[i]) < 0
+		} else {
+			cleaved = strings.IndexByte(sites, sequence[i]) >= 0 && (last || strings.IndexByte(blocked, sequence[i+1]) < 0)
+		}
+
+		if cleaved || last {
+			if length := i + 1 - start; length >= minLength && length <= maxLength {
+				peptides = append(peptides, sequence[start:i+1])
+			}
+			start = i + 1
+		}
+	}
+
+	return peptides
+}
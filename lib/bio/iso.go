@@ -0,0 +1,144 @@
+package bio
+
+const (
+	// C13Difference is the mass difference between the 13C and 12C isotopes
+	C13Difference = 1.0033548378
+
+	// averagineMass is the monoisotopic mass of one averagine unit
+	averagineMass = 111.0543
+)
+
+// Formula is the elemental composition of a molecule
+type Formula struct {
+	C float64
+	H float64
+	N float64
+	O float64
+	S float64
+}
+
+// residueFormulas holds the elemental composition of the amino acid residues
+// indexed by the one letter code
+var residueFormulas = map[byte]Formula{
+	'A': {C: 3, H: 5, N: 1, O: 1},
+	'R': {C: 6, H: 12, N: 4, O: 1},
+	'N': {C: 4, H: 6, N: 2, O: 2},
+	'D': {C: 4, H: 5, N: 1, O: 3},
+	'C': {C: 3, H: 5, N: 1, O: 1, S: 1},
+	'E': {C: 5, H: 7, N: 1, O: 3},
+	'Q': {C: 5, H: 8, N: 2, O: 2},
+	'G': {C: 2, H: 3, N: 1, O: 1},
+	'H': {C: 6, H: 7, N: 3, O: 1},
+	'I': {C: 6, H: 11, N: 1, O: 1},
+	'L': {C: 6, H: 11, N: 1, O: 1},
+	'K': {C: 6, H: 12, N: 2, O: 1},
+	'M': {C: 5, H: 9, N: 1, O: 1, S: 1},
+	'F': {C: 9, H: 9, N: 1, O: 1},
+	'P': {C: 5, H: 7, N: 1, O: 1},
+	'S': {C: 3, H: 5, N: 1, O: 2},
+	'T': {C: 4, H: 7, N: 1, O: 2},
+	'W': {C: 11, H: 10, N: 2, O: 1},
+	'Y': {C: 9, H: 9, N: 1, O: 2},
+	'V': {C: 5, H: 9, N: 1, O: 1},
+}
+
+// averagine is the average composition of one amino acid residue, used for
+// the unknown residues and the modifications
+var averagine = Formula{C: 4.9384, H: 7.7583, N: 1.3577, O: 1.4773, S: 0.0417}
+
+// isotopeAbundances holds the natural abundance of each element isotope,
+// spaced by one nominal mass unit
+var isotopeAbundances = map[string][]float64{
+	"C": {0.9893, 0.0107},
+	"H": {0.999885, 0.000115},
+	"N": {0.99636, 0.00364},
+	"O": {0.99757, 0.00038, 0.00205},
+	"S": {0.9499, 0.0075, 0.0425, 0, 0.0001},
+}
+
+// PeptideFormula returns the elemental composition of a peptide sequence, the
+// mass difference from the modifications is approximated by averagine units
+func PeptideFormula(sequence string, modificationMass float64) Formula {
+
+	// water from the termini
+	var f = Formula{H: 2, O: 1}
+
+	for i := 0; i < len(sequence); i++ {
+		r, ok := residueFormulas[sequence[i]]
+		if !ok {
+			r = averagine
+		}
+		f = f.add(r, 1)
+	}
+
+	if modificationMass > 0 {
+		f = f.add(averagine, modificationMass/averagineMass)
+	}
+
+	return f
+}
+
+// add returns the formula with n units of the other formula added
+func (f Formula) add(o Formula, n float64) Formula {
+	return Formula{
+		C: f.C + o.C*n,
+		H: f.H + o.H*n,
+		N: f.N + o.N*n,
+		O: f.O + o.O*n,
+		S: f.S + o.S*n,
+	}
+}
+
+// IsotopeDistribution returns the relative abundances of the first n isotopic
+// peaks of the formula, normalized to sum one
+func (f Formula) IsotopeDistribution(n int) []float64 {
+
+	if n < 1 {
+		return nil
+	}
+
+	var distribution = make([]float64, n)
+	distribution[0] = 1
+
+	counts := map[string]float64{"C": f.C, "H": f.H, "N": f.N, "O": f.O, "S": f.S}
+	for _, element := range []string{"C", "H", "N", "O", "S"} {
+
+		// the fractional atoms from averagine are rounded
+		atoms := int(counts[element] + 0.5)
+		for i := 0; i < atoms; i++ {
+			distribution = convolve(distribution, isotopeAbundances[element])
+		}
+	}
+
+	var sum float64
+	for _, i := range distribution {
+		sum += i
+	}
+
+	for i := range distribution {
+		distribution[i] /= sum
+	}
+
+	return distribution
+}
+
+// convolve combines two isotope distributions keeping the length of the first one
+func convolve(a, b []float64) []float64 {
+
+	var result = make([]float64, len(a))
+
+	for i := range a {
+		for j := range b {
+			if i+j < len(result) {
+				result[i+j] += a[i] * b[j]
+			}
+		}
+	}
+
+	return result
+}
+
+// MonoisotopicMass returns the monoisotopic mass of the formula
+func (f Formula) MonoisotopicMass() float64 {
+	return f.C*12 + f.H*Hydrogen + f.N*14.0030740048 + f.O*Oxygen + f.S*31.97207100
+}
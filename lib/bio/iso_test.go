@@ -0,0 +1,38 @@
+package bio
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPeptideFormula(t *testing.T) {
+
+	f := PeptideFormula("PEPTIDE", 0)
+	want := Formula{C: 34, H: 53, N: 7, O: 15}
+
+	if f != want {
+		t.Errorf("PeptideFormula() = %+v, want %+v", f, want)
+	}
+
+	if mass := f.MonoisotopicMass(); math.Abs(mass-799.3600) > 0.001 {
+		t.Errorf("MonoisotopicMass() = %f, want %f", mass, 799.3600)
+	}
+}
+
+func TestFormula_IsotopeDistribution(t *testing.T) {
+
+	d := PeptideFormula("PEPTIDE", 0).IsotopeDistribution(3)
+
+	if len(d) != 3 {
+		t.Fatalf("IsotopeDistribution() returned %d peaks, want %d", len(d), 3)
+	}
+
+	// C34H53N7O15 has a first isotope at about 40% of the monoisotopic peak
+	if ratio := d[1] / d[0]; math.Abs(ratio-0.405) > 0.01 {
+		t.Errorf("IsotopeDistribution() M+1/M ratio = %f, want %f", ratio, 0.405)
+	}
+
+	if d[2] >= d[1] {
+		t.Errorf("IsotopeDistribution() M+2 should be lower than M+1, got %v", d)
+	}
+}
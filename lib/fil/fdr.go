@@ -10,6 +10,7 @@ import (
 	"philosopher/lib/cla"
 	"philosopher/lib/id"
 	"philosopher/lib/msg"
+	"philosopher/lib/uti"
 
 	"github.com/sirupsen/logrus"
 )
@@ -76,6 +77,8 @@ func PepXMLFDRFilter(input map[string]id.PepIDListPtrs, targetFDR float64, level
 	sort.Sort(list)
 
 	var scoreMap = make(map[float64]float64)
+	var targetMap = make(map[float64]float64)
+	var decoyMap = make(map[float64]float64)
 
 	limit := (len(list) - 1)
 
@@ -85,12 +88,17 @@ func PepXMLFDRFilter(input map[string]id.PepIDListPtrs, targetFDR float64, level
 
 		if cla.IsDecoyPSM(*list[j], decoyTag) {
 			decoys--
+			decoyMap[list[j].Probability]++
 		} else {
 			targets--
+			targetMap[list[j].Probability]++
 		}
 
 	}
 
+	qValues := uti.QValueMap(scoreMap)
+	peps := uti.PEPMap(targetMap, decoyMap)
+
 	var keys []float64
 	for k := range scoreMap {
 		keys = append(keys, k)
@@ -112,7 +120,11 @@ func PepXMLFDRFilter(input map[string]id.PepIDListPtrs, targetFDR float64, level
 	for i := range list {
 		if list[i].Probability >= minProb {
 
-			cleanlist = append(cleanlist, list[i])
+			// each level gets its own copy so the q-values from one level do not overwrite the others
+			psm := *list[i]
+			psm.Qvalue = qValues[psm.Probability]
+			psm.PEP = peps[psm.Probability]
+			cleanlist = append(cleanlist, &psm)
 
 			if cla.IsDecoyPSM(*list[i], decoyTag) {
 				decoys++
@@ -483,17 +495,24 @@ func ProtXMLFilter(p id.ProtXML, targetFDR, pepProb, protProb float64, isPicked,
 	// the score is only calculates to the first (last) protein in each block
 	// proteins with the same score, get the same fdr value.
 	var scoreMap = make(map[float64]float64)
+	var targetMap = make(map[float64]float64)
+	var decoyMap = make(map[float64]float64)
 	for j := (len(list) - 1); j >= 0; j-- {
 
 		scoreMap[list[j].TopPepProb] = float64(decoys) / float64(targets)
 
 		if cla.IsDecoyProtein(list[j], p.DecoyTag) {
 			decoys--
+			decoyMap[list[j].TopPepProb]++
 		} else {
 			targets--
+			targetMap[list[j].TopPepProb]++
 		}
 	}
 
+	qValues := uti.QValueMap(scoreMap)
+	peps := uti.PEPMap(targetMap, decoyMap)
+
 	var keys []float64
 	for k := range scoreMap {
 		keys = append(keys, k)
@@ -577,6 +596,8 @@ func ProtXMLFilter(p id.ProtXML, targetFDR, pepProb, protProb float64, isPicked,
 	for i := range list {
 		_, ok := probList[list[i].TopPepProb]
 		if ok {
+			list[i].Qvalue = qValues[list[i].TopPepProb]
+			list[i].PEP = peps[list[i].TopPepProb]
 			cleanlist = append(cleanlist, list[i])
 			if cla.IsDecoyProtein(list[i], p.DecoyTag) {
 				decoys++
@@ -14,6 +14,7 @@ import (
 	"philosopher/lib/inf"
 	"philosopher/lib/met"
 	"philosopher/lib/mod"
+	"philosopher/lib/msg"
 	"philosopher/lib/rep"
 	"philosopher/lib/sys"
 
@@ -62,10 +63,40 @@ func Run(f met.Data) met.Data {
 		f.Filter.TwoD = true
 	}
 
-	pepid, searchEngine := id.ReadPepXMLInput(f.Filter.Pex, f.Filter.Tag, f.Temp, f.Filter.Model)
+	var pepid id.PepIDListPtrs
+	var searchEngine string
+
+	if f.Filter.Rescored {
+
+		// the identifications were already read and rescored by the rescore command
+		var pepxml id.PepXML
+		pepxml.Restore()
+		pepid = id.ToPepIDListPtrs(pepxml.PeptideIdentification)
+		searchEngine = pepxml.SearchEngine
+
+	} else if len(f.Filter.Mzid) > 0 {
+		pepid, searchEngine = id.ReadIdentificationInput(f.Filter.Mzid, "mzid", f.Filter.Tag, f.Filter.ScoreName, f.Temp, f.Filter.Model)
+	} else {
+		pepid, searchEngine = id.ReadIdentificationInput(f.Filter.Pex, "", f.Filter.Tag, f.Filter.ScoreName, f.Temp, f.Filter.Model)
+	}
+
+	// the PSMs are ranked by a search engine score only when it is selected, missing
+	// probabilities usually mean that PeptideProphet failed upstream
+	score := f.Filter.Score
+	if len(score) == 0 && !hasProbabilities(pepid) {
+		if hasExpectations(pepid) {
+			msg.Custom(errors.New("the PSMs have no probabilities, check the PeptideProphet results or rank the PSMs by their e-values with --score expect"), "fatal")
+		}
+		msg.Custom(errors.New("the PSMs have no probabilities or e-values, run PeptideProphet or select a search engine score with --score"), "fatal")
+	}
 
 	f.SearchEngine = searchEngine
 
+	// without PeptideProphet probabilities, the PSMs are ranked by the search engine score
+	if len(score) > 0 {
+		pepid = rankBySearchScore(score, f.Filter.Tag)
+	}
+
 	psmT, pepT, ionT := processPeptideIdentifications(pepid, f.Filter.Tag, f.Filter.Mods, f.Filter.PsmFDR, f.Filter.PepFDR, f.Filter.IonFDR, f.Filter.Delta)
 	_ = psmT
 	_ = pepT
@@ -89,14 +120,16 @@ func Run(f met.Data) met.Data {
 			var filteredPSM id.PepIDList
 			filteredPSM.Restore("psm")
 
-			pepid, razorMap, coverMap := inf.ProteinInference(filteredPSM)
+			inferredPSM, razorMap, coverMap := inf.ProteinInference(filteredPSM)
 			filteredPSM = nil
 
-			pepid.Serialize("psm")
-			pepid.Serialize("pep")
-			pepid.Serialize("ion")
+			inferredPSM.Serialize("psm")
+
+			filteredPeptides, filteredIons := inferredLevels(pepid, inferredPSM, f.Filter.PepFDR, f.Filter.IonFDR, f.Filter.Tag)
+			filteredPeptides.Serialize("pep")
+			filteredIons.Serialize("ion")
 
-			processProteinInferenceIdentifications(pepid, razorMap, coverMap, f.Filter.PtFDR, f.Filter.PepFDR, f.Filter.ProtProb, f.Filter.Picked, f.Filter.Tag)
+			processProteinInferenceIdentifications(inferredPSM, razorMap, coverMap, f.Filter.PtFDR, f.Filter.PepFDR, f.Filter.ProtProb, f.Filter.Picked, f.Filter.Tag)
 		}
 	}
 	var pepxml id.PepXML
@@ -104,6 +137,7 @@ func Run(f met.Data) met.Data {
 	// restoring for the modifications
 	e.Mods = pepxml.Modifications
 	e.AssembleSearchParameters(pepxml.SearchParameters)
+	e.Parameters.SearchEngineVersion = pepxml.SearchEngineVersion
 
 	if f.Filter.Seq {
 		// sequential analysis
@@ -283,6 +317,7 @@ func Run(f met.Data) met.Data {
 	}).Info("Final report numbers after FDR filtering, and post-processing")
 	logrus.Info("Saving")
 	e.SerializeGranular()
+	e.SerializeSearch()
 
 	return f
 }
@@ -363,6 +398,31 @@ func processPeptideIdentifications(p id.PepIDListPtrs, decoyTag, mods string, ps
 	return psmThreshold, peptideThreshold, ionThreshold
 }
 
+// inferredLevels runs the peptide and ion target-decoy estimation on all PSMs, with the protein
+// assignments of the inference, so each level keeps its own q-values instead of the PSM ones.
+// The inference only sees the filtered PSMs, the decoys are still needed for the estimation
+func inferredLevels(p id.PepIDListPtrs, inferred id.PepIDList, peptide, ion float64, decoyTag string) (id.PepIDListPtrs, id.PepIDListPtrs) {
+
+	var assigned = make(map[string]*id.PeptideIdentification)
+	for i := range inferred {
+		assigned[inferred[i].SpectrumFileName().Str()] = &inferred[i]
+	}
+
+	var psms id.PepIDListPtrs
+	for _, i := range p {
+		if j, ok := assigned[i.SpectrumFileName().Str()]; ok {
+			psms = append(psms, j)
+		} else {
+			psms = append(psms, i)
+		}
+	}
+
+	filteredPeptides, _ := PepXMLFDRFilter(GetUniquePeptides(psms), peptide, "Peptide", decoyTag, "")
+	filteredIons, _ := PepXMLFDRFilter(getUniquePeptideIons(psms), ion, "Ion", decoyTag, "")
+
+	return filteredPeptides, filteredIons
+}
+
 func deltaMassBasedPSMFiltering(uniqPsms map[string]id.PepIDListPtrs, targetFDR float64, decoyTag string) {
 
 	logrus.Info("Separating PSMs based on the delta mass profile")
@@ -0,0 +1,57 @@
+package fil
+
+import (
+	"testing"
+
+	"philosopher/lib/id"
+)
+
+func Test_inferredLevels(t *testing.T) {
+
+	psms := id.PepIDList{
+		{Spectrum: "run.1.1.2", Peptide: "AAAK", AssumedCharge: 2, Protein: "sp|P1", Probability: 0.9},
+		{Spectrum: "run.2.2.2", Peptide: "AAAK", AssumedCharge: 2, Protein: "sp|P1", Probability: 0.85},
+		{Spectrum: "run.3.3.2", Peptide: "AAAK", AssumedCharge: 2, Protein: "sp|P1", Probability: 0.8},
+		{Spectrum: "run.4.4.2", Peptide: "DDDK", AssumedCharge: 2, Protein: "rev_sp|P9", Probability: 0.75},
+		{Spectrum: "run.5.5.2", Peptide: "CCCK", AssumedCharge: 2, Protein: "sp|P2", Probability: 0.7},
+	}
+
+	filteredPSM, _ := PepXMLFDRFilter(GetUniquePSMs(id.ToPepIDListPtrs(psms)), 1, "PSM", "rev_", "")
+
+	// the inference only sees the filtered targets and may assign them to another protein
+	inferred := id.PepIDList{psms[0], psms[1], psms[2], psms[4]}
+	inferred[3].Protein = "sp|P3"
+
+	peptides, ions := inferredLevels(id.ToPepIDListPtrs(psms), inferred, 1, 1, "rev_")
+
+	if len(peptides) != 3 || len(ions) != 3 {
+		t.Fatalf("inferredLevels() got %d peptides and %d ions, want 3 and 3", len(peptides), len(ions))
+	}
+
+	var psmQvalue, peptideQvalue, ionQvalue float64
+	for _, i := range filteredPSM {
+		if i.Peptide == "CCCK" {
+			psmQvalue = i.Qvalue
+		}
+	}
+
+	for _, i := range peptides {
+		if i.Peptide == "CCCK" {
+			peptideQvalue = i.Qvalue
+			if i.Protein != "sp|P3" {
+				t.Errorf("inferredLevels() protein is %s, want sp|P3", i.Protein)
+			}
+		}
+	}
+
+	for _, i := range ions {
+		if i.Peptide == "CCCK" {
+			ionQvalue = i.Qvalue
+		}
+	}
+
+	// the repeated AAAK PSMs count once at the peptide level
+	if psmQvalue != 0.25 || peptideQvalue != 0.5 || ionQvalue != 0.5 {
+		t.Errorf("inferredLevels() q-values are PSM %f, peptide %f, ion %f, want 0.25, 0.5 and 0.5", psmQvalue, peptideQvalue, ionQvalue)
+	}
+}
@@ -0,0 +1,182 @@
+package fil
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+
+	"philosopher/lib/cla"
+	"philosopher/lib/id"
+	"philosopher/lib/msg"
+
+	"github.com/sirupsen/logrus"
+)
+
+// scoreFunc returns a search engine score where higher values are better
+type scoreFunc func(p *id.PeptideIdentification) float64
+
+// getScoreFunc selects the search engine score used for ranking the PSMs
+func getScoreFunc(score string) scoreFunc {
+
+	switch strings.ToLower(score) {
+	case "hyperscore":
+		return func(p *id.PeptideIdentification) float64 { return p.Hyperscore }
+	case "xcorr":
+		return func(p *id.PeptideIdentification) float64 { return p.Xcorr }
+	case "expect":
+		// a missing or zero e-value carries no evidence, so the PSM ranks last
+		return func(p *id.PeptideIdentification) float64 {
+			if p.Expectation <= 0 {
+				return math.Inf(-1)
+			}
+			return -math.Log10(p.Expectation)
+		}
+	case "custom":
+		return func(p *id.PeptideIdentification) float64 { return p.DiscriminantValue }
+	default:
+		msg.Custom(errors.New("unknown score type "+score+", valid options are hyperscore, expect, xcorr and custom"), "fatal")
+	}
+
+	return nil
+}
+
+// scanKey removes the assumed charge from the spectrum name, so all the charge
+// states searched for the same scan compete against each other
+func scanKey(p *id.PeptideIdentification) string {
+
+	spectrum := p.Spectrum
+	if i := strings.LastIndex(spectrum, "."); i > 0 {
+		spectrum = spectrum[:i]
+	}
+
+	return spectrum + "#" + p.SpectrumFile
+}
+
+// TargetDecoyCompetition ranks the PSMs by the given search engine score, keeps
+// only the best target or decoy hit for each spectrum and assigns to each winner
+// a probability of 1 - q-value. This allows the FDR filters to work on results
+// that were not processed by PeptideProphet.
+func TargetDecoyCompetition(p id.PepIDListPtrs, score, decoyTag string) id.PepIDListPtrs {
+
+	fn := getScoreFunc(score)
+
+	var best = make(map[string]*id.PeptideIdentification)
+	for _, i := range p {
+		key := scanKey(i)
+		v, ok := best[key]
+		if !ok {
+			best[key] = i
+			continue
+		}
+
+		// on ties, the decoy wins to keep the estimation conservative
+		if fn(i) > fn(v) || (fn(i) == fn(v) && cla.IsDecoyPSM(*i, decoyTag)) {
+			best[key] = i
+		}
+	}
+
+	var list id.PepIDListPtrs
+	for _, v := range best {
+		list = append(list, v)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if fn(list[i]) == fn(list[j]) {
+			return scanKey(list[i]) < scanKey(list[j])
+		}
+		return fn(list[i]) > fn(list[j])
+	})
+
+	var targets, decoys float64
+	var fdr = make([]float64, len(list))
+	for i := range list {
+		if cla.IsDecoyPSM(*list[i], decoyTag) {
+			decoys++
+		} else {
+			targets++
+		}
+
+		if targets > 0 {
+			fdr[i] = decoys / targets
+		} else {
+			fdr[i] = 1
+		}
+	}
+
+	// PSMs sharing the same score must share the same FDR
+	for i := len(list) - 2; i >= 0; i-- {
+		if fn(list[i]) == fn(list[i+1]) {
+			fdr[i] = fdr[i+1]
+		}
+	}
+
+	// the q-value is the lowest FDR at which the PSM is accepted
+	var qValue = 1.0
+	for i := len(list) - 1; i >= 0; i-- {
+		if fdr[i] < qValue {
+			qValue = fdr[i]
+		}
+		list[i].Probability = 1 - math.Min(qValue, 1)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"psms":    len(p),
+		"spectra": len(list),
+		"score":   score,
+	}).Info("Target-decoy competition")
+
+	return list
+}
+
+// hasProbabilities checks if any of the PSMs carries a probability assigned by a
+// validation tool
+func hasProbabilities(p id.PepIDListPtrs) bool {
+
+	for _, i := range p {
+		if i.Probability > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasExpectations checks if any of the PSMs carries an e-value assigned by the search engine
+func hasExpectations(p id.PepIDListPtrs) bool {
+
+	for _, i := range p {
+		if i.Expectation > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rankBySearchScore replaces the serialized pepXML identifications with the
+// winners of the target-decoy competition
+func rankBySearchScore(score, decoyTag string) id.PepIDListPtrs {
+
+	var pepXML id.PepXML
+	pepXML.Restore()
+
+	list := TargetDecoyCompetition(id.ToPepIDListPtrs(pepXML.PeptideIdentification), score, decoyTag)
+	sort.Sort(list)
+
+	var ser id.PepXML4Serialiazation
+	ser.FileName = pepXML.FileName
+	ser.SpectraFile = pepXML.SpectraFile
+	ser.SearchEngine = pepXML.SearchEngine
+	ser.SearchEngineVersion = pepXML.SearchEngineVersion
+	ser.DecoyTag = pepXML.DecoyTag
+	ser.Database = pepXML.Database
+	ser.Prophet = pepXML.Prophet
+	ser.SearchParameters = pepXML.SearchParameters
+	ser.Models = pepXML.Models
+	ser.Modifications = pepXML.Modifications
+	ser.PeptideIdentification = list
+	ser.Serialize()
+
+	return list
+}
@@ -0,0 +1,74 @@
+package fil
+
+import (
+	"testing"
+
+	"philosopher/lib/id"
+)
+
+func TestTargetDecoyCompetition(t *testing.T) {
+
+	list := id.PepIDListPtrs{
+		{Spectrum: "run.1.1.2", SpectrumFile: "a.pep.xml", Protein: "sp|P1", Hyperscore: 40},
+		{Spectrum: "run.1.1.3", SpectrumFile: "a.pep.xml", Protein: "rev_sp|P9", Hyperscore: 20},
+		{Spectrum: "run.2.2.2", SpectrumFile: "a.pep.xml", Protein: "sp|P2", Hyperscore: 35},
+		{Spectrum: "run.3.3.2", SpectrumFile: "a.pep.xml", Protein: "rev_sp|P8", Hyperscore: 30},
+		{Spectrum: "run.4.4.2", SpectrumFile: "a.pep.xml", Protein: "sp|P3", Hyperscore: 25},
+		{Spectrum: "run.5.5.2", SpectrumFile: "a.pep.xml", Protein: "sp|P4", Hyperscore: 10},
+	}
+
+	got := TargetDecoyCompetition(list, "hyperscore", "rev_")
+
+	if len(got) != 5 {
+		t.Fatalf("TargetDecoyCompetition() got %d spectra, want %d", len(got), 5)
+	}
+
+	want := map[string]float64{
+		"run.1.1.2": 1,
+		"run.2.2.2": 1,
+		"run.3.3.2": 0.75,
+		"run.4.4.2": 0.75,
+		"run.5.5.2": 0.75,
+	}
+
+	for _, i := range got {
+		v, ok := want[i.Spectrum]
+		if !ok {
+			t.Errorf("TargetDecoyCompetition() kept the losing hit %s", i.Spectrum)
+			continue
+		}
+		if i.Probability < v-1e-9 || i.Probability > v+1e-9 {
+			t.Errorf("TargetDecoyCompetition() %s probability = %f, want %f", i.Spectrum, i.Probability, v)
+		}
+	}
+}
+
+func TestTargetDecoyCompetition_ZeroExpectation(t *testing.T) {
+
+	list := id.PepIDListPtrs{
+		{Spectrum: "run.1.1.2", SpectrumFile: "a.pep.xml", Protein: "sp|P1", Expectation: 1e-8},
+		{Spectrum: "run.2.2.2", SpectrumFile: "a.pep.xml", Protein: "sp|P2", Expectation: 1e-6},
+		{Spectrum: "run.3.3.2", SpectrumFile: "a.pep.xml", Protein: "rev_sp|P8", Expectation: 1e-2},
+		{Spectrum: "run.4.4.2", SpectrumFile: "a.pep.xml", Protein: "sp|P3", Expectation: 0},
+	}
+
+	got := TargetDecoyCompetition(list, "expect", "rev_")
+
+	if len(got) != 4 {
+		t.Fatalf("TargetDecoyCompetition() got %d spectra, want %d", len(got), 4)
+	}
+
+	if got[len(got)-1].Spectrum != "run.4.4.2" {
+		t.Errorf("TargetDecoyCompetition() ranked the zero expectation PSM as %s, want it last", got[len(got)-1].Spectrum)
+	}
+
+	for _, i := range got {
+		// the zero expectation PSM comes after the decoy, it would be accepted at 0% FDR when ranked first
+		if i.Spectrum == "run.4.4.2" && (i.Probability < 2.0/3-1e-9 || i.Probability > 2.0/3+1e-9) {
+			t.Errorf("TargetDecoyCompetition() zero expectation PSM probability = %f, want %f", i.Probability, 2.0/3)
+		}
+		if i.Spectrum == "run.1.1.2" && i.Probability < 1-1e-9 {
+			t.Errorf("TargetDecoyCompetition() best PSM probability = %f, want %f", i.Probability, 1.0)
+		}
+	}
+}
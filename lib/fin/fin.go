@@ -504,6 +504,7 @@ type RawData struct {
 	Detector        []string
 	Scanevents      ScanEvents
 	Scanindex       ScanIndex
+	trailer         *trailerIndex
 }
 
 // ProcessRaw calls other low level functions and fill out RawData struct
@@ -541,6 +542,16 @@ func (rd *RawData) ProcessRaw(f string) {
 		scanindex[i].Offset += rh.DataAddr
 	}
 
+	// the trailer carries the charge state, master scan and FAIMS CV of each scan
+	stat, e := file.Stat()
+	if e != nil {
+		msg.ReadFile(e, "fatal")
+	}
+
+	if trailer, ok := readTrailerIndex(file, stat.Size(), rh, nScans); ok {
+		rd.trailer = &trailer
+	}
+
 	rd.File = file
 	rd.FileName = f
 	rd.Version = ver
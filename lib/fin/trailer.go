@@ -0,0 +1,277 @@
+package fin
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// maxTrailerFields is the largest number of fields accepted in a generic data header
+const maxTrailerFields = 1000
+
+// maxTrailerSearch is how far before a known label the generic data header is searched
+const maxTrailerSearch = 1 << 16
+
+// chargeStateLabel is the trailer field used to locate the scan trailer header
+const chargeStateLabel = "Charge State:"
+
+// GenericDataDescriptor describes one field of a generic record, like the
+// scan trailer values (charge state, monoisotopic m/z, FAIMS CV ...)
+type GenericDataDescriptor struct {
+	Type   uint32
+	Length uint32
+	Label  PascalString
+}
+
+// GenericDataHeader is the list of descriptors of a generic record
+type GenericDataHeader struct {
+	N           uint32
+	Descriptors []GenericDataDescriptor
+}
+
+// trailerIndex holds the position and layout of the per scan trailer records
+type trailerIndex struct {
+	header     GenericDataHeader
+	start      uint64
+	recordSize uint64
+}
+
+// size returns the number of bytes a field of this descriptor takes in a record
+func (data GenericDataDescriptor) size() (uint64, bool) {
+
+	switch data.Type {
+	case 0x0:
+		return 0, true
+	case 0x1, 0x2, 0x3, 0x4, 0x5:
+		return 1, true
+	case 0x6, 0x7:
+		return 2, true
+	case 0x8, 0x9, 0xA:
+		return 4, true
+	case 0xB:
+		return 8, true
+	case 0xC:
+		return uint64(data.Length), true
+	case 0xD:
+		return 2 * uint64(data.Length), true
+	}
+
+	return 0, false
+}
+
+// value formats a field of this descriptor read from a record
+func (data GenericDataDescriptor) value(b []byte) string {
+
+	switch data.Type {
+	case 0x1:
+		return string(b[:1])
+	case 0x2, 0x3, 0x4, 0x5:
+		return strconv.Itoa(int(b[0]))
+	case 0x6:
+		return strconv.Itoa(int(int16(binary.LittleEndian.Uint16(b))))
+	case 0x7:
+		return strconv.Itoa(int(binary.LittleEndian.Uint16(b)))
+	case 0x8:
+		return strconv.Itoa(int(int32(binary.LittleEndian.Uint32(b))))
+	case 0x9:
+		return strconv.FormatUint(uint64(binary.LittleEndian.Uint32(b)), 10)
+	case 0xA:
+		return strconv.FormatFloat(float64(math.Float32frombits(binary.LittleEndian.Uint32(b))), 'f', -1, 32)
+	case 0xB:
+		return strconv.FormatFloat(math.Float64frombits(binary.LittleEndian.Uint64(b)), 'f', -1, 64)
+	case 0xC:
+		return strings.TrimSpace(strings.TrimRight(string(b), "\x00"))
+	case 0xD:
+		text := make([]uint16, len(b)/2)
+		for i := range text {
+			text[i] = binary.LittleEndian.Uint16(b[2*i:])
+		}
+		return strings.TrimSpace(strings.TrimRight(string(utf16.Decode(text)), "\x00"))
+	}
+
+	return ""
+}
+
+// Read parses the header and validates it, the labels must be printable and
+// the field types known, otherwise N is set to 0
+func (data *GenericDataHeader) Read(r io.Reader, v Version) {
+
+	if e := binary.Read(r, binary.LittleEndian, &data.N); e != nil || data.N == 0 || data.N > maxTrailerFields {
+		data.N = 0
+		return
+	}
+
+	data.Descriptors = make([]GenericDataDescriptor, data.N)
+	for i := range data.Descriptors {
+
+		d := &data.Descriptors[i]
+		if binary.Read(r, binary.LittleEndian, &d.Type) != nil || binary.Read(r, binary.LittleEndian, &d.Length) != nil {
+			data.N = 0
+			return
+		}
+
+		if _, ok := d.size(); !ok || d.Length > 1<<16 {
+			data.N = 0
+			return
+		}
+
+		if binary.Read(r, binary.LittleEndian, &d.Label.Length) != nil || d.Label.Length < 0 || d.Label.Length > 256 {
+			data.N = 0
+			return
+		}
+
+		d.Label.Text = make([]uint16, d.Label.Length)
+		if binary.Read(r, binary.LittleEndian, &d.Label.Text) != nil {
+			data.N = 0
+			return
+		}
+
+		for _, c := range d.Label.Text {
+			if c < 0x20 || c > 0x7e {
+				data.N = 0
+				return
+			}
+		}
+	}
+
+}
+
+// recordSize returns the number of bytes of one record described by the header
+func (data GenericDataHeader) recordSize() uint64 {
+
+	var size uint64
+	for _, i := range data.Descriptors {
+		s, _ := i.size()
+		size += s
+	}
+
+	return size
+}
+
+// readTrailerIndex locates the scan trailer records. The header describing
+// them is not referenced by the RunHeader, it sits among the other headers
+// before the scan index, so it is found by its charge state label and
+// validated against the size of the records at ScanparamsAddr
+func readTrailerIndex(file io.ReaderAt, size int64, rh RunHeader, nScans uint64) (trailerIndex, bool) {
+
+	var index trailerIndex
+
+	begin := rh.ErrorlogAddr
+	if begin == 0 || begin >= rh.ScanindexAddr {
+		begin = rh.Address
+	}
+
+	end := rh.ScanindexAddr
+	if end <= begin || end > uint64(size) || rh.ScanparamsAddr == 0 || rh.ScanparamsAddr >= uint64(size) || nScans == 0 {
+		return index, false
+	}
+
+	region := make([]byte, end-begin)
+	if _, e := file.ReadAt(region, int64(begin)); e != nil && e != io.EOF {
+		return index, false
+	}
+
+	var label bytes.Buffer
+	binary.Write(&label, binary.LittleEndian, int32(len(chargeStateLabel)))
+	binary.Write(&label, binary.LittleEndian, utf16.Encode([]rune(chargeStateLabel)))
+
+	// the records may be preceded by their count
+	start := rh.ScanparamsAddr
+	var count uint32
+	binary.Read(io.NewSectionReader(file, int64(start), 4), binary.LittleEndian, &count)
+	if uint64(count) == nScans {
+		start += 4
+	}
+	available := uint64(size) - start
+
+	var found bool
+	var fallback trailerIndex
+
+	for offset := 0; ; {
+
+		l := bytes.Index(region[offset:], label.Bytes())
+		if l < 0 {
+			break
+		}
+		l += offset
+		offset = l + 1
+
+		// the label is preceded by the type and length of its descriptor
+		for h := l - 12; h >= 0 && h >= l-maxTrailerSearch; h-- {
+
+			var header GenericDataHeader
+			header.Read(bytes.NewReader(region[h:]), 0)
+			if header.N == 0 || !header.contains(h, l) {
+				continue
+			}
+
+			recordSize := header.recordSize()
+			if recordSize == 0 || recordSize*nScans > available {
+				continue
+			}
+
+			candidate := trailerIndex{header: header, start: start, recordSize: recordSize}
+
+			// the trailer records are the last stream of the file
+			if recordSize*nScans == available {
+				return candidate, true
+			}
+
+			if !found {
+				fallback = candidate
+				found = true
+			}
+		}
+	}
+
+	return fallback, found
+}
+
+// contains reports if the header that starts at position h has a descriptor
+// whose label starts at position l
+func (data GenericDataHeader) contains(h, l int) bool {
+
+	position := h + 4
+	for _, i := range data.Descriptors {
+		if position+8 == l && i.Label.String() == chargeStateLabel {
+			return true
+		}
+		position += 12 + 2*int(i.Label.Length)
+	}
+
+	return false
+}
+
+// Trailer returns the trailer values of the scan at the scan number in
+// argument, indexed by their label without the trailing colon. An empty map is
+// returned when the trailer could not be located in the file
+func (rd *RawData) Trailer(sn int) map[string]string {
+
+	var values = make(map[string]string)
+
+	if rd.trailer == nil || sn < 1 || sn > rd.NScans() {
+		return values
+	}
+
+	record := make([]byte, rd.trailer.recordSize)
+	if _, e := rd.File.ReadAt(record, int64(rd.trailer.start+uint64(sn-1)*rd.trailer.recordSize)); e != nil {
+		return values
+	}
+
+	var position uint64
+	for _, i := range rd.trailer.header.Descriptors {
+
+		size, _ := i.size()
+		if size > 0 {
+			label := strings.TrimSuffix(strings.TrimSpace(i.Label.String()), ":")
+			values[label] = i.value(record[position : position+size])
+		}
+		position += size
+	}
+
+	return values
+}
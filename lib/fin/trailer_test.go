@@ -0,0 +1,82 @@
+package fin
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+)
+
+func writeDescriptor(b *bytes.Buffer, t, length uint32, label string) {
+	binary.Write(b, binary.LittleEndian, t)
+	binary.Write(b, binary.LittleEndian, length)
+	binary.Write(b, binary.LittleEndian, int32(len(label)))
+	binary.Write(b, binary.LittleEndian, utf16.Encode([]rune(label)))
+}
+
+func TestRawData_Trailer(t *testing.T) {
+
+	var file bytes.Buffer
+
+	// unrelated data before the header
+	file.Write(bytes.Repeat([]byte{0xff}, 37))
+
+	binary.Write(&file, binary.LittleEndian, uint32(5))
+	writeDescriptor(&file, 0x0, 0, "Trailer Extra:")
+	writeDescriptor(&file, 0x6, 0, "Charge State:")
+	writeDescriptor(&file, 0xB, 0, "Monoisotopic M/Z:")
+	writeDescriptor(&file, 0x8, 0, "Master Scan Number:")
+	writeDescriptor(&file, 0xA, 0, "FAIMS CV:")
+
+	scanIndex := uint64(file.Len())
+	file.Write(make([]byte, 64))
+
+	params := uint64(file.Len())
+	binary.Write(&file, binary.LittleEndian, uint32(2))
+
+	// MS1 then MS2 scan, 18 bytes per record
+	binary.Write(&file, binary.LittleEndian, int16(0))
+	binary.Write(&file, binary.LittleEndian, math.Float64bits(0))
+	binary.Write(&file, binary.LittleEndian, int32(0))
+	binary.Write(&file, binary.LittleEndian, math.Float32bits(-45))
+
+	binary.Write(&file, binary.LittleEndian, int16(3))
+	binary.Write(&file, binary.LittleEndian, math.Float64bits(612.8123))
+	binary.Write(&file, binary.LittleEndian, int32(1))
+	binary.Write(&file, binary.LittleEndian, math.Float32bits(-45))
+
+	dir, _ := ioutil.TempDir("", "fin")
+	defer os.RemoveAll(dir)
+
+	fileName := filepath.Join(dir, "test.raw")
+	ioutil.WriteFile(fileName, file.Bytes(), 0644)
+
+	f, _ := os.Open(fileName)
+	defer f.Close()
+
+	rh := RunHeader{ScanindexAddr: scanIndex, ScanparamsAddr: params}
+
+	trailer, ok := readTrailerIndex(f, int64(file.Len()), rh, 2)
+	if !ok {
+		t.Fatalf("Trailer header was not found")
+	}
+
+	if trailer.recordSize != 18 || trailer.start != params+4 {
+		t.Fatalf("Trailer layout is incorrect, got %d bytes at %d", trailer.recordSize, trailer.start)
+	}
+
+	rd := RawData{File: f, Scanindex: make(ScanIndex, 2), trailer: &trailer}
+
+	values := rd.Trailer(2)
+	if values["Charge State"] != "3" || values["Monoisotopic M/Z"] != "612.8123" || values["Master Scan Number"] != "1" || values["FAIMS CV"] != "-45" {
+		t.Errorf("Trailer values are incorrect, got %v", values)
+	}
+
+	if _, ok := values["Trailer Extra"]; ok {
+		t.Errorf("Trailer section labels should not be reported as values")
+	}
+}
@@ -0,0 +1,361 @@
+package id
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"philosopher/lib/bio"
+	"philosopher/lib/mod"
+	"philosopher/lib/msg"
+	"philosopher/lib/uti"
+
+	"github.com/sirupsen/logrus"
+)
+
+// siteMod is a modification placed on a peptide position, where 0 is the N-terminus
+// and the peptide length + 1 is the C-terminus
+type siteMod struct {
+	location int
+	massDiff float64
+	id       string
+	name     string
+	variable bool
+}
+
+// IdentificationFormat returns the format of the identification input based on the
+// file extension. Directories are inspected for the known formats, giving priority
+// to pepXML files.
+func IdentificationFormat(input string) string {
+
+	lower := strings.ToLower(input)
+
+	switch {
+	case strings.Contains(lower, "pep.xml") || strings.Contains(lower, "pepxml"):
+		return "pepxml"
+	case strings.HasSuffix(lower, ".mzid"):
+		return "mzid"
+	case strings.HasSuffix(lower, ".pin"):
+		return "pin"
+	case strings.HasSuffix(lower, ".tsv"):
+		return "tsv"
+	case strings.HasSuffix(lower, ".txt"):
+		return "txt"
+	}
+
+	if len(uti.IOReadDir(input, "pep.xml")) > 0 {
+		return "pepxml"
+	}
+
+	if len(uti.IOReadDir(input, ".mzid")) > 0 {
+		return "mzid"
+	}
+
+	if len(uti.IOReadDir(input, ".pin")) > 0 {
+		return "pin"
+	}
+
+	for _, i := range uti.IOReadDir(input, ".tsv") {
+		if isMSFraggerTSV(i) {
+			return "tsv"
+		}
+	}
+
+	for _, i := range uti.IOReadDir(input, ".txt") {
+		if isCometTXT(i) {
+			return "txt"
+		}
+	}
+
+	return "pepxml"
+}
+
+// ReadIdentificationInput reads the identification files in any of the supported formats.
+// When no format is given, it is selected by the file extension.
+func ReadIdentificationInput(input, format, decoyTag, scoreName, temp string, models bool) (PepIDListPtrs, string) {
+
+	if len(format) == 0 {
+		format = IdentificationFormat(input)
+	}
+
+	switch format {
+	case "pepxml":
+		return ReadPepXMLInput(input, decoyTag, scoreName, temp, models)
+	case "mzid":
+		return ReadMzIdentMLInput(input, decoyTag, scoreName)
+	}
+
+	return ReadTabularInput(input, format, decoyTag, scoreName)
+}
+
+// ReadTabularInput reads one or more MSFragger tsv, Comet txt or Percolator pin files
+// and organize the data into a PSM list
+func ReadTabularInput(input, format, decoyTag, scoreName string) (PepIDListPtrs, string) {
+
+	var files []string
+
+	if strings.HasSuffix(strings.ToLower(input), "."+format) {
+		files = append(files, input)
+	} else {
+		files = uti.IOReadDir(input, "."+format)
+	}
+
+	sort.Strings(files)
+
+	var list []PepXML
+	for _, i := range files {
+
+		var p PepXML
+		p.DecoyTag = decoyTag
+		p.ScoreName = scoreName
+
+		switch format {
+		case "tsv":
+			if !isMSFraggerTSV(i) {
+				logrus.Warn("ignoring ", i, ", not a MSFragger tsv file")
+				continue
+			}
+			p.ReadMSFraggerTSV(i)
+		case "txt":
+			if !isCometTXT(i) {
+				logrus.Warn("ignoring ", i, ", not a Comet txt file")
+				continue
+			}
+			p.ReadCometTXT(i)
+		case "pin":
+			p.ReadPin(i)
+		}
+
+		list = append(list, p)
+	}
+
+	if len(list) == 0 {
+		msg.NoParametersFound(errors.New("missing "+format+" identification files"), "fatal")
+	}
+
+	return mergeIdentifications(list, decoyTag)
+}
+
+// mergeIdentifications creates a "fake" global pepXML comprising all identifications
+// and serializes it the same way the pepXML input is
+func mergeIdentifications(list []PepXML, decoyTag string) (PepIDListPtrs, string) {
+
+	var searchEngine string
+	var modsIndex = make(map[string]mod.Modification)
+
+	var pepXML PepXML4Serialiazation
+	pepXML.DecoyTag = decoyTag
+	pepXML.PeptideIdentification = make(PepIDListPtrs, 0)
+
+	for idx := range list {
+
+		p := &list[idx]
+
+		if idx == 0 {
+			searchEngine = p.SearchEngine
+			pepXML.FileName = p.FileName
+			pepXML.SpectraFile = p.SpectraFile
+			pepXML.Database = p.Database
+			pepXML.SearchEngine = p.SearchEngine
+			pepXML.SearchEngineVersion = p.SearchEngineVersion
+			pepXML.SearchParameters = p.SearchParameters
+		}
+
+		for k, v := range p.Modifications.Index {
+			if _, ok := modsIndex[k]; !ok {
+				modsIndex[k] = v
+			}
+		}
+
+		for j := range p.PeptideIdentification {
+			pepXML.PeptideIdentification = append(pepXML.PeptideIdentification, &p.PeptideIdentification[j])
+		}
+	}
+
+	pepXML.Modifications.Index = modsIndex
+
+	// promoting Spectra that matches to both decoys and targets to TRUE hits
+	pepXML.PromoteProteinIDs()
+
+	sort.Sort(pepXML.PeptideIdentification)
+	pepXML.Serialize()
+
+	return pepXML.PeptideIdentification, searchEngine
+}
+
+// mapSiteMods converts the modifications placed on the peptide positions into the same
+// indexes created for the pepXML modifications, and rebuilds the pepXML style modified peptide
+func (p *PeptideIdentification) mapSiteMods(sites []siteMod, mods mod.Modifications) {
+
+	pModificationsIndex := make(map[string]mod.Modification)
+
+	var nTerm float64
+	var cTerm float64
+	var residues = make(map[int]float64)
+
+	for _, i := range sites {
+
+		m := mod.Modification{
+			ID:       i.id,
+			Name:     i.name,
+			Type:     mod.Assigned,
+			MassDiff: uti.ToFixed(i.massDiff, 4),
+			Variable: i.variable,
+		}
+
+		if i.location <= 0 {
+
+			m.AminoAcid = "N-term"
+			m.Index = fmt.Sprintf("N-term#%.4f", bio.Hydrogen+i.massDiff)
+			nTerm += i.massDiff
+
+			if _, ok := mods.Index[m.Index]; !ok {
+				mods.Index[m.Index] = m
+			}
+			pModificationsIndex[m.Index] = m
+
+		} else if i.location > len(p.Peptide) {
+
+			m.AminoAcid = "C-term"
+			m.Index = fmt.Sprintf("C-term#%.4f", bio.Oxygen+bio.Hydrogen+i.massDiff)
+			cTerm += i.massDiff
+
+			if _, ok := mods.Index[m.Index]; !ok {
+				mods.Index[m.Index] = m
+			}
+			pModificationsIndex[m.Index] = m
+
+		} else {
+
+			aa := string(p.Peptide[i.location-1])
+			mass := bio.NewFromCode(aa).MonoIsotopeMass + i.massDiff
+			residues[i.location] += i.massDiff
+
+			m.AminoAcid = aa
+			m.Index = fmt.Sprintf("%s#%.4f", aa, mass)
+
+			if _, ok := mods.Index[m.Index]; !ok {
+				mods.Index[m.Index] = m
+			}
+
+			m.Index = fmt.Sprintf("%s#%d#%.4f", aa, i.location, mass)
+			m.Position = i.location
+			pModificationsIndex[m.Index] = m
+		}
+	}
+
+	if len(sites) > 0 {
+
+		var modified strings.Builder
+
+		if nTerm != 0 {
+			fmt.Fprintf(&modified, "n[%.0f]", bio.Hydrogen+nTerm)
+		}
+
+		for i, aa := range p.Peptide {
+			modified.WriteRune(aa)
+			if delta, ok := residues[i+1]; ok {
+				fmt.Fprintf(&modified, "[%.0f]", bio.NewFromCode(string(aa)).MonoIsotopeMass+delta)
+			}
+		}
+
+		if cTerm != 0 {
+			fmt.Fprintf(&modified, "c[%.0f]", bio.Oxygen+bio.Hydrogen+cTerm)
+		}
+
+		p.ModifiedPeptide = modified.String()
+	}
+
+	key := fmt.Sprintf("%.4f", p.Massdiff)
+	if _, ok := pModificationsIndex[key]; !ok {
+		pModificationsIndex[key] = mod.Modification{
+			Index:    key,
+			Name:     "Unknown",
+			Type:     mod.Observed,
+			MassDiff: p.Massdiff,
+		}
+	}
+
+	if len(pModificationsIndex) != 0 {
+		p.Modifications = mod.Modifications{Index: pModificationsIndex}.ToSlice()
+	}
+}
+
+// readTable reads a tab-delimited file and returns the header and the data rows. The
+// number of lines before the header is given by skip
+func readTable(f string, skip int) ([]string, [][]string) {
+
+	file, e := os.Open(f)
+	if e != nil {
+		msg.ReadFile(e, "fatal")
+	}
+	defer file.Close()
+
+	var header []string
+	var rows [][]string
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 1024*1024), 16*1024*1024)
+
+	var line int
+	for scanner.Scan() {
+
+		line++
+		if line <= skip || len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+
+		fields := strings.Split(strings.TrimRight(scanner.Text(), "\r"), "\t")
+		if header == nil {
+			header = fields
+			continue
+		}
+
+		rows = append(rows, fields)
+	}
+
+	if e := scanner.Err(); e != nil {
+		msg.ReadFile(e, "fatal")
+	}
+
+	return header, rows
+}
+
+// firstLine returns the first line of a file
+func firstLine(f string) string {
+
+	file, e := os.Open(f)
+	if e != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 1024*1024), 16*1024*1024)
+	if scanner.Scan() {
+		return scanner.Text()
+	}
+
+	return ""
+}
+
+// columnIndex maps each column name to its position
+func columnIndex(header []string) map[string]int {
+
+	var index = make(map[string]int)
+	for i, j := range header {
+		index[strings.ToLower(strings.TrimSpace(j))] = i
+	}
+
+	return index
+}
+
+// fileBase returns the file name without the directory and extension
+func fileBase(f string) string {
+	base := filepath.Base(f)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
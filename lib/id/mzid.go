@@ -0,0 +1,350 @@
+package id
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"philosopher/lib/bio"
+	"philosopher/lib/mod"
+	"philosopher/lib/msg"
+	"philosopher/lib/psi"
+	"philosopher/lib/spc"
+	"philosopher/lib/uti"
+
+	"github.com/sirupsen/logrus"
+)
+
+var scanRegex = regexp.MustCompile(`scan=(\d+)`)
+var indexRegex = regexp.MustCompile(`index=(\d+)`)
+
+// ReadMzIdentMLInput reads one or more mzIdentML files and organize the data into a PSM list.
+// The identifications are stored the same way the pepXML files are, so the rest of the
+// pipeline can process them without knowing the original format.
+func ReadMzIdentMLInput(input, decoyTag, scoreName string) (PepIDListPtrs, string) {
+
+	var files []string
+
+	if strings.HasSuffix(strings.ToLower(input), ".mzid") {
+		files = append(files, input)
+	} else {
+		files = uti.IOReadDir(input, ".mzid")
+		if len(files) == 0 {
+			msg.NoParametersFound(errors.New("missing mzIdentML files"), "fatal")
+		}
+	}
+
+	sort.Strings(files)
+
+	var list []PepXML
+	for _, i := range files {
+		var p PepXML
+		p.DecoyTag = decoyTag
+		p.ScoreName = scoreName
+		p.ReadMzIdentML(i)
+		list = append(list, p)
+	}
+
+	return mergeIdentifications(list, decoyTag)
+}
+
+// ReadMzIdentML parses a mzIdentML file and converts the top ranked spectrum identification
+// items into peptide identifications
+func (p *PepXML) ReadMzIdentML(f string) {
+
+	var mzid psi.MzIdentML
+	logrus.Info("Parsing ", f)
+	mzid.Parse(f)
+
+	p.FileName = filepath.Base(f)
+	p.Modifications.Index = make(map[string]mod.Modification)
+
+	if len(mzid.AnalysisSoftwareList.AnalysisSoftware) > 0 {
+		sw := mzid.AnalysisSoftwareList.AnalysisSoftware[0]
+		p.SearchEngine = sw.Name
+		p.SearchEngineVersion = sw.Version
+		if len(p.SearchEngine) == 0 {
+			p.SearchEngine = sw.SoftwareName.CVParam.Name
+		}
+	}
+
+	if len(mzid.DataCollection.Inputs.SearchDatabase) > 0 {
+		p.Database = mzid.DataCollection.Inputs.SearchDatabase[0].Location
+	}
+
+	var spectraData = make(map[string]string)
+	for _, i := range mzid.DataCollection.Inputs.SpectraData {
+		name := filepath.Base(strings.Replace(i.Location, "\\", "/", -1))
+		spectraData[i.ID] = strings.TrimSuffix(name, filepath.Ext(name))
+		if len(p.SpectraFile) == 0 {
+			p.SpectraFile = name
+		}
+	}
+
+	var dbSequences = make(map[string]psi.DBSequence)
+	for _, i := range mzid.SequenceCollection.DBSequence {
+		dbSequences[i.ID] = i
+	}
+
+	var peptides = make(map[string]psi.Peptide)
+	for _, i := range mzid.SequenceCollection.Peptide {
+		peptides[i.ID] = i
+	}
+
+	var evidences = make(map[string]psi.PeptideEvidence)
+	for _, i := range mzid.SequenceCollection.PeptideEvidence {
+		evidences[i.ID] = i
+	}
+
+	// fixed modifications are indexed by residue and mass difference
+	var fixed = make(map[string]bool)
+
+	for _, i := range mzid.AnalysisProtocolCollection.SpectrumIdentificationProtocol {
+		if i.ModificationParams != nil {
+			for _, j := range i.ModificationParams.SearchModification {
+				if strings.EqualFold(j.FixedMod, "true") {
+					for _, k := range strings.Fields(j.Residues) {
+						fixed[fmt.Sprintf("%s#%.4f", k, j.MassDelta)] = true
+					}
+				}
+			}
+		}
+		if i.AdditionalSearchParams != nil {
+			for _, j := range i.AdditionalSearchParams.UserParam {
+				p.SearchParameters = append(p.SearchParameters, spc.Parameter{Name: j.Name, Value: j.Value})
+			}
+			for _, j := range i.AdditionalSearchParams.CVParam {
+				p.SearchParameters = append(p.SearchParameters, spc.Parameter{Name: j.Name, Value: j.Value})
+			}
+		}
+	}
+
+	var index uint32
+	var results, unknown int
+	for _, i := range mzid.DataCollection.AnalysisData.SpectrumIdentificationList {
+		for _, j := range i.SpectrumIdentificationResult {
+
+			results++
+
+			// unrecognized spectrum references fall back to the result order, so they
+			// do not share the same scan during the target-decoy competition
+			scan, ok := mzIdentMLScan(j)
+			if !ok {
+				scan = results
+				unknown++
+			}
+
+			rt := mzIdentMLRetentionTime(j.CVParam)
+
+			for _, k := range j.SpectrumIdentificationItem {
+
+				if k.Rank != 1 {
+					continue
+				}
+
+				pep, ok := peptides[k.PeptideRef]
+				if !ok {
+					continue
+				}
+
+				var psm PeptideIdentification
+				psm.AlternativeProteins = make(map[string]int)
+
+				psm.Index = index
+				psm.SpectrumFile = p.FileName
+				psm.Spectrum = fmt.Sprintf("%s.%05d.%05d.%d", spectraData[j.SpectraDataRef], scan, scan, k.ChargeState)
+				psm.AssumedCharge = k.ChargeState
+				psm.HitRank = k.Rank
+				psm.RetentionTime = rt
+				psm.Peptide = pep.PeptideSequence.Value
+
+				z := float64(k.ChargeState)
+				psm.PrecursorNeutralMass = k.ExperimentalMassToCharge*z - z*bio.Proton
+				psm.UncalibratedPrecursorNeutralMass = psm.PrecursorNeutralMass
+				psm.CalcNeutralPepMass = k.CalculatedMassToCharge*z - z*bio.Proton
+				psm.Massdiff = uti.ToFixed(psm.PrecursorNeutralMass-psm.CalcNeutralPepMass, 4)
+
+				for _, l := range k.PeptideEvidenceRef {
+					ev, ok := evidences[l.PeptideEvidenceRef]
+					if !ok {
+						continue
+					}
+
+					protein := ev.DBSequenceRef
+					if db, ok := dbSequences[ev.DBSequenceRef]; ok && len(db.Accession) > 0 {
+						protein = db.Accession
+					}
+
+					// decoys reported by the search engine are tagged, the classification relies on the protein names
+					if (strings.EqualFold(ev.IsDecoy, "true") || ev.IsDecoy == "1") && !strings.HasPrefix(protein, p.DecoyTag) {
+						protein = p.DecoyTag + protein
+					}
+
+					if len(psm.Protein) == 0 {
+						psm.Protein = protein
+					} else if protein != psm.Protein {
+						psm.AlternativeProteins[protein]++
+					}
+				}
+
+				psm.mapScoresFromMzIdentML(k.CVParam, k.UserParam, p.ScoreName)
+				psm.mapModsFromMzIdentML(pep, p.Modifications, fixed)
+
+				p.PeptideIdentification = append(p.PeptideIdentification, psm)
+				index++
+			}
+		}
+	}
+
+	if unknown > 0 {
+		logrus.Warn(unknown, " spectrum identification results in ", p.FileName, " have an unrecognized spectrumID format, using the result order as the scan number")
+	}
+
+	if len(p.PeptideIdentification) == 0 {
+		msg.NoPSMFound(errors.New(f), "warning")
+	}
+}
+
+// mzIdentMLScan returns the scan number from the spectrum identification result cvParams,
+// or from the spectrum native ID, and false when the spectrumID format is not recognized
+func mzIdentMLScan(sir psi.SpectrumIdentificationResult) (int, bool) {
+
+	for _, i := range sir.CVParam {
+		if i.Accession == "MS:1001115" {
+			scan, e := strconv.Atoi(i.Value)
+			if e == nil {
+				return scan, true
+			}
+		}
+	}
+
+	if m := scanRegex.FindStringSubmatch(sir.SpectrumID); len(m) > 1 {
+		scan, _ := strconv.Atoi(m[1])
+		return scan, true
+	}
+
+	// mgf spectra are referenced by a zero-based index
+	if m := indexRegex.FindStringSubmatch(sir.SpectrumID); len(m) > 1 {
+		scan, _ := strconv.Atoi(m[1])
+		return scan + 1, true
+	}
+
+	return 0, false
+}
+
+// mzIdentMLRetentionTime returns the retention time in seconds
+func mzIdentMLRetentionTime(params []psi.CVParam) float64 {
+
+	for _, i := range params {
+		if i.Accession == "MS:1000894" || i.Accession == "MS:1000016" {
+			rt, e := strconv.ParseFloat(i.Value, 64)
+			if e != nil {
+				continue
+			}
+
+			if strings.EqualFold(i.UnitName, "minute") || i.UnitAccession == "UO:0000031" {
+				rt *= 60
+			}
+
+			return rt
+		}
+	}
+
+	return 0
+}
+
+// mapScoresFromMzIdentML assigns the search engine scores reported as cvParams or userParams.
+// The engine-specific terms are recognized by their name, e.g. Comet:xcorr or X!Tandem:expect
+func (p *PeptideIdentification) mapScoresFromMzIdentML(cvParams []psi.CVParam, userParams []psi.UserParam, scoreName string) {
+
+	type score struct {
+		accession string
+		name      string
+		value     string
+	}
+
+	var scores []score
+	for _, i := range cvParams {
+		scores = append(scores, score{i.Accession, i.Name, i.Value})
+	}
+
+	for _, i := range userParams {
+		scores = append(scores, score{"", i.Name, i.Value})
+	}
+
+	for _, i := range scores {
+
+		value, e := strconv.ParseFloat(i.value, 64)
+		if e != nil {
+			continue
+		}
+
+		if len(scoreName) > 0 && (i.name == scoreName || i.accession == scoreName) {
+			p.DiscriminantValue = value
+		}
+
+		name := strings.ToLower(i.name)
+		if idx := strings.LastIndex(name, ":"); idx > -1 {
+			name = name[idx+1:]
+		}
+
+		switch name {
+		case "xcorr":
+			p.Xcorr = value
+		case "deltacn":
+			p.DeltaCN = value
+		case "sprank":
+			p.SPRank = value
+		case "hyperscore":
+			p.Hyperscore = value
+		case "nextscore":
+			p.Nextscore = value
+		case "expect", "evalue", "expectation value", "e-value":
+			p.Expectation = value
+		case "specevalue":
+			// MS-GF+ reports the spectrum level e-value next to the database one, which takes precedence
+			if p.Expectation == 0 {
+				p.Expectation = value
+			}
+		case "psm-level probability", "peptideprophet probability":
+			p.Probability = value
+		case "pep", "posterior error probability", "psm-level posterior error probability":
+			p.PEP = value
+			p.Probability = 1 - value
+		}
+	}
+}
+
+// mapModsFromMzIdentML converts the peptide modifications into the same indexes created
+// for the pepXML modifications
+func (p *PeptideIdentification) mapModsFromMzIdentML(pep psi.Peptide, mods mod.Modifications, fixed map[string]bool) {
+
+	var sites []siteMod
+
+	for _, i := range pep.Modification {
+
+		location, _ := strconv.Atoi(i.Location)
+
+		site := siteMod{
+			location: location,
+			massDiff: i.MonoIsotopicMassDelta,
+			variable: !fixed[fmt.Sprintf("%s#%.4f", i.Residues, i.MonoIsotopicMassDelta)],
+		}
+
+		for _, j := range i.CVParam {
+			if strings.HasPrefix(j.Accession, "UNIMOD:") || j.Accession == "MS:1001460" {
+				site.id = j.Accession
+				site.name = j.Name
+				break
+			}
+		}
+
+		sites = append(sites, site)
+	}
+
+	p.mapSiteMods(sites, mods)
+}
@@ -0,0 +1,114 @@
+package id
+
+import (
+	"testing"
+
+	"philosopher/lib/mod"
+	"philosopher/lib/psi"
+)
+
+func Test_mzIdentMLScan(t *testing.T) {
+
+	tests := []struct {
+		name string
+		sir  psi.SpectrumIdentificationResult
+		want int
+		ok   bool
+	}{
+		{
+			name: "Testing scan number cvParam",
+			sir:  psi.SpectrumIdentificationResult{SpectrumID: "index=4", CVParam: []psi.CVParam{{Accession: "MS:1001115", Value: "1234"}}},
+			want: 1234,
+			ok:   true,
+		},
+		{
+			name: "Testing Thermo native ID",
+			sir:  psi.SpectrumIdentificationResult{SpectrumID: "controllerType=0 controllerNumber=1 scan=5678"},
+			want: 5678,
+			ok:   true,
+		},
+		{
+			name: "Testing mgf index",
+			sir:  psi.SpectrumIdentificationResult{SpectrumID: "index=9"},
+			want: 10,
+			ok:   true,
+		},
+		{
+			name: "Testing unrecognized native ID",
+			sir:  psi.SpectrumIdentificationResult{SpectrumID: "sample=1 period=1 cycle=42 experiment=3"},
+			want: 0,
+			ok:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, ok := mzIdentMLScan(tt.sir); got != tt.want || ok != tt.ok {
+				t.Errorf("mzIdentMLScan() = %v, %v, want %v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestPeptideIdentification_mapModsFromMzIdentML(t *testing.T) {
+
+	pep := psi.Peptide{
+		PeptideSequence: psi.PeptideSequence{Value: "PEPMCK"},
+		Modification: []psi.Modification{
+			{Location: "0", MonoIsotopicMassDelta: 42.010565, CVParam: []psi.CVParam{{Accession: "UNIMOD:1", Name: "Acetyl"}}},
+			{Location: "4", Residues: "M", MonoIsotopicMassDelta: 15.994915, CVParam: []psi.CVParam{{Accession: "UNIMOD:35", Name: "Oxidation"}}},
+			{Location: "5", Residues: "C", MonoIsotopicMassDelta: 57.021464, CVParam: []psi.CVParam{{Accession: "UNIMOD:4", Name: "Carbamidomethyl"}}},
+		},
+	}
+
+	mods := mod.Modifications{Index: make(map[string]mod.Modification)}
+	fixed := map[string]bool{"C#57.0215": true}
+
+	var p PeptideIdentification
+	p.Peptide = pep.PeptideSequence.Value
+	p.mapModsFromMzIdentML(pep, mods, fixed)
+
+	if p.ModifiedPeptide != "n[43]PEPM[147]C[160]K" {
+		t.Errorf("ModifiedPeptide = %v, want %v", p.ModifiedPeptide, "n[43]PEPM[147]C[160]K")
+	}
+
+	if len(mods.Index) != 3 {
+		t.Fatalf("global modifications = %d, want %d", len(mods.Index), 3)
+	}
+
+	if m, ok := mods.Index["M#147.0354"]; !ok || m.Name != "Oxidation" || !m.Variable {
+		t.Errorf("oxidation was not mapped as a variable modification: %v", m)
+	}
+
+	if m, ok := mods.Index["C#160.0306"]; !ok || m.Variable {
+		t.Errorf("carbamidomethylation was not mapped as a fixed modification: %v", m)
+	}
+
+	var positions = make(map[string]int)
+	for _, i := range p.Modifications.IndexSlice {
+		positions[i.AminoAcid] = i.Position
+	}
+
+	if positions["M"] != 4 || positions["C"] != 5 {
+		t.Errorf("modification positions = %v", positions)
+	}
+}
+
+func TestPeptideIdentification_mapScoresFromMzIdentML(t *testing.T) {
+
+	var p PeptideIdentification
+	p.mapScoresFromMzIdentML([]psi.CVParam{
+		{Accession: "MS:1002052", Name: "MS-GF:SpecEValue", Value: "1.5e-12"},
+		{Accession: "MS:1002053", Name: "MS-GF:EValue", Value: "2.0e-6"},
+	}, nil, "")
+
+	if p.Expectation != 2.0e-6 {
+		t.Errorf("mapScoresFromMzIdentML() Expectation = %v, want %v", p.Expectation, 2.0e-6)
+	}
+
+	var q PeptideIdentification
+	q.mapScoresFromMzIdentML([]psi.CVParam{{Accession: "MS:1002052", Name: "MS-GF:SpecEValue", Value: "1.5e-12"}}, nil, "")
+
+	if q.Expectation != 1.5e-12 {
+		t.Errorf("mapScoresFromMzIdentML() Expectation = %v, want %v", q.Expectation, 1.5e-12)
+	}
+}
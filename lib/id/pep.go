@@ -31,7 +31,9 @@ type PepXML struct {
 	FileName              string
 	SpectraFile           string
 	SearchEngine          string
+	SearchEngineVersion   string
 	DecoyTag              string
+	ScoreName             string
 	Database              string
 	Prophet               string
 	SearchParameters      []spc.Parameter
@@ -44,6 +46,7 @@ type PepXML4Serialiazation struct {
 	FileName              string
 	SpectraFile           string
 	SearchEngine          string
+	SearchEngineVersion   string
 	DecoyTag              string
 	Database              string
 	Prophet               string
@@ -130,6 +133,8 @@ type PeptideIdentification struct {
 	CalcNeutralPepMass               float64
 	Massdiff                         float64
 	Probability                      float64
+	Qvalue                           float64
+	PEP                              float64
 	Expectation                      float64
 	Xcorr                            float64
 	DeltaCN                          float64
@@ -138,6 +143,7 @@ type PeptideIdentification struct {
 	Nextscore                        float64
 	SpectralSim                      float64
 	Rtscore                          float64
+	DiscriminantValue                float64
 	IonMobility                      float64
 	Intensity                        float64
 	AlternativeProteins              map[string]int
@@ -247,6 +253,7 @@ func (p *PepXML) Read(f string) {
 
 		// get the search engine
 		p.SearchEngine = string(mpa.MsmsRunSummary.SearchSummary.SearchEngine)
+		p.SearchEngineVersion = string(mpa.MsmsRunSummary.SearchSummary.SearchEngineVersion)
 		if strings.Contains(string(mpa.MsmsRunSummary.SearchSummary.SearchEngineVersion), "MSFragger") {
 			p.SearchEngine = "MSFragger"
 		}
@@ -319,7 +326,7 @@ func (p *PepXML) Read(f string) {
 		sq := mpa.MsmsRunSummary.SpectrumQuery
 		p.PeptideIdentification = make(PepIDList, len(sq), len(sq))
 		for idx, i := range sq {
-			p.PeptideIdentification[idx] = processSpectrumQuery(i, p.Modifications, p.DecoyTag, p.FileName)
+			p.PeptideIdentification[idx] = processSpectrumQuery(i, p.Modifications, p.DecoyTag, p.ScoreName, p.FileName)
 		}
 
 		p.Prophet = string(mpa.AnalysisSummary[0].Analysis)
@@ -334,8 +341,9 @@ func (p *PepXML) Read(f string) {
 	}
 }
 
-// ReadPepXMLInput reads one or more fies and organize the data into PSM list
-func ReadPepXMLInput(xmlFile, decoyTag, temp string, models bool) (PepIDListPtrs, string) {
+// ReadPepXMLInput reads one or more fies and organize the data into PSM list.
+// The search score named by scoreName, if any, is stored as the discriminant value.
+func ReadPepXMLInput(xmlFile, decoyTag, scoreName, temp string, models bool) (PepIDListPtrs, string) {
 
 	var files = make(map[string]struct{})
 	var params []spc.Parameter
@@ -383,6 +391,7 @@ func ReadPepXMLInput(xmlFile, decoyTag, temp string, models bool) (PepIDListPtrs
 	processSinglePepXML := func(idx int, i string) {
 		var p PepXML
 		p.DecoyTag = decoyTag
+		p.ScoreName = scoreName
 		p.Read(i)
 		if idx == 0 {
 			params = p.SearchParameters
@@ -446,7 +455,7 @@ func ReadPepXMLInput(xmlFile, decoyTag, temp string, models bool) (PepIDListPtrs
 	return pepXML.PeptideIdentification, searchEngine
 }
 
-func processSpectrumQuery(sq spc.SpectrumQuery, mods mod.Modifications, decoyTag, FileName string) PeptideIdentification {
+func processSpectrumQuery(sq spc.SpectrumQuery, mods mod.Modifications, decoyTag, scoreName, FileName string) PeptideIdentification {
 
 	var psm PeptideIdentification
 	psm.AlternativeProteins = make(map[string]int)
@@ -513,6 +522,11 @@ func processSpectrumQuery(sq spc.SpectrumQuery, mods mod.Modifications, decoyTag
 		}
 
 		for _, j := range i.Score {
+			if len(scoreName) > 0 && string(j.Name) == scoreName {
+				value, _ := uti.ParseFloat(j.Value)
+				psm.DiscriminantValue = value
+			}
+
 			if string(j.Name) == "expect" {
 				eValue, _ := uti.ParseFloat(j.Value)
 				psm.Expectation = eValue
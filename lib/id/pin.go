@@ -0,0 +1,216 @@
+package id
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"philosopher/lib/bio"
+	"philosopher/lib/mod"
+	"philosopher/lib/msg"
+	"philosopher/lib/uti"
+
+	"github.com/sirupsen/logrus"
+)
+
+// MSFragger writes <base>.<scan>.<scan>.<charge>_<rank> and Comet writes <base>_<scan>_<charge>_<rank>
+var fraggerSpecIDRegex = regexp.MustCompile(`^(.+)\.(\d+)\.(\d+)\.(\d+)(_(\d+))?$`)
+var cometSpecIDRegex = regexp.MustCompile(`^(.+)_(\d+)_(\d+)_(\d+)$`)
+var pinModRegex = regexp.MustCompile(`([nc]?)([A-Z]?)\[([-+\d.]+)\]`)
+
+// ReadPin parses a Percolator pin file and converts the top ranked hits into peptide
+// identifications. The features are mapped to the search engine scores when possible.
+func (p *PepXML) ReadPin(f string) {
+
+	logrus.Info("Parsing ", f)
+
+	p.FileName = filepath.Base(f)
+	p.Modifications.Index = make(map[string]mod.Modification)
+
+	header, rows := readTable(f, 0)
+	col := columnIndex(header)
+
+	peptideCol, ok := col["peptide"]
+	if !ok {
+		msg.Custom(errors.New("the pin file "+f+" has no Peptide column"), "fatal")
+	}
+
+	p.SearchEngine = "Percolator"
+	if _, ok := col["hyperscore"]; ok {
+		p.SearchEngine = "MSFragger"
+	} else if _, ok := col["xcorr"]; ok {
+		p.SearchEngine = "Comet"
+	}
+
+	base := fileBase(f)
+
+	for _, i := range rows {
+
+		if len(i) <= peptideCol || strings.EqualFold(i[0], "DefaultDirection") {
+			continue
+		}
+
+		var psm PeptideIdentification
+		psm.AlternativeProteins = make(map[string]int)
+
+		scan, charge, rank := parseSpecID(i[0])
+		if rank > 1 {
+			continue
+		}
+
+		if idx, ok := col["scannr"]; ok && scan == 0 {
+			scan, _ = strconv.Atoi(i[idx])
+		}
+
+		for j, name := range header[:peptideCol] {
+
+			value, e := strconv.ParseFloat(i[j], 64)
+			if e != nil {
+				continue
+			}
+
+			if len(p.ScoreName) > 0 && strings.EqualFold(name, p.ScoreName) {
+				psm.DiscriminantValue = value
+			}
+
+			name = strings.ToLower(name)
+
+			// charges are one-hot encoded, e.g. Charge2 or charge_2
+			if strings.HasPrefix(name, "charge") && value == 1 {
+				if z, e := strconv.Atoi(strings.TrimLeft(name[6:], "_")); e == nil && charge == 0 {
+					charge = z
+				}
+			}
+
+			switch name {
+			case "expmass":
+				psm.PrecursorNeutralMass = value - bio.Proton
+			case "calcmass":
+				psm.CalcNeutralPepMass = value - bio.Proton
+			case "hyperscore":
+				psm.Hyperscore = value
+			case "xcorr":
+				psm.Xcorr = value
+			case "deltcn":
+				psm.DeltaCN = value
+			case "lnexpect":
+				psm.Expectation = math.Exp(value)
+			case "log10_evalue":
+				psm.Expectation = math.Pow(10, value)
+			case "nmc", "enzint":
+				psm.NumberofMissedCleavages = uint8(value)
+			case "ntt":
+				psm.NumberOfEnzymaticTermini = uint8(value)
+			}
+		}
+
+		psm.Index = uint32(len(p.PeptideIdentification))
+		psm.SpectrumFile = p.FileName
+		psm.Spectrum = fmt.Sprintf("%s.%05d.%05d.%d", base, scan, scan, charge)
+		psm.AssumedCharge = uint8(charge)
+		psm.HitRank = 1
+		psm.UncalibratedPrecursorNeutralMass = psm.PrecursorNeutralMass
+		psm.Massdiff = uti.ToFixed(psm.PrecursorNeutralMass-psm.CalcNeutralPepMass, 4)
+
+		// the Label column marks decoys with -1, the proteins are tagged because the
+		// classification relies on the protein names
+		var decoy bool
+		if idx, ok := col["label"]; ok && idx < len(i) && strings.TrimSpace(i[idx]) == "-1" {
+			decoy = true
+		}
+
+		for idx, j := range i[peptideCol+1:] {
+			if decoy && len(j) > 0 && !strings.HasPrefix(j, p.DecoyTag) {
+				j = p.DecoyTag + j
+			}
+			if idx == 0 {
+				psm.Protein = j
+			} else if len(j) > 0 && j != psm.Protein {
+				psm.AlternativeProteins[j]++
+			}
+		}
+
+		var sites []siteMod
+		psm.Peptide, sites = parsePinPeptide(i[peptideCol])
+		psm.mapSiteMods(sites, p.Modifications)
+
+		p.PeptideIdentification = append(p.PeptideIdentification, psm)
+	}
+
+	if len(p.PeptideIdentification) == 0 {
+		msg.NoPSMFound(errors.New(f), "warning")
+	}
+}
+
+// parseSpecID extracts the scan number, charge and rank from the pin SpecId column
+func parseSpecID(id string) (int, int, int) {
+
+	if m := fraggerSpecIDRegex.FindStringSubmatch(id); len(m) > 0 {
+		scan, _ := strconv.Atoi(m[2])
+		charge, _ := strconv.Atoi(m[4])
+		rank, _ := strconv.Atoi(m[6])
+		return scan, charge, rank
+	}
+
+	if m := cometSpecIDRegex.FindStringSubmatch(id); len(m) > 0 {
+		scan, _ := strconv.Atoi(m[2])
+		charge, _ := strconv.Atoi(m[3])
+		rank, _ := strconv.Atoi(m[4])
+		return scan, charge, rank
+	}
+
+	return 0, 0, 0
+}
+
+// parsePinPeptide removes the flanking residues from the pin peptide, e.g. K.n[42.0106]PEPM[15.9949]TIDE.R,
+// and returns the plain sequence with the modifications, given as mass differences
+func parsePinPeptide(peptide string) (string, []siteMod) {
+
+	if len(peptide) > 4 && peptide[1] == '.' && peptide[len(peptide)-2] == '.' {
+		peptide = peptide[2 : len(peptide)-2]
+	}
+
+	var sites []siteMod
+	var sequence strings.Builder
+
+	var last int
+	for _, m := range pinModRegex.FindAllStringSubmatchIndex(peptide, -1) {
+
+		// copy the residues before the modification
+		sequence.WriteString(peptide[last:m[0]])
+		last = m[1]
+
+		massDiff, _ := strconv.ParseFloat(peptide[m[6]:m[7]], 64)
+		terminus := peptide[m[2]:m[3]]
+		residue := peptide[m[4]:m[5]]
+
+		sequence.WriteString(residue)
+
+		site := siteMod{massDiff: massDiff, variable: true}
+		switch {
+		case terminus == "n":
+			site.location = 0
+		case terminus == "c":
+			site.location = -1
+		default:
+			site.location = sequence.Len()
+		}
+
+		sites = append(sites, site)
+	}
+
+	sequence.WriteString(peptide[last:])
+	plain := sequence.String()
+
+	for i := range sites {
+		if sites[i].location == -1 {
+			sites[i].location = len(plain) + 1
+		}
+	}
+
+	return plain, sites
+}
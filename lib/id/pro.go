@@ -50,6 +50,8 @@ type ProteinIdentification struct {
 	PercentCoverage          float32
 	Probability              float64
 	TopPepProb               float64
+	Qvalue                   float64
+	PEP                      float64
 	PeptideIons              []PeptideIonIdentification
 	HasRazor                 bool
 }
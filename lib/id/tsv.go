@@ -0,0 +1,277 @@
+package id
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"philosopher/lib/mod"
+	"philosopher/lib/msg"
+	"philosopher/lib/uti"
+
+	"github.com/sirupsen/logrus"
+)
+
+var fraggerModRegex = regexp.MustCompile(`^(N-term|C-term|(\d+)([A-Z]))\(([-+\d.]+)\)$`)
+
+// isMSFraggerTSV checks if the file header belongs to a MSFragger tsv output
+func isMSFraggerTSV(f string) bool {
+	return strings.HasPrefix(firstLine(f), "scannum\t")
+}
+
+// isCometTXT checks if the file belongs to a Comet txt output
+func isCometTXT(f string) bool {
+	return strings.HasPrefix(firstLine(f), "CometVersion")
+}
+
+// ReadMSFraggerTSV parses a MSFragger tsv file and converts the top ranked hits
+// into peptide identifications
+func (p *PepXML) ReadMSFraggerTSV(f string) {
+
+	logrus.Info("Parsing ", f)
+
+	p.FileName = filepath.Base(f)
+	p.SearchEngine = "MSFragger"
+	p.Modifications.Index = make(map[string]mod.Modification)
+
+	header, rows := readTable(f, 0)
+	col := columnIndex(header)
+
+	get := func(row []string, name string) string {
+		if i, ok := col[name]; ok && i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+
+	num := func(row []string, name string) float64 {
+		v, _ := strconv.ParseFloat(get(row, name), 64)
+		return v
+	}
+
+	base := fileBase(f)
+
+	for _, i := range rows {
+
+		rank, _ := strconv.Atoi(get(i, "hit_rank"))
+		if rank > 1 {
+			continue
+		}
+
+		var psm PeptideIdentification
+		psm.AlternativeProteins = make(map[string]int)
+
+		scan, _ := strconv.Atoi(get(i, "scannum"))
+		charge, _ := strconv.Atoi(get(i, "charge"))
+
+		psm.Index = uint32(len(p.PeptideIdentification))
+		psm.SpectrumFile = p.FileName
+		psm.Spectrum = fmt.Sprintf("%s.%05d.%05d.%d", base, scan, scan, charge)
+		psm.AssumedCharge = uint8(charge)
+		psm.HitRank = 1
+		psm.Peptide = get(i, "peptide")
+		psm.CompensationVoltage = get(i, "compensation_voltage")
+		psm.IonMobility = num(i, "ion_mobility")
+
+		// the retention time is reported in minutes
+		psm.RetentionTime = num(i, "retention_time") * 60
+
+		psm.PrecursorNeutralMass = num(i, "precursor_neutral_mass")
+		psm.UncalibratedPrecursorNeutralMass = psm.PrecursorNeutralMass
+		psm.CalcNeutralPepMass = num(i, "calc_neutral_pep_mass")
+		psm.Massdiff = uti.ToFixed(num(i, "massdiff"), 4)
+		psm.NumberOfEnzymaticTermini = uint8(num(i, "num_tol_term"))
+		psm.NumberofMissedCleavages = uint8(num(i, "num_missed_cleavages"))
+
+		psm.Hyperscore = num(i, "hyperscore")
+		psm.Nextscore = num(i, "nextscore")
+		psm.Expectation = num(i, "expectscore")
+
+		if len(p.ScoreName) > 0 {
+			psm.DiscriminantValue = num(i, strings.ToLower(p.ScoreName))
+		}
+
+		if fields := strings.Fields(get(i, "protein")); len(fields) > 0 {
+			psm.Protein = fields[0]
+		}
+
+		for _, j := range strings.Split(get(i, "alternative_proteins"), ",") {
+			if fields := strings.Fields(j); len(fields) > 0 && fields[0] != psm.Protein {
+				psm.AlternativeProteins[fields[0]]++
+			}
+		}
+
+		if len(get(i, "best_locs")) > 0 {
+			psm.MSFragerLoc = &MSFraggerLoc{
+				MSFragerLocalization:                 get(i, "best_locs"),
+				MSFraggerLocalizationScoreWithPTM:    get(i, "best_score_with_delta_mass"),
+				MSFraggerLocalizationScoreWithoutPTM: get(i, "score_without_delta_mass")}
+		}
+
+		psm.mapSiteMods(parseMSFraggerMods(get(i, "modification_info"), len(psm.Peptide)), p.Modifications)
+
+		p.PeptideIdentification = append(p.PeptideIdentification, psm)
+	}
+
+	if len(p.PeptideIdentification) == 0 {
+		msg.NoPSMFound(errors.New(f), "warning")
+	}
+}
+
+// parseMSFraggerMods reads the MSFragger modification info, e.g. N-term(42.0106), 5M(15.9949)
+func parseMSFraggerMods(info string, length int) []siteMod {
+
+	var sites []siteMod
+
+	for _, i := range strings.Split(info, ",") {
+
+		m := fraggerModRegex.FindStringSubmatch(strings.TrimSpace(i))
+		if len(m) == 0 {
+			continue
+		}
+
+		massDiff, _ := strconv.ParseFloat(m[4], 64)
+		site := siteMod{massDiff: massDiff, variable: true}
+
+		switch m[1] {
+		case "N-term":
+			site.location = 0
+		case "C-term":
+			site.location = length + 1
+		default:
+			site.location, _ = strconv.Atoi(m[2])
+		}
+
+		sites = append(sites, site)
+	}
+
+	return sites
+}
+
+// ReadCometTXT parses a Comet txt file and converts the top ranked hits into
+// peptide identifications
+func (p *PepXML) ReadCometTXT(f string) {
+
+	logrus.Info("Parsing ", f)
+
+	p.FileName = filepath.Base(f)
+	p.SearchEngine = "Comet"
+	p.Modifications.Index = make(map[string]mod.Modification)
+
+	// the first line carries the Comet version, the spectra file and the database
+	version := strings.Split(firstLine(f), "\t")
+	if len(version) > 3 {
+		p.SpectraFile = version[1]
+		p.Database = version[3]
+	}
+
+	header, rows := readTable(f, 1)
+	col := columnIndex(header)
+
+	get := func(row []string, name string) string {
+		if i, ok := col[name]; ok && i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+
+	num := func(row []string, name string) float64 {
+		v, _ := strconv.ParseFloat(get(row, name), 64)
+		return v
+	}
+
+	base := fileBase(f)
+
+	for _, i := range rows {
+
+		rank, _ := strconv.Atoi(get(i, "num"))
+		if rank > 1 {
+			continue
+		}
+
+		var psm PeptideIdentification
+		psm.AlternativeProteins = make(map[string]int)
+
+		scan, _ := strconv.Atoi(get(i, "scan"))
+		charge, _ := strconv.Atoi(get(i, "charge"))
+
+		psm.Index = uint32(len(p.PeptideIdentification))
+		psm.SpectrumFile = p.FileName
+		psm.Spectrum = fmt.Sprintf("%s.%05d.%05d.%d", base, scan, scan, charge)
+		psm.AssumedCharge = uint8(charge)
+		psm.HitRank = 1
+		psm.Peptide = get(i, "plain_peptide")
+		psm.RetentionTime = num(i, "retention_time_sec")
+
+		psm.PrecursorNeutralMass = num(i, "exp_neutral_mass")
+		psm.UncalibratedPrecursorNeutralMass = psm.PrecursorNeutralMass
+		psm.CalcNeutralPepMass = num(i, "calc_neutral_mass")
+		psm.Massdiff = uti.ToFixed(psm.PrecursorNeutralMass-psm.CalcNeutralPepMass, 4)
+
+		psm.Expectation = num(i, "e-value")
+		psm.Xcorr = num(i, "xcorr")
+		psm.DeltaCN = num(i, "delta_cn")
+		psm.SPRank = num(i, "sp_rank")
+
+		if len(p.ScoreName) > 0 {
+			psm.DiscriminantValue = num(i, strings.ToLower(p.ScoreName))
+		}
+
+		for idx, j := range strings.Split(get(i, "protein"), ",") {
+			if idx == 0 {
+				psm.Protein = j
+			} else if j != psm.Protein {
+				psm.AlternativeProteins[j]++
+			}
+		}
+
+		psm.mapSiteMods(parseCometMods(get(i, "modifications"), len(psm.Peptide)), p.Modifications)
+
+		p.PeptideIdentification = append(p.PeptideIdentification, psm)
+	}
+
+	if len(p.PeptideIdentification) == 0 {
+		msg.NoPSMFound(errors.New(f), "warning")
+	}
+}
+
+// parseCometMods reads the Comet modifications list, formatted as position_type_mass
+// where the type is V for variable and S for static modifications, e.g. 3_V_15.994915,n_V_42.010565
+func parseCometMods(info string, length int) []siteMod {
+
+	var sites []siteMod
+
+	for _, i := range strings.Split(info, ",") {
+
+		parts := strings.Split(strings.TrimSpace(i), "_")
+		if len(parts) < 3 {
+			continue
+		}
+
+		massDiff, e := strconv.ParseFloat(parts[2], 64)
+		if e != nil {
+			continue
+		}
+
+		site := siteMod{massDiff: massDiff, variable: !strings.EqualFold(parts[1], "S")}
+
+		switch strings.ToLower(parts[0]) {
+		case "n":
+			site.location = 0
+		case "c":
+			site.location = length + 1
+		default:
+			site.location, e = strconv.Atoi(parts[0])
+			if e != nil {
+				continue
+			}
+		}
+
+		sites = append(sites, site)
+	}
+
+	return sites
+}
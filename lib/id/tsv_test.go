@@ -0,0 +1,105 @@
+package id
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_parseMSFraggerMods(t *testing.T) {
+
+	got := parseMSFraggerMods("N-term(42.0106), 4M(15.9949), C-term(0.9840)", 8)
+
+	want := []siteMod{
+		{location: 0, massDiff: 42.0106, variable: true},
+		{location: 4, massDiff: 15.9949, variable: true},
+		{location: 9, massDiff: 0.9840, variable: true},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseMSFraggerMods() = %v, want %v", got, want)
+	}
+}
+
+func Test_parseCometMods(t *testing.T) {
+
+	got := parseCometMods("n_V_42.010565,3_V_15.994915,5_S_57.021464", 6)
+
+	want := []siteMod{
+		{location: 0, massDiff: 42.010565, variable: true},
+		{location: 3, massDiff: 15.994915, variable: true},
+		{location: 5, massDiff: 57.021464, variable: false},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseCometMods() = %v, want %v", got, want)
+	}
+}
+
+func Test_parsePinPeptide(t *testing.T) {
+
+	peptide, sites := parsePinPeptide("K.n[42.0106]PEPM[15.9949]TIDEc[-0.9840].R")
+
+	if peptide != "PEPMTIDE" {
+		t.Errorf("parsePinPeptide() peptide = %v, want %v", peptide, "PEPMTIDE")
+	}
+
+	want := []siteMod{
+		{location: 0, massDiff: 42.0106, variable: true},
+		{location: 4, massDiff: 15.9949, variable: true},
+		{location: 9, massDiff: -0.9840, variable: true},
+	}
+
+	if !reflect.DeepEqual(sites, want) {
+		t.Errorf("parsePinPeptide() sites = %v, want %v", sites, want)
+	}
+}
+
+func Test_parseSpecID(t *testing.T) {
+
+	tests := []struct {
+		id                 string
+		scan, charge, rank int
+	}{
+		{"run01.12345.12345.3_1", 12345, 3, 1},
+		{"run01_12345_2_1", 12345, 2, 1},
+		{"unknown", 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		scan, charge, rank := parseSpecID(tt.id)
+		if scan != tt.scan || charge != tt.charge || rank != tt.rank {
+			t.Errorf("parseSpecID(%s) = %d, %d, %d, want %d, %d, %d", tt.id, scan, charge, rank, tt.scan, tt.charge, tt.rank)
+		}
+	}
+}
+
+func TestPepXML_ReadPin(t *testing.T) {
+
+	dir, _ := ioutil.TempDir("", "id")
+	defer os.RemoveAll(dir)
+
+	f := filepath.Join(dir, "run.pin")
+	ioutil.WriteFile(f, []byte("SpecId\tLabel\tScanNr\tlnExpect\tPeptide\tProteins\n"+
+		"run.00010.00010.2_1\t1\t10\t-9.2\tK.PEPTIDE.R\tsp|P1|A\n"+
+		"run.00011.00011.2_1\t-1\t11\t-2.3\tK.EDITPEP.R\tsp|P2|B\tsp|P3|C\n"), 0644)
+
+	var p PepXML
+	p.DecoyTag = "rev_"
+	p.ReadPin(f)
+
+	if len(p.PeptideIdentification) != 2 {
+		t.Fatalf("ReadPin() got %d PSMs, want %d", len(p.PeptideIdentification), 2)
+	}
+
+	if p.PeptideIdentification[0].Protein != "sp|P1|A" {
+		t.Errorf("ReadPin() target protein = %v, want %v", p.PeptideIdentification[0].Protein, "sp|P1|A")
+	}
+
+	decoy := p.PeptideIdentification[1]
+	if decoy.Protein != "rev_sp|P2|B" || decoy.AlternativeProteins["rev_sp|P3|C"] != 1 {
+		t.Errorf("ReadPin() decoy proteins = %v %v, want them tagged", decoy.Protein, decoy.AlternativeProteins)
+	}
+}
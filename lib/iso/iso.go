@@ -1,5 +1,25 @@
 package iso
 
+import (
+	"math"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// isotopeSpacing is the 13C mass difference separating a reporter ion from its isotope neighbours
+const isotopeSpacing = 1.0033548
+
+// neighbourTolerance locates the isotope neighbours of a channel on the kits resolving the
+// 15N and 13C reporters, their 6.3 mDa difference keeps the 13C isotopes off the 15N channels
+const neighbourTolerance = 0.002
+
+// nominalTolerance locates the isotope neighbours on the kits with one reporter per
+// nominal mass, where the isotopes are not resolved from the next reporter
+const nominalTolerance = 0.01
+
+// resolvedSpacing is the distance below which two reporters only differ by their 15N and 13C labels
+const resolvedSpacing = 0.5
+
 // Labels main struct
 type Labels struct {
 	Spectrum      string
@@ -8,169 +28,225 @@ type Labels struct {
 	RetentionTime float64
 	ChargeState   int
 	IsUsed        bool
-	Channel1      Channel1
-	Channel2      Channel2
-	Channel3      Channel3
-	Channel4      Channel4
-	Channel5      Channel5
-	Channel6      Channel6
-	Channel7      Channel7
-	Channel8      Channel8
-	Channel9      Channel9
-	Channel10     Channel10
-	Channel11     Channel11
-	Channel12     Channel12
-	Channel13     Channel13
-	Channel14     Channel14
-	Channel15     Channel15
-	Channel16     Channel16
-	Channel17     Channel17
-	Channel18     Channel18
+	Channels      []Channel
 }
 
 // LabeledSpectra is a list of spectra lables
 type LabeledSpectra map[string]Labels
 
-// Channel1 TMT
-type Channel1 struct {
-	Name       string
-	CustomName string
-	Mz         float64
-	Intensity  float64
+// Channel is a reporter ion channel
+type Channel struct {
+	Name         string
+	CustomName   string
+	Mz           float64
+	Intensity    float64
+	RawIntensity float64
 }
 
-// Channel2 TMT
-type Channel2 struct {
-	Name       string
-	CustomName string
-	Mz         float64
-	Intensity  float64
+// Kit is a set of isobaric reagents, the normalization is the method applied to the
+// channel intensities of the quantified data, and corrected marks the intensities
+// corrected for the reagent impurities
+type Kit struct {
+	Brand         string
+	Plex          string
+	Normalization string
+	Corrected     bool
+	Channels      []KitChannel
 }
 
-// Channel3 TMT
-type Channel3 struct {
+// KitChannel is a reporter ion of a kit, the neighbours are the indexes of the
+// channels carrying its -2, -1, +1 and +2 13C isotopes, or -1 when absent
+type KitChannel struct {
 	Name       string
-	CustomName string
 	Mz         float64
-	Intensity  float64
+	Neighbours [4]int
 }
 
-// Channel4 TMT
-type Channel4 struct {
-	Name       string
-	CustomName string
-	Mz         float64
-	Intensity  float64
-}
+// NewKit builds a kit from the reporter ion names and m/z values
+func NewKit(brand, plex string, names []string, mz []float64) Kit {
 
-// Channel5 TMT
-type Channel5 struct {
-	Name       string
-	CustomName string
-	Mz         float64
-	Intensity  float64
-}
+	var k = Kit{Brand: brand, Plex: plex}
 
-// Channel6 TMT
-type Channel6 struct {
-	Name       string
-	CustomName string
-	Mz         float64
-	Intensity  float64
-}
+	for i := range names {
+		k.Channels = append(k.Channels, KitChannel{Name: names[i], Mz: mz[i]})
+	}
 
-// Channel7 TMT
-type Channel7 struct {
-	Name       string
-	CustomName string
-	Mz         float64
-	Intensity  float64
-}
+	tolerance := nominalTolerance
+	for i := range k.Channels {
+		for j := i + 1; j < len(k.Channels); j++ {
+			if math.Abs(k.Channels[i].Mz-k.Channels[j].Mz) < resolvedSpacing {
+				tolerance = neighbourTolerance
+			}
+		}
+	}
 
-// Channel8 TMT
-type Channel8 struct {
-	Name       string
-	CustomName string
-	Mz         float64
-	Intensity  float64
-}
+	for i := range k.Channels {
+		for j, shift := range []float64{-2, -1, 1, 2} {
+			k.Channels[i].Neighbours[j] = k.closest(k.Channels[i].Mz+shift*isotopeSpacing, tolerance)
+		}
+	}
 
-// Channel9 TMT
-type Channel9 struct {
-	Name       string
-	CustomName string
-	Mz         float64
-	Intensity  float64
+	return k
 }
 
-// Channel10 TMT
-type Channel10 struct {
-	Name       string
-	CustomName string
-	Mz         float64
-	Intensity  float64
-}
+// closest returns the index of the channel closest to the m/z, -1 when none is within the tolerance
+func (k Kit) closest(mz, tolerance float64) int {
 
-// Channel11 TMT
-type Channel11 struct {
-	Name       string
-	CustomName string
-	Mz         float64
-	Intensity  float64
+	var index = -1
+	var delta = tolerance
+
+	for i := range k.Channels {
+		d := math.Abs(k.Channels[i].Mz - mz)
+		if d <= delta {
+			index = i
+			delta = d
+		}
+	}
+
+	return index
 }
 
-// Channel12 TMT
-type Channel12 struct {
-	Name       string
-	CustomName string
-	Mz         float64
-	Intensity  float64
+// Names lists the channel names of the kit
+func (k Kit) Names() []string {
+
+	var names []string
+	for _, i := range k.Channels {
+		names = append(names, i.Name)
+	}
+
+	return names
 }
 
-// Channel13 TMT
-type Channel13 struct {
-	Name       string
-	CustomName string
-	Mz         float64
-	Intensity  float64
+// Labels creates an empty label set with the kit channels
+func (k Kit) Labels() Labels {
+
+	var l Labels
+	for _, i := range k.Channels {
+		l.Channels = append(l.Channels, Channel{Name: i.Name, Mz: i.Mz})
+	}
+
+	return l
 }
 
-// Channel14 TMT
-type Channel14 struct {
-	Name       string
-	CustomName string
-	Mz         float64
-	Intensity  float64
+// Select returns the label channels in the kit order, the channels missing from
+// the labels are empty. Workspaces written before the channel slices hold all 18
+// TMT channels whatever the plex.
+func (k Kit) Select(l *Labels) []Channel {
+
+	var channels = make([]Channel, len(k.Channels))
+
+	for i := range k.Channels {
+
+		channels[i].Name = k.Channels[i].Name
+		channels[i].CustomName = k.Channels[i].Name
+		channels[i].Mz = k.Channels[i].Mz
+
+		if l == nil {
+			continue
+		}
+
+		for _, j := range l.Channels {
+			if j.Name == k.Channels[i].Name {
+				channels[i] = j
+				break
+			}
+		}
+	}
+
+	return channels
 }
 
-// Channel15 TMT
-type Channel15 struct {
-	Name       string
-	CustomName string
-	Mz         float64
-	Intensity  float64
+// Sum returns the summed intensity of all channels
+func (l Labels) Sum() float64 {
+
+	var sum float64
+	for _, i := range l.Channels {
+		sum += i.Intensity
+	}
+
+	return sum
 }
 
-// Channel16 TMT
-type Channel16 struct {
-	Name       string
-	CustomName string
-	Mz         float64
-	Intensity  float64
+// Add sums the channel intensities of o into the labels, taking the channel names from o
+func (l *Labels) Add(o Labels) {
+
+	for len(l.Channels) < len(o.Channels) {
+		l.Channels = append(l.Channels, Channel{})
+	}
+
+	for i := range o.Channels {
+		l.Channels[i].Name = o.Channels[i].Name
+		l.Channels[i].CustomName = o.Channels[i].CustomName
+		l.Channels[i].Mz = o.Channels[i].Mz
+		l.Channels[i].Intensity += o.Channels[i].Intensity
+		l.Channels[i].RawIntensity += o.Channels[i].RawIntensity
+	}
 }
 
-// Channel17 TMT
-type Channel17 struct {
-	Name       string
-	CustomName string
-	Mz         float64
-	Intensity  float64
+// ClearIntensities sets all channel intensities to zero
+func (l *Labels) ClearIntensities() {
+	for i := range l.Channels {
+		l.Channels[i].Intensity = 0
+		l.Channels[i].RawIntensity = 0
+	}
 }
 
-// Channel18 TMT
-type Channel18 struct {
-	Name       string
-	CustomName string
-	Mz         float64
-	Intensity  float64
+// legacyLabels is the layout of the workspaces written with one field per channel
+type legacyLabels struct {
+	Spectrum      string
+	Index         string
+	Scan          string
+	RetentionTime float64
+	ChargeState   int
+	IsUsed        bool
+	Channels      []Channel
+	Channel1      Channel
+	Channel2      Channel
+	Channel3      Channel
+	Channel4      Channel
+	Channel5      Channel
+	Channel6      Channel
+	Channel7      Channel
+	Channel8      Channel
+	Channel9      Channel
+	Channel10     Channel
+	Channel11     Channel
+	Channel12     Channel
+	Channel13     Channel
+	Channel14     Channel
+	Channel15     Channel
+	Channel16     Channel
+	Channel17     Channel
+	Channel18     Channel
+}
+
+// DecodeMsgpack reads both the channel slices and the fixed channels of older workspaces
+func (l *Labels) DecodeMsgpack(dec *msgpack.Decoder) error {
+
+	var o legacyLabels
+	if e := dec.Decode(&o); e != nil {
+		return e
+	}
+
+	l.Spectrum = o.Spectrum
+	l.Index = o.Index
+	l.Scan = o.Scan
+	l.RetentionTime = o.RetentionTime
+	l.ChargeState = o.ChargeState
+	l.IsUsed = o.IsUsed
+	l.Channels = o.Channels
+
+	if len(l.Channels) > 0 {
+		return nil
+	}
+
+	for _, i := range []Channel{o.Channel1, o.Channel2, o.Channel3, o.Channel4, o.Channel5, o.Channel6,
+		o.Channel7, o.Channel8, o.Channel9, o.Channel10, o.Channel11, o.Channel12,
+		o.Channel13, o.Channel14, o.Channel15, o.Channel16, o.Channel17, o.Channel18} {
+		if len(i.Name) > 0 {
+			l.Channels = append(l.Channels, i)
+		}
+	}
+
+	return nil
 }
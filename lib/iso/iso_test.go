@@ -0,0 +1,76 @@
+package iso
+
+import (
+	"testing"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestLegacyLabels(t *testing.T) {
+
+	// the layout written before the channel slices
+	type legacyChannel struct {
+		Name       string
+		CustomName string
+		Mz         float64
+		Intensity  float64
+	}
+
+	legacy := struct {
+		Scan     string
+		IsUsed   bool
+		Channel1 legacyChannel
+		Channel2 legacyChannel
+	}{
+		Scan:     "00042",
+		IsUsed:   true,
+		Channel1: legacyChannel{Name: "126", CustomName: "control", Mz: 126.127726, Intensity: 100},
+		Channel2: legacyChannel{Name: "127N", Mz: 127.124761, Intensity: 200},
+	}
+
+	b, e := msgpack.Marshal(legacy)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	var l *Labels
+	if e := msgpack.Unmarshal(b, &l); e != nil {
+		t.Fatal(e)
+	}
+
+	if l.Scan != "00042" || !l.IsUsed || len(l.Channels) != 2 {
+		t.Fatalf("Legacy labels are incorrect, got %+v", l)
+	}
+
+	if l.Channels[0].CustomName != "control" || l.Channels[1].Intensity != 200 {
+		t.Errorf("Legacy channels are incorrect, got %+v", l.Channels)
+	}
+
+	// the current layout reads back unchanged
+	b, _ = msgpack.Marshal(map[string]Labels{"a": *l})
+
+	var m map[string]Labels
+	if e := msgpack.Unmarshal(b, &m); e != nil {
+		t.Fatal(e)
+	}
+
+	if len(m["a"].Channels) != 2 || m["a"].Channels[1].Name != "127N" {
+		t.Errorf("Labels are incorrect, got %+v", m["a"])
+	}
+}
+
+func TestKitSelect(t *testing.T) {
+
+	k := NewKit("tmt", "2", []string{"126", "127C"}, []float64{126.127726, 127.131081})
+
+	if k.Channels[0].Neighbours != [4]int{-1, -1, 1, -1} {
+		t.Errorf("Neighbours are incorrect, got %v", k.Channels[0].Neighbours)
+	}
+
+	l := Labels{Channels: []Channel{{Name: "126", Intensity: 1}, {Name: "127N", Intensity: 2}, {Name: "127C", Intensity: 3}}}
+
+	s := k.Select(&l)
+	if len(s) != 2 || s[1].Intensity != 3 {
+		t.Errorf("Selected channels are incorrect, got %+v", s)
+	}
+}
@@ -44,6 +44,8 @@ type Data struct {
 	InterProphet   InterProphet
 	ProteinProphet ProteinProphet
 	PTMProphet     PTMProphet
+	Rescore        Rescore
+	Align          Align
 	Filter         Filter
 	Quantify       Quantify
 	BioQuant       BioQuant
@@ -337,13 +339,26 @@ type PTMProphet struct {
 	NoMinoFactor       bool    `yaml:"nominofactor"`
 }
 
+// Rescore options and parameters
+type Rescore struct {
+	Pex        string  `yaml:"pepxml"`
+	Tag        string  `yaml:"tag"`
+	Model      string  `yaml:"model"`
+	FDR        float64 `yaml:"fdr"`
+	Folds      int     `yaml:"folds"`
+	Iterations int     `yaml:"iterations"`
+}
+
 // Filter options and parameters
 type Filter struct {
 	Pex       string  `yaml:"pepxml"`
+	Mzid      string  `yaml:"mzid"`
 	Pox       string  `yaml:"protxml"`
 	Tag       string  `yaml:"tag"`
 	Mods      string  `yaml:"mods"`
 	RazorBin  string  `yaml:"razorbin"`
+	Score     string  `yaml:"score"`
+	ScoreName string  `yaml:"scoreName"`
 	PsmFDR    float64 `yaml:"psmFDR"`
 	PepFDR    float64 `yaml:"peptideFDR"`
 	IonFDR    float64 `yaml:"ionFDR"`
@@ -358,49 +373,88 @@ type Filter struct {
 	TwoD      bool    `yaml:"two-dimensional"`
 	Mapmods   bool    `yaml:"mapMods"`
 	Delta     bool    `yaml:"delta"`
+	Rescored  bool    `yaml:"rescored"`
 	Inference bool
 }
 
 // Quantify options and parameters
 type Quantify struct {
-	Pex        string  `yaml:"pepxml"`
-	Tag        string  `yaml:"tag"`
-	Format     string  `yaml:"format"`
-	Dir        string  `yaml:"dir"`
-	Brand      string  `yaml:"brand"`
-	Plex       string  `yaml:"plex"`
-	ChanNorm   string  `yaml:"chanNorm"`
-	Annot      string  `yaml:"annotation"`
-	Level      int     `yaml:"level"`
-	RTWin      float64 `yaml:"retentionTimeWindow"`
-	PTWin      float64 `yaml:"peakTimeWindow"`
-	Tol        float64 `yaml:"tolerance"`
-	Purity     float64 `yaml:"purity"`
-	MinProb    float64 `yaml:"minprob"`
-	RemoveLow  float64 `yaml:"removeLow"`
-	Isolated   bool    `yaml:"isolated"`
-	IntNorm    bool    `yaml:"intNorm"`
-	Unique     bool    `yaml:"uniqueOnly"`
-	BestPSM    bool    `yaml:"bestPSM"`
-	Raw        bool    `yaml:"raw"`
-	Faims      bool    `yaml:"faims"`
-	LabelNames map[string]string
+	Pex            string  `yaml:"pepxml"`
+	Tag            string  `yaml:"tag"`
+	Format         string  `yaml:"format"`
+	Dir            string  `yaml:"dir"`
+	Brand          string  `yaml:"brand"`
+	Plex           string  `yaml:"plex"`
+	ChanNorm       string  `yaml:"chanNorm"`
+	Annot          string  `yaml:"annotation"`
+	Impurity       string  `yaml:"impurity"`
+	Labels         string  `yaml:"labels"`
+	Sites          string  `yaml:"sites"`
+	Library        string  `yaml:"library"`
+	Level          int     `yaml:"level"`
+	RTWin          float64 `yaml:"retentionTimeWindow"`
+	PTWin          float64 `yaml:"peakTimeWindow"`
+	Tol            float64 `yaml:"tolerance"`
+	IMTol          float64 `yaml:"ionMobilityTolerance"`
+	Purity         float64 `yaml:"purity"`
+	SiteProb       float64 `yaml:"siteProbability"`
+	MinProb        float64 `yaml:"minprob"`
+	RemoveLow      float64 `yaml:"removeLow"`
+	MinIsoCorr     float64 `yaml:"minIsotopeCorrelation"`
+	MinFragCor     float64 `yaml:"minFragmentCorrelation"`
+	Isotopes       int     `yaml:"isotopes"`
+	Fragments      int     `yaml:"fragments"`
+	Isolated       bool    `yaml:"isolated"`
+	IntNorm        bool    `yaml:"intNorm"`
+	Unique         bool    `yaml:"uniqueOnly"`
+	BestPSM        bool    `yaml:"bestPSM"`
+	Raw            bool    `yaml:"raw"`
+	Faims          bool    `yaml:"faims"`
+	MS1            bool    `yaml:"ms1"`
+	LabelNames     map[string]string
+	ImpurityMatrix [][]float64
+}
+
+// Align options and parameters
+type Align struct {
+	Method    string  `yaml:"method"`
+	Reference string  `yaml:"reference"`
+	MinProb   float64 `yaml:"minProb"`
+	Span      float64 `yaml:"span"`
+	Models    map[string]Calibration
+}
+
+// Calibration is the stored retention time model of a run, the knots map its
+// retention times onto the reference run
+type Calibration struct {
+	Reference string
+	Method    string
+	X         []float64
+	Y         []float64
+	Points    int
+	Deviation float64
 }
 
 // Abacus options ad parameters
 type Abacus struct {
-	Tag      string  `yaml:"tag"`
-	Plex     string  `yaml:"plex"`
-	ProtProb float64 `yaml:"proteinProbability"`
-	PepProb  float64 `yaml:"peptideProbability"`
-	Peptide  bool    `yaml:"peptide"`
-	Protein  bool    `yaml:"protein"`
-	Razor    bool    `yaml:"razor"`
-	Picked   bool    `yaml:"picked"`
-	Labels   bool    `yaml:"labels"`
-	Unique   bool    `yaml:"uniqueOnly"`
-	Reprint  bool    `yaml:"reprint"`
-	Full     bool    `yaml:"full"`
+	Tag       string  `yaml:"tag"`
+	Plex      string  `yaml:"plex"`
+	ProtProb  float64 `yaml:"proteinProbability"`
+	PepProb   float64 `yaml:"peptideProbability"`
+	Peptide   bool    `yaml:"peptide"`
+	Protein   bool    `yaml:"protein"`
+	Razor     bool    `yaml:"razor"`
+	Picked    bool    `yaml:"picked"`
+	Labels    bool    `yaml:"labels"`
+	IRS       bool    `yaml:"irs"`
+	Unique    bool    `yaml:"uniqueOnly"`
+	Normalize string  `yaml:"normalize"`
+	Impute    string  `yaml:"impute"`
+	MBRFDR    float64 `yaml:"mbrFDR"`
+	MBRWin    float64 `yaml:"mbrWindow"`
+	Reprint   bool    `yaml:"reprint"`
+	Full      bool    `yaml:"full"`
+	MBR       bool    `yaml:"mbr"`
 }
 
 // BioQuant options and parameters
@@ -415,6 +469,7 @@ type Report struct {
 	RemoveContam bool `yaml:"removecontam"`
 	MSstats      bool `yaml:"msstats"`
 	MZID         bool `yaml:"mzID"`
+	MzTab        bool `yaml:"mzTab"`
 	IonMob       bool `yaml:"ionmobility"`
 	Prefix       bool `yaml:"prefix"`
 }
@@ -16,6 +16,7 @@ import (
 
 	"philosopher/lib/psi"
 
+	"github.com/klauspost/compress/zstd"
 	"github.com/sirupsen/logrus"
 )
 
@@ -24,6 +25,11 @@ type MsData struct {
 	FileName string
 	Spectra  Spectra
 	//	RefSpectra sync.Map
+	scans  map[int]int
+	ids    map[string]int
+	ms1    []int
+	ms2    []int
+	stream *stream
 }
 
 // Spectra struct
@@ -33,6 +39,7 @@ type Spectra []Spectrum
 type Spectrum struct {
 	Index               string
 	Scan                string
+	NativeID            string
 	Level               string
 	SpectrumName        string
 	CompensationVoltage string
@@ -62,6 +69,7 @@ type Mz struct {
 	DecodedStream []float64
 	Precision     string
 	Compression   string
+	Numpress      string
 }
 
 // Intensity struct
@@ -70,6 +78,7 @@ type Intensity struct {
 	DecodedStream []float64
 	Precision     string
 	Compression   string
+	Numpress      string
 }
 
 // IonMobility struct
@@ -78,6 +87,7 @@ type IonMobility struct {
 	DecodedStream []float64
 	Precision     string
 	Compression   string
+	Numpress      string
 }
 
 func (a Spectra) Len() int           { return len(a) }
@@ -185,6 +195,7 @@ func (p *MsData) ReadRaw(fileName, f string) {
 	}
 
 	p.Spectra = spectra
+	p.indexSpectra()
 }
 
 // Read is the main function for parsing mzML data
@@ -193,12 +204,7 @@ func (p *MsData) Read(f string) {
 	var xml psi.IndexedMzML
 	xml.Parse(f)
 
-	if xml.MzML.SoftwareList.Software[0].ID == "pwiz" {
-		version, _ := strconv.Atoi(strings.Replace(xml.MzML.SoftwareList.Software[0].Version, ".", "", -1))
-		if version <= 3020232 {
-			msg.Custom(errors.New("the msconvert version used to convert this file is not supported, or is deprecated. Please update your ProteoWizard and convert the raw files again"), "warning")
-		}
-	}
+	checkSoftware(xml.MzML.SoftwareList)
 
 	p.FileName = f
 
@@ -217,6 +223,19 @@ func (p *MsData) Read(f string) {
 	}
 
 	p.Spectra = spectra
+	p.indexSpectra()
+
+}
+
+// checkSoftware warns about mzML files converted with deprecated msconvert versions
+func checkSoftware(sl psi.SoftwareList) {
+
+	if len(sl.Software) > 0 && sl.Software[0].ID == "pwiz" {
+		version, _ := strconv.Atoi(strings.Replace(sl.Software[0].Version, ".", "", -1))
+		if version <= 3020232 {
+			msg.Custom(errors.New("the msconvert version used to convert this file is not supported, or is deprecated. Please update your ProteoWizard and convert the raw files again"), "warning")
+		}
+	}
 
 }
 
@@ -225,6 +244,7 @@ func processSpectrum(mzSpec psi.Spectrum) Spectrum {
 	var spec Spectrum
 
 	spec.Index = string(mzSpec.Index)
+	spec.NativeID = mzSpec.ID
 
 	indexStr := string(mzSpec.Index)
 	indexInt, _ := strconv.Atoi(indexStr)
@@ -320,50 +340,22 @@ func processSpectrum(mzSpec psi.Spectrum) Spectrum {
 		}
 	}
 
-	spec.Mz.Stream = mzSpec.BinaryDataArrayList.BinaryDataArray[0].Binary.Value
-	for _, j := range mzSpec.BinaryDataArrayList.BinaryDataArray[0].CVParam {
-		if string(j.Accession) == "MS:1000523" {
-			spec.Mz.Precision = "64"
-		} else if string(j.Accession) == "MS:1000521" {
-			spec.Mz.Precision = "32"
-		}
-
-		if string(j.Accession) == "MS:1000574" {
-			spec.Mz.Compression = "1"
-		} else if string(j.Accession) == "MS:1000576" {
-			spec.Mz.Compression = "0"
-		}
+	// the spectrum headers read by the index have no binary arrays
+	if len(mzSpec.BinaryDataArrayList.BinaryDataArray) < 2 {
+		return spec
 	}
 
+	spec.Mz.Stream = mzSpec.BinaryDataArrayList.BinaryDataArray[0].Binary.Value
+	spec.Mz.Precision, spec.Mz.Compression, spec.Mz.Numpress = binaryEncoding(mzSpec.BinaryDataArrayList.BinaryDataArray[0].CVParam)
+
 	spec.Intensity.Stream = mzSpec.BinaryDataArrayList.BinaryDataArray[1].Binary.Value
-	for _, j := range mzSpec.BinaryDataArrayList.BinaryDataArray[1].CVParam {
-		if string(j.Accession) == "MS:1000523" {
-			spec.Intensity.Precision = "64"
-		} else if string(j.Accession) == "MS:1000521" {
-			spec.Intensity.Precision = "32"
-		}
+	spec.Intensity.Precision, spec.Intensity.Compression, spec.Intensity.Numpress = binaryEncoding(mzSpec.BinaryDataArrayList.BinaryDataArray[1].CVParam)
 
-		if string(j.Accession) == "MS:1000574" {
-			spec.Intensity.Compression = "1"
-		} else if string(j.Accession) == "MS:1000576" {
-			spec.Intensity.Compression = "0"
-		}
-	}
-
-	if mzSpec.BinaryDataArrayList.Count == 3 {
-		spec.IonMobility.Stream = mzSpec.BinaryDataArrayList.BinaryDataArray[2].Binary.Value
-		for _, j := range mzSpec.BinaryDataArrayList.BinaryDataArray[2].CVParam {
-			if string(j.Accession) == "MS:1000523" {
-				spec.IonMobility.Precision = "64"
-			} else if string(j.Accession) == "MS:1000521" {
-				spec.IonMobility.Precision = "32"
-			}
-
-			if string(j.Accession) == "MS:1000574" {
-				spec.IonMobility.Compression = "1"
-			} else if string(j.Accession) == "MS:1000576" {
-				spec.IonMobility.Compression = "0"
-			}
+	// the ion mobility array is recognized by its type, or is the third array
+	for i, j := range mzSpec.BinaryDataArrayList.BinaryDataArray {
+		if i > 1 && (isMobilityArray(j.CVParam) || (len(mzSpec.BinaryDataArrayList.BinaryDataArray) == 3 && len(spec.IonMobility.Stream) == 0)) {
+			spec.IonMobility.Stream = j.Binary.Value
+			spec.IonMobility.Precision, spec.IonMobility.Compression, spec.IonMobility.Numpress = binaryEncoding(j.CVParam)
 		}
 	}
 
@@ -374,22 +366,75 @@ func processSpectrum(mzSpec psi.Spectrum) Spectrum {
 func (s *Spectrum) Decode() {
 
 	if len(s.Mz.Stream) > 0 && len(s.Intensity.Stream) > 0 {
-		s.Mz.DecodedStream = readEncoded(s.Mz.Stream, s.Mz.Precision, s.Mz.Compression)
+		s.Mz.DecodedStream = readEncoded(s.Mz.Stream, s.Mz.Precision, s.Mz.Compression, s.Mz.Numpress)
 		s.Mz.Stream = nil
 
-		s.Intensity.DecodedStream = readEncoded(s.Intensity.Stream, s.Intensity.Precision, s.Intensity.Compression)
+		s.Intensity.DecodedStream = readEncoded(s.Intensity.Stream, s.Intensity.Precision, s.Intensity.Compression, s.Intensity.Numpress)
 		s.Intensity.Stream = nil
 	}
 
 	if len(s.IonMobility.Stream) > 0 {
-		s.IonMobility.DecodedStream = readEncoded(s.IonMobility.Stream, s.IonMobility.Precision, s.IonMobility.Compression)
+		s.IonMobility.DecodedStream = readEncoded(s.IonMobility.Stream, s.IonMobility.Precision, s.IonMobility.Compression, s.IonMobility.Numpress)
 		s.IonMobility.Stream = nil
 	}
 
 }
 
+// isMobilityArray checks whether a binary data array holds the ion mobility of
+// each peak, as the inverse reduced ion mobility (1/K0) or the drift time
+func isMobilityArray(params []psi.CVParam) bool {
+
+	for _, j := range params {
+		switch j.Accession {
+		case "MS:1002816", "MS:1003006", "MS:1003007", "MS:1003008", "MS:1003153":
+			return true
+		}
+	}
+
+	return false
+}
+
+// binaryEncoding reads the precision, compression and numpress encoding of a binary data array
+func binaryEncoding(params []psi.CVParam) (string, string, string) {
+
+	var precision, compression, numpress string
+
+	for _, j := range params {
+		switch string(j.Accession) {
+		case "MS:1000523":
+			precision = "64"
+		case "MS:1000521":
+			precision = "32"
+		case "MS:1000574":
+			compression = "1"
+		case "MS:1000576":
+			if len(compression) == 0 {
+				compression = "0"
+			}
+		case "MS:1002312":
+			numpress = "linear"
+		case "MS:1002313":
+			numpress = "pic"
+		case "MS:1002314":
+			numpress = "slof"
+		case "MS:1002746":
+			numpress, compression = "linear", "1"
+		case "MS:1002747":
+			numpress, compression = "pic", "1"
+		case "MS:1002748":
+			numpress, compression = "slof", "1"
+		default:
+			if strings.Contains(strings.ToLower(j.Name), "zstd") {
+				compression = "zstd"
+			}
+		}
+	}
+
+	return precision, compression, numpress
+}
+
 // readEncoded transforms the binary data into float64 values
-func readEncoded(bin []byte, precision, isCompressed string) []float64 {
+func readEncoded(bin []byte, precision, isCompressed, numpress string) []float64 {
 
 	var stream []uint8
 	var floatArray []float64
@@ -398,7 +443,18 @@ func readEncoded(bin []byte, precision, isCompressed string) []float64 {
 	b64 := base64.NewDecoder(base64.StdEncoding, b)
 
 	var bytestream bytes.Buffer
-	if isCompressed == "1" {
+	if isCompressed == "zstd" {
+		r, e := zstd.NewReader(b64)
+		if e != nil {
+			msg.Custom(e, "error")
+			return []float64{0.0}
+		}
+		defer r.Close()
+		if _, e := io.Copy(&bytestream, r); e != nil {
+			msg.Custom(e, "error")
+			return []float64{0.0}
+		}
+	} else if isCompressed == "1" {
 		r, e := zlib.NewReader(b64)
 		if e != nil {
 			msg.ReadingMzMLZlib(e, "error")
@@ -411,6 +467,10 @@ func readEncoded(bin []byte, precision, isCompressed string) []float64 {
 		io.Copy(&bytestream, b64)
 	}
 
+	if len(numpress) > 0 {
+		return readNumpress(bytestream.Bytes(), numpress)
+	}
+
 	dataArray := bytestream.Bytes()
 
 	var counter int
@@ -445,3 +505,26 @@ func readEncoded(bin []byte, precision, isCompressed string) []float64 {
 
 	return floatArray
 }
+
+// readNumpress decodes the MS-Numpress encoded arrays
+func readNumpress(data []byte, numpress string) []float64 {
+
+	var values []float64
+	var e error
+
+	switch numpress {
+	case "linear":
+		values, e = decodeLinear(data)
+	case "pic":
+		values, e = decodePic(data)
+	case "slof":
+		values, e = decodeSlof(data)
+	}
+
+	if e != nil {
+		msg.Custom(e, "error")
+		return []float64{0.0}
+	}
+
+	return values
+}
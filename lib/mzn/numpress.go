@@ -0,0 +1,187 @@
+package mzn
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// MS-Numpress decoders, following the reference implementation from
+// https://github.com/ms-numpress/ms-numpress. The integers are stored as
+// half-byte sequences, where the first half-byte gives the number of leading
+// zero (0-8) or leading 0xf (9-15) half-bytes that were omitted
+
+// decodeFixedPoint reads the scaling factor stored in the first 8 bytes, the reference
+// encoder writes it big-endian while the integers that follow are little-endian
+func decodeFixedPoint(data []byte) float64 {
+	return math.Float64frombits(binary.BigEndian.Uint64(data[:8]))
+}
+
+// halfByteReader walks a byte stream one half-byte at a time
+type halfByteReader struct {
+	data []byte
+	pos  int
+	half bool
+}
+
+// done reports if the stream is exhausted, the last half-byte of an odd sequence is padding
+func (r *halfByteReader) done() bool {
+
+	if r.pos >= len(r.data) {
+		return true
+	}
+
+	if r.pos == len(r.data)-1 && r.half && r.data[r.pos]&0xf == 0 {
+		return true
+	}
+
+	return false
+}
+
+// next returns the following half-byte
+func (r *halfByteReader) next() (uint32, error) {
+
+	if r.pos >= len(r.data) {
+		return 0, errors.New("numpress stream ended unexpectedly")
+	}
+
+	var hb byte
+	if !r.half {
+		hb = r.data[r.pos] >> 4
+	} else {
+		hb = r.data[r.pos] & 0xf
+		r.pos++
+	}
+	r.half = !r.half
+
+	return uint32(hb), nil
+}
+
+// decodeInt reads one integer encoded as a variable number of half-bytes
+func (r *halfByteReader) decodeInt() (uint32, error) {
+
+	head, e := r.next()
+	if e != nil {
+		return 0, e
+	}
+
+	var n uint32
+	var res uint32
+
+	if head <= 8 {
+		n = head
+	} else {
+		// the omitted leading half-bytes are all ones
+		n = head - 8
+		for i := uint32(0); i < n; i++ {
+			res |= 0xf0000000 >> (4 * i)
+		}
+	}
+
+	for i := uint32(0); i < 8-n; i++ {
+		hb, e := r.next()
+		if e != nil {
+			return 0, e
+		}
+		res |= hb << (4 * i)
+	}
+
+	return res, nil
+}
+
+// decodeLinear decodes the numpress linear prediction compression, used for m/z
+// and retention times
+func decodeLinear(data []byte) ([]float64, error) {
+
+	if len(data) == 8 {
+		return nil, nil
+	}
+
+	if len(data) < 8 {
+		return nil, errors.New("corrupt numpress linear stream, missing the fixed point")
+	}
+
+	fixedPoint := decodeFixedPoint(data)
+
+	if len(data) < 12 {
+		return nil, errors.New("corrupt numpress linear stream, missing the first value")
+	}
+
+	var values []float64
+	var ints [3]int64
+
+	ints[1] = int64(binary.LittleEndian.Uint32(data[8:12]))
+	values = append(values, float64(ints[1])/fixedPoint)
+
+	if len(data) == 12 {
+		return values, nil
+	}
+
+	if len(data) < 16 {
+		return nil, errors.New("corrupt numpress linear stream, missing the second value")
+	}
+
+	ints[2] = int64(binary.LittleEndian.Uint32(data[12:16]))
+	values = append(values, float64(ints[2])/fixedPoint)
+
+	r := halfByteReader{data: data, pos: 16}
+	for !r.done() {
+
+		ints[0] = ints[1]
+		ints[1] = ints[2]
+
+		buff, e := r.decodeInt()
+		if e != nil {
+			return nil, e
+		}
+
+		extrapolation := ints[1] + (ints[1] - ints[0])
+		y := extrapolation + int64(int32(buff))
+
+		values = append(values, float64(y)/fixedPoint)
+		ints[2] = y
+	}
+
+	return values, nil
+}
+
+// decodePic decodes the numpress positive integer compression, used for ion counts
+func decodePic(data []byte) ([]float64, error) {
+
+	var values []float64
+
+	r := halfByteReader{data: data}
+	for !r.done() {
+
+		count, e := r.decodeInt()
+		if e != nil {
+			return nil, e
+		}
+
+		values = append(values, float64(count))
+	}
+
+	return values, nil
+}
+
+// decodeSlof decodes the numpress short logged float compression, used for intensities
+func decodeSlof(data []byte) ([]float64, error) {
+
+	if len(data) < 8 {
+		return nil, errors.New("corrupt numpress slof stream, missing the fixed point")
+	}
+
+	if (len(data)-8)%2 != 0 {
+		return nil, errors.New("corrupt numpress slof stream, odd number of bytes")
+	}
+
+	fixedPoint := decodeFixedPoint(data)
+
+	var values []float64
+	for i := 8; i < len(data); i += 2 {
+		x := binary.LittleEndian.Uint16(data[i : i+2])
+		values = append(values, math.Exp(float64(x)/fixedPoint)-1)
+	}
+
+	return values, nil
+}
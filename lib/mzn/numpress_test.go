@@ -0,0 +1,235 @@
+package mzn
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"philosopher/lib/psi"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// encodeInt mirrors the reference numpress half-byte integer encoding
+func encodeInt(x uint32) []byte {
+
+	const mask = 0xf0000000
+	init := x & mask
+
+	var l int
+	var head byte
+
+	switch init {
+	case 0:
+		l = 8
+		for i := 0; i < 8; i++ {
+			if x&(mask>>(4*uint(i))) != 0 {
+				l = i
+				break
+			}
+		}
+		head = byte(l)
+	case mask:
+		l = 7
+		for i := 0; i < 8; i++ {
+			m := uint32(mask >> (4 * uint(i)))
+			if x&m != m {
+				l = i
+				break
+			}
+		}
+		head = byte(l + 8)
+	default:
+		l = 0
+		head = 0
+	}
+
+	res := []byte{head}
+	for i := l; i < 8; i++ {
+		res = append(res, byte(x>>(4*uint(i-l)))&0xf)
+	}
+
+	return res
+}
+
+func packHalfBytes(half []byte) []byte {
+
+	var res []byte
+	for i := 0; i+1 < len(half); i += 2 {
+		res = append(res, half[i]<<4|half[i+1])
+	}
+
+	if len(half)%2 == 1 {
+		res = append(res, half[len(half)-1]<<4)
+	}
+
+	return res
+}
+
+func fixedPointBytes(fp float64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, math.Float64bits(fp))
+	return b
+}
+
+func encodeLinear(values []float64, fp float64) []byte {
+
+	res := fixedPointBytes(fp)
+
+	var ints [3]int64
+	ints[1] = int64(values[0]*fp + 0.5)
+	ints[2] = int64(values[1]*fp + 0.5)
+
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, uint32(ints[1]))
+	res = append(res, b...)
+	binary.LittleEndian.PutUint32(b, uint32(ints[2]))
+	res = append(res, b...)
+
+	var half []byte
+	for _, i := range values[2:] {
+		ints[0] = ints[1]
+		ints[1] = ints[2]
+		ints[2] = int64(i*fp + 0.5)
+		extrapolation := ints[1] + (ints[1] - ints[0])
+		half = append(half, encodeInt(uint32(int32(ints[2]-extrapolation)))...)
+	}
+
+	return append(res, packHalfBytes(half)...)
+}
+
+func encodePic(values []float64) []byte {
+
+	var half []byte
+	for _, i := range values {
+		half = append(half, encodeInt(uint32(i+0.5))...)
+	}
+
+	return packHalfBytes(half)
+}
+
+func encodeSlof(values []float64, fp float64) []byte {
+
+	res := fixedPointBytes(fp)
+	b := make([]byte, 2)
+	for _, i := range values {
+		binary.LittleEndian.PutUint16(b, uint16(math.Log(i+1)*fp+0.5))
+		res = append(res, b...)
+	}
+
+	return res
+}
+
+func zlibBase64(data []byte) []byte {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	w.Write(data)
+	w.Close()
+	return []byte(base64.StdEncoding.EncodeToString(buf.Bytes()))
+}
+
+func sameValues(t *testing.T, name string, got, want []float64, tol float64) {
+
+	if len(got) != len(want) {
+		t.Fatalf("%s decoded %d values, want %d", name, len(got), len(want))
+	}
+
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > tol*math.Max(1, math.Abs(want[i])) {
+			t.Errorf("%s value %d = %f, want %f", name, i, got[i], want[i])
+		}
+	}
+}
+
+func Test_readNumpress(t *testing.T) {
+
+	mz := []float64{100.0001, 100.0523, 250.9876, 250.9881, 901.4434, 1200.5}
+	intensity := []float64{0, 12, 1500, 99999, 3, 420000}
+
+	linear := readEncoded([]byte(base64.StdEncoding.EncodeToString(encodeLinear(mz, 1e5))), "64", "0", "linear")
+	sameValues(t, "linear", linear, mz, 1e-5)
+
+	pic := readEncoded(zlibBase64(encodePic(intensity)), "64", "1", "pic")
+	sameValues(t, "pic", pic, intensity, 0)
+
+	slof := readEncoded(zlibBase64(encodeSlof(intensity, 4000)), "64", "1", "slof")
+	sameValues(t, "slof", slof, intensity, 5e-4)
+}
+
+func Test_readNumpressReference(t *testing.T) {
+
+	// encodeLinear test vector of the reference ms-numpress suite, 100, 200, 300.00005 and
+	// 400.0001 with a 100000 fixed point
+	linear, e := decodeLinear([]byte{
+		0x40, 0xf8, 0x6a, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x80, 0x96, 0x98, 0x00,
+		0x00, 0x2d, 0x31, 0x01,
+		0x75, 0x80,
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	sameValues(t, "reference linear", linear, []float64{100, 200, 300.00005, 400.0001}, 1e-9)
+
+	// 0, 12 and 1500 as the half-bytes 8, 7 c, 5 c d 5 and a padding zero
+	pic, e := decodePic([]byte{0x87, 0xc5, 0xcd, 0x50})
+	if e != nil {
+		t.Fatal(e)
+	}
+	sameValues(t, "reference pic", pic, []float64{0, 12, 1500}, 0)
+
+	// 0, 9 and 99 with a 1000 fixed point, round(log(x+1) * 1000) as little-endian shorts
+	slof, e := decodeSlof([]byte{
+		0x40, 0x8f, 0x40, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00,
+		0xff, 0x08,
+		0xfd, 0x11,
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	sameValues(t, "reference slof", slof, []float64{0, 9, 99}, 5e-4)
+}
+
+func Test_binaryEncoding(t *testing.T) {
+
+	params := []psi.CVParam{
+		{Accession: "MS:1000523", Name: "64-bit float"},
+		{Accession: "MS:1002747", Name: "MS-Numpress positive integer compression followed by zlib compression"},
+	}
+
+	precision, compression, numpress := binaryEncoding(params)
+	if precision != "64" || compression != "1" || numpress != "pic" {
+		t.Errorf("binaryEncoding() = %s %s %s, want 64 1 pic", precision, compression, numpress)
+	}
+
+	params = []psi.CVParam{
+		{Accession: "MS:1000521", Name: "32-bit float"},
+		{Accession: "MS:1002314", Name: "MS-Numpress short logged float compression"},
+		{Accession: "MS:1000574", Name: "zlib compression"},
+	}
+
+	precision, compression, numpress = binaryEncoding(params)
+	if precision != "32" || compression != "1" || numpress != "slof" {
+		t.Errorf("binaryEncoding() = %s %s %s, want 32 1 slof", precision, compression, numpress)
+	}
+}
+
+func Test_readEncodedZstd(t *testing.T) {
+
+	values := []float64{445.12003, 446.12344, 1000.5}
+
+	var raw bytes.Buffer
+	for _, i := range values {
+		binary.Write(&raw, binary.LittleEndian, math.Float64bits(i))
+	}
+
+	enc, _ := zstd.NewWriter(nil)
+	compressed := enc.EncodeAll(raw.Bytes(), nil)
+
+	got := readEncoded([]byte(base64.StdEncoding.EncodeToString(compressed)), "64", "zstd", "")
+	sameValues(t, "zstd", got, values, 0)
+}
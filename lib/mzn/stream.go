@@ -0,0 +1,519 @@
+package mzn
+
+import (
+	"bufio"
+	"container/list"
+	"encoding/xml"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"philosopher/lib/fin"
+	"philosopher/lib/msg"
+	"philosopher/lib/psi"
+
+	"github.com/rogpeppe/go-charset/charset"
+	"github.com/sirupsen/logrus"
+)
+
+// maxCachedSpectra is the number of decoded spectra kept in memory by the streaming reader
+const maxCachedSpectra = 2048
+
+// tailSize is how much of the end of the file is read to find the index offset
+const tailSize = 8192
+
+var indexListOffsetRegex = regexp.MustCompile(`<indexListOffset>\s*(\d+)\s*</indexListOffset>`)
+
+// stream holds the open mzML file and the byte offset of each spectrum, or the
+// open Thermo RAW file. The decoded spectra are cached, the least recently used
+// spectrum is evicted when the cache is full
+type stream struct {
+	file    *os.File
+	size    int64
+	offsets []int64
+	raw     *fin.RawData
+	cache   map[int]*list.Element
+	lru     *list.List
+}
+
+// cachedSpectrum is a decoded spectrum and its position, kept on the cache list
+type cachedSpectrum struct {
+	index    int
+	spectrum Spectrum
+}
+
+// newStream creates the stream for an open mzML file, or for a Thermo RAW file
+func newStream(file *os.File, size int64, raw *fin.RawData) *stream {
+	return &stream{
+		file:  file,
+		size:  size,
+		raw:   raw,
+		cache: make(map[int]*list.Element),
+		lru:   list.New(),
+	}
+}
+
+// Index reads the spectrum headers of an mzML file without loading the binary
+// arrays. The headers are read at the offsets from the indexList, the file is
+// only read sequentially when the index is missing or does not match the spectra.
+// The spectra are loaded and decoded on demand by Load. Close must be called when done
+func (p *MsData) Index(f string) {
+
+	file, e := os.Open(f)
+	if e != nil {
+		msg.ReadFile(e, "fatal")
+	}
+
+	info, e := file.Stat()
+	if e != nil {
+		msg.ReadFile(e, "fatal")
+	}
+
+	s := newStream(file, info.Size(), nil)
+
+	s.readSoftware()
+
+	var spectra Spectra
+	var ok bool
+
+	if offsets := s.readIndexList(); len(offsets) > 0 {
+		spectra, ok = s.readIndexedHeaders(offsets)
+		if !ok {
+			logrus.Warn("the index of ", f, " does not match the spectra positions, reading the file sequentially")
+		}
+	}
+
+	if !ok {
+		spectra = s.readHeaders()
+	}
+
+	if len(spectra) == 0 {
+		msg.NoSpectraFound(errors.New(""), "fatal")
+	}
+
+	p.FileName = f
+	p.Spectra = spectra
+	p.stream = s
+	p.indexSpectra()
+}
+
+// Close releases the file opened by Index or IndexRaw
+func (p *MsData) Close() {
+
+	if p.stream != nil {
+		if p.stream.raw != nil {
+			p.stream.raw.Close()
+		} else {
+			p.stream.file.Close()
+		}
+		p.stream = nil
+	}
+
+}
+
+// Load returns the spectrum at the given position with the binary arrays decoded
+func (p *MsData) Load(i int) Spectrum {
+
+	if p.stream == nil {
+		p.Spectra[i].Decode()
+		return p.Spectra[i]
+	}
+
+	if spec, ok := p.stream.cached(i); ok {
+		return spec
+	}
+
+	var spec Spectrum
+	if p.stream.raw != nil {
+		spec = p.stream.readRaw(p.Spectra[i])
+	} else {
+		spec = p.stream.read(i)
+		spec.Decode()
+	}
+
+	p.stream.store(i, spec)
+
+	return spec
+}
+
+// ByScan returns the position of the spectrum with the given scan number
+func (p *MsData) ByScan(scan string) (int, bool) {
+
+	n, e := strconv.Atoi(scan)
+	if e != nil {
+		return 0, false
+	}
+
+	i, ok := p.scans[n]
+
+	return i, ok
+}
+
+// ByID returns the position of the spectrum with the given native ID
+func (p *MsData) ByID(id string) (int, bool) {
+	i, ok := p.ids[id]
+	return i, ok
+}
+
+// MS1 calls f for each MS1 spectrum within the retention time range, in
+// retention time order, loading and decoding the spectra lazily
+func (p *MsData) MS1(minRT, maxRT float64, f func(Spectrum)) {
+
+	// spectra that fall before the window are not needed by the following calls
+	if p.stream != nil {
+		p.stream.evictBefore("1", minRT)
+	}
+
+	start := sort.Search(len(p.ms1), func(i int) bool { return p.Spectra[p.ms1[i]].ScanStartTime >= minRT })
+
+	for _, i := range p.ms1[start:] {
+		if p.Spectra[i].ScanStartTime > maxRT {
+			break
+		}
+		f(p.Load(i))
+	}
+
+}
+
+// MS2 calls f for each MS2 spectrum within the retention time range whose
+// isolation window contains the precursor m/z, in retention time order. It is
+// used to trace the fragments of the DIA precursors
+func (p *MsData) MS2(precursorMz, minRT, maxRT float64, f func(Spectrum)) {
+
+	if p.stream != nil {
+		p.stream.evictBefore("2", minRT)
+	}
+
+	start := sort.Search(len(p.ms2), func(i int) bool { return p.Spectra[p.ms2[i]].ScanStartTime >= minRT })
+
+	for _, i := range p.ms2[start:] {
+
+		if p.Spectra[i].ScanStartTime > maxRT {
+			break
+		}
+
+		precursor := p.Spectra[i].Precursor
+		if precursorMz < precursor.TargetIon-precursor.IsolationWindowLowerOffset || precursorMz > precursor.TargetIon+precursor.IsolationWindowUpperOffset {
+			continue
+		}
+
+		f(p.Load(i))
+	}
+
+}
+
+// indexSpectra maps the scan numbers and native IDs to the spectra positions
+// and sorts the MS1 and MS2 spectra by retention time
+func (p *MsData) indexSpectra() {
+
+	p.scans = make(map[int]int)
+	p.ids = make(map[string]int)
+	p.ms1 = nil
+	p.ms2 = nil
+
+	for i := range p.Spectra {
+
+		if n, e := strconv.Atoi(p.Spectra[i].Scan); e == nil {
+			p.scans[n] = i
+		}
+
+		if len(p.Spectra[i].NativeID) > 0 {
+			p.ids[p.Spectra[i].NativeID] = i
+		}
+
+		if p.Spectra[i].Level == "1" {
+			p.ms1 = append(p.ms1, i)
+		} else if p.Spectra[i].Level == "2" {
+			p.ms2 = append(p.ms2, i)
+		}
+	}
+
+	sort.SliceStable(p.ms1, func(a, b int) bool {
+		return p.Spectra[p.ms1[a]].ScanStartTime < p.Spectra[p.ms1[b]].ScanStartTime
+	})
+
+	sort.SliceStable(p.ms2, func(a, b int) bool {
+		return p.Spectra[p.ms2[a]].ScanStartTime < p.Spectra[p.ms2[b]].ScanStartTime
+	})
+
+}
+
+// cached returns a decoded spectrum from the cache and marks it as recently used
+func (s *stream) cached(i int) (Spectrum, bool) {
+
+	e, ok := s.cache[i]
+	if !ok {
+		return Spectrum{}, false
+	}
+
+	s.lru.MoveToFront(e)
+
+	return e.Value.(*cachedSpectrum).spectrum, true
+}
+
+// store adds a decoded spectrum to the cache, evicting the least recently used one when full
+func (s *stream) store(i int, spec Spectrum) {
+
+	if s.lru.Len() >= maxCachedSpectra {
+		s.evict(s.lru.Back().Value.(*cachedSpectrum).index)
+	}
+
+	s.cache[i] = s.lru.PushFront(&cachedSpectrum{index: i, spectrum: spec})
+}
+
+// evict removes a spectrum from the cache
+func (s *stream) evict(i int) {
+
+	if e, ok := s.cache[i]; ok {
+		s.lru.Remove(e)
+		delete(s.cache, i)
+	}
+
+}
+
+// evictBefore removes the cached spectra of the given level that elute before the retention time
+func (s *stream) evictBefore(level string, rt float64) {
+
+	for k, v := range s.cache {
+		spec := v.Value.(*cachedSpectrum).spectrum
+		if spec.Level == level && spec.ScanStartTime < rt {
+			s.evict(k)
+		}
+	}
+
+}
+
+// readSoftware checks the software list at the beginning of the file, the reading
+// stops at the spectrum list
+func (s *stream) readSoftware() {
+
+	decoder := xml.NewDecoder(bufio.NewReader(io.NewSectionReader(s.file, 0, s.size)))
+	decoder.CharsetReader = charset.NewReader
+
+	for {
+		t, e := decoder.Token()
+		if e != nil {
+			return
+		}
+
+		se, ok := t.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch se.Name.Local {
+		case "softwareList":
+			var sl psi.SoftwareList
+			if e := decoder.DecodeElement(&sl, &se); e != nil {
+				msg.DecodeMsgPck(e, "fatal")
+			}
+			checkSoftware(sl)
+			return
+		case "run", "spectrumList":
+			return
+		}
+	}
+
+}
+
+// readIndexedHeaders seeks to each indexed spectrum and reads its header. It returns
+// false when an offset does not point to the spectrum referenced by the index
+func (s *stream) readIndexedHeaders(offsets []psi.Offset) (Spectra, bool) {
+
+	var spectra Spectra
+
+	for _, i := range offsets {
+
+		if i.Value <= 0 || i.Value >= s.size {
+			return nil, false
+		}
+
+		decoder := xml.NewDecoder(bufio.NewReader(io.NewSectionReader(s.file, i.Value, s.size-i.Value)))
+		decoder.CharsetReader = charset.NewReader
+
+		t, e := decoder.Token()
+		if e != nil {
+			return nil, false
+		}
+
+		se, ok := t.(xml.StartElement)
+		if !ok || se.Name.Local != "spectrum" {
+			return nil, false
+		}
+
+		mzSpec := spectrumHeader(decoder, se, false)
+		if mzSpec.ID != i.IDRef {
+			return nil, false
+		}
+
+		spectra = append(spectra, processSpectrum(mzSpec))
+		s.offsets = append(s.offsets, i.Value)
+	}
+
+	return spectra, true
+}
+
+// readHeaders reads the spectrum headers sequentially, for files without a valid index
+func (s *stream) readHeaders() Spectra {
+
+	s.offsets = nil
+
+	decoder := xml.NewDecoder(bufio.NewReaderSize(io.NewSectionReader(s.file, 0, s.size), 1<<20))
+	decoder.CharsetReader = charset.NewReader
+
+	var spectra Spectra
+
+	for {
+
+		// the decoder stops at the beginning of the next token
+		offset := decoder.InputOffset()
+
+		t, e := decoder.Token()
+		if e == io.EOF {
+			break
+		} else if e != nil {
+			msg.DecodeMsgPck(e, "fatal")
+		}
+
+		se, ok := t.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		if se.Name.Local == "spectrum" {
+			spectra = append(spectra, processSpectrum(spectrumHeader(decoder, se, true)))
+			s.offsets = append(s.offsets, offset)
+		} else if se.Name.Local == "chromatogramList" || se.Name.Local == "indexList" {
+			break
+		}
+	}
+
+	return spectra
+}
+
+// spectrumHeader decodes the spectrum attributes, cvParams, scans and precursors, the
+// binary data arrays are not decoded. The decoder is left after the spectrum when
+// skipping the arrays, otherwise it stops at the binary data array list
+func spectrumHeader(decoder *xml.Decoder, se xml.StartElement, skip bool) psi.Spectrum {
+
+	var mzSpec psi.Spectrum
+
+	for _, i := range se.Attr {
+		switch i.Name.Local {
+		case "id":
+			mzSpec.ID = i.Value
+		case "index":
+			mzSpec.Index = i.Value
+		case "defaultArrayLength":
+			mzSpec.DefaultArrayLength, _ = strconv.ParseFloat(i.Value, 64)
+		}
+	}
+
+	for {
+		t, e := decoder.Token()
+		if e != nil {
+			msg.DecodeMsgPck(e, "fatal")
+		}
+
+		switch v := t.(type) {
+		case xml.StartElement:
+
+			switch v.Name.Local {
+			case "cvParam":
+				var param psi.CVParam
+				if e := decoder.DecodeElement(&param, &v); e != nil {
+					msg.DecodeMsgPck(e, "fatal")
+				}
+				mzSpec.CVParam = append(mzSpec.CVParam, param)
+			case "scanList":
+				if e := decoder.DecodeElement(&mzSpec.ScanList, &v); e != nil {
+					msg.DecodeMsgPck(e, "fatal")
+				}
+			case "precursorList":
+				if e := decoder.DecodeElement(&mzSpec.PrecursorList, &v); e != nil {
+					msg.DecodeMsgPck(e, "fatal")
+				}
+			case "binaryDataArrayList":
+				if !skip {
+					return mzSpec
+				}
+				decoder.Skip()
+			default:
+				decoder.Skip()
+			}
+
+		case xml.EndElement:
+			if v.Name.Local == "spectrum" {
+				return mzSpec
+			}
+		}
+	}
+
+}
+
+// readIndexList returns the spectrum offsets from the indexList at the end of an
+// indexed mzML file, in the index order, or nil if the file is not indexed
+func (s *stream) readIndexList() []psi.Offset {
+
+	n := int64(math.Min(float64(s.size), tailSize))
+	tail := make([]byte, n)
+	if _, e := s.file.ReadAt(tail, s.size-n); e != nil && e != io.EOF {
+		return nil
+	}
+
+	match := indexListOffsetRegex.FindSubmatch(tail)
+	if match == nil {
+		return nil
+	}
+
+	position, e := strconv.ParseInt(string(match[1]), 10, 64)
+	if e != nil || position <= 0 || position >= s.size {
+		return nil
+	}
+
+	var il psi.IndexList
+	decoder := xml.NewDecoder(io.NewSectionReader(s.file, position, s.size-position))
+	decoder.CharsetReader = charset.NewReader
+
+	if e := decoder.Decode(&il); e != nil {
+		logrus.Trace("Cannot decode the mzML index:", e)
+		return nil
+	}
+
+	for _, i := range il.Index {
+		if i.Name == "spectrum" {
+			return i.Offset
+		}
+	}
+
+	return nil
+}
+
+// read parses the spectrum starting at the offset of the given position
+func (s *stream) read(i int) Spectrum {
+
+	decoder := xml.NewDecoder(bufio.NewReader(io.NewSectionReader(s.file, s.offsets[i], s.size-s.offsets[i])))
+	decoder.CharsetReader = charset.NewReader
+
+	for {
+		t, e := decoder.Token()
+		if e != nil {
+			msg.DecodeMsgPck(e, "fatal")
+		}
+
+		if se, ok := t.(xml.StartElement); ok && se.Name.Local == "spectrum" {
+
+			var mzSpec psi.Spectrum
+			if e := decoder.DecodeElement(&mzSpec, &se); e != nil {
+				msg.DecodeMsgPck(e, "fatal")
+			}
+
+			return processSpectrum(mzSpec)
+		}
+	}
+
+}
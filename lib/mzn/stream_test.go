@@ -0,0 +1,181 @@
+package mzn_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"philosopher/lib/mzn"
+)
+
+// encodeArray compresses and encodes a 64-bit float array as in mzML
+func encodeArray(values []float64) string {
+
+	var raw bytes.Buffer
+	for _, i := range values {
+		binary.Write(&raw, binary.LittleEndian, math.Float64bits(i))
+	}
+
+	var compressed bytes.Buffer
+	w := zlib.NewWriter(&compressed)
+	w.Write(raw.Bytes())
+	w.Close()
+
+	return base64.StdEncoding.EncodeToString(compressed.Bytes())
+}
+
+func writeSpectrum(level int, index int, rt float64, mz, intensity []float64) string {
+
+	var precursor string
+	if level == 2 {
+		precursor = fmt.Sprintf(`<precursorList count="1"><precursor spectrumRef="controllerType=0 controllerNumber=1 scan=%d"><isolationWindow><cvParam cvRef="MS" accession="MS:1000827" name="isolation window target m/z" value="500.25"/></isolationWindow><selectedIonList count="1"><selectedIon><cvParam cvRef="MS" accession="MS:1000744" name="selected ion m/z" value="500.25"/><cvParam cvRef="MS" accession="MS:1000041" name="charge state" value="2"/></selectedIon></selectedIonList></precursor></precursorList>`, index)
+	}
+
+	return fmt.Sprintf(`<spectrum index="%d" id="controllerType=0 controllerNumber=1 scan=%d" defaultArrayLength="%d">
+<cvParam cvRef="MS" accession="MS:1000511" name="ms level" value="%d"/>
+<scanList count="1"><scan><cvParam cvRef="MS" accession="MS:1000016" name="scan start time" value="%f" unitName="minute"/></scan></scanList>
+%s<binaryDataArrayList count="2">
+<binaryDataArray><cvParam cvRef="MS" accession="MS:1000523" name="64-bit float"/><cvParam cvRef="MS" accession="MS:1000574" name="zlib compression"/><cvParam cvRef="MS" accession="MS:1000514" name="m/z array"/><binary>%s</binary></binaryDataArray>
+<binaryDataArray><cvParam cvRef="MS" accession="MS:1000523" name="64-bit float"/><cvParam cvRef="MS" accession="MS:1000574" name="zlib compression"/><cvParam cvRef="MS" accession="MS:1000515" name="intensity array"/><binary>%s</binary></binaryDataArray>
+</binaryDataArrayList>
+</spectrum>
+`, index, index+1, len(mz), level, rt, precursor, encodeArray(mz), encodeArray(intensity))
+}
+
+// writeIndexedMzML writes a small indexed mzML file, the index offsets are moved by shift bytes
+func writeIndexedMzML(dir string, shift int) string {
+
+	var body strings.Builder
+	body.WriteString(`<?xml version="1.0" encoding="utf-8"?>
+<indexedmzML xmlns="http://psi.hupo.org/ms/mzml">
+<mzML id="test" version="1.1.0">
+<softwareList count="1"><software id="pwiz" version="3.0.21193"/></softwareList>
+<run id="test"><spectrumList count="4">
+`)
+
+	var spectra = []string{
+		writeSpectrum(1, 0, 1.0, []float64{400.1, 500.25, 600.3}, []float64{10, 1000, 20}),
+		writeSpectrum(2, 1, 1.1, []float64{126.1277, 127.1248}, []float64{50, 60}),
+		writeSpectrum(1, 2, 1.5, []float64{500.25}, []float64{2000}),
+		writeSpectrum(1, 3, 3.0, []float64{500.25}, []float64{3000}),
+	}
+
+	var offsets []int
+	for _, i := range spectra {
+		offsets = append(offsets, body.Len())
+		body.WriteString(i)
+	}
+
+	body.WriteString("</spectrumList></run>\n</mzML>\n")
+
+	indexOffset := body.Len()
+	body.WriteString(`<indexList count="1"><index name="spectrum">`)
+	for i, j := range offsets {
+		body.WriteString(fmt.Sprintf(`<offset idRef="controllerType=0 controllerNumber=1 scan=%d">%d</offset>`, i+1, j+shift))
+	}
+	body.WriteString(fmt.Sprintf("</index></indexList>\n<indexListOffset>%d</indexListOffset>\n</indexedmzML>\n", indexOffset))
+
+	fileName := filepath.Join(dir, "test.mzML")
+	ioutil.WriteFile(fileName, []byte(body.String()), 0644)
+
+	return fileName
+}
+
+func TestMsData_Index(t *testing.T) {
+
+	dir, _ := ioutil.TempDir("", "mzn")
+	defer os.RemoveAll(dir)
+
+	fileName := writeIndexedMzML(dir, 0)
+
+	var msd mzn.MsData
+	msd.Index(fileName)
+	defer msd.Close()
+
+	if len(msd.Spectra) != 4 {
+		t.Fatalf("Spectra number is incorrect, got %d, want %d", len(msd.Spectra), 4)
+	}
+
+	if len(msd.Spectra[0].Mz.Stream) != 0 || len(msd.Spectra[0].Mz.DecodedStream) != 0 {
+		t.Errorf("Spectrum headers should not hold the binary arrays")
+	}
+
+	i, ok := msd.ByScan("00002")
+	if !ok || i != 1 {
+		t.Fatalf("Spectrum scan lookup is incorrect, got %d", i)
+	}
+
+	spec := msd.Load(i)
+	if spec.Level != "2" || spec.Precursor.ParentScan != "1" || spec.Precursor.ChargeState != 2 {
+		t.Errorf("MS2 spectrum header is incorrect, got %+v", spec.Precursor)
+	}
+
+	if len(spec.Intensity.DecodedStream) != 2 || spec.Intensity.DecodedStream[1] != 60 {
+		t.Errorf("MS2 intensities are incorrect, got %v", spec.Intensity.DecodedStream)
+	}
+
+	if i, ok := msd.ByID("controllerType=0 controllerNumber=1 scan=4"); !ok || i != 3 {
+		t.Errorf("Spectrum native ID lookup is incorrect, got %d", i)
+	}
+
+	var intensities []float64
+	msd.MS1(0.9, 2.0, func(s mzn.Spectrum) {
+		for k := range s.Mz.DecodedStream {
+			if s.Mz.DecodedStream[k] == 500.25 {
+				intensities = append(intensities, s.Intensity.DecodedStream[k])
+			}
+		}
+	})
+
+	if len(intensities) != 2 || intensities[0] != 1000 || intensities[1] != 2000 {
+		t.Errorf("MS1 range iteration is incorrect, got %v", intensities)
+	}
+
+	var scans []string
+	msd.MS2(500.25, 0.9, 2.0, func(s mzn.Spectrum) {
+		scans = append(scans, s.Scan)
+	})
+
+	if len(scans) != 1 || scans[0] != "2" {
+		t.Errorf("MS2 isolation window iteration is incorrect, got %v", scans)
+	}
+
+	scans = nil
+	msd.MS2(510, 0.9, 2.0, func(s mzn.Spectrum) {
+		scans = append(scans, s.Scan)
+	})
+
+	if len(scans) != 0 {
+		t.Errorf("MS2 spectra outside the isolation window should be skipped, got %v", scans)
+	}
+}
+
+func TestMsData_Index_BrokenIndex(t *testing.T) {
+
+	dir, _ := ioutil.TempDir("", "mzn")
+	defer os.RemoveAll(dir)
+
+	// the offsets do not point to the spectra, the file is read sequentially
+	fileName := writeIndexedMzML(dir, 7)
+
+	var msd mzn.MsData
+	msd.Index(fileName)
+	defer msd.Close()
+
+	if len(msd.Spectra) != 4 {
+		t.Fatalf("Spectra number is incorrect, got %d, want %d", len(msd.Spectra), 4)
+	}
+
+	spec := msd.Load(3)
+	if spec.NativeID != "controllerType=0 controllerNumber=1 scan=4" || len(spec.Intensity.DecodedStream) != 1 || spec.Intensity.DecodedStream[0] != 3000 {
+		t.Errorf("Spectrum read without the index is incorrect, got %s %v", spec.NativeID, spec.Intensity.DecodedStream)
+	}
+}
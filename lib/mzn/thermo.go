@@ -0,0 +1,116 @@
+package mzn
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"philosopher/lib/fin"
+	"philosopher/lib/msg"
+)
+
+// defaultIsolationOffset is used when the trailer does not report the isolation width
+const defaultIsolationOffset = 0.6
+
+// IndexRaw reads the scan headers of a Thermo RAW file with the native reader,
+// without converting the file to mzML. The centroided peaks are loaded on
+// demand by Load, and Close must be called when done
+func (p *MsData) IndexRaw(f string) {
+
+	var rd fin.RawData
+	rd.ProcessRaw(f)
+
+	var spectra Spectra
+
+	// last scan number of each MS level, used when the trailer has no master scan
+	var lastScan = make(map[int]int)
+
+	for sn := 1; sn <= rd.NScans(); sn++ {
+
+		scan := rd.Scan(sn)
+		trailer := rd.Trailer(sn)
+
+		level := int(scan.MSLevel)
+		if level < 1 {
+			level = 1
+		}
+
+		var spec Spectrum
+
+		spec.Index = strconv.Itoa(sn - 1)
+		spec.Scan = strconv.Itoa(sn)
+		spec.NativeID = fmt.Sprintf("controllerType=0 controllerNumber=1 scan=%d", sn)
+		spec.Level = strconv.Itoa(level)
+		spec.ScanStartTime = scan.Time
+		spec.CompensationVoltage = trailer["FAIMS CV"]
+
+		if level > 1 {
+
+			parent := lastScan[level-1]
+			if master, e := strconv.Atoi(trailer["Master Scan Number"]); e == nil && master > 0 && master < sn {
+				parent = master
+			}
+
+			if parent > 0 {
+				spec.Precursor.ParentScan = strconv.Itoa(parent)
+				spec.Precursor.ParentIndex = strconv.Itoa(parent - 1)
+			}
+
+			// the first reaction is the isolation of the precursor from the parent scan
+			if len(rd.Scanevents[sn-1].Reaction) > 0 {
+				spec.Precursor.TargetIon = rd.Scanevents[sn-1].Reaction[0].Precursormz
+				spec.Precursor.SelectedIon = spec.Precursor.TargetIon
+			}
+
+			if mono, e := strconv.ParseFloat(trailer["Monoisotopic M/Z"], 64); e == nil && mono > 0 {
+				spec.Precursor.SelectedIon = mono
+			}
+
+			if charge, e := strconv.Atoi(trailer["Charge State"]); e == nil {
+				spec.Precursor.ChargeState = charge
+			}
+
+			spec.Precursor.IsolationWindowLowerOffset = defaultIsolationOffset
+			spec.Precursor.IsolationWindowUpperOffset = defaultIsolationOffset
+
+			width, e := strconv.ParseFloat(trailer[fmt.Sprintf("MS%d Isolation Width", level)], 64)
+			if e == nil && width > 0 {
+				spec.Precursor.IsolationWindowLowerOffset = width / 2
+				spec.Precursor.IsolationWindowUpperOffset = width / 2
+			}
+		}
+
+		spec.Mz.Precision = "64"
+		spec.Intensity.Precision = "64"
+
+		lastScan[level] = sn
+		spectra = append(spectra, spec)
+	}
+
+	if len(spectra) == 0 {
+		msg.NoSpectraFound(errors.New(""), "fatal")
+	}
+
+	p.FileName = f
+	p.Spectra = spectra
+	p.stream = newStream(nil, 0, &rd)
+	p.indexSpectra()
+}
+
+// readRaw fills the spectrum header with the centroided peaks of its scan,
+// the low m/z range is kept so the reporter ions are available
+func (s *stream) readRaw(spec Spectrum) Spectrum {
+
+	sn, _ := strconv.Atoi(spec.Scan)
+
+	peaks := s.raw.Scan(sn).Spectrum(true)
+
+	spec.Mz.DecodedStream = make([]float64, len(peaks))
+	spec.Intensity.DecodedStream = make([]float64, len(peaks))
+	for i := range peaks {
+		spec.Mz.DecodedStream[i] = peaks[i].Mz
+		spec.Intensity.DecodedStream[i] = float64(peaks[i].I)
+	}
+
+	return spec
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 
 	"philosopher/lib/dat"
 	"philosopher/lib/msg"
@@ -22,6 +23,8 @@ import (
 	"philosopher/lib/fil"
 	"philosopher/lib/qua"
 	"philosopher/lib/rep"
+	"philosopher/lib/rsc"
+	"philosopher/lib/rta"
 
 	"philosopher/lib/ext/comet"
 	"philosopher/lib/ext/msfragger"
@@ -43,11 +46,14 @@ type Directives struct {
 	PeptideProphet met.PeptideProphet `yaml:"Peptide Validation"`
 	PTMProphet     met.PTMProphet     `yaml:"PTM Localization"`
 	ProteinProphet met.ProteinProphet `yaml:"Protein Inference"`
+	Rescore        met.Rescore        `yaml:"PSM Rescoring"`
 	Filter         met.Filter         `yaml:"FDR Filtering"`
 	Freequant      met.Quantify       `yaml:"Label-Free Quantification"`
 	LabelQuant     met.Quantify       `yaml:"Isobaric Quantification"`
+	DIAQuant       met.Quantify       `yaml:"DIA Quantification"`
 	Report         met.Report         `yaml:"Individual Reports"`
 	BioQuant       met.BioQuant       `yaml:"Bio Cluster Quantification"`
+	Align          met.Align          `yaml:"Retention Time Alignment"`
 	Abacus         met.Abacus         `yaml:"Integrated Reports"`
 	TMTIntegrator  met.TMTIntegrator  `yaml:"Integrated Isobaric Quantification"`
 }
@@ -58,9 +64,12 @@ type Steps struct {
 	PeptideValidation        string `yaml:"Peptide Validation"`
 	PTMLocalization          string `yaml:"PTM Localization"`
 	ProteinInference         string `yaml:"Protein Inference"`
+	PSMRescoring             string `yaml:"PSM Rescoring"`
 	LabelFreeQuantification  string `yaml:"Label-Free Quantification"`
 	IsobaricQuantification   string `yaml:"Isobaric Quantification"`
+	DIAQuantification        string `yaml:"DIA Quantification"`
 	BioClusterQuantification string `yaml:"Bio Cluster Quantification"`
+	RetentionTimeAlignment   string `yaml:"Retention Time Alignment"`
 	FDRFiltering             string `yaml:"FDR Filtering"`
 	IndividualReports        string `yaml:"Individual Reports"`
 	IntegratedReports        string `yaml:"Integrated Reports"`
@@ -568,6 +577,11 @@ func FreeQuant(meta met.Data, p Directives, dir string, data []string) met.Data
 // LabelQuant executes the isobaric-tag quantification method
 func LabelQuant(meta met.Data, p Directives, dir string, data []string) met.Data {
 
+	// the impurity table is shared by all datasets
+	if len(p.LabelQuant.Impurity) > 0 {
+		p.LabelQuant.Impurity, _ = filepath.Abs(p.LabelQuant.Impurity)
+	}
+
 	for _, i := range data {
 
 		// getting inside  each dataset folder again
@@ -585,17 +599,64 @@ func LabelQuant(meta met.Data, p Directives, dir string, data []string) met.Data
 		// 	return meta
 		// }
 
-		logrus.Info("Executing isobaric quantification on ", i)
-
 		meta.Quantify = p.LabelQuant
 		meta.Quantify.Dir = dsAbs
 		meta.Quantify.Format = "mzML"
-		meta.Quantify.Annot = annotation[0]
-		meta.Quantify.Brand = p.LabelQuant.Brand
 		meta.Quantify.Pex = fmt.Sprintf("%s%sinteract.pep.xml", dsAbs, string(filepath.Separator))
 		meta.Quantify.Tag = "rev_"
 
-		meta.Quantify = qua.RunIsobaricLabelQuantification(meta.Quantify, meta.Filter.Mapmods)
+		if meta.Quantify.MS1 {
+
+			logrus.Info("Executing MS1 label quantification on ", i)
+
+			meta.Quantify = qua.RunMS1LabelQuantification(meta.Quantify)
+
+		} else {
+
+			logrus.Info("Executing isobaric quantification on ", i)
+
+			meta.Quantify.Annot = annotation[0]
+			meta.Quantify.Brand = p.LabelQuant.Brand
+
+			meta.Quantify = qua.RunIsobaricLabelQuantification(meta.Quantify, meta.Filter.Mapmods)
+		}
+
+		meta.Serialize()
+
+		// return to the top level directory
+		os.Chdir(dir)
+	}
+
+	return meta
+}
+
+// DIAQuant executes the DIA fragment-level quantification method
+func DIAQuant(meta met.Data, p Directives, dir string, data []string) met.Data {
+
+	// the spectral library is shared by all datasets
+	if len(p.DIAQuant.Library) > 0 {
+		p.DIAQuant.Library, _ = filepath.Abs(p.DIAQuant.Library)
+	}
+
+	for _, i := range data {
+
+		// getting inside  each dataset folder again
+		dsAbs, _ := filepath.Abs(i)
+		os.Chdir(dsAbs)
+
+		// reload the meta data
+		meta.Restore(sys.Meta())
+
+		logrus.Info("Executing DIA quantification on ", i)
+
+		meta.Quantify = p.DIAQuant
+		meta.Quantify.Dir = dsAbs
+		meta.Quantify.Format = "mzML"
+
+		//forcing the larger time window to be the same as the smaller one
+		meta.Quantify.RTWin = meta.Quantify.PTWin
+
+		qua.RunDIAQuantification(meta.Quantify)
 
 		meta.Serialize()
 
@@ -636,6 +697,77 @@ func BioQuant(meta met.Data, p Directives, dir string, data []string) met.Data {
 	return meta
 }
 
+// Align executes the retention time alignment between runs
+func Align(meta met.Data, p Directives, dir string, data []string) met.Data {
+
+	for _, i := range data {
+
+		// getting inside  each dataset folder again
+		dsAbs, _ := filepath.Abs(i)
+		os.Chdir(dsAbs)
+
+		// reload the meta data
+		meta.Restore(sys.Meta())
+
+		logrus.Info("Executing retention time alignment on ", i)
+
+		meta.Align = p.Align
+		meta.Align.Method = strings.ToLower(meta.Align.Method)
+
+		meta = rta.Run(meta)
+
+		meta.Serialize()
+
+		// return to the top level directory
+		os.Chdir(dir)
+	}
+
+	return meta
+}
+
+// Rescore executes the semi-supervised rescoring of the PSMs
+func Rescore(meta met.Data, p Directives, dir string, data []string) met.Data {
+
+	for _, i := range data {
+
+		// getting inside  each dataset folder again
+		dsAbs, _ := filepath.Abs(i)
+		os.Chdir(dsAbs)
+
+		// reload the meta data
+		meta.Restore(sys.Meta())
+
+		logrus.Info("Executing rescore on ", i)
+
+		meta.Rescore = p.Rescore
+		meta.Rescore.Tag = p.DatabaseSearch.DecoyTag
+
+		if len(p.Rescore.Pex) == 0 {
+			meta.Rescore.Pex = "interact.pep.xml"
+			if p.Steps.PTMLocalization == "yes" {
+				meta.Rescore.Pex = "interact.mod.pep.xml"
+			}
+
+			// without the validation, the tsv or pin search results are read from the dataset folder
+			format := strings.ToLower(p.DatabaseSearch.MSFragger.OutputFormat)
+			if p.Steps.PeptideValidation != "yes" && (strings.Contains(format, "tsv") || strings.Contains(format, "pin")) {
+				meta.Rescore.Pex = "."
+			}
+		}
+
+		os.RemoveAll(sys.PepxmlBin())
+
+		meta = rsc.Run(meta)
+
+		meta.Serialize()
+
+		// return to the top level directory
+		os.Chdir(dir)
+	}
+
+	return meta
+}
+
 // Filter executes the Filter, Quantify and Report commands in tandem
 func Filter(meta met.Data, p Directives, dir string, data []string) met.Data {
 
@@ -663,6 +795,12 @@ func Filter(meta met.Data, p Directives, dir string, data []string) met.Data {
 				if p.Steps.PTMLocalization == "yes" {
 					meta.Filter.Pex = "interact.mod.pep.xml"
 				}
+
+				// without the validation, the tsv or pin search results are read from the dataset folder
+				format := strings.ToLower(p.DatabaseSearch.MSFragger.OutputFormat)
+				if p.Steps.PeptideValidation != "yes" && (strings.Contains(format, "tsv") || strings.Contains(format, "pin")) {
+					meta.Filter.Pex = "."
+				}
 			} else {
 				meta.Filter.Pex = p.Filter.Pex
 			}
@@ -686,6 +824,11 @@ func Filter(meta met.Data, p Directives, dir string, data []string) met.Data {
 				meta.Filter.RazorBin = p.Filter.RazorBin
 			}
 
+			// the rescored identifications replace the search results
+			if p.Steps.PSMRescoring == "yes" {
+				meta.Filter.Rescored = true
+			}
+
 			meta := fil.Run(meta)
 
 			meta.Serialize()
@@ -717,6 +860,10 @@ func Report(meta met.Data, p Directives, dir string, data []string) met.Data {
 
 			meta.Report = p.Report
 
+			if len(meta.Filter.Pox) > 0 || meta.Filter.Inference {
+				qua.RunSpectralAbundances()
+			}
+
 			rep.Run(meta)
 			meta.Serialize()
 		}
@@ -16,9 +16,9 @@ type MzIdentML struct {
 	XsiSchemaLocation          string                     `xml:"xsi:schemaLocation,attr"`
 	CvList                     CvList                     `xml:"cvList"`
 	AnalysisSoftwareList       AnalysisSoftwareList       `xml:"AnalysisSoftwareList"`
-	Provider                   Provider                   `xml:"Provider"`
-	AuditCollection            AuditCollection            `xml:"AuditCollection"`
-	AnalysisSampleCollection   AnalysisSampleCollection   `xml:"AnalysisSampleCollection"`
+	Provider                   *Provider                  `xml:"Provider,omitempty"`
+	AuditCollection            *AuditCollection           `xml:"AuditCollection,omitempty"`
+	AnalysisSampleCollection   *AnalysisSampleCollection  `xml:"AnalysisSampleCollection,omitempty"`
 	SequenceCollection         SequenceCollection         `xml:"SequenceCollection"`
 	AnalysisCollection         AnalysisCollection         `xml:"AnalysisCollection"`
 	AnalysisProtocolCollection AnalysisProtocolCollection `xml:"AnalysisProtocolCollection"`
@@ -34,14 +34,14 @@ type AnalysisSoftwareList struct {
 
 // AnalysisSoftware is the software used for performing the analysis
 type AnalysisSoftware struct {
-	XMLName        xml.Name       `xml:"AnalysisSoftware"`
-	ID             string         `xml:"id,attr,omitempty"`
-	Name           string         `xml:"name,attr,omitempty"`
-	URI            string         `xml:"uri,attr,omitempty"`
-	Version        string         `xml:"version,attr,omitempty"`
-	ContactRole    ContactRole    `xml:"ContactRole"`
-	SoftwareName   SoftwareName   `xml:"SoftwareName"`
-	Customizations Customizations `xml:"Customizations"`
+	XMLName        xml.Name        `xml:"AnalysisSoftware"`
+	ID             string          `xml:"id,attr,omitempty"`
+	Name           string          `xml:"name,attr,omitempty"`
+	URI            string          `xml:"uri,attr,omitempty"`
+	Version        string          `xml:"version,attr,omitempty"`
+	ContactRole    *ContactRole    `xml:"ContactRole,omitempty"`
+	SoftwareName   SoftwareName    `xml:"SoftwareName"`
+	Customizations *Customizations `xml:"Customizations,omitempty"`
 }
 
 // ContactRole is the Contact that provided the document instance
@@ -60,9 +60,9 @@ type Role struct {
 // SoftwareName is the name of the analysis software package, sourced from a CV
 // if available
 type SoftwareName struct {
-	XMLName   xml.Name  `xml:"SoftwareName"`
-	CVParam   CVParam   `xml:"cvParam"`
-	UserParam UserParam `xml:"userParam"`
+	XMLName   xml.Name   `xml:"SoftwareName"`
+	CVParam   *CVParam   `xml:"cvParam,omitempty"`
+	UserParam *UserParam `xml:"userParam,omitempty"`
 }
 
 // Customizations is Any customizations to the software, such as alternative
@@ -75,11 +75,11 @@ type Customizations struct {
 // Provider is the Provider of the mzIdentML record in terms of the contact and
 // software
 type Provider struct {
-	XMLName             xml.Name    `xml:"Provider"`
-	AnalysisSoftwareRef string      `xml:"analysisSoftware_ref,attr,omitempty"`
-	ID                  string      `xml:"id,attr,omitempty"`
-	Name                string      `xml:"name,attr,omitempty"`
-	ContactRole         ContactRole `xml:"ContactRole"`
+	XMLName             xml.Name     `xml:"Provider"`
+	AnalysisSoftwareRef string       `xml:"analysisSoftware_ref,attr,omitempty"`
+	ID                  string       `xml:"id,attr,omitempty"`
+	Name                string       `xml:"name,attr,omitempty"`
+	ContactRole         *ContactRole `xml:"ContactRole,omitempty"`
 }
 
 // AuditCollection is the complete set of Contacts (people and organisations)
@@ -120,7 +120,7 @@ type Organization struct {
 	Name      string      `xml:"name,attr,omitempty"`
 	CVParam   []CVParam   `xml:"cvParam"`
 	UserParam []UserParam `xml:"userParam"`
-	Parent    Parent      `xml:"Parent"`
+	Parent    *Parent     `xml:"Parent,omitempty"`
 }
 
 // Parent is the containing organization (the university or business which a lab
@@ -166,7 +166,7 @@ type DBSequence struct {
 	Length            string      `xml:"length,attr,omitempty"`
 	Name              string      `xml:"name,attr,omitempty"`
 	SearchDatabaseRef string      `xml:"searchDatabase_ref,attr,omitempty"`
-	Seq               Seq         `xml:"Seq"`
+	Seq               *Seq        `xml:"Seq,omitempty"`
 	CVParam           []CVParam   `xml:"cvParam"`
 	UserParam         []UserParam `xml:"userParam"`
 }
@@ -244,7 +244,7 @@ type PeptideEvidence struct {
 	PeptideRef          string      `xml:"peptide_ref,attr,omitempty"`
 	Post                string      `xml:"post,attr,omitempty"`
 	Pre                 string      `xml:"pre,attr,omitempty"`
-	Start               string      `xml:"start,attr,omitempty"`
+	Start               int         `xml:"start,attr,omitempty"`
 	TranslationTableRef string      `xml:"translationTable_ref,attr,omitempty"`
 	CVParam             []CVParam   `xml:"cvParam"`
 	UserParam           []UserParam `xml:"userParam"`
@@ -257,7 +257,7 @@ type PeptideEvidence struct {
 type AnalysisCollection struct {
 	XMLName                xml.Name                 `xml:"AnalysisCollection"`
 	SpectrumIdentification []SpectrumIdentification `xml:"SpectrumIdentification"`
-	ProteinDetection       ProteinDetection         `xml:"ProteinDetection"`
+	ProteinDetection       *ProteinDetection        `xml:"ProteinDetection,omitempty"`
 }
 
 // SpectrumIdentification is an analysis which tries to identify peptides in
@@ -309,44 +309,44 @@ type InputSpectrumIdentifications struct {
 type AnalysisProtocolCollection struct {
 	XMLName                        xml.Name                         `xml:"AnalysisProtocolCollection"`
 	SpectrumIdentificationProtocol []SpectrumIdentificationProtocol `xml:"SpectrumIdentificationProtocol"`
-	ProteinDetectionProtocol       ProteinDetectionProtocol         `xml:"ProteinDetectionProtocol"`
+	ProteinDetectionProtocol       *ProteinDetectionProtocol        `xml:"ProteinDetectionProtocol,omitempty"`
 }
 
 // SpectrumIdentificationProtocol is the parameters and settings of a
 // SpectrumIdentification analysis
 type SpectrumIdentificationProtocol struct {
-	XMLName                xml.Name               `xml:"SpectrumIdentificationProtocol"`
-	AnalysisSoftwareRef    string                 `xml:"analysisSoftware_ref,attr,omitempty"`
-	ID                     string                 `xml:"id,attr,omitempty"`
-	Name                   string                 `xml:"name,attr,omitempty"`
-	SearchType             SearchType             `xml:"SearchType"`
-	AdditionalSearchParams AdditionalSearchParams `xml:"AdditionalSearchParams"`
-	ModificationParams     ModificationParams     `xml:"ModificationParams"`
-	Enzymes                Enzymes                `xml:"Enzymes"`
-	MassTable              []MassTable            `xml:"MassTable"`
-	FragmentTolerance      FragmentTolerance      `xml:"FragmentTolerance"`
-	ParentTolerance        ParentTolerance        `xml:"ParentTolerance"`
-	Threshold              Threshold              `xml:"Threshold"`
-	DatabaseFilters        DatabaseFilters        `xml:"DatabaseFilters"`
-	DatabaseTranslation    DatabaseTranslation    `xml:"DatabaseTranslation"`
+	XMLName                xml.Name                `xml:"SpectrumIdentificationProtocol"`
+	AnalysisSoftwareRef    string                  `xml:"analysisSoftware_ref,attr,omitempty"`
+	ID                     string                  `xml:"id,attr,omitempty"`
+	Name                   string                  `xml:"name,attr,omitempty"`
+	SearchType             SearchType              `xml:"SearchType"`
+	AdditionalSearchParams *AdditionalSearchParams `xml:"AdditionalSearchParams,omitempty"`
+	ModificationParams     *ModificationParams     `xml:"ModificationParams,omitempty"`
+	Enzymes                *Enzymes                `xml:"Enzymes,omitempty"`
+	MassTable              []MassTable             `xml:"MassTable"`
+	FragmentTolerance      *FragmentTolerance      `xml:"FragmentTolerance,omitempty"`
+	ParentTolerance        *ParentTolerance        `xml:"ParentTolerance,omitempty"`
+	Threshold              Threshold               `xml:"Threshold"`
+	DatabaseFilters        *DatabaseFilters        `xml:"DatabaseFilters,omitempty"`
+	DatabaseTranslation    *DatabaseTranslation    `xml:"DatabaseTranslation,omitempty"`
 }
 
 // ProteinDetectionProtocol is the parameters and settings of a
 // ProteinDetection process
 type ProteinDetectionProtocol struct {
-	XMLName             xml.Name       `xml:"ProteinDetectionProtocol"`
-	AnalysisSoftwareRef string         `xml:"analysisSoftware_ref,attr,omitempty"`
-	ID                  string         `xml:"id,attr,omitempty"`
-	Name                string         `xml:"name,attr,omitempty"`
-	AnalysisParams      AnalysisParams `xml:"AnalysisParams"`
-	Threshold           Threshold      `xml:"Threshold"`
+	XMLName             xml.Name        `xml:"ProteinDetectionProtocol"`
+	AnalysisSoftwareRef string          `xml:"analysisSoftware_ref,attr,omitempty"`
+	ID                  string          `xml:"id,attr,omitempty"`
+	Name                string          `xml:"name,attr,omitempty"`
+	AnalysisParams      *AnalysisParams `xml:"AnalysisParams,omitempty"`
+	Threshold           Threshold       `xml:"Threshold"`
 }
 
 // AnalysisParams is the parameters and settings for the protein detection given
 // as CV terms
 type AnalysisParams struct {
 	XMLName   xml.Name    `xml:"AnalysisParams"`
-	CVParam   CVParam     `xml:"cvParam"`
+	CVParam   []CVParam   `xml:"cvParam"`
 	UserParam []UserParam `xml:"userParam"`
 }
 
@@ -406,16 +406,16 @@ type Enzymes struct {
 // giving a regular expression or a CV term if a "standard" enzyme cleavage has
 // been performed
 type Enzyme struct {
-	XMLName         xml.Name   `xml:"Enzyme"`
-	CTermGain       string     `xml:"cTermGain,attr,omitempty"`
-	ID              string     `xml:"id,attr,omitempty"`
-	MinDistance     int        `xml:"minDistance,attr,omitempty"`
-	MissedCleavages int        `xml:"missedCleavages,attr,omitempty"`
-	NTermGain       string     `xml:"nTermGain,attr,omitempty"`
-	Name            string     `xml:"name,attr,omitempty"`
-	SemiSpecific    bool       `xml:"semiSpecific,attr,omitempty"`
-	SiteRegexp      SiteRegexp `xml:"SiteRegexp"`
-	EnzymeName      EnzymeName `xml:"EnzymeName"`
+	XMLName         xml.Name    `xml:"Enzyme"`
+	CTermGain       string      `xml:"cTermGain,attr,omitempty"`
+	ID              string      `xml:"id,attr,omitempty"`
+	MinDistance     int         `xml:"minDistance,attr,omitempty"`
+	MissedCleavages int         `xml:"missedCleavages,attr,omitempty"`
+	NTermGain       string      `xml:"nTermGain,attr,omitempty"`
+	Name            string      `xml:"name,attr,omitempty"`
+	SemiSpecific    bool        `xml:"semiSpecific,attr,omitempty"`
+	SiteRegexp      *SiteRegexp `xml:"SiteRegexp,omitempty"`
+	EnzymeName      *EnzymeName `xml:"EnzymeName,omitempty"`
 }
 
 // SiteRegexp is the Regular expression for specifying the enzyme cleavage site
@@ -435,7 +435,7 @@ type EnzymeName struct {
 type MassTable struct {
 	XMLName          xml.Name           `xml:"MassTable"`
 	ID               string             `xml:"id,attr,omitempty"`
-	MSLevel          []int              `xml:"msLevel,attr,omitempty"`
+	MSLevel          string             `xml:"msLevel,attr,omitempty"`
 	Name             string             `xml:"Name,attr,omitempty"`
 	Residue          []Residue          `xml:"Residue"`
 	AmbiguousResidue []AmbiguousResidue `xml:"AmbiguousResidue"`
@@ -559,18 +559,18 @@ type Inputs struct {
 // set of amino acid sequence entries, nucleotide databases (e.g. 6 frame
 // translated) or annotated spectra libraries
 type SearchDatabase struct {
-	XMLName                     xml.Name                    `xml:"SearchDatabase"`
-	ID                          string                      `xml:"id,attr,omitempty"`
-	Location                    string                      `xml:"location,attr,omitempty"`
-	Name                        string                      `xml:"name,attr,omitempty"`
-	NumDatabaseSequences        int                         `xml:"numDatabaseSequences,attr,omitempty"`
-	NumResidues                 string                      `xml:"numResidues,attr,omitempty"`
-	ReleaseDate                 string                      `xml:"releaseDate,attr,omitempty"`
-	Version                     string                      `xml:"version,attr,omitempty"`
-	ExternalFormatDocumentation ExternalFormatDocumentation `xml:"ExternalFormatDocumentation"`
-	FileFormat                  FileFormat                  `xml:"FileFormat"`
-	DatabaseName                DatabaseName                `xml:"DatabaseName"`
-	CVParam                     []CVParam                   `xml:"cvParam"`
+	XMLName                     xml.Name                     `xml:"SearchDatabase"`
+	ID                          string                       `xml:"id,attr,omitempty"`
+	Location                    string                       `xml:"location,attr,omitempty"`
+	Name                        string                       `xml:"name,attr,omitempty"`
+	NumDatabaseSequences        int                          `xml:"numDatabaseSequences,attr,omitempty"`
+	NumResidues                 string                       `xml:"numResidues,attr,omitempty"`
+	ReleaseDate                 string                       `xml:"releaseDate,attr,omitempty"`
+	Version                     string                       `xml:"version,attr,omitempty"`
+	ExternalFormatDocumentation *ExternalFormatDocumentation `xml:"ExternalFormatDocumentation,omitempty"`
+	FileFormat                  FileFormat                   `xml:"FileFormat"`
+	DatabaseName                DatabaseName                 `xml:"DatabaseName"`
+	CVParam                     []CVParam                    `xml:"cvParam"`
 }
 
 // ExternalFormatDocumentation is a URI to access documentation and tools to
@@ -592,20 +592,20 @@ type FileFormat struct {
 // exactly to one of the release databases listed in the CV, otherwise a
 // userParam should be used
 type DatabaseName struct {
-	XMLName   xml.Name  `xml:"DatabaseName"`
-	CVParam   CVParam   `xml:"cvParam"`
-	UserParam UserParam `xml:"userParam"`
+	XMLName   xml.Name   `xml:"DatabaseName"`
+	CVParam   *CVParam   `xml:"cvParam,omitempty"`
+	UserParam *UserParam `xml:"userParam,omitempty"`
 }
 
 // SpectraData should be used
 type SpectraData struct {
-	XMLName                     xml.Name                    `xml:"SpectraData"`
-	ID                          string                      `xml:"id,attr,omitempty"`
-	Location                    string                      `xml:"location,attr,omitempty"`
-	Name                        string                      `xml:"name,attr,omitempty"`
-	ExternalFormatDocumentation ExternalFormatDocumentation `xml:"ExternalFormatDocumentation"`
-	FileFormat                  FileFormat                  `xml:"FileFormat"`
-	SpectrumIDFormat            SpectrumIDFormat            `xml:"SpectrumIDFormat"`
+	XMLName                     xml.Name                     `xml:"SpectraData"`
+	ID                          string                       `xml:"id,attr,omitempty"`
+	Location                    string                       `xml:"location,attr,omitempty"`
+	Name                        string                       `xml:"name,attr,omitempty"`
+	ExternalFormatDocumentation *ExternalFormatDocumentation `xml:"ExternalFormatDocumentation,omitempty"`
+	FileFormat                  FileFormat                   `xml:"FileFormat"`
+	SpectrumIDFormat            SpectrumIDFormat             `xml:"SpectrumIDFormat"`
 }
 
 // SpectrumIDFormat is the format of the spectrum identifier within the source
@@ -620,7 +620,7 @@ type SpectrumIDFormat struct {
 type AnalysisData struct {
 	XMLName                    xml.Name                     `xml:"AnalysisData"`
 	SpectrumIdentificationList []SpectrumIdentificationList `xml:"SpectrumIdentificationList"`
-	ProteinDetectionList       ProteinDetectionList         `xml:"ProteinDetectionList"`
+	ProteinDetectionList       *ProteinDetectionList        `xml:"ProteinDetectionList,omitempty"`
 }
 
 // SpectrumIdentificationList is the set of all search results from
@@ -630,7 +630,7 @@ type SpectrumIdentificationList struct {
 	ID                           string                         `xml:"id,attr,omitempty"`
 	Name                         string                         `xml:"name,attr,omitempty"`
 	NumSequencesSearched         float64                        `xml:"numSequencesSearched,attr,omitempty"`
-	FragmentationTable           FragmentationTable             `xml:"FragmentationTable"`
+	FragmentationTable           *FragmentationTable            `xml:"FragmentationTable,omitempty"`
 	SpectrumIdentificationResult []SpectrumIdentificationResult `xml:"SpectrumIdentificationResult"`
 	CVParam                      []CVParam                      `xml:"cvParam"`
 	UserParam                    []UserParam                    `xml:"userParam"`
@@ -664,6 +664,8 @@ type SpectrumIdentificationResult struct {
 	SpectraDataRef             string                       `xml:"spectraData_ref,attr,omitempty"`
 	SpectrumID                 string                       `xml:"spectrumID,attr,omitempty"`
 	SpectrumIdentificationItem []SpectrumIdentificationItem `xml:"SpectrumIdentificationItem"`
+	CVParam                    []CVParam                    `xml:"cvParam"`
+	UserParam                  []UserParam                  `xml:"userParam"`
 }
 
 // SpectrumIdentificationItem is an identification of a single (poly)peptide,
@@ -675,7 +677,7 @@ type SpectrumIdentificationItem struct {
 	XMLName                  xml.Name             `xml:"SpectrumIdentificationItem"`
 	CalculatedMassToCharge   float64              `xml:"calculatedMassToCharge,attr,omitempty"`
 	CalculatedPI             float64              `xml:"calculatedPI,attr,omitempty"`
-	ChargeState              uint8                `xml:"chargeState,attr,omitempty"`
+	ChargeState              uint8                `xml:"chargeState,attr"`
 	ExperimentalMassToCharge float64              `xml:"experimentalMassToCharge,attr,omitempty"`
 	ID                       string               `xml:"id,attr,omitempty"`
 	MassTableRef             string               `xml:"massTable_ref,attr,omitempty"`
@@ -685,7 +687,7 @@ type SpectrumIdentificationItem struct {
 	Rank                     uint8                `xml:"rank,attr,omitempty"`
 	SampleRef                string               `xml:"sample_ref,attr,omitempty"`
 	PeptideEvidenceRef       []PeptideEvidenceRef `xml:"PeptideEvidenceRef"`
-	Fragmentation            Fragmentation        `xml:"Fragmentation"`
+	Fragmentation            *Fragmentation       `xml:"Fragmentation,omitempty"`
 	CVParam                  []CVParam            `xml:"cvParam"`
 	UserParam                []UserParam          `xml:"userParam"`
 }
@@ -711,7 +713,7 @@ type Fragmentation struct {
 type IonType struct {
 	XMLName       xml.Name        `xml:"IonType"`
 	Charge        int             `xml:"charge,attr,omitempty"`
-	Index         []string        `xml:"index,attr,omitempty"`
+	Index         string          `xml:"index,attr,omitempty"`
 	FragmentArray []FragmentArray `xml:"FragmentArray"`
 	CVParam       []CVParam       `xml:"cvParam"`
 	UserParam     []UserParam     `xml:"userParam"`
@@ -722,7 +724,7 @@ type IonType struct {
 type FragmentArray struct {
 	XMLName    xml.Name `xml:"FragmentArray"`
 	MeasureRef string   `xml:"measure_ref,attr,omitempty"`
-	Values     []string `xml:"values,attr,omitempty"`
+	Values     string   `xml:"values,attr,omitempty"`
 }
 
 // ProteinDetectionList is the protein list resulting from a protein detection
@@ -755,7 +757,7 @@ type ProteinDetectionHypothesis struct {
 	DBSquenceRef      string              `xml:"dBSequence_ref,attr,omitempty"`
 	ID                string              `xml:"id,attr,omitempty"`
 	Name              string              `xml:"name,attr,omitempty"`
-	PassThreshold     string              `xml:"PassThreshold,attr"`
+	PassThreshold     string              `xml:"passThreshold,attr"`
 	PeptideHypothesis []PeptideHypothesis `xml:"PeptideHypothesis"`
 	CVParam           []CVParam           `xml:"cvParam"`
 	UserParam         []UserParam         `xml:"userParam"`
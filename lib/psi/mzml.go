@@ -405,3 +405,24 @@ type Binary struct {
 	XMLName xml.Name `xml:"binary"`
 	Value   []byte   `xml:",chardata"`
 }
+
+// IndexList contains the byte offsets of the spectra and chromatograms of an indexed mzML file
+type IndexList struct {
+	XMLName xml.Name `xml:"indexList"`
+	Count   int      `xml:"count,attr"`
+	Index   []Index  `xml:"index"`
+}
+
+// Index is the list of offsets for one type of element, spectrum or chromatogram
+type Index struct {
+	XMLName xml.Name `xml:"index"`
+	Name    string   `xml:"name,attr"`
+	Offset  []Offset `xml:"offset"`
+}
+
+// Offset is the byte position of an element, referenced by its native ID
+type Offset struct {
+	XMLName xml.Name `xml:"offset"`
+	IDRef   string   `xml:"idRef,attr"`
+	Value   int64    `xml:",chardata"`
+}
@@ -3,6 +3,7 @@ package psi
 import (
 	"bytes"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -26,14 +27,14 @@ type DataFormat interface {
 
 // SourceFile is a file from which this instance was created
 type SourceFile struct {
-	XMLName                     xml.Name                    `xml:"SourceFile"`
-	ID                          string                      `xml:"id,attr,omitempty"`
-	Location                    string                      `xml:"location,attr,omitempty"`
-	Name                        string                      `xml:"name,attr,omitempty"`
-	ExternalFormatDocumentation ExternalFormatDocumentation `xml:"ExternalFormatDocumentation"`
-	FileFormat                  FileFormat                  `xml:"FileFormat"`
-	CVParam                     []CVParam                   `xml:"cvParam"`
-	UserParam                   []UserParam                 `xml:"userParam"`
+	XMLName                     xml.Name                     `xml:"SourceFile"`
+	ID                          string                       `xml:"id,attr,omitempty"`
+	Location                    string                       `xml:"location,attr,omitempty"`
+	Name                        string                       `xml:"name,attr,omitempty"`
+	ExternalFormatDocumentation *ExternalFormatDocumentation `xml:"ExternalFormatDocumentation,omitempty"`
+	FileFormat                  FileFormat                   `xml:"FileFormat"`
+	CVParam                     []CVParam                    `xml:"cvParam"`
+	UserParam                   []UserParam                  `xml:"userParam"`
 }
 
 // CvList is the container for one or more controlled vocabulary definitions
@@ -86,7 +87,7 @@ type UserParam struct {
 // cells, DNA, solutions, compounds and experimental substances
 // (gels, arrays etc.)
 type Sample struct {
-	XMLName     xml.Name      `xml:"sample"`
+	XMLName     xml.Name      `xml:"Sample"`
 	ID          string        `xml:"id,attr,omitempty"`
 	Name        string        `xml:"name,attr,omitempty"`
 	ContactRole []ContactRole `xml:"ContactRole"`
@@ -165,3 +166,259 @@ func (p *MzIdentML) Write() {
 	sys.CopyFile(output, filepath.Base(output))
 
 }
+
+// Validate checks that the elements and references required by the mzIdentML
+// 1.2 schema are present before the document is written
+func (p *MzIdentML) Validate() error {
+
+	if len(p.ID) == 0 || len(p.Version) == 0 {
+		return errors.New("the id and version attributes are required")
+	}
+
+	if len(p.CvList.CV) == 0 {
+		return errors.New("the cvList is empty")
+	}
+
+	if len(p.AnalysisSoftwareList.AnalysisSoftware) == 0 {
+		return errors.New("no analysis software is defined")
+	}
+
+	if len(p.DataCollection.Inputs.SpectraData) == 0 {
+		return errors.New("no spectra data is defined")
+	}
+
+	if len(p.DataCollection.AnalysisData.SpectrumIdentificationList) == 0 {
+		return errors.New("no spectrum identification list is defined")
+	}
+
+	var ids = make(map[string]string)
+	register := func(id, element string) error {
+		if len(id) == 0 {
+			return fmt.Errorf("%s without id", element)
+		}
+		if _, ok := ids[id]; ok {
+			return fmt.Errorf("duplicated id %s", id)
+		}
+		ids[id] = element
+		return nil
+	}
+
+	resolve := func(ref, element string) error {
+		if e, ok := ids[ref]; !ok || e != element {
+			return fmt.Errorf("reference %s to a missing %s", ref, element)
+		}
+		return nil
+	}
+
+	var e error
+	for _, i := range p.AnalysisSoftwareList.AnalysisSoftware {
+		if e = register(i.ID, "AnalysisSoftware"); e != nil {
+			return e
+		}
+	}
+
+	if p.AuditCollection != nil {
+		if e = register(p.AuditCollection.Person.ID, "Person"); e != nil {
+			return e
+		}
+		if e = register(p.AuditCollection.Organization.ID, "Organization"); e != nil {
+			return e
+		}
+	}
+
+	for _, i := range p.DataCollection.Inputs.SearchDatabase {
+		if e = register(i.ID, "SearchDatabase"); e != nil {
+			return e
+		}
+	}
+
+	for _, i := range p.DataCollection.Inputs.SpectraData {
+		if e = register(i.ID, "SpectraData"); e != nil {
+			return e
+		}
+	}
+
+	for _, i := range p.SequenceCollection.DBSequence {
+		if e = register(i.ID, "DBSequence"); e != nil {
+			return e
+		}
+		if e = resolve(i.SearchDatabaseRef, "SearchDatabase"); e != nil {
+			return e
+		}
+	}
+
+	for _, i := range p.SequenceCollection.Peptide {
+		if e = register(i.ID, "Peptide"); e != nil {
+			return e
+		}
+		if len(i.PeptideSequence.Value) == 0 {
+			return fmt.Errorf("peptide %s without sequence", i.ID)
+		}
+	}
+
+	for _, i := range p.SequenceCollection.PeptideEvidence {
+		if e = register(i.ID, "PeptideEvidence"); e != nil {
+			return e
+		}
+		if e = resolve(i.PeptideRef, "Peptide"); e != nil {
+			return e
+		}
+		if e = resolve(i.DBSequenceRef, "DBSequence"); e != nil {
+			return e
+		}
+	}
+
+	for _, i := range p.AnalysisProtocolCollection.SpectrumIdentificationProtocol {
+		if e = register(i.ID, "SpectrumIdentificationProtocol"); e != nil {
+			return e
+		}
+		if e = resolve(i.AnalysisSoftwareRef, "AnalysisSoftware"); e != nil {
+			return e
+		}
+		if len(i.Threshold.CVParam) == 0 && len(i.Threshold.UserParam) == 0 {
+			return fmt.Errorf("protocol %s without threshold", i.ID)
+		}
+	}
+
+	if i := p.AnalysisProtocolCollection.ProteinDetectionProtocol; i != nil {
+		if e = register(i.ID, "ProteinDetectionProtocol"); e != nil {
+			return e
+		}
+		if e = resolve(i.AnalysisSoftwareRef, "AnalysisSoftware"); e != nil {
+			return e
+		}
+		if len(i.Threshold.CVParam) == 0 && len(i.Threshold.UserParam) == 0 {
+			return fmt.Errorf("protocol %s without threshold", i.ID)
+		}
+	}
+
+	// identifications from search engines that do not report the charge state are still valid
+	var noCharge int
+	for _, i := range p.DataCollection.AnalysisData.SpectrumIdentificationList {
+		if e = register(i.ID, "SpectrumIdentificationList"); e != nil {
+			return e
+		}
+		for _, j := range i.SpectrumIdentificationResult {
+			if e = register(j.ID, "SpectrumIdentificationResult"); e != nil {
+				return e
+			}
+			if e = resolve(j.SpectraDataRef, "SpectraData"); e != nil {
+				return e
+			}
+			if len(j.SpectrumID) == 0 {
+				return fmt.Errorf("result %s without spectrumID", j.ID)
+			}
+			if len(j.SpectrumIdentificationItem) == 0 {
+				return fmt.Errorf("result %s without identification items", j.ID)
+			}
+			for _, k := range j.SpectrumIdentificationItem {
+				if e = register(k.ID, "SpectrumIdentificationItem"); e != nil {
+					return e
+				}
+				if e = resolve(k.PeptideRef, "Peptide"); e != nil {
+					return e
+				}
+				if len(k.PassThreshold) == 0 || k.Rank == 0 {
+					return fmt.Errorf("item %s without passThreshold or rank", k.ID)
+				}
+				if k.ChargeState == 0 {
+					noCharge++
+				}
+				if len(k.PeptideEvidenceRef) == 0 {
+					return fmt.Errorf("item %s without peptide evidence", k.ID)
+				}
+				for _, l := range k.PeptideEvidenceRef {
+					if e = resolve(l.PeptideEvidenceRef, "PeptideEvidence"); e != nil {
+						return e
+					}
+				}
+			}
+		}
+	}
+
+	if i := p.DataCollection.AnalysisData.ProteinDetectionList; i != nil {
+		if e = register(i.ID, "ProteinDetectionList"); e != nil {
+			return e
+		}
+		for _, j := range i.ProteinAmbiguityGroup {
+			if e = register(j.ID, "ProteinAmbiguityGroup"); e != nil {
+				return e
+			}
+			if len(j.ProteinDetectionHypothesis) == 0 {
+				return fmt.Errorf("group %s without protein detection hypothesis", j.ID)
+			}
+			for _, k := range j.ProteinDetectionHypothesis {
+				if e = register(k.ID, "ProteinDetectionHypothesis"); e != nil {
+					return e
+				}
+				if e = resolve(k.DBSquenceRef, "DBSequence"); e != nil {
+					return e
+				}
+				if len(k.PeptideHypothesis) == 0 {
+					return fmt.Errorf("hypothesis %s without peptide hypothesis", k.ID)
+				}
+				for _, l := range k.PeptideHypothesis {
+					if e = resolve(l.PeptideEvidenceRef, "PeptideEvidence"); e != nil {
+						return e
+					}
+					if len(l.SpectrumIdentificationItemRef) == 0 {
+						return fmt.Errorf("hypothesis %s without identification items", k.ID)
+					}
+					for _, m := range l.SpectrumIdentificationItemRef {
+						if e = resolve(m.SpectrumIdentificationItemRef, "SpectrumIdentificationItem"); e != nil {
+							return e
+						}
+					}
+				}
+			}
+		}
+	}
+
+	if noCharge > 0 {
+		logrus.Warn(noCharge, " spectrum identification items have no charge state")
+	}
+
+	for _, i := range p.AnalysisCollection.SpectrumIdentification {
+		if e = register(i.ID, "SpectrumIdentification"); e != nil {
+			return e
+		}
+		if e = resolve(i.SpectrumIdentificationListRef, "SpectrumIdentificationList"); e != nil {
+			return e
+		}
+		if e = resolve(i.SpectrumIdentificationProtocolRef, "SpectrumIdentificationProtocol"); e != nil {
+			return e
+		}
+		if len(i.InputSpectra) == 0 || len(i.SearchDatabaseRef) == 0 {
+			return fmt.Errorf("spectrum identification %s without input spectra or search database", i.ID)
+		}
+		for _, j := range i.InputSpectra {
+			if e = resolve(j.SpectraDataRef, "SpectraData"); e != nil {
+				return e
+			}
+		}
+		for _, j := range i.SearchDatabaseRef {
+			if e = resolve(j.SearchDatabaseRef, "SearchDatabase"); e != nil {
+				return e
+			}
+		}
+	}
+
+	if i := p.AnalysisCollection.ProteinDetection; i != nil {
+		if e = register(i.ID, "ProteinDetection"); e != nil {
+			return e
+		}
+		if e = resolve(i.ProteinDetectionListRef, "ProteinDetectionList"); e != nil {
+			return e
+		}
+		if e = resolve(i.ProteinDetectionProtocolRef, "ProteinDetectionProtocol"); e != nil {
+			return e
+		}
+		for _, j := range i.InputSpectrumIdentifications {
+			if e = resolve(j.SpectrumIdentificationListRef, "SpectrumIdentificationList"); e != nil {
+				return e
+			}
+		}
+	}
+
+	return nil
+}
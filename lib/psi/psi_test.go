@@ -0,0 +1,76 @@
+package psi
+
+import (
+	"testing"
+)
+
+// minimalMzIdentML builds the smallest document that passes the validation, with one identification
+func minimalMzIdentML(charge uint8) MzIdentML {
+
+	var p MzIdentML
+
+	p.ID = "philosopher"
+	p.Version = "1.2.0"
+	p.CvList.CV = []CV{{ID: "PSI-MS"}}
+	p.AnalysisSoftwareList.AnalysisSoftware = []AnalysisSoftware{{ID: "AS_1"}}
+
+	p.DataCollection.Inputs.SearchDatabase = []SearchDatabase{{ID: "SDB_1"}}
+	p.DataCollection.Inputs.SpectraData = []SpectraData{{ID: "SD_1"}}
+
+	p.SequenceCollection.DBSequence = []DBSequence{{ID: "DBSeq_1", SearchDatabaseRef: "SDB_1"}}
+	p.SequenceCollection.Peptide = []Peptide{{ID: "Pep_1", PeptideSequence: PeptideSequence{Value: "PEPTIDE"}}}
+	p.SequenceCollection.PeptideEvidence = []PeptideEvidence{{ID: "PE_1", PeptideRef: "Pep_1", DBSequenceRef: "DBSeq_1"}}
+
+	p.AnalysisProtocolCollection.SpectrumIdentificationProtocol = []SpectrumIdentificationProtocol{{
+		ID:                  "SIP_1",
+		AnalysisSoftwareRef: "AS_1",
+		Threshold:           Threshold{CVParam: []CVParam{{Accession: "MS:1001494", Name: "no threshold"}}},
+	}}
+
+	p.DataCollection.AnalysisData.SpectrumIdentificationList = []SpectrumIdentificationList{{
+		ID: "SIL_1",
+		SpectrumIdentificationResult: []SpectrumIdentificationResult{{
+			ID:             "SIR_1",
+			SpectraDataRef: "SD_1",
+			SpectrumID:     "controllerType=0 controllerNumber=1 scan=10",
+			SpectrumIdentificationItem: []SpectrumIdentificationItem{{
+				ID:                 "SII_1",
+				PassThreshold:      "true",
+				Rank:               1,
+				ChargeState:        charge,
+				PeptideRef:         "Pep_1",
+				PeptideEvidenceRef: []PeptideEvidenceRef{{PeptideEvidenceRef: "PE_1"}},
+			}},
+		}},
+	}}
+
+	p.AnalysisCollection.SpectrumIdentification = []SpectrumIdentification{{
+		ID:                                "SI_1",
+		SpectrumIdentificationListRef:     "SIL_1",
+		SpectrumIdentificationProtocolRef: "SIP_1",
+		InputSpectra:                      []InputSpectra{{SpectraDataRef: "SD_1"}},
+		SearchDatabaseRef:                 []SearchDatabaseRef{{SearchDatabaseRef: "SDB_1"}},
+	}}
+
+	return p
+}
+
+func TestMzIdentML_Validate(t *testing.T) {
+
+	p := minimalMzIdentML(2)
+	if e := p.Validate(); e != nil {
+		t.Errorf("Validate() error = %v, want nil", e)
+	}
+
+	// a missing charge state is reported as a warning
+	p = minimalMzIdentML(0)
+	if e := p.Validate(); e != nil {
+		t.Errorf("Validate() error = %v for a PSM without charge state, want nil", e)
+	}
+
+	p = minimalMzIdentML(2)
+	p.DataCollection.AnalysisData.SpectrumIdentificationList[0].SpectrumIdentificationResult[0].SpectrumIdentificationItem[0].Rank = 0
+	if e := p.Validate(); e == nil {
+		t.Errorf("Validate() error = nil for a PSM without rank, want an error")
+	}
+}
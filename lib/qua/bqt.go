@@ -319,33 +319,19 @@ func savetoDisk(list List, temp, uid string) {
 
 	var headerIndex int
 	for i := range list {
-		if len(list[i].Labels.Channel1.Name) > 0 {
+		if len(list[i].Labels.Channels) > 0 {
 			headerIndex = i
 			break
 		}
 	}
 
-	header = fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
-		header,
-		list[headerIndex].Labels.Channel1.CustomName,
-		list[headerIndex].Labels.Channel2.CustomName,
-		list[headerIndex].Labels.Channel3.CustomName,
-		list[headerIndex].Labels.Channel4.CustomName,
-		list[headerIndex].Labels.Channel5.CustomName,
-		list[headerIndex].Labels.Channel6.CustomName,
-		list[headerIndex].Labels.Channel7.CustomName,
-		list[headerIndex].Labels.Channel8.CustomName,
-		list[headerIndex].Labels.Channel9.CustomName,
-		list[headerIndex].Labels.Channel10.CustomName,
-		list[headerIndex].Labels.Channel11.CustomName,
-		list[headerIndex].Labels.Channel12.CustomName,
-		list[headerIndex].Labels.Channel13.CustomName,
-		list[headerIndex].Labels.Channel14.CustomName,
-		list[headerIndex].Labels.Channel15.CustomName,
-		list[headerIndex].Labels.Channel16.CustomName,
-		list[headerIndex].Labels.Channel17.CustomName,
-		list[headerIndex].Labels.Channel18.CustomName,
-	)
+	var channels []string
+	if len(list) > 0 {
+		for _, i := range list[headerIndex].Labels.Channels {
+			channels = append(channels, i.CustomName)
+			header += fmt.Sprintf("\t%s", i.CustomName)
+		}
+	}
 
 	header += "\n"
 
@@ -395,26 +381,15 @@ func savetoDisk(list List, temp, uid string) {
 				}
 			}
 
-			line += fmt.Sprintf("%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f",
-				list[i].Labels.Channel1.Intensity,
-				list[i].Labels.Channel2.Intensity,
-				list[i].Labels.Channel3.Intensity,
-				list[i].Labels.Channel4.Intensity,
-				list[i].Labels.Channel5.Intensity,
-				list[i].Labels.Channel6.Intensity,
-				list[i].Labels.Channel7.Intensity,
-				list[i].Labels.Channel8.Intensity,
-				list[i].Labels.Channel9.Intensity,
-				list[i].Labels.Channel10.Intensity,
-				list[i].Labels.Channel11.Intensity,
-				list[i].Labels.Channel12.Intensity,
-				list[i].Labels.Channel13.Intensity,
-				list[i].Labels.Channel14.Intensity,
-				list[i].Labels.Channel15.Intensity,
-				list[i].Labels.Channel16.Intensity,
-				list[i].Labels.Channel17.Intensity,
-				list[i].Labels.Channel18.Intensity,
-			)
+			var intensities []string
+			for j := range channels {
+				var intensity float64
+				if j < len(list[i].Labels.Channels) {
+					intensity = list[i].Labels.Channels[j].Intensity
+				}
+				intensities = append(intensities, fmt.Sprintf("%.4f", intensity))
+			}
+			line += strings.Join(intensities, "\t")
 
 			line += "\n"
 
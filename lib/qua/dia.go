@@ -0,0 +1,430 @@
+package qua
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"philosopher/lib/bio"
+	"philosopher/lib/id"
+	"philosopher/lib/met"
+	"philosopher/lib/msg"
+	"philosopher/lib/mzn"
+	"philosopher/lib/rep"
+
+	"github.com/sirupsen/logrus"
+)
+
+// minCoElutingFragments is the number of co-eluting fragments needed to quantify a precursor
+const minCoElutingFragments = 3
+
+// libraryFragment is a fragment ion of a precursor, from the spectral library
+// or from the spectrum that identified the precursor
+type libraryFragment struct {
+	mz        float64
+	intensity float64
+}
+
+// libraryEntry is a precursor of the spectral library with its fragments
+type libraryEntry struct {
+	precursorMz float64
+	fragments   []libraryFragment
+}
+
+// diaPrecursor is an identified precursor with the fragments used to quantify it
+type diaPrecursor struct {
+	psm       rep.PSMEvidence
+	mz        float64
+	fragments []libraryFragment
+}
+
+// RunDIAQuantification is the top function for the DIA quantification, the
+// top fragments of each identified precursor are traced on the MS2 spectra of
+// its isolation window and the co-eluting fragments are summed
+func RunDIAQuantification(p met.Quantify) {
+
+	var evi rep.Evidence
+	evi.RestoreGranular()
+
+	var library map[string][]libraryEntry
+	if len(p.Library) > 0 {
+		library = readSpectralLibrary(p.Library)
+	}
+
+	evi = fragmentIntensities(evi, library, p)
+
+	evi = calculateIntensities(evi)
+
+	evi.SerializeGranular()
+
+}
+
+// readSpectralLibrary parses a tab-separated spectral library with the
+// PeptideSequence, PrecursorCharge, PrecursorMz, ProductMz and LibraryIntensity
+// columns, the entries are indexed by the peptide sequence and charge
+func readSpectralLibrary(f string) map[string][]libraryEntry {
+
+	file, e := os.Open(f)
+	if e != nil {
+		msg.ReadFile(errors.New("cannot open the spectral library, "+e.Error()), "fatal")
+	}
+	defer file.Close()
+
+	var library = make(map[string][]libraryEntry)
+	var columns = make(map[string]int)
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 1<<20), 1<<20)
+
+	if scanner.Scan() {
+		for i, j := range strings.Split(strings.TrimSpace(scanner.Text()), "\t") {
+			columns[j] = i
+		}
+	}
+
+	for _, i := range []string{"PeptideSequence", "PrecursorCharge", "PrecursorMz", "ProductMz", "LibraryIntensity"} {
+		if _, ok := columns[i]; !ok {
+			msg.ReadFile(errors.New("the spectral library has no "+i+" column"), "fatal")
+		}
+	}
+
+	for scanner.Scan() {
+
+		parts := strings.Split(strings.TrimSpace(scanner.Text()), "\t")
+		if len(parts) < len(columns) {
+			continue
+		}
+
+		precursorMz, e1 := strconv.ParseFloat(parts[columns["PrecursorMz"]], 64)
+		productMz, e2 := strconv.ParseFloat(parts[columns["ProductMz"]], 64)
+		intensity, e3 := strconv.ParseFloat(parts[columns["LibraryIntensity"]], 64)
+		if e1 != nil || e2 != nil || e3 != nil {
+			continue
+		}
+
+		key := parts[columns["PeptideSequence"]] + "#" + parts[columns["PrecursorCharge"]]
+
+		entries := library[key]
+		var found bool
+		for k := range entries {
+			if entries[k].precursorMz == precursorMz {
+				entries[k].fragments = append(entries[k].fragments, libraryFragment{productMz, intensity})
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			entries = append(entries, libraryEntry{precursorMz, []libraryFragment{{productMz, intensity}}})
+		}
+
+		library[key] = entries
+	}
+
+	if e := scanner.Err(); e != nil {
+		msg.ReadFile(errors.New("cannot read the spectral library, "+e.Error()), "fatal")
+	}
+
+	return library
+}
+
+// libraryFragments returns the library fragments of the precursor, matching the
+// precursor m/z of the library entries within the tolerance
+func libraryFragments(library map[string][]libraryEntry, psm rep.PSMEvidence, mz, ppmPrecision float64) ([]libraryFragment, bool) {
+
+	for _, i := range library[fmt.Sprintf("%s#%d", psm.Peptide, psm.AssumedCharge)] {
+		if math.Abs(i.precursorMz-mz) <= mz*ppmPrecision {
+			return i.fragments, true
+		}
+	}
+
+	return nil, false
+}
+
+// spectrumFragments annotates the b and y ions of the PSM on the spectrum that
+// identified it, the matched peaks are the fragments of the precursor
+func spectrumFragments(psm rep.PSMEvidence, spec mzn.Spectrum, ppmPrecision float64) []libraryFragment {
+
+	var fragments []libraryFragment
+
+	for _, f := range rep.TheoreticalFragments(psm) {
+		if intensity := peakIntensityAt(spec, f.Mz, ppmPrecision, mobilityWindow{}); intensity > 0 {
+			fragments = append(fragments, libraryFragment{f.Mz, intensity})
+		}
+	}
+
+	return fragments
+}
+
+// topFragments keeps the most intense fragments, the fragments closer than the
+// tolerance to a more intense one are removed
+func topFragments(fragments []libraryFragment, n int, ppmPrecision float64) []libraryFragment {
+
+	sorted := make([]libraryFragment, len(fragments))
+	copy(sorted, fragments)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].intensity > sorted[j].intensity })
+
+	var top []libraryFragment
+	for _, i := range sorted {
+
+		if len(top) == n {
+			break
+		}
+
+		var duplicated bool
+		for _, j := range top {
+			if math.Abs(i.mz-j.mz) <= j.mz*ppmPrecision {
+				duplicated = true
+				break
+			}
+		}
+
+		if !duplicated {
+			top = append(top, i)
+		}
+	}
+
+	return top
+}
+
+// diaPrecursors selects the best PSM of each identified ion in the run and the
+// top fragments used to quantify it
+func diaPrecursors(psms []rep.PSMEvidence, mz *mzn.MsData, library map[string][]libraryEntry, fragments int, ppmPrecision float64) []diaPrecursor {
+
+	var best = make(map[id.IonFormType]rep.PSMEvidence)
+	for _, i := range psms {
+		if v, ok := best[i.IonForm()]; !ok || i.Probability > v.Probability {
+			best[i.IonForm()] = i
+		}
+	}
+
+	var precursors []diaPrecursor
+
+	for _, psm := range best {
+
+		mzValue := (psm.CalcNeutralPepMass + float64(psm.AssumedCharge)*bio.Proton) / float64(psm.AssumedCharge)
+
+		candidates, ok := libraryFragments(library, psm, mzValue, ppmPrecision)
+		if !ok {
+
+			parts := strings.Split(psm.Spectrum, ".")
+			if len(parts) < 2 {
+				continue
+			}
+
+			i, ok := mz.ByScan(parts[1])
+			if !ok {
+				continue
+			}
+
+			candidates = spectrumFragments(psm, mz.Load(i), ppmPrecision)
+		}
+
+		top := topFragments(candidates, fragments, ppmPrecision)
+		if len(top) < minCoElutingFragments {
+			continue
+		}
+
+		precursors = append(precursors, diaPrecursor{psm, mzValue, top})
+	}
+
+	// the MS2 spectra are streamed in retention time order
+	sort.SliceStable(precursors, func(i, j int) bool { return precursors[i].psm.RetentionTime < precursors[j].psm.RetentionTime })
+
+	return precursors
+}
+
+// coElution scores the fragment traces against the summed trace inside the
+// peak. The least correlated fragment is removed and the sum rebuilt until all
+// fragments are above the threshold, it returns the co-eluting fragments and
+// their mean correlation
+func coElution(times []float64, traces [][]float64, start, end, minCorr float64) ([]int, float64) {
+
+	var inside []int
+	for t := range times {
+		if times[t] >= start && times[t] <= end {
+			inside = append(inside, t)
+		}
+	}
+
+	// the correlation needs a few points across the peak
+	if len(inside) < 3 {
+		return nil, 0
+	}
+
+	var kept []int
+	for f := range traces {
+		kept = append(kept, f)
+	}
+
+	for len(kept) > 0 {
+
+		var summed = make([]float64, len(inside))
+		for _, f := range kept {
+			for k, t := range inside {
+				summed[k] += traces[f][t]
+			}
+		}
+
+		var correlations = make([]float64, len(kept))
+		var worst int
+		for k, f := range kept {
+
+			var trace = make([]float64, len(inside))
+			for l, t := range inside {
+				trace[l] = traces[f][t]
+			}
+
+			correlations[k] = pearson(trace, summed)
+			if correlations[k] < correlations[worst] {
+				worst = k
+			}
+		}
+
+		if correlations[worst] >= minCorr {
+			var score float64
+			for _, r := range correlations {
+				score += r
+			}
+			return kept, score / float64(len(kept))
+		}
+
+		kept = append(kept[:worst], kept[worst+1:]...)
+	}
+
+	return nil, 0
+}
+
+// fragmentIntensities traces the top fragments of each identified precursor on
+// the MS2 spectra of its isolation window. The PSM with the best probability of
+// each ion carries the summed intensity of the co-eluting fragments, the other
+// PSMs of the ion are left without intensity so the ion is counted once
+func fragmentIntensities(evi rep.Evidence, library map[string][]libraryEntry, p met.Quantify) rep.Evidence {
+
+	var sourceMap = make(map[string][]rep.PSMEvidence)
+	for i := range evi.PSM {
+
+		evi.PSM[i].Intensity = 0
+		evi.PSM[i].CoElutionScore = 0
+		evi.PSM[i].QuantifiedFragments = 0
+
+		if evi.PSM[i].IsDecoy || evi.PSM[i].AssumedCharge == 0 {
+			continue
+		}
+
+		source := strings.Split(evi.PSM[i].Spectrum, ".")[0]
+		sourceMap[source] = append(sourceMap[source], evi.PSM[i])
+	}
+
+	var sourceList []string
+	for k := range sourceMap {
+		sourceList = append(sourceList, k)
+	}
+
+	sort.Strings(sourceList)
+
+	ppmPrecision := p.Tol / math.Pow(10, 6)
+
+	var quantified = make(map[id.SpectrumType]rep.PSMEvidence)
+
+	logrus.Info("Reading spectra and tracing the precursor fragments")
+
+	for _, s := range sourceList {
+
+		logrus.Info("Processing ", s)
+
+		var mz mzn.MsData
+		if p.Raw {
+			mz.IndexRaw(fmt.Sprintf("%s%s%s.raw", p.Dir, string(filepath.Separator), s))
+		} else {
+			mz.Index(fmt.Sprintf("%s%s%s.mzML", p.Dir, string(filepath.Separator), s))
+		}
+
+		for _, i := range diaPrecursors(sourceMap[s], &mz, library, p.Fragments, ppmPrecision) {
+
+			rt := i.psm.RetentionTime / 60
+			window := newMobilityWindow(i.psm, p.Faims, p.IMTol)
+
+			var times []float64
+			var traces = make([][]float64, len(i.fragments))
+
+			mz.MS2(i.mz, rt-p.RTWin, rt+p.RTWin, func(spec mzn.Spectrum) {
+				times = append(times, spec.ScanStartTime)
+				for f := range i.fragments {
+					traces[f] = append(traces[f], peakIntensityAt(spec, i.fragments[f].mz, ppmPrecision, window))
+				}
+			})
+
+			peak, ok := detectPeak(summedTrace(times, traces, nil), rt, p.PTWin)
+			if !ok {
+				continue
+			}
+
+			kept, score := coElution(times, traces, peak.Start/60, peak.End/60, p.MinFragCor)
+			if len(kept) < minCoElutingFragments {
+				continue
+			}
+
+			if len(kept) < len(traces) {
+				if peak, ok = detectPeak(summedTrace(times, traces, kept), rt, p.PTWin); !ok {
+					continue
+				}
+			}
+
+			psm := i.psm
+			psm.Intensity = peak.Intensity
+			psm.ApexRetentionTime = peak.Apex
+			psm.PeakStart = peak.Start
+			psm.PeakEnd = peak.End
+			psm.FWHM = peak.FWHM
+			psm.PeakArea = peak.Area
+			psm.PeakIntensity = peak.Intensity
+			psm.CoElutionScore = score
+			psm.QuantifiedFragments = uint8(len(kept))
+
+			quantified[psm.SpectrumFileName()] = psm
+		}
+
+		mz.Close()
+	}
+
+	logrus.Info("Quantified ", len(quantified), " precursors")
+
+	for i := range evi.PSM {
+		if v, ok := quantified[evi.PSM[i].SpectrumFileName()]; ok {
+			evi.PSM[i] = v
+		}
+	}
+
+	return evi
+}
+
+// summedTrace sums the fragment traces at each retention time, only the
+// selected fragments are summed when the selection is not nil
+func summedTrace(times []float64, traces [][]float64, selected []int) map[float64]float64 {
+
+	if selected == nil {
+		for f := range traces {
+			selected = append(selected, f)
+		}
+	}
+
+	var summed = make(map[float64]float64)
+	for t := range times {
+		var sum float64
+		for _, f := range selected {
+			sum += traces[f][t]
+		}
+		if sum > 0 {
+			summed[times[t]] = sum
+		}
+	}
+
+	return summed
+}
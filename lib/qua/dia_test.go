@@ -0,0 +1,75 @@
+package qua
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"philosopher/lib/rep"
+)
+
+func Test_coElution(t *testing.T) {
+
+	times := []float64{10.0, 10.1, 10.2, 10.3, 10.4, 10.5, 10.6}
+	profile := []float64{0, 10, 50, 100, 50, 10, 0}
+
+	var traces [][]float64
+	for _, scale := range []float64{1, 0.5, 0.2} {
+		var trace []float64
+		for _, v := range profile {
+			trace = append(trace, v*scale)
+		}
+		traces = append(traces, trace)
+	}
+
+	// an interfered fragment eluting at the end of the peak
+	traces = append(traces, []float64{0, 0, 0, 0, 10, 80, 100})
+
+	kept, score := coElution(times, traces, 10.0, 10.6, 0.6)
+	if len(kept) != 3 {
+		t.Fatalf("Co-eluting fragments are incorrect, got %v, want the first 3", kept)
+	}
+
+	if score < 0.9 {
+		t.Errorf("Co-elution score is incorrect, got %f, want above %f", score, 0.9)
+	}
+
+	summed := summedTrace(times, traces, kept)
+	if summed[10.3] != 170 {
+		t.Errorf("Summed fragment intensity is incorrect, got %f, want %f", summed[10.3], 170.0)
+	}
+}
+
+func Test_topFragments(t *testing.T) {
+
+	fragments := []libraryFragment{{300.1, 10}, {400.2, 50}, {400.2001, 40}, {500.3, 30}, {600.4, 20}}
+
+	top := topFragments(fragments, 3, 20/1e6)
+	if len(top) != 3 || top[0].mz != 400.2 || top[1].mz != 500.3 || top[2].mz != 600.4 {
+		t.Errorf("Top fragments are incorrect, got %v", top)
+	}
+}
+
+func Test_readSpectralLibrary(t *testing.T) {
+
+	dir, _ := ioutil.TempDir("", "qua")
+	defer os.RemoveAll(dir)
+
+	f := filepath.Join(dir, "library.tsv")
+	ioutil.WriteFile(f, []byte("PrecursorMz\tProductMz\tLibraryIntensity\tPeptideSequence\tPrecursorCharge\n"+
+		"500.25\t600.3\t100\tPEPTIDEK\t2\n"+
+		"500.25\t700.4\t80\tPEPTIDEK\t2\n"+
+		"508.26\t608.3\t90\tPEPTIDEK\t2\n"), 0644)
+
+	library := readSpectralLibrary(f)
+	if len(library["PEPTIDEK#2"]) != 2 {
+		t.Fatalf("Library entries are incorrect, got %d, want %d", len(library["PEPTIDEK#2"]), 2)
+	}
+
+	psm := rep.PSMEvidence{Peptide: "PEPTIDEK", AssumedCharge: 2}
+	fragments, ok := libraryFragments(library, psm, 500.2501, 20/1e6)
+	if !ok || len(fragments) != 2 {
+		t.Errorf("Library fragments are incorrect, got %v", fragments)
+	}
+}
@@ -0,0 +1,341 @@
+package qua
+
+import (
+	"bufio"
+	"errors"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"philosopher/lib/iso"
+	"philosopher/lib/msg"
+	"philosopher/lib/uti"
+)
+
+// impurityColumns are the header aliases of the -2, -1, +1 and +2 isotope columns
+var impurityColumns = [4][]string{
+	{"-2", "-2x13c", "-213c"},
+	{"-1", "-13c", "-1x13c", "-113c"},
+	{"+1", "+13c", "+1x13c", "+113c"},
+	{"+2", "+2x13c", "+213c"},
+}
+
+// readImpurityTable reads the reagent impurities from a TSV table with the channel
+// name followed by the -2, -1, +1 and +2 percentages. Vendor tables are recognized
+// by their header, other columns like the 15N isotopes are ignored.
+func readImpurityTable(f string) map[string][4]float64 {
+
+	file, e := os.Open(f)
+	if e != nil {
+		msg.ReadFile(errors.New("cannot open the impurity table, "+e.Error()), "fatal")
+	}
+	defer file.Close()
+
+	var impurities = make(map[string][4]float64)
+	var columns = [4]int{1, 2, 3, 4}
+	var header bool
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+
+		parts := splitImpurityLine(scanner.Text())
+		if len(parts) < 2 || strings.HasPrefix(parts[0], "#") {
+			continue
+		}
+
+		if !header {
+			header = true
+			if found, ok := impurityHeader(parts); ok {
+				columns = found
+				continue
+			}
+		}
+
+		var values [4]float64
+		var valid = true
+		for i, j := range columns {
+
+			if j < 0 || j >= len(parts) {
+				continue
+			}
+
+			v, ok := impurityValue(parts[j])
+			if !ok {
+				valid = false
+				break
+			}
+			values[i] = v
+		}
+
+		if valid {
+			impurities[channelKey(parts[0])] = values
+		}
+	}
+
+	if e := scanner.Err(); e != nil {
+		msg.ReadFile(errors.New("cannot read the impurity table, "+e.Error()), "fatal")
+	}
+
+	if len(impurities) == 0 {
+		msg.ReadFile(errors.New("the impurity table has no channels"), "fatal")
+	}
+
+	return impurities
+}
+
+// splitImpurityLine splits a table line on tabs, or on any white space when there are no tabs
+func splitImpurityLine(line string) []string {
+
+	var parts []string
+	if strings.Contains(line, "\t") {
+		parts = strings.Split(line, "\t")
+	} else {
+		parts = strings.Fields(line)
+	}
+
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+
+	return parts
+}
+
+// impurityHeader locates the isotope columns in a header line, absent columns are set to -1
+func impurityHeader(parts []string) ([4]int, bool) {
+
+	var columns = [4]int{-1, -1, -1, -1}
+	var found bool
+
+	for i, j := range parts {
+
+		name := strings.ToLower(j)
+		for _, k := range []string{" ", "(%)", "%", "_"} {
+			name = strings.Replace(name, k, "", -1)
+		}
+		name = strings.Replace(name, "−", "-", -1)
+
+		for c := range impurityColumns {
+			for _, alias := range impurityColumns[c] {
+				if name == alias && columns[c] < 0 {
+					columns[c] = i
+					found = true
+				}
+			}
+		}
+	}
+
+	return columns, found
+}
+
+// impurityValue parses a percentage, empty cells and dashes are pure
+func impurityValue(s string) (float64, bool) {
+
+	s = strings.TrimSuffix(strings.TrimSpace(s), "%")
+
+	if len(s) == 0 || s == "-" || strings.EqualFold(s, "n/a") || strings.EqualFold(s, "na") {
+		return 0, true
+	}
+
+	v, e := strconv.ParseFloat(s, 64)
+	if e != nil || v < 0 {
+		return 0, false
+	}
+
+	return v, true
+}
+
+// channelKey normalizes the reagent names so that 126, TMT126 and TMTpro-126 are the same channel,
+// the vendor sheets name the 131N channel 131 and the 126 channel 126C
+func channelKey(name string) string {
+
+	name = strings.ToUpper(name)
+	for _, i := range []string{"TMTPRO", "TMT", "ITRAQ", "-", "_", " "} {
+		name = strings.Replace(name, i, "", -1)
+	}
+
+	switch name {
+	case "131":
+		return "131N"
+	case "126C":
+		return "126"
+	}
+
+	return name
+}
+
+// impurityMatrix builds the kit correction matrix, the column j holds the fraction of the
+// channel j reagent observed in each channel. Every kit channel must be on the table.
+func impurityMatrix(kit iso.Kit, impurities map[string][4]float64) [][]float64 {
+
+	var n = len(kit.Channels)
+	var matrix = make([][]float64, n)
+	for i := range matrix {
+		matrix[i] = make([]float64, n)
+	}
+
+	for j, c := range kit.Channels {
+
+		v, ok := impurities[channelKey(c.Name)]
+		if !ok {
+			msg.Custom(errors.New("no impurities were found for the channel "+c.Name+" on the impurity table"), "fatal")
+		}
+
+		var total float64
+		for k, neighbour := range c.Neighbours {
+			total += v[k]
+			if neighbour >= 0 {
+				matrix[neighbour][j] += v[k] / 100
+			}
+		}
+
+		matrix[j][j] += 1 - (total / 100)
+	}
+
+	return matrix
+}
+
+// correctImpurities replaces the channel intensities by the non-negative solution of
+// the impurity system, the measured intensities are kept as the raw intensities
+func correctImpurities(labels map[string]iso.Labels, matrix [][]float64) map[string]iso.Labels {
+
+	for k, v := range labels {
+
+		if len(v.Channels) != len(matrix) {
+			continue
+		}
+
+		var observed = make([]float64, len(v.Channels))
+		for i := range v.Channels {
+			observed[i] = v.Channels[i].RawIntensity
+		}
+
+		corrected := nnls(matrix, observed)
+		for i := range v.Channels {
+			v.Channels[i].Intensity = corrected[i]
+		}
+
+		labels[k] = v
+	}
+
+	return labels
+}
+
+// nnls solves min ||Ax - b|| subject to x >= 0 with the Lawson-Hanson active set method
+func nnls(a [][]float64, b []float64) []float64 {
+
+	var m = len(a)
+	var n int
+	if m > 0 {
+		n = len(a[0])
+	}
+
+	var x = make([]float64, n)
+	var passive = make([]bool, n)
+
+	var scale float64
+	for _, i := range b {
+		scale = math.Max(scale, math.Abs(i))
+	}
+	tol := 1e-10 * math.Max(1, scale)
+
+	gradient := func() []float64 {
+		var r = make([]float64, m)
+		for i := 0; i < m; i++ {
+			r[i] = b[i]
+			for j := 0; j < n; j++ {
+				r[i] -= a[i][j] * x[j]
+			}
+		}
+		var w = make([]float64, n)
+		for j := 0; j < n; j++ {
+			for i := 0; i < m; i++ {
+				w[j] += a[i][j] * r[i]
+			}
+		}
+		return w
+	}
+
+	for iter := 0; iter < 3*n; iter++ {
+
+		w := gradient()
+
+		var index = -1
+		var best = tol
+		for j := 0; j < n; j++ {
+			if !passive[j] && w[j] > best {
+				index = j
+				best = w[j]
+			}
+		}
+
+		if index < 0 {
+			break
+		}
+		passive[index] = true
+
+		for {
+			z := passiveLeastSquares(a, b, passive)
+
+			var alpha = 1.0
+			var feasible = true
+			for j := 0; j < n; j++ {
+				if passive[j] && z[j] <= 0 {
+					feasible = false
+					if x[j]-z[j] > 0 {
+						alpha = math.Min(alpha, x[j]/(x[j]-z[j]))
+					}
+				}
+			}
+
+			if feasible {
+				copy(x, z)
+				break
+			}
+
+			for j := 0; j < n; j++ {
+				x[j] += alpha * (z[j] - x[j])
+				if passive[j] && x[j] <= tol {
+					x[j] = 0
+					passive[j] = false
+				}
+			}
+		}
+	}
+
+	return x
+}
+
+// passiveLeastSquares solves the unconstrained least squares on the passive columns, the other columns are zero
+func passiveLeastSquares(a [][]float64, b []float64, passive []bool) []float64 {
+
+	var cols []int
+	for j := range passive {
+		if passive[j] {
+			cols = append(cols, j)
+		}
+	}
+
+	// normal equations of the passive columns
+	var p = len(cols)
+	var system = make([][]float64, p)
+	var rhs = make([]float64, p)
+	for r := 0; r < p; r++ {
+		system[r] = make([]float64, p)
+		for c := 0; c < p; c++ {
+			for i := range a {
+				system[r][c] += a[i][cols[r]] * a[i][cols[c]]
+			}
+		}
+		for i := range a {
+			rhs[r] += a[i][cols[r]] * b[i]
+		}
+	}
+
+	var z = make([]float64, len(passive))
+	for r, v := range uti.Solve(system, rhs) {
+		z[cols[r]] = v
+	}
+
+	return z
+}
@@ -0,0 +1,124 @@
+package qua
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"philosopher/lib/iso"
+	"philosopher/lib/tmt"
+)
+
+func Test_readImpurityTable(t *testing.T) {
+
+	dir, _ := ioutil.TempDir("", "qua")
+	defer os.RemoveAll(dir)
+
+	simple := filepath.Join(dir, "simple.tsv")
+	ioutil.WriteFile(simple, []byte("126\t0\t0\t5.0\t0.2\n127N\t0\t0.5\t6.2\t0\n"), 0644)
+
+	impurities := readImpurityTable(simple)
+	if impurities["127N"] != [4]float64{0, 0.5, 6.2, 0} {
+		t.Errorf("Simple impurity table is incorrect, got %v", impurities["127N"])
+	}
+
+	vendor := filepath.Join(dir, "vendor.tsv")
+	ioutil.WriteFile(vendor, []byte("Mass Tag\t-2x13C\t-13C\t-15N\t+15N\t+13C\t+2x13C\n"+
+		"TMTpro-126\t-\t-\t-\t0.31%\t7.68%\t0.12%\n"+
+		"TMTpro-127C\t0.00%\t0.78%\t0.00%\t0.00%\t6.90%\t0.16%\n"), 0644)
+
+	impurities = readImpurityTable(vendor)
+	if impurities["126"] != [4]float64{0, 0, 7.68, 0.12} {
+		t.Errorf("Vendor impurity table is incorrect, got %v", impurities["126"])
+	}
+
+	if impurities["127C"] != [4]float64{0, 0.78, 6.90, 0.16} {
+		t.Errorf("Vendor impurity table is incorrect, got %v", impurities["127C"])
+	}
+}
+
+func Test_impurityMatrix(t *testing.T) {
+
+	// the table lists every channel, 127N is the only impure one
+	table := func(kit iso.Kit) map[string][4]float64 {
+		var impurities = make(map[string][4]float64)
+		for _, i := range kit.Channels {
+			impurities[channelKey(i.Name)] = [4]float64{}
+		}
+		impurities["127N"] = [4]float64{0, 0, 8, 0}
+		return impurities
+	}
+
+	kit, _ := tmt.Kit("6")
+	matrix := impurityMatrix(kit, table(kit))
+
+	if matrix[1][1] != 0.92 || matrix[1][0] != 0 {
+		t.Errorf("Impurity matrix diagonal is incorrect, got %v", matrix[1])
+	}
+
+	// the 6-plex 128 channel is 128C, it still receives the 127N +1 13C isotope
+	if matrix[0][0] != 1 || matrix[2][1] != 0.08 {
+		t.Errorf("Impurity matrix is incorrect, got %v", matrix)
+	}
+
+	kit, _ = tmt.Kit("10")
+	matrix = impurityMatrix(kit, table(kit))
+	if matrix[3][1] != 0.08 || matrix[4][1] != 0 {
+		t.Errorf("Impurity matrix neighbour is incorrect, got %f, want %f", matrix[3][1], 0.08)
+	}
+}
+
+func Test_channelKey(t *testing.T) {
+
+	for k, v := range map[string]string{"TMTpro-126C": "126", "TMT-131": "131N", "131N": "131N", "127_C": "127C", "iTRAQ 114": "114"} {
+		if got := channelKey(k); got != v {
+			t.Errorf("channelKey(%s) = %s, want %s", k, got, v)
+		}
+	}
+}
+
+func Test_nnls(t *testing.T) {
+
+	a := [][]float64{{0.9, 0.05, 0}, {0.1, 0.9, 0.05}, {0, 0.05, 0.95}}
+	x := []float64{100, 50, 0}
+
+	var b = make([]float64, 3)
+	for i := range a {
+		for j := range x {
+			b[i] += a[i][j] * x[j]
+		}
+	}
+
+	solution := nnls(a, b)
+	for i := range x {
+		if math.Abs(solution[i]-x[i]) > 1e-6 {
+			t.Errorf("NNLS solution is incorrect, got %v, want %v", solution, x)
+		}
+	}
+
+	// the unconstrained solution has a negative intensity
+	solution = nnls([][]float64{{1, 0.5}, {0, 1}}, []float64{1, 10})
+	if solution[0] != 0 || solution[1] <= 0 {
+		t.Errorf("NNLS solution is not constrained, got %v", solution)
+	}
+}
+
+func Test_correctImpurities(t *testing.T) {
+
+	labels := map[string]iso.Labels{
+		"00001": {Channels: []iso.Channel{{Name: "126", Intensity: 95, RawIntensity: 95}, {Name: "127", Intensity: 105, RawIntensity: 105}}},
+	}
+
+	labels = correctImpurities(labels, [][]float64{{0.95, 0}, {0.05, 1}})
+
+	c := labels["00001"].Channels
+	if math.Abs(c[0].Intensity-100) > 1e-6 || math.Abs(c[1].Intensity-100) > 1e-6 {
+		t.Errorf("Corrected intensities are incorrect, got %v", c)
+	}
+
+	if c[0].RawIntensity != 95 || c[1].RawIntensity != 105 {
+		t.Errorf("Raw intensities are incorrect, got %v", c)
+	}
+}
@@ -0,0 +1,117 @@
+package qua
+
+import (
+	"math"
+	"sort"
+
+	"philosopher/lib/bio"
+	"philosopher/lib/mzn"
+	"philosopher/lib/rep"
+)
+
+// minIsotopes is the number of isotopic peaks needed to score the isotope pattern
+const minIsotopes = 3
+
+// Envelope is the isotope pattern of a feature, compared against the
+// theoretical one from the peptide elemental composition
+type Envelope struct {
+	Correlation float64
+	Intensity   float64
+}
+
+// theoreticalEnvelope returns the m/z and the relative abundances of the first
+// isotopic peaks of a PSM
+func theoreticalEnvelope(psm rep.PSMEvidence, mz float64, isotopes int) ([]float64, []float64) {
+
+	unmodified := bio.PeptideFormula(psm.Peptide, 0)
+	formula := bio.PeptideFormula(psm.Peptide, psm.CalcNeutralPepMass-unmodified.MonoisotopicMass())
+
+	var mzValues []float64
+	for k := 0; k < isotopes; k++ {
+		mzValues = append(mzValues, mz+float64(k)*bio.C13Difference/float64(psm.AssumedCharge))
+	}
+
+	return mzValues, formula.IsotopeDistribution(isotopes)
+}
+
+// xicEnvelope extracts one ion chromatogram for each isotopic peak in a single
+// pass over the MS1 spectra, inside the mobility window of the ion
+func xicEnvelope(mz *mzn.MsData, minRT, maxRT, ppmPrecision float64, mzValues []float64, window mobilityWindow) []map[float64]float64 {
+
+	var traces = make([]map[float64]float64, len(mzValues))
+	for i := range traces {
+		traces[i] = make(map[float64]float64)
+	}
+
+	mz.MS1(minRT, maxRT, func(s mzn.Spectrum) {
+
+		for i, mzValue := range mzValues {
+
+			lowi := sort.Search(len(s.Mz.DecodedStream), func(i int) bool { return s.Mz.DecodedStream[i] >= mzValue-ppmPrecision*mzValue })
+			highi := sort.Search(len(s.Mz.DecodedStream), func(i int) bool { return s.Mz.DecodedStream[i] >= mzValue+ppmPrecision*mzValue })
+
+			var maxI = 0.0
+			for k := lowi; k < highi; k++ {
+				if s.Intensity.DecodedStream[k] > maxI && window.contains(s, k) {
+					maxI = s.Intensity.DecodedStream[k]
+				}
+			}
+
+			if maxI > 0 {
+				traces[i][s.ScanStartTime] = maxI
+			}
+		}
+	})
+
+	return traces
+}
+
+// scoreEnvelope takes the most intense point of each isotope trace inside the
+// retention time range and correlates them with the theoretical abundances
+func scoreEnvelope(traces []map[float64]float64, theoretical []float64, start, end float64) Envelope {
+
+	var envelope Envelope
+
+	var observed = make([]float64, len(traces))
+	for i := range traces {
+		for rt, intensity := range traces[i] {
+			if rt >= start && rt <= end && intensity > observed[i] {
+				observed[i] = intensity
+			}
+		}
+		envelope.Intensity += observed[i]
+	}
+
+	if len(observed) >= minIsotopes && len(observed) == len(theoretical) {
+		envelope.Correlation = pearson(observed, theoretical)
+	}
+
+	return envelope
+}
+
+// pearson returns the Pearson correlation coefficient between two series
+func pearson(x, y []float64) float64 {
+
+	n := float64(len(x))
+
+	var meanX, meanY float64
+	for i := range x {
+		meanX += x[i]
+		meanY += y[i]
+	}
+	meanX /= n
+	meanY /= n
+
+	var cov, varX, varY float64
+	for i := range x {
+		cov += (x[i] - meanX) * (y[i] - meanY)
+		varX += (x[i] - meanX) * (x[i] - meanX)
+		varY += (y[i] - meanY) * (y[i] - meanY)
+	}
+
+	if varX == 0 || varY == 0 {
+		return 0
+	}
+
+	return cov / math.Sqrt(varX*varY)
+}
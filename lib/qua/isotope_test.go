@@ -0,0 +1,57 @@
+package qua
+
+import (
+	"testing"
+
+	"philosopher/lib/rep"
+)
+
+func Test_scoreEnvelope(t *testing.T) {
+
+	theoretical := []float64{0.55, 0.3, 0.15}
+
+	var traces = []map[float64]float64{
+		{1.0: 550, 1.1: 1100, 1.2: 500, 3.0: 90000},
+		{1.0: 300, 1.1: 600, 1.2: 310},
+		{1.0: 140, 1.1: 300, 1.2: 160},
+	}
+
+	envelope := scoreEnvelope(traces, theoretical, 0.9, 1.3)
+	if envelope.Correlation < 0.99 {
+		t.Errorf("Isotope correlation is incorrect, got %f", envelope.Correlation)
+	}
+
+	if envelope.Intensity != 2000 {
+		t.Errorf("Summed isotope intensity is incorrect, got %f, want %f", envelope.Intensity, 2000.0)
+	}
+
+	// an interference on the second isotope
+	traces[1][1.1] = 5000
+
+	envelope = scoreEnvelope(traces, theoretical, 0.9, 1.3)
+	if envelope.Correlation > 0.8 {
+		t.Errorf("Interfered isotope correlation is too high, got %f", envelope.Correlation)
+	}
+}
+
+func Test_calculateIntensities_Interference(t *testing.T) {
+
+	var evi rep.Evidence
+	evi.PSM = rep.PSMEvidenceList{
+		{Spectrum: "run.00010.00010.2", Peptide: "PEPTIDEK", AssumedCharge: 2, CalcNeutralPepMass: 927.4549, Intensity: 1000},
+		{Spectrum: "run.00020.00020.2", Peptide: "PEPTIDEK", AssumedCharge: 2, CalcNeutralPepMass: 927.4549, Intensity: 3000, IsotopeInterference: true},
+	}
+	evi.Ions = rep.IonEvidenceList{{Sequence: "PEPTIDEK", ChargeState: 2, PeptideMass: 927.4549}}
+	evi.Peptides = rep.PeptideEvidenceList{{Sequence: "PEPTIDEK"}}
+
+	evi = calculateIntensities(evi)
+
+	// the interfered PSM is flagged, but still quantified
+	if evi.Peptides[0].Intensity != 4000 {
+		t.Errorf("Peptide intensity is incorrect, got %f, want %f", evi.Peptides[0].Intensity, 4000.0)
+	}
+
+	if evi.Ions[0].Intensity != 3000 || !evi.Ions[0].IsotopeInterference {
+		t.Errorf("Ion intensity is incorrect, got %f and interference %t", evi.Ions[0].Intensity, evi.Ions[0].IsotopeInterference)
+	}
+}
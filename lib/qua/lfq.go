@@ -32,7 +32,7 @@ func NewLFQ() LFQ {
 	return self
 }
 
-func peakIntensity(evi rep.Evidence, dir, format string, rTWin, pTWin, tol float64, isIso, isRaw, isFaims bool) rep.Evidence {
+func peakIntensity(evi rep.Evidence, dir, format string, rTWin, pTWin, tol, imTol, minIsoCorr float64, isotopes int, isIso, isRaw, isFaims bool) rep.Evidence {
 
 	logrus.Info("Indexing PSM information")
 
@@ -41,13 +41,13 @@ func peakIntensity(evi rep.Evidence, dir, format string, rTWin, pTWin, tol float
 	var spectra = make(map[string][]id.SpectrumType)
 	var ppmPrecision = make(map[id.SpectrumType]float64)
 	var mzMap = make(map[string]float64)
-	var mzCVMap = make(map[string]string)
 	var minRT = make(map[id.SpectrumType]float64)
 	var maxRT = make(map[id.SpectrumType]float64)
-	var compVoltageMap = make(map[id.SpectrumType]string)
+	var mobility = make(map[id.SpectrumType]mobilityWindow)
 	var retentionTime = make(map[id.SpectrumType]float64)
 	var intensity = make(map[id.SpectrumType]float64)
-	var instensityCV = make(map[id.SpectrumType]float64)
+	var peaks = make(map[id.SpectrumType]Peak)
+	var envelopes = make(map[id.SpectrumType]Envelope)
 
 	var charges = make(map[id.SpectrumType]int)
 
@@ -62,7 +62,7 @@ func peakIntensity(evi rep.Evidence, dir, format string, rTWin, pTWin, tol float
 		minRT[i.SpectrumFileName()] = (i.RetentionTime / 60) - rTWin
 		maxRT[i.SpectrumFileName()] = (i.RetentionTime / 60) + rTWin
 		retentionTime[i.SpectrumFileName()] = i.RetentionTime
-		compVoltageMap[i.SpectrumFileName()] = i.CompensationVoltage
+		mobility[i.SpectrumFileName()] = newMobilityWindow(i, isFaims, imTol)
 		charges[i.SpectrumFileName()] = int(i.AssumedCharge)
 		psmMap[i.SpectrumFileName()] = i
 	}
@@ -84,28 +84,18 @@ func peakIntensity(evi rep.Evidence, dir, format string, rTWin, pTWin, tol float
 		var fileName string
 
 		if isRaw {
-			//fileName = fmt.Sprintf("%s%s%s.raw", dir, string(filepath.Separator), s)
-			//stream := rawfilereader.Run(fileName, "")
-			//mz.ReadRaw(s, stream)
+			fileName = fmt.Sprintf("%s%s%s.raw", dir, string(filepath.Separator), s)
+			mz.IndexRaw(fileName)
 		} else {
 			fileName = fmt.Sprintf("%s%s%s.mzML", dir, string(filepath.Separator), s)
-			mz.Read(fileName)
+			mz.Index(fileName)
 		}
 
 		for i := range mz.Spectra {
 
 			spectrum := fmt.Sprintf("%s.%05s.%05s.%d", s, mz.Spectra[i].Scan, mz.Spectra[i].Scan, mz.Spectra[i].Precursor.ChargeState)
 
-			if mz.Spectra[i].Level == "1" {
-				if !isRaw {
-					mz.Spectra[i].Decode()
-				}
-
-				if isFaims {
-					mzCVMap[mz.Spectra[i].Scan] = mz.Spectra[i].CompensationVoltage
-				}
-
-			} else if mz.Spectra[i].Level == "2" {
+			if mz.Spectra[i].Level == "2" {
 				_, ok := mzMap[spectrum]
 				if ok {
 					mzMap[spectrum] = mz.Spectra[i].Precursor.TargetIon
@@ -126,50 +116,68 @@ func peakIntensity(evi rep.Evidence, dir, format string, rTWin, pTWin, tol float
 
 		v, ok := spectra[s]
 		if ok {
+
+			// the MS1 spectra are streamed in retention time order
+			sort.SliceStable(v, func(a, b int) bool { return minRT[v[a]] < minRT[v[b]] })
+
 			for _, j := range v {
 
-				measuredFaims, measured, retrieved := xic(mz.Spectra, minRT[j], maxRT[j], ppmPrecision[j], mzMap[j.Str()])
+				measured, retrieved := xic(&mz, minRT[j], maxRT[j], ppmPrecision[j], mzMap[j.Str()], mobility[j])
 
 				if retrieved {
 
 					var timeW = retentionTime[j] / 60
 					var topI = 0.0
-					var topCVI = 0.0
-					var ms2CompensationVoltage = compVoltageMap[j]
 
 					for k, v := range measured {
-
 						if k > (timeW-pTWin) && k < (timeW+pTWin) {
 							if v > topI {
 								topI = v
 							}
 						}
+					}
 
-						if isFaims {
-							v1, ok := measuredFaims[ms2CompensationVoltage]
-							if ok {
-								if v1 > topCVI {
-									topCVI = v1
-								}
-							}
-						}
+					// the intensity stays the apex inside the window, the detected peak apex is
+					// reported apart and delimits the isotope envelope scoring
+					start, end := timeW-pTWin, timeW+pTWin
+					if peak, ok := detectPeak(measured, timeW, pTWin); ok {
+						peaks[j] = peak
+						start, end = peak.Start/60, peak.End/60
+					}
+
+					if isotopes > 0 {
+						mzValues, theoretical := theoreticalEnvelope(psmMap[j], mzMap[j.Str()], isotopes)
+						traces := xicEnvelope(&mz, minRT[j], maxRT[j], ppmPrecision[j], mzValues, mobility[j])
+						envelopes[j] = scoreEnvelope(traces, theoretical, start, end)
 					}
 
 					intensity[j] = topI
-					instensityCV[j] = topCVI
 				}
 			}
 		}
+
+		mz.Close()
 	}
 
 	for i := range evi.PSM {
 		partName := strings.Split(evi.PSM[i].Spectrum, ".")
 		_, ok := spectra[partName[0]]
 		if ok {
-			if isFaims {
-				evi.PSM[i].Intensity = instensityCV[evi.PSM[i].SpectrumFileName()]
-			} else {
-				evi.PSM[i].Intensity = intensity[evi.PSM[i].SpectrumFileName()]
+			evi.PSM[i].Intensity = intensity[evi.PSM[i].SpectrumFileName()]
+
+			if peak, ok := peaks[evi.PSM[i].SpectrumFileName()]; ok {
+				evi.PSM[i].ApexRetentionTime = peak.Apex
+				evi.PSM[i].PeakStart = peak.Start
+				evi.PSM[i].PeakEnd = peak.End
+				evi.PSM[i].FWHM = peak.FWHM
+				evi.PSM[i].PeakArea = peak.Area
+				evi.PSM[i].PeakIntensity = peak.Intensity
+			}
+
+			if envelope, ok := envelopes[evi.PSM[i].SpectrumFileName()]; ok {
+				evi.PSM[i].IsotopeCorrelation = envelope.Correlation
+				evi.PSM[i].SummedIsotopeIntensity = envelope.Intensity
+				evi.PSM[i].IsotopeInterference = isotopes >= minIsotopes && envelope.Correlation < minIsoCorr
 			}
 		}
 
@@ -183,42 +191,39 @@ func peakIntensity(evi rep.Evidence, dir, format string, rTWin, pTWin, tol float
 	return evi
 }
 
-// xic extract ion chomatograms
-func xic(mz mzn.Spectra, minRT, maxRT, ppmPrecision, mzValue float64) (map[string]float64, map[float64]float64, bool) {
+// xic extract ion chomatograms, keeping the peaks inside the mobility window
+func xic(mz *mzn.MsData, minRT, maxRT, ppmPrecision, mzValue float64, window mobilityWindow) (map[float64]float64, bool) {
 
 	var list = make(map[float64]float64)
-	var ms1CompensationVoltage = make(map[string]float64)
 
-	for j := range mz {
-		if mz[j].Level == "1" {
-
-			if mz[j].ScanStartTime >= minRT && mz[j].ScanStartTime <= maxRT {
+	mz.MS1(minRT, maxRT, func(s mzn.Spectrum) {
+		if maxI := peakIntensityAt(s, mzValue, ppmPrecision, window); maxI > 0 {
+			list[s.ScanStartTime] = maxI
+		}
+	})
 
-				lowi := sort.Search(len(mz[j].Mz.DecodedStream), func(i int) bool { return mz[j].Mz.DecodedStream[i] >= mzValue-ppmPrecision*mzValue })
-				highi := sort.Search(len(mz[j].Mz.DecodedStream), func(i int) bool { return mz[j].Mz.DecodedStream[i] >= mzValue+ppmPrecision*mzValue })
+	if len(list) >= 5 {
+		return list, true
+	}
 
-				var maxI = 0.0
+	return list, false
+}
 
-				for _, k := range mz[j].Intensity.DecodedStream[lowi:highi] {
-					if k > maxI {
-						maxI = k
-					}
-				}
+// peakIntensityAt returns the most intense peak of the spectrum inside the m/z
+// tolerance and the mobility window
+func peakIntensityAt(s mzn.Spectrum, mzValue, ppmPrecision float64, window mobilityWindow) float64 {
 
-				if maxI > 0 {
-					list[mz[j].ScanStartTime] = maxI
-					ms1CompensationVoltage[mz[j].CompensationVoltage] = maxI
-				}
+	lowi := sort.Search(len(s.Mz.DecodedStream), func(i int) bool { return s.Mz.DecodedStream[i] >= mzValue-ppmPrecision*mzValue })
+	highi := sort.Search(len(s.Mz.DecodedStream), func(i int) bool { return s.Mz.DecodedStream[i] >= mzValue+ppmPrecision*mzValue })
 
-			}
+	var maxI float64
+	for i := lowi; i < highi; i++ {
+		if s.Intensity.DecodedStream[i] > maxI && window.contains(s, i) {
+			maxI = s.Intensity.DecodedStream[i]
 		}
 	}
 
-	if len(list) >= 5 {
-		return ms1CompensationVoltage, list, true
-	}
-
-	return ms1CompensationVoltage, list, false
+	return maxI
 }
 
 func calculateIntensities(e rep.Evidence) rep.Evidence {
@@ -231,7 +236,9 @@ func calculateIntensities(e rep.Evidence) rep.Evidence {
 
 	var peptideIntMap = make(map[string]float64)
 	var ionIntMap = make(map[id.IonFormType]float64)
+	var ionPeakMap = make(map[id.IonFormType]rep.PSMEvidence)
 
+	// the features with a poor isotope pattern are only flagged, they still count for the roll-up
 	for _, i := range e.PSM {
 
 		// peptide intensity : sum of all
@@ -247,9 +254,11 @@ func calculateIntensities(e rep.Evidence) rep.Evidence {
 		if ok {
 			if i.Intensity > ionV {
 				ionIntMap[i.IonForm()] = i.Intensity
+				ionPeakMap[i.IonForm()] = i
 			}
 		} else {
 			ionIntMap[i.IonForm()] = i.Intensity
+			ionPeakMap[i.IonForm()] = i
 		}
 
 	}
@@ -266,6 +275,22 @@ func calculateIntensities(e rep.Evidence) rep.Evidence {
 		if ok {
 			e.Ions[i].Intensity = v
 		}
+
+		// the ion peak is the one from the most intense PSM
+		p, ok := ionPeakMap[e.Ions[i].IonForm()]
+		if ok {
+			e.Ions[i].ApexRetentionTime = p.ApexRetentionTime
+			e.Ions[i].PeakStart = p.PeakStart
+			e.Ions[i].PeakEnd = p.PeakEnd
+			e.Ions[i].FWHM = p.FWHM
+			e.Ions[i].PeakArea = p.PeakArea
+			e.Ions[i].PeakIntensity = p.PeakIntensity
+			e.Ions[i].IsotopeCorrelation = p.IsotopeCorrelation
+			e.Ions[i].SummedIsotopeIntensity = p.SummedIsotopeIntensity
+			e.Ions[i].CoElutionScore = p.CoElutionScore
+			e.Ions[i].QuantifiedFragments = p.QuantifiedFragments
+			e.Ions[i].IsotopeInterference = p.IsotopeInterference
+		}
 	}
 
 	// protein intensities : top 3 most intense ions
@@ -0,0 +1,367 @@
+package qua
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"philosopher/lib/bio"
+	"philosopher/lib/id"
+	"philosopher/lib/met"
+	"philosopher/lib/msg"
+	"philosopher/lib/mzn"
+	"philosopher/lib/rep"
+	"philosopher/lib/rta"
+
+	"github.com/sirupsen/logrus"
+)
+
+// decoyMassShift moves the decoy transfers away from the target ion, it is not
+// a multiple of the isotope spacing so decoys land between real features
+const decoyMassShift = 8.5
+
+// Run is one workspace taking part in the match-between-runs
+type Run struct {
+	Name     string
+	Evidence rep.Evidence
+	Quantify met.Quantify
+}
+
+// Transfer is an ion quantified in a run where it was not identified, using
+// the m/z, charge and aligned retention time of the ion from a donor run
+type Transfer struct {
+	Ion           id.IonFormType
+	Donor         string
+	RetentionTime float64
+	Peak          Peak
+	Score         float64
+	Qvalue        float64
+	IsDecoy       bool
+	mobility      mobilityWindow
+}
+
+// feature is an identified ion in a run, the retention time is in seconds
+type feature struct {
+	psm           rep.PSMEvidence
+	retentionTime float64
+	source        string
+}
+
+// MatchBetweenRuns transfers the ions identified in one run to the runs where
+// they were not identified. The spectra files are aligned pairwise on their
+// shared ions, the XICs are extracted on each acceptor file at the aligned
+// retention time of the ion in the best aligned donor file, and decoy transfers
+// with shifted m/z estimate the transfer FDR. The window is the retention time
+// tolerance in minutes
+func MatchBetweenRuns(runs []Run, window, fdr float64) map[string]map[id.IonFormType]Transfer {
+
+	var transfers = make(map[string]map[id.IonFormType]Transfer)
+
+	var features = make(map[string]map[id.IonFormType]feature)
+	var fractions = make(map[string]map[string]map[id.IonFormType]feature)
+	for _, r := range runs {
+		features[r.Name] = collectFeatures(r.Evidence)
+		fractions[r.Name] = splitSources(features[r.Name])
+	}
+
+	for _, a := range runs {
+
+		if len(a.Quantify.Dir) == 0 {
+			msg.Custom(errors.New("skipping match-between-runs for "+a.Name+", the spectra directory is not set"), "warning")
+			continue
+		}
+
+		var sources []string
+		for k := range fractions[a.Name] {
+			sources = append(sources, k)
+		}
+		sort.Strings(sources)
+
+		var scored []Transfer
+
+		for _, source := range sources {
+
+			candidates := transferCandidates(a, features[a.Name], fractions[a.Name][source], runs, fractions)
+			if len(candidates) == 0 {
+				continue
+			}
+
+			logrus.Info("Matching ", len(candidates), " ions between runs on ", a.Name, " ", source)
+
+			scored = append(scored, extractTransfers(a.Quantify, source, candidates, window)...)
+		}
+
+		transfers[a.Name] = transferFDR(bestTransfers(scored), fdr)
+	}
+
+	return transfers
+}
+
+// collectFeatures takes the m/z and retention time of each identified ion from
+// its most intense PSM, or the best scoring one when there is no intensity
+func collectFeatures(e rep.Evidence) map[id.IonFormType]feature {
+
+	var features = make(map[id.IonFormType]feature)
+
+	for _, i := range e.PSM {
+
+		if i.IsDecoy || i.AssumedCharge == 0 {
+			continue
+		}
+
+		rt := i.RetentionTime
+		if i.ApexRetentionTime > 0 {
+			rt = i.ApexRetentionTime
+		}
+
+		f, ok := features[i.IonForm()]
+		if ok && (f.psm.Intensity > i.Intensity || (f.psm.Intensity == i.Intensity && f.psm.Probability >= i.Probability)) {
+			continue
+		}
+
+		features[i.IonForm()] = feature{
+			psm:           i,
+			retentionTime: rt,
+			source:        strings.Split(i.Spectrum, ".")[0],
+		}
+	}
+
+	return features
+}
+
+// alignRuns fits the piecewise-linear retention time mapping from the donor to
+// the acceptor run on their shared ions
+func alignRuns(donor, acceptor map[id.IonFormType]feature) (rta.Model, bool) {
+
+	var x, y []float64
+	for k, v := range donor {
+		if w, ok := acceptor[k]; ok {
+			x = append(x, v.retentionTime)
+			y = append(y, w.retentionTime)
+		}
+	}
+
+	return rta.Fit(x, y, rta.Linear, 0)
+}
+
+// splitSources groups the features of a run by the spectra file they were identified on
+func splitSources(features map[id.IonFormType]feature) map[string]map[id.IonFormType]feature {
+
+	var sources = make(map[string]map[id.IonFormType]feature)
+
+	for k, v := range features {
+		if _, ok := sources[v.source]; !ok {
+			sources[v.source] = make(map[id.IonFormType]feature)
+		}
+		sources[v.source][k] = v
+	}
+
+	return sources
+}
+
+// transferCandidates lists the ions missing in the acceptor run for one of its
+// spectra files, each taken from the donor file that shares the most ions with
+// it. The transfers keep the mobility window of the donor PSM
+func transferCandidates(acceptor Run, identified, fraction map[id.IonFormType]feature, runs []Run, fractions map[string]map[string]map[id.IonFormType]feature) []Transfer {
+
+	var candidates = make(map[id.IonFormType]Transfer)
+	var best = make(map[id.IonFormType]rta.Model)
+
+	for _, d := range runs {
+
+		if d.Name == acceptor.Name {
+			continue
+		}
+
+		for _, donor := range fractions[d.Name] {
+
+			al, ok := alignRuns(donor, fraction)
+			if !ok {
+				continue
+			}
+
+			for k, v := range donor {
+
+				if _, ok := identified[k]; ok {
+					continue
+				}
+
+				if b, ok := best[k]; ok && b.Points >= al.Points {
+					continue
+				}
+
+				best[k] = al
+				candidates[k] = Transfer{
+					Ion:           k,
+					Donor:         d.Name,
+					RetentionTime: al.Predict(v.retentionTime),
+					mobility:      newMobilityWindow(v.psm, acceptor.Quantify.Faims, acceptor.Quantify.IMTol),
+				}
+			}
+		}
+	}
+
+	var list []Transfer
+	for _, v := range candidates {
+		list = append(list, v)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].RetentionTime < list[j].RetentionTime })
+
+	return list
+}
+
+// extractTransfers traces the target and decoy ions in the acceptor spectra
+// and scores the peaks found by their isotope pattern and retention time error
+func extractTransfers(q met.Quantify, source string, candidates []Transfer, window float64) []Transfer {
+
+	var mz mzn.MsData
+
+	if q.Raw {
+		mz.IndexRaw(fmt.Sprintf("%s%s%s.raw", q.Dir, string(filepath.Separator), source))
+	} else {
+		mz.Index(fmt.Sprintf("%s%s%s.mzML", q.Dir, string(filepath.Separator), source))
+	}
+	defer mz.Close()
+
+	tol := q.Tol
+	if tol <= 0 {
+		tol = 10
+	}
+	ppmPrecision := tol / math.Pow(10, 6)
+
+	var scored []Transfer
+
+	for _, c := range candidates {
+
+		psm := rep.PSMEvidence{
+			Peptide:            c.Ion.Peptide,
+			CalcNeutralPepMass: float64(c.Ion.CalcNeutralPepMass),
+			AssumedCharge:      c.Ion.AssumedCharge,
+		}
+
+		rt := c.RetentionTime / 60
+		mzValue := (psm.CalcNeutralPepMass + float64(psm.AssumedCharge)*bio.Proton) / float64(psm.AssumedCharge)
+
+		for _, decoy := range []bool{false, true} {
+
+			target := mzValue
+			if decoy {
+				target += decoyMassShift / float64(psm.AssumedCharge)
+			}
+
+			mzValues, theoretical := theoreticalEnvelope(psm, target, minIsotopes)
+			traces := xicEnvelope(&mz, rt-window, rt+window, ppmPrecision, mzValues, c.mobility)
+
+			peak, score, ok := scoreTransfer(traces, theoretical, rt, window)
+			if !ok {
+				continue
+			}
+
+			t := c
+			t.Peak = peak
+			t.IsDecoy = decoy
+			t.Score = score
+
+			scored = append(scored, t)
+		}
+	}
+
+	return scored
+}
+
+// scoreTransfer detects the peak on the monoisotopic trace and scores it by the
+// isotope pattern correlation minus the retention time error relative to the window
+func scoreTransfer(traces []map[float64]float64, theoretical []float64, rt, window float64) (Peak, float64, bool) {
+
+	if len(traces) == 0 || len(traces[0]) < 5 {
+		return Peak{}, 0, false
+	}
+
+	peak, ok := detectPeak(traces[0], rt, window)
+	if !ok {
+		return Peak{}, 0, false
+	}
+
+	envelope := scoreEnvelope(traces, theoretical, peak.Start/60, peak.End/60)
+
+	return peak, envelope.Correlation - math.Abs(peak.Apex/60-rt)/window, true
+}
+
+// bestTransfers keeps the best scoring target and decoy of each ion, an ion
+// is extracted once for each spectra file of the acceptor run
+func bestTransfers(scored []Transfer) []Transfer {
+
+	var targets = make(map[id.IonFormType]Transfer)
+	var decoys = make(map[id.IonFormType]Transfer)
+
+	for _, i := range scored {
+
+		best := targets
+		if i.IsDecoy {
+			best = decoys
+		}
+
+		if b, ok := best[i.Ion]; !ok || i.Score > b.Score {
+			best[i.Ion] = i
+		}
+	}
+
+	var list []Transfer
+	for _, v := range targets {
+		list = append(list, v)
+	}
+	for _, v := range decoys {
+		list = append(list, v)
+	}
+
+	return list
+}
+
+// transferFDR estimates the q-value of each transfer from the decoy transfers
+// with a better score, and keeps the target transfers within the threshold
+func transferFDR(scored []Transfer, fdr float64) map[id.IonFormType]Transfer {
+
+	sort.SliceStable(scored, func(i, j int) bool { return scored[i].Score > scored[j].Score })
+
+	var targets, decoys float64
+	var qvalues = make([]float64, len(scored))
+
+	for i := range scored {
+
+		if scored[i].IsDecoy {
+			decoys++
+		} else {
+			targets++
+		}
+
+		if targets > 0 {
+			qvalues[i] = decoys / targets
+		} else {
+			qvalues[i] = 1
+		}
+	}
+
+	// the q-value is the lowest FDR at which the transfer is accepted
+	for i := len(qvalues) - 2; i >= 0; i-- {
+		if qvalues[i+1] < qvalues[i] {
+			qvalues[i] = qvalues[i+1]
+		}
+	}
+
+	var accepted = make(map[id.IonFormType]Transfer)
+	for i := range scored {
+
+		if scored[i].IsDecoy || qvalues[i] > fdr {
+			continue
+		}
+
+		scored[i].Qvalue = qvalues[i]
+		accepted[scored[i].Ion] = scored[i]
+	}
+
+	return accepted
+}
@@ -0,0 +1,130 @@
+package qua
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"philosopher/lib/bio"
+	"philosopher/lib/id"
+	"philosopher/lib/rep"
+)
+
+func Test_alignRuns(t *testing.T) {
+
+	var donor = make(map[id.IonFormType]feature)
+	var acceptor = make(map[id.IonFormType]feature)
+
+	// the acceptor run elutes 10% later with a 30 seconds delay
+	for i := 0; i < 50; i++ {
+		ion := id.IonFormType{Peptide: fmt.Sprintf("PEPTIDE%d", i), AssumedCharge: 2}
+		rt := 600 + float64(i)*60
+		donor[ion] = feature{retentionTime: rt}
+		acceptor[ion] = feature{retentionTime: rt*1.1 + 30}
+	}
+
+	al, ok := alignRuns(donor, acceptor)
+	if !ok {
+		t.Fatalf("alignRuns() failed with %d shared ions", len(donor))
+	}
+
+	if al.Points != 50 {
+		t.Errorf("Shared ions are incorrect, got %d, want %d", al.Points, 50)
+	}
+
+	for _, rt := range []float64{900, 1800, 3000} {
+		if p := al.Predict(rt); math.Abs(p-(rt*1.1+30)) > 1 {
+			t.Errorf("Aligned retention time is incorrect, got %f, want %f", p, rt*1.1+30)
+		}
+	}
+
+	if _, ok := alignRuns(donor, map[id.IonFormType]feature{}); ok {
+		t.Errorf("alignRuns() should fail without shared ions")
+	}
+}
+
+func Test_transferFDR(t *testing.T) {
+
+	var scored []Transfer
+	for i := 0; i < 10; i++ {
+		scored = append(scored, Transfer{Ion: id.IonFormType{Peptide: fmt.Sprintf("PEPTIDE%d", i)}, Score: 1 - float64(i)/10})
+	}
+
+	// a decoy scoring below the eighth target
+	scored = append(scored, Transfer{Ion: id.IonFormType{Peptide: "DECOY"}, Score: 0.25, IsDecoy: true})
+
+	accepted := transferFDR(scored, 0.01)
+	if len(accepted) != 8 {
+		t.Errorf("Accepted transfers are incorrect, got %d, want %d", len(accepted), 8)
+	}
+
+	if _, ok := accepted[id.IonFormType{Peptide: "DECOY"}]; ok {
+		t.Errorf("Decoy transfers should not be accepted")
+	}
+
+	accepted = transferFDR(scored, 0.2)
+	if len(accepted) != 10 {
+		t.Errorf("Accepted transfers are incorrect, got %d, want %d", len(accepted), 10)
+	}
+}
+
+func Test_scoreTransfer(t *testing.T) {
+
+	psm := rep.PSMEvidence{Peptide: "LVNELTEFAK", CalcNeutralPepMass: 1162.6234, AssumedCharge: 2}
+	mzValue := (psm.CalcNeutralPepMass + 2*bio.Proton) / 2
+	_, theoretical := theoreticalEnvelope(psm, mzValue, minIsotopes)
+
+	// the target elutes at 30 minutes with its isotope pattern, the decoy m/z
+	// picks up an unrelated feature eluting one minute later
+	var target = make([]map[float64]float64, minIsotopes)
+	var decoy = make([]map[float64]float64, minIsotopes)
+	for i := 0; i < minIsotopes; i++ {
+		target[i] = make(map[float64]float64)
+		decoy[i] = make(map[float64]float64)
+	}
+
+	for s := 0; s < 41; s++ {
+		rt := 29 + float64(s)*0.05
+		target[0][rt] = 1e6 * math.Exp(-math.Pow(rt-30, 2)/0.02)
+		for i := range theoretical {
+			target[i][rt] = target[0][rt] * theoretical[i] / theoretical[0]
+			decoy[i][rt] = 1e5 * math.Exp(-math.Pow(rt-31, 2)/0.02) * float64(i+1)
+		}
+	}
+
+	_, targetScore, ok := scoreTransfer(target, theoretical, 30, 1)
+	if !ok || targetScore < 0.9 {
+		t.Errorf("Target transfer score is incorrect, got %f", targetScore)
+	}
+
+	_, decoyScore, ok := scoreTransfer(decoy, theoretical, 30, 1)
+	if !ok || decoyScore >= targetScore {
+		t.Errorf("Decoy transfer is not separated, got %f, target %f", decoyScore, targetScore)
+	}
+
+	if _, _, ok := scoreTransfer([]map[float64]float64{{30: 1e6}}, theoretical, 30, 1); ok {
+		t.Errorf("scoreTransfer() should fail without a chromatogram")
+	}
+}
+
+func Test_bestTransfers(t *testing.T) {
+
+	ion := id.IonFormType{Peptide: "PEPTIDE", AssumedCharge: 2}
+
+	best := bestTransfers([]Transfer{
+		{Ion: ion, Score: 0.5},
+		{Ion: ion, Score: 0.9},
+		{Ion: ion, Score: 0.7, IsDecoy: true},
+		{Ion: ion, Score: 0.2, IsDecoy: true},
+	})
+
+	if len(best) != 2 {
+		t.Fatalf("Best transfers are incorrect, got %d, want %d", len(best), 2)
+	}
+
+	for _, i := range best {
+		if (!i.IsDecoy && i.Score != 0.9) || (i.IsDecoy && i.Score != 0.7) {
+			t.Errorf("Best transfer is incorrect, got %v", i)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package qua
+
+import (
+	"math"
+	"strconv"
+
+	"philosopher/lib/mzn"
+	"philosopher/lib/rep"
+)
+
+// cvTolerance matches the FAIMS compensation voltages, they are set in discrete steps
+const cvTolerance = 0.5
+
+// mobilityWindow restricts the extracted ion chromatograms to the ion mobility
+// of the PSM. FAIMS separates the ions by the compensation voltage of each MS1
+// spectrum and trapped ion mobility by the 1/K0 of each peak, both are handled
+// as a mobility value with a tolerance. A zero tolerance accepts every peak
+type mobilityWindow struct {
+	faims     bool
+	center    float64
+	tolerance float64
+}
+
+// newMobilityWindow builds the window around the compensation voltage of the
+// PSM when FAIMS is used, or around its 1/K0 when a mobility tolerance is set
+func newMobilityWindow(psm rep.PSMEvidence, isFaims bool, imTol float64) mobilityWindow {
+
+	var w mobilityWindow
+
+	if isFaims {
+		if cv, e := strconv.ParseFloat(psm.CompensationVoltage, 64); e == nil {
+			w.faims = true
+			w.center = cv
+			w.tolerance = cvTolerance
+		}
+	} else if imTol > 0 && psm.IonMobility > 0 {
+		w.center = psm.IonMobility
+		w.tolerance = imTol
+	}
+
+	return w
+}
+
+// mobility returns the ion mobility of a peak, false when the spectrum carries no mobility information
+func (w mobilityWindow) mobility(s mzn.Spectrum, i int) (float64, bool) {
+
+	if w.faims {
+		cv, e := strconv.ParseFloat(s.CompensationVoltage, 64)
+		return cv, e == nil
+	}
+
+	if i < len(s.IonMobility.DecodedStream) {
+		return s.IonMobility.DecodedStream[i], true
+	}
+
+	return 0, false
+}
+
+// contains checks if a peak is inside the mobility window, the peaks without
+// mobility information are kept
+func (w mobilityWindow) contains(s mzn.Spectrum, i int) bool {
+
+	if w.tolerance <= 0 {
+		return true
+	}
+
+	m, ok := w.mobility(s, i)
+	if !ok {
+		return true
+	}
+
+	return math.Abs(m-w.center) <= w.tolerance
+}
@@ -0,0 +1,43 @@
+package qua
+
+import (
+	"testing"
+
+	"philosopher/lib/mzn"
+	"philosopher/lib/rep"
+)
+
+func Test_mobilityWindow(t *testing.T) {
+
+	var s mzn.Spectrum
+	s.CompensationVoltage = "-45"
+	s.Mz.DecodedStream = []float64{500.1, 500.2, 500.3}
+	s.IonMobility.DecodedStream = []float64{0.85, 0.95, 1.10}
+
+	psm := rep.PSMEvidence{CompensationVoltage: "-45", IonMobility: 0.96}
+
+	// trapped ion mobility keeps the peaks close to the PSM 1/K0
+	w := newMobilityWindow(psm, false, 0.05)
+	for i, want := range []bool{false, true, false} {
+		if got := w.contains(s, i); got != want {
+			t.Errorf("Peak %d mobility check is incorrect, got %t, want %t", i, got, want)
+		}
+	}
+
+	// FAIMS keeps the spectra acquired at the PSM compensation voltage
+	w = newMobilityWindow(psm, true, 0.05)
+	if !w.contains(s, 0) {
+		t.Errorf("Spectrum at the PSM compensation voltage should be kept")
+	}
+
+	s.CompensationVoltage = "-60"
+	if w.contains(s, 0) {
+		t.Errorf("Spectrum at another compensation voltage should be left out")
+	}
+
+	// without a tolerance every peak is kept
+	w = newMobilityWindow(psm, false, 0)
+	if !w.contains(s, 2) {
+		t.Errorf("Peaks should be kept when the mobility window is disabled")
+	}
+}
@@ -0,0 +1,495 @@
+package qua
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"philosopher/lib/bio"
+	"philosopher/lib/id"
+	"philosopher/lib/met"
+	"philosopher/lib/mod"
+	"philosopher/lib/msg"
+	"philosopher/lib/mzn"
+	"philosopher/lib/rep"
+	"philosopher/lib/uti"
+
+	"github.com/sirupsen/logrus"
+)
+
+// labelTolerance matches the assigned modifications to the label mass shifts, in Daltons
+const labelTolerance = 0.01
+
+// nTerm is the site of the labels added to the peptide N-terminus
+const nTerm = "N-term"
+
+// labelChannel is one partner of an MS1 label scheme, the mass shifts are
+// added to each labelled residue and to the peptide N-terminus
+type labelChannel struct {
+	name   string
+	shifts map[string]float64
+}
+
+// labelSchemes are the SILAC and dimethyl schemes, with the light, medium and heavy
+// channels. The shifts select the label modifications of the search, the partner
+// masses are taken from the search modifications
+var labelSchemes = map[string][]labelChannel{
+	"silac-KR8": {
+		{"light", map[string]float64{"K": 0, "R": 0}},
+		{"heavy", map[string]float64{"K": 8.014199, "R": 10.008269}},
+	},
+	"silac-KR4-KR8": {
+		{"light", map[string]float64{"K": 0, "R": 0}},
+		{"medium", map[string]float64{"K": 4.025107, "R": 6.020129}},
+		{"heavy", map[string]float64{"K": 8.014199, "R": 10.008269}},
+	},
+	"dimethyl-LH": {
+		{"light", map[string]float64{"K": 28.0313, nTerm: 28.0313}},
+		{"heavy", map[string]float64{"K": 36.0757, nTerm: 36.0757}},
+	},
+	"dimethyl-LMH": {
+		{"light", map[string]float64{"K": 28.0313, nTerm: 28.0313}},
+		{"medium", map[string]float64{"K": 32.0564, nTerm: 32.0564}},
+		{"heavy", map[string]float64{"K": 36.0757, nTerm: 36.0757}},
+	},
+}
+
+// labelSite is a labelled residue of the peptide, the N-terminus has position zero
+type labelSite struct {
+	residue  string
+	position int
+}
+
+// labelPair is the light/heavy pair of a PSM, named after its light partner, and
+// the channel the PSM was identified on
+type labelPair struct {
+	key     string
+	channel int
+}
+
+// RunMS1LabelQuantification is the top function for the SILAC and dimethyl
+// quantification, the partners of each PSM are traced on the MS1 spectra
+func RunMS1LabelQuantification(p met.Quantify) met.Quantify {
+
+	scheme, ok := labelSchemes[p.Labels]
+	if !ok {
+		var names []string
+		for k := range labelSchemes {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		msg.InputNotFound(errors.New("unknown label scheme "+p.Labels+", use one of "+strings.Join(names, ", ")), "fatal")
+	}
+
+	var evi rep.Evidence
+	evi.RestoreGranular()
+	evi.RestoreSearch()
+
+	scheme = searchLabelShifts(scheme, evi.Mods)
+
+	// a missing retention time window falls back to the peak window
+	if p.RTWin <= 0 {
+		p.RTWin = p.PTWin
+	}
+
+	evi = partnerIntensities(evi, scheme, p)
+
+	evi = rollUpMS1Labels(evi, scheme, p.Unique)
+
+	evi.SerializeGranular()
+
+	return p
+}
+
+// searchLabelShifts replaces the label shifts of the scheme with the mass differences
+// of the matching search modifications, every labelled channel must be searched
+func searchLabelShifts(scheme []labelChannel, mods mod.Modifications) []labelChannel {
+
+	var channels []labelChannel
+
+	for _, c := range scheme {
+
+		channel := labelChannel{name: c.name, shifts: make(map[string]float64)}
+
+		for residue, shift := range c.shifts {
+
+			if shift == 0 {
+				channel.shifts[residue] = 0
+				continue
+			}
+
+			var found bool
+			var distance = labelTolerance
+			for _, m := range mods.Index {
+				if !strings.EqualFold(m.AminoAcid, residue) {
+					continue
+				}
+				if d := math.Abs(m.MassDiff - shift); d <= distance {
+					channel.shifts[residue] = m.MassDiff
+					distance = d
+					found = true
+				}
+			}
+
+			if !found {
+				msg.Custom(fmt.Errorf("the %s %s label (%.4f) is not one of the search modifications", c.name, residue, shift), "fatal")
+			}
+		}
+
+		channels = append(channels, channel)
+	}
+
+	return channels
+}
+
+// labelSites lists the residues and the N-terminus carrying the labels of the scheme
+func labelSites(peptide string, scheme []labelChannel) []labelSite {
+
+	var sites []labelSite
+
+	if _, ok := scheme[0].shifts[nTerm]; ok {
+		sites = append(sites, labelSite{nTerm, 0})
+	}
+
+	for i, aa := range strings.Split(peptide, "") {
+		if _, ok := scheme[0].shifts[aa]; ok {
+			sites = append(sites, labelSite{aa, i + 1})
+		}
+	}
+
+	return sites
+}
+
+// identifiedChannel finds the channel of the PSM from the assigned modifications
+// on its labelled sites, false when the modifications match no channel
+func identifiedChannel(psm rep.PSMEvidence, scheme []labelChannel, sites []labelSite) (int, bool) {
+
+	var assigned = make(map[int]float64)
+	for _, m := range psm.Modifications.IndexSlice {
+		if m.Type != mod.Assigned {
+			continue
+		}
+		if m.AminoAcid == nTerm {
+			assigned[0] += m.MassDiff
+		} else if m.AminoAcid != "C-term" {
+			assigned[m.Position] += m.MassDiff
+		}
+	}
+
+	for c, channel := range scheme {
+
+		var matched = true
+		for _, s := range sites {
+			if math.Abs(assigned[s.position]-channel.shifts[s.residue]) > labelTolerance {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			return c, true
+		}
+	}
+
+	return 0, false
+}
+
+// partnerMasses calculates the neutral mass of the peptide in each channel of
+// the scheme, swapping the labels of the identified channel on every site
+func partnerMasses(mass float64, scheme []labelChannel, sites []labelSite, identified int) []float64 {
+
+	var masses = make([]float64, len(scheme))
+
+	for c, channel := range scheme {
+		masses[c] = mass
+		for _, s := range sites {
+			masses[c] += channel.shifts[s.residue] - scheme[identified].shifts[s.residue]
+		}
+	}
+
+	return masses
+}
+
+// partnerIntensities traces the light, medium and heavy partners of each PSM
+// around its retention time, using the same XIC and peak detection as freequant
+func partnerIntensities(evi rep.Evidence, scheme []labelChannel, p met.Quantify) rep.Evidence {
+
+	var sourceMap = make(map[string][]int)
+	var partners = make(map[id.SpectrumType][]float64)
+
+	for i := range evi.PSM {
+
+		evi.PSM[i].MS1Labels = nil
+
+		if evi.PSM[i].IsDecoy || evi.PSM[i].AssumedCharge == 0 || evi.PSM[i].Probability < p.MinProb {
+			continue
+		}
+
+		source := strings.Split(evi.PSM[i].Spectrum, ".")[0]
+		sourceMap[source] = append(sourceMap[source], i)
+	}
+
+	var sourceList []string
+	for k := range sourceMap {
+		sourceList = append(sourceList, k)
+	}
+
+	sort.Strings(sourceList)
+
+	ppmPrecision := p.Tol / math.Pow(10, 6)
+
+	logrus.Info("Reading spectra and tracing the label partners")
+
+	for _, s := range sourceList {
+
+		logrus.Info("Processing ", s)
+
+		var mz mzn.MsData
+		if p.Raw {
+			mz.IndexRaw(fmt.Sprintf("%s%s%s.raw", p.Dir, string(filepath.Separator), s))
+		} else {
+			mz.Index(fmt.Sprintf("%s%s%s.mzML", p.Dir, string(filepath.Separator), s))
+		}
+
+		// the MS1 spectra are streamed in retention time order
+		v := sourceMap[s]
+		sort.SliceStable(v, func(a, b int) bool { return evi.PSM[v[a]].RetentionTime < evi.PSM[v[b]].RetentionTime })
+
+		for _, i := range v {
+
+			psm := evi.PSM[i]
+
+			sites := labelSites(psm.Peptide, scheme)
+			if len(sites) == 0 {
+				continue
+			}
+
+			identified, ok := identifiedChannel(psm, scheme, sites)
+			if !ok {
+				continue
+			}
+
+			rt := psm.RetentionTime / 60
+			window := newMobilityWindow(psm, p.Faims, p.IMTol)
+
+			var intensities = make([]float64, len(scheme))
+			for c, mass := range partnerMasses(psm.CalcNeutralPepMass, scheme, sites, identified) {
+
+				mzValue := (mass + float64(psm.AssumedCharge)*bio.Proton) / float64(psm.AssumedCharge)
+
+				measured, retrieved := xic(&mz, rt-p.RTWin, rt+p.RTWin, ppmPrecision, mzValue, window)
+				if !retrieved {
+					continue
+				}
+
+				if peak, ok := detectPeak(measured, rt, p.PTWin); ok {
+					intensities[c] = peak.Intensity
+					continue
+				}
+
+				for k, v := range measured {
+					if k > (rt-p.PTWin) && k < (rt+p.PTWin) && v > intensities[c] {
+						intensities[c] = v
+					}
+				}
+			}
+
+			partners[psm.SpectrumFileName()] = intensities
+		}
+
+		mz.Close()
+	}
+
+	for i := range evi.PSM {
+		if v, ok := partners[evi.PSM[i].SpectrumFileName()]; ok {
+			l := assignChannels(scheme, v)
+			evi.PSM[i].MS1Labels = &l
+		}
+	}
+
+	return evi
+}
+
+// assignChannels places the partner intensities on the light, medium and heavy labels
+func assignChannels(scheme []labelChannel, intensities []float64) rep.MS1Labels {
+
+	var l rep.MS1Labels
+
+	for c, channel := range scheme {
+		switch channel.name {
+		case "light":
+			l.Light = intensities[c]
+		case "medium":
+			l.Medium = intensities[c]
+		case "heavy":
+			l.Heavy = intensities[c]
+		}
+	}
+
+	labelRatios(&l)
+
+	return l
+}
+
+// labelRatios calculates the medium and heavy ratios to the light partner
+func labelRatios(l *rep.MS1Labels) {
+
+	l.MediumLight = 0
+	l.HeavyLight = 0
+
+	if l.Light > 0 {
+		l.MediumLight = l.Medium / l.Light
+		l.HeavyLight = l.Heavy / l.Light
+	}
+}
+
+// psmLabelPair finds the light/heavy pair of a PSM, PSMs identified on any
+// channel of the pair trace the same partner features
+func psmLabelPair(psm rep.PSMEvidence, scheme []labelChannel) labelPair {
+
+	sites := labelSites(psm.Peptide, scheme)
+	identified, _ := identifiedChannel(psm, scheme, sites)
+	light := partnerMasses(psm.CalcNeutralPepMass, scheme, sites, identified)[0]
+
+	return labelPair{fmt.Sprintf("%s#%d#%.2f", psm.Peptide, psm.AssumedCharge, light), identified}
+}
+
+// rollUpMS1Labels sums the partner intensities of the PSMs on each ion, peptide
+// and protein, counting each light/heavy pair once. The protein ratios are the
+// median of their unique and razor pair ratios
+func rollUpMS1Labels(evi rep.Evidence, scheme []labelChannel, uniqueOnly bool) rep.Evidence {
+
+	var spectrumMap = make(map[id.SpectrumType]rep.MS1Labels)
+	var pairMap = make(map[id.SpectrumType]labelPair)
+	for _, i := range evi.PSM {
+		if i.MS1Labels != nil {
+			spectrumMap[i.SpectrumFileName()] = *i.MS1Labels
+			pairMap[i.SpectrumFileName()] = psmLabelPair(i, scheme)
+		}
+	}
+
+	// the PSMs identified on the same channel are summed, and the pair keeps
+	// the most intense of its identified channels
+	pairLabels := func(spectra []id.SpectrumType) map[string]rep.MS1Labels {
+
+		var forms = make(map[labelPair]rep.MS1Labels)
+		for _, s := range spectra {
+			if l, ok := spectrumMap[s]; ok {
+				f := forms[pairMap[s]]
+				f.Light += l.Light
+				f.Medium += l.Medium
+				f.Heavy += l.Heavy
+				forms[pairMap[s]] = f
+			}
+		}
+
+		var pairs = make(map[string]rep.MS1Labels)
+		var channels = make(map[string]int)
+		for k, v := range forms {
+			p, ok := pairs[k.key]
+			sum := v.Light + v.Medium + v.Heavy
+			best := p.Light + p.Medium + p.Heavy
+			if !ok || sum > best || (sum == best && k.channel < channels[k.key]) {
+				pairs[k.key] = v
+				channels[k.key] = k.channel
+			}
+		}
+
+		return pairs
+	}
+
+	sumLabels := func(pairs map[string]rep.MS1Labels) *rep.MS1Labels {
+
+		if len(pairs) == 0 {
+			return nil
+		}
+
+		var sum rep.MS1Labels
+		for _, l := range pairs {
+			sum.Light += l.Light
+			sum.Medium += l.Medium
+			sum.Heavy += l.Heavy
+		}
+
+		labelRatios(&sum)
+
+		return &sum
+	}
+
+	for i := range evi.Ions {
+		var spectra []id.SpectrumType
+		for s := range evi.Ions[i].Spectra {
+			spectra = append(spectra, s)
+		}
+		evi.Ions[i].MS1Labels = sumLabels(pairLabels(spectra))
+	}
+
+	for i := range evi.Peptides {
+		var spectra []id.SpectrumType
+		for s := range evi.Peptides[i].Spectra {
+			spectra = append(spectra, s)
+		}
+		evi.Peptides[i].MS1Labels = sumLabels(pairLabels(spectra))
+	}
+
+	for i := range evi.Proteins {
+
+		evi.Proteins[i].MS1Labels = nil
+
+		var spectra []id.SpectrumType
+
+		for _, ion := range evi.Proteins[i].TotalPeptideIons {
+
+			if uniqueOnly && !ion.IsUnique {
+				continue
+			}
+
+			if !ion.IsUnique && !ion.IsURazor {
+				continue
+			}
+
+			for s := range ion.Spectra {
+				spectra = append(spectra, s)
+			}
+		}
+
+		pairs := pairLabels(spectra)
+
+		l := sumLabels(pairs)
+		if l == nil {
+			continue
+		}
+
+		var mediumRatios, heavyRatios []float64
+		for _, p := range pairs {
+
+			if p.Light > 0 && p.Medium > 0 {
+				mediumRatios = append(mediumRatios, math.Log2(p.Medium/p.Light))
+			}
+
+			if p.Light > 0 && p.Heavy > 0 {
+				heavyRatios = append(heavyRatios, math.Log2(p.Heavy/p.Light))
+			}
+		}
+
+		l.MediumLight = medianRatio(mediumRatios)
+		l.HeavyLight = medianRatio(heavyRatios)
+
+		evi.Proteins[i].MS1Labels = l
+	}
+
+	return evi
+}
+
+// medianRatio returns the median of the log2 ratios as a ratio, zero when there are none
+func medianRatio(logRatios []float64) float64 {
+
+	if len(logRatios) == 0 {
+		return 0
+	}
+
+	return math.Pow(2, uti.Median(logRatios))
+}
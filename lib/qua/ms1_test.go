@@ -0,0 +1,127 @@
+package qua
+
+import (
+	"math"
+	"testing"
+
+	"philosopher/lib/id"
+	"philosopher/lib/mod"
+	"philosopher/lib/rep"
+)
+
+func Test_partnerMasses(t *testing.T) {
+
+	scheme := labelSchemes["silac-KR4-KR8"]
+
+	// a heavy peptide with one lysine and one arginine
+	psm := rep.PSMEvidence{
+		Peptide:            "PEPKTIDER",
+		CalcNeutralPepMass: 1000,
+		Modifications: mod.ModificationsSlice{IndexSlice: []mod.Modification{
+			{AminoAcid: "K", Position: 4, MassDiff: 8.014199, Type: mod.Assigned},
+			{AminoAcid: "R", Position: 9, MassDiff: 10.008269, Type: mod.Assigned},
+		}},
+	}
+
+	sites := labelSites(psm.Peptide, scheme)
+	if len(sites) != 2 {
+		t.Fatalf("Label sites are incorrect, got %d, want %d", len(sites), 2)
+	}
+
+	identified, ok := identifiedChannel(psm, scheme, sites)
+	if !ok || scheme[identified].name != "heavy" {
+		t.Fatalf("identifiedChannel() failed to find the heavy channel")
+	}
+
+	want := []float64{1000 - 8.014199 - 10.008269, 1000 - 8.014199 - 10.008269 + 4.025107 + 6.020129, 1000}
+	for c, m := range partnerMasses(psm.CalcNeutralPepMass, scheme, sites, identified) {
+		if math.Abs(m-want[c]) > 1e-6 {
+			t.Errorf("Partner mass of the %s channel is incorrect, got %f, want %f", scheme[c].name, m, want[c])
+		}
+	}
+
+	// a lysine with an unrelated modification matches no channel
+	psm.Modifications.IndexSlice[0].MassDiff = 42.0106
+	if _, ok := identifiedChannel(psm, scheme, sites); ok {
+		t.Errorf("identifiedChannel() should fail with an unknown label mass")
+	}
+}
+
+func Test_rollUpMS1Labels(t *testing.T) {
+
+	var evi rep.Evidence
+
+	for _, s := range []string{"a.00001.00001.2", "a.00002.00002.2"} {
+		evi.PSM = append(evi.PSM, rep.PSMEvidence{Spectrum: s, MS1Labels: &rep.MS1Labels{Light: 100, Heavy: 200}})
+	}
+
+	ion := rep.IonEvidence{IsUnique: true, Spectra: make(map[id.SpectrumType]int)}
+	for _, i := range evi.PSM {
+		ion.Spectra[i.SpectrumFileName()] = 0
+	}
+
+	evi.Ions = append(evi.Ions, ion)
+	evi.Proteins = append(evi.Proteins, rep.ProteinEvidence{TotalPeptideIons: map[id.IonFormType]rep.IonEvidence{{Peptide: "PEPKTIDER"}: ion}})
+
+	evi = rollUpMS1Labels(evi, labelSchemes["silac-KR8"], false)
+
+	if evi.Ions[0].MS1Labels == nil || evi.Ions[0].MS1Labels.Heavy != 400 || evi.Ions[0].MS1Labels.HeavyLight != 2 {
+		t.Errorf("Ion labels are incorrect, got %+v", evi.Ions[0].MS1Labels)
+	}
+
+	if evi.Proteins[0].MS1Labels == nil || evi.Proteins[0].MS1Labels.HeavyLight != 2 {
+		t.Errorf("Protein labels are incorrect, got %+v", evi.Proteins[0].MS1Labels)
+	}
+}
+
+func Test_rollUpMS1Labels_Pairs(t *testing.T) {
+
+	scheme := labelSchemes["silac-KR8"]
+
+	// the light and heavy forms of the same pair were both identified
+	light := rep.PSMEvidence{Spectrum: "a.00001.00001.2", Peptide: "PEPTIDEK", AssumedCharge: 2, CalcNeutralPepMass: 1000, MS1Labels: &rep.MS1Labels{Light: 100, Heavy: 300}}
+	heavy := rep.PSMEvidence{Spectrum: "a.00002.00002.2", Peptide: "PEPTIDEK", AssumedCharge: 2, CalcNeutralPepMass: 1000 + 8.014199, MS1Labels: &rep.MS1Labels{Light: 110, Heavy: 320},
+		Modifications: mod.ModificationsSlice{IndexSlice: []mod.Modification{{AminoAcid: "K", Position: 8, MassDiff: 8.014199, Type: mod.Assigned}}}}
+
+	other := rep.PSMEvidence{Spectrum: "a.00003.00003.2", Peptide: "ANOTHERK", AssumedCharge: 2, CalcNeutralPepMass: 900, MS1Labels: &rep.MS1Labels{Light: 100, Heavy: 100}}
+
+	var evi rep.Evidence
+	evi.PSM = rep.PSMEvidenceList{light, heavy, other}
+
+	var ions = make(map[id.IonFormType]rep.IonEvidence)
+	for _, i := range evi.PSM {
+		ion := rep.IonEvidence{IsUnique: true, Spectra: map[id.SpectrumType]int{i.SpectrumFileName(): 0}}
+		ions[i.IonForm()] = ion
+	}
+	evi.Proteins = append(evi.Proteins, rep.ProteinEvidence{TotalPeptideIons: ions})
+
+	evi = rollUpMS1Labels(evi, scheme, false)
+
+	l := evi.Proteins[0].MS1Labels
+	if l == nil || l.Light != 210 || l.Heavy != 420 {
+		t.Errorf("Protein labels should count each pair once, got %+v", l)
+	}
+
+	if l != nil && math.Abs(l.HeavyLight-math.Sqrt(320.0/110)) > 1e-6 {
+		t.Errorf("Protein ratio is incorrect, got %f, want %f", l.HeavyLight, math.Sqrt(320.0/110))
+	}
+}
+
+func Test_searchLabelShifts(t *testing.T) {
+
+	mods := mod.Modifications{Index: map[string]mod.Modification{
+		"K#136.1092": {AminoAcid: "K", MassDiff: 8.0142},
+		"R#166.1094": {AminoAcid: "R", MassDiff: 10.0083},
+		"M#147.0354": {AminoAcid: "M", MassDiff: 15.9949},
+	}}
+
+	scheme := searchLabelShifts(labelSchemes["silac-KR8"], mods)
+
+	if scheme[0].shifts["K"] != 0 || scheme[1].shifts["K"] != 8.0142 || scheme[1].shifts["R"] != 10.0083 {
+		t.Errorf("Label shifts are incorrect, got %v", scheme)
+	}
+
+	if labelSchemes["silac-KR8"][1].shifts["K"] != 8.014199 {
+		t.Errorf("searchLabelShifts() should not change the scheme definitions")
+	}
+}
@@ -0,0 +1,221 @@
+package qua
+
+import (
+	"errors"
+	"math"
+
+	"philosopher/lib/iso"
+	"philosopher/lib/msg"
+	"philosopher/lib/rep"
+	"philosopher/lib/uti"
+)
+
+// channel normalization methods
+const (
+	normNone      = "none"
+	normTotal     = "total"
+	normReference = "reference"
+	normMean      = "mean"
+	normMedian    = "median"
+	normLog2      = "log2-median"
+)
+
+// checkNormalization verifies the normalization method before the quantification starts
+func checkNormalization(method string, references []string) {
+
+	switch method {
+	case "", normNone, normTotal, normMean, normMedian, normLog2:
+	case normReference:
+		if len(references) == 0 {
+			msg.Custom(errors.New("the reference normalization requires reference channels marked on the annotation file"), "fatal")
+		}
+	default:
+		msg.Custom(errors.New("unknown normalization method "+method), "fatal")
+	}
+}
+
+// normalizeLabels applies the channel normalization to the PSM, ion, peptide, protein and site labels,
+// without a method only the unique+razor protein labels are normalized to the total
+func normalizeLabels(evi rep.Evidence, method string, references []string) rep.Evidence {
+
+	if method == "" {
+		return NormToTotalProteins(evi)
+	} else if method == normNone {
+		return evi
+	}
+
+	var layers [][]*iso.Labels
+
+	var psm []*iso.Labels
+	for i := range evi.PSM {
+		psm = append(psm, evi.PSM[i].Labels)
+	}
+
+	var ions []*iso.Labels
+	for i := range evi.Ions {
+		ions = append(ions, evi.Ions[i].Labels)
+	}
+
+	var peptides []*iso.Labels
+	for i := range evi.Peptides {
+		peptides = append(peptides, evi.Peptides[i].Labels)
+	}
+
+	var total, unique, razor []*iso.Labels
+	for i := range evi.Proteins {
+		total = append(total, evi.Proteins[i].TotalLabels)
+		unique = append(unique, evi.Proteins[i].UniqueLabels)
+		razor = append(razor, evi.Proteins[i].URazorLabels)
+	}
+
+	var sites []*iso.Labels
+	for i := range evi.Sites {
+		sites = append(sites, evi.Sites[i].Labels)
+	}
+
+	layers = append(layers, psm, ions, peptides, total, unique, razor, sites)
+
+	for _, i := range layers {
+		if method == normTotal {
+			scaleToTotal(i)
+		} else if method == normLog2 {
+			medianCenter(i)
+		} else {
+			for _, j := range distinctLabels(i) {
+				ratioToReference(j, method, references)
+			}
+		}
+	}
+
+	return evi
+}
+
+// distinctLabels removes the nil and repeated labels, layers may share the same labels
+func distinctLabels(labels []*iso.Labels) []*iso.Labels {
+
+	var seen = make(map[*iso.Labels]struct{})
+	var list []*iso.Labels
+
+	for _, i := range labels {
+		if i == nil {
+			continue
+		}
+		if _, ok := seen[i]; ok {
+			continue
+		}
+		seen[i] = struct{}{}
+		list = append(list, i)
+	}
+
+	return list
+}
+
+// scaleToTotal scales the channels so that each channel sum matches the highest channel sum of the layer
+func scaleToTotal(labels []*iso.Labels) {
+
+	labels = distinctLabels(labels)
+
+	var topValue float64
+	var channelSum []float64
+
+	for _, i := range labels {
+		for len(channelSum) < len(i.Channels) {
+			channelSum = append(channelSum, 0)
+		}
+		for j, c := range i.Channels {
+			channelSum[j] += c.Intensity
+		}
+	}
+
+	for _, i := range channelSum {
+		if i > topValue {
+			topValue = i
+		}
+	}
+
+	for _, i := range labels {
+		for j := range i.Channels {
+			if channelSum[j] > 0 {
+				i.Channels[j].Intensity *= topValue / channelSum[j]
+			}
+		}
+	}
+}
+
+// ratioToReference divides the channels by the reference channels mean, or by the
+// mean or median of all quantified channels for the virtual references
+func ratioToReference(l *iso.Labels, method string, references []string) {
+
+	var values []float64
+
+	if method == normReference {
+		var isReference = make(map[string]struct{})
+		for _, i := range references {
+			isReference[i] = struct{}{}
+		}
+
+		for _, i := range l.Channels {
+			if _, ok := isReference[i.Name]; ok {
+				values = append(values, i.Intensity)
+			}
+		}
+	} else {
+		for _, i := range l.Channels {
+			if i.Intensity > 0 {
+				values = append(values, i.Intensity)
+			}
+		}
+	}
+
+	var reference float64
+	if method == normMedian {
+		reference = uti.Median(values)
+	} else {
+		reference = uti.Mean(values)
+	}
+
+	for i := range l.Channels {
+		if reference > 0 {
+			l.Channels[i].Intensity /= reference
+		} else {
+			l.Channels[i].Intensity = 0
+		}
+	}
+}
+
+// medianCenter centers each channel on the global median of the log2 intensities, the
+// intensities are kept on the linear scale since the combined reports sum and scale them.
+// Channels without intensity are kept at zero and do not count for the medians
+func medianCenter(labels []*iso.Labels) {
+
+	labels = distinctLabels(labels)
+
+	var columns [][]float64
+	var all []float64
+
+	for _, i := range labels {
+		for len(columns) < len(i.Channels) {
+			columns = append(columns, nil)
+		}
+		for j, c := range i.Channels {
+			if c.Intensity > 0 {
+				columns[j] = append(columns[j], math.Log2(c.Intensity))
+				all = append(all, math.Log2(c.Intensity))
+			}
+		}
+	}
+
+	var shifts = make([]float64, len(columns))
+	global := uti.Median(all)
+	for i := range columns {
+		shifts[i] = global - uti.Median(columns[i])
+	}
+
+	for _, i := range labels {
+		for j := range i.Channels {
+			if i.Channels[j].Intensity > 0 {
+				i.Channels[j].Intensity *= math.Exp2(shifts[j])
+			}
+		}
+	}
+}
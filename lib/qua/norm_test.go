@@ -0,0 +1,107 @@
+package qua
+
+import (
+	"math"
+	"testing"
+
+	"philosopher/lib/iso"
+	"philosopher/lib/rep"
+)
+
+func newTestLabels(values ...float64) *iso.Labels {
+
+	var l iso.Labels
+	for i, j := range values {
+		l.Channels = append(l.Channels, iso.Channel{Name: []string{"126", "127N", "127C", "128N"}[i], Intensity: j})
+	}
+
+	return &l
+}
+
+func Test_ratioToReference(t *testing.T) {
+
+	l := newTestLabels(100, 200, 50, 0)
+	ratioToReference(l, normReference, []string{"126", "127N"})
+	if l.Channels[0].Intensity != 100.0/150 || l.Channels[2].Intensity != 50.0/150 || l.Channels[3].Intensity != 0 {
+		t.Errorf("Reference ratios are incorrect, got %v", l.Channels)
+	}
+
+	l = newTestLabels(100, 200, 300, 0)
+	ratioToReference(l, normMedian, nil)
+	if l.Channels[1].Intensity != 1 || l.Channels[2].Intensity != 1.5 {
+		t.Errorf("Virtual reference ratios are incorrect, got %v", l.Channels)
+	}
+
+	l = newTestLabels(0, 0, 10, 0)
+	ratioToReference(l, normReference, []string{"126"})
+	if l.Channels[2].Intensity != 0 {
+		t.Errorf("Ratios without reference should be empty, got %v", l.Channels)
+	}
+}
+
+func Test_medianCenter(t *testing.T) {
+
+	a := newTestLabels(4, 16, 0)
+	b := newTestLabels(16, 64, 8)
+	medianCenter([]*iso.Labels{a, b, a})
+
+	// channel medians are 3, 5 and 3, the global median is 4 on the log2 scale
+	if a.Channels[0].Intensity != 8 || b.Channels[1].Intensity != 32 || b.Channels[2].Intensity != 16 {
+		t.Errorf("Median centered intensities are incorrect, got %v %v", a.Channels, b.Channels)
+	}
+
+	if a.Channels[2].Intensity != 0 {
+		t.Errorf("Missing intensities should stay empty, got %v", a.Channels[2])
+	}
+}
+
+func Test_normalizeLabels(t *testing.T) {
+
+	var evi rep.Evidence
+	evi.PSM = rep.PSMEvidenceList{{Labels: newTestLabels(10, 30)}}
+	evi.Peptides = rep.PeptideEvidenceList{{Labels: newTestLabels(20, 60)}}
+	evi.Proteins = rep.ProteinEvidenceList{{TotalLabels: newTestLabels(1, 3), UniqueLabels: newTestLabels(2, 6), URazorLabels: newTestLabels(4, 12)}}
+
+	evi = normalizeLabels(evi, normMean, nil)
+
+	for _, i := range []*iso.Labels{evi.PSM[0].Labels, evi.Peptides[0].Labels, evi.Proteins[0].TotalLabels, evi.Proteins[0].URazorLabels} {
+		if math.Abs(i.Channels[0].Intensity-0.5) > 1e-9 || math.Abs(i.Channels[1].Intensity-1.5) > 1e-9 {
+			t.Errorf("Normalized intensities are incorrect, got %v", i.Channels)
+		}
+	}
+}
+
+func Test_normalizeLabels_Total(t *testing.T) {
+
+	var evi rep.Evidence
+	evi.PSM = rep.PSMEvidenceList{{Labels: newTestLabels(10, 20)}, {Labels: newTestLabels(30, 0)}}
+	evi.Proteins = rep.ProteinEvidenceList{{TotalLabels: newTestLabels(1, 4), UniqueLabels: newTestLabels(2, 1), URazorLabels: newTestLabels(4, 2)}}
+
+	evi = normalizeLabels(evi, normTotal, nil)
+
+	if evi.PSM[0].Labels.Channels[0].Intensity != 10 || evi.PSM[0].Labels.Channels[1].Intensity != 40 {
+		t.Errorf("PSM total normalization is incorrect, got %v %v", evi.PSM[0].Labels.Channels, evi.PSM[1].Labels.Channels)
+	}
+
+	if evi.Proteins[0].TotalLabels.Channels[0].Intensity != 4 || evi.Proteins[0].UniqueLabels.Channels[1].Intensity != 2 {
+		t.Errorf("Protein total normalization is incorrect, got %v %v", evi.Proteins[0].TotalLabels.Channels, evi.Proteins[0].UniqueLabels.Channels)
+	}
+}
+
+func Test_normalizeLabels_Default(t *testing.T) {
+
+	var evi rep.Evidence
+	evi.PSM = rep.PSMEvidenceList{{Labels: newTestLabels(10, 20)}}
+	evi.Proteins = rep.ProteinEvidenceList{{TotalLabels: newTestLabels(1, 4), UniqueLabels: newTestLabels(2, 1), URazorLabels: newTestLabels(4, 2)}}
+
+	evi = normalizeLabels(evi, "", nil)
+
+	// only the unique+razor protein labels are normalized
+	if evi.PSM[0].Labels.Channels[1].Intensity != 20 || evi.Proteins[0].TotalLabels.Channels[1].Intensity != 4 {
+		t.Errorf("Default normalization should keep the other levels, got %v %v", evi.PSM[0].Labels.Channels, evi.Proteins[0].TotalLabels.Channels)
+	}
+
+	if evi.Proteins[0].URazorLabels.Channels[1].Intensity != 1 {
+		t.Errorf("Unique+razor normalization is incorrect, got %v", evi.Proteins[0].URazorLabels.Channels)
+	}
+}
@@ -0,0 +1,197 @@
+package qua
+
+import (
+	"sort"
+)
+
+// boundaryFraction is the fraction of the apex intensity where a peak boundary is set
+const boundaryFraction = 0.05
+
+// noiseFraction is the fraction of the highest smoothed signal under which a maximum is noise
+const noiseFraction = 0.01
+
+// savitzkyGolay holds the coefficients of the 5 point quadratic smoothing filter
+var savitzkyGolay = []float64{-3, 12, 17, 12, -3}
+
+// Peak is a chromatographic peak detected on an extracted ion chromatogram,
+// the retention times are in seconds
+type Peak struct {
+	Apex      float64
+	Start     float64
+	End       float64
+	FWHM      float64
+	Area      float64
+	Intensity float64
+}
+
+// smooth applies the Savitzky-Golay filter to the chromatogram, the edges are
+// smoothed with a 3 point moving average
+func smooth(intensities []float64) []float64 {
+
+	var smoothed = make([]float64, len(intensities))
+
+	for i := range intensities {
+
+		if i < 2 || i > len(intensities)-3 {
+
+			var sum, n float64
+			for j := i - 1; j <= i+1; j++ {
+				if j >= 0 && j < len(intensities) {
+					sum += intensities[j]
+					n++
+				}
+			}
+			smoothed[i] = sum / n
+
+			continue
+		}
+
+		var sum float64
+		for j, k := range savitzkyGolay {
+			sum += k * intensities[i+j-2]
+		}
+
+		if sum < 0 {
+			sum = 0
+		}
+		smoothed[i] = sum / 35
+	}
+
+	return smoothed
+}
+
+// boundaries walks down both sides of the apex until the smoothed signal drops
+// below the boundary fraction or starts rising again into a neighbouring peak
+func boundaries(smoothed []float64, apex int) (int, int) {
+
+	limit := smoothed[apex] * boundaryFraction
+
+	left := apex
+	for left > 0 && smoothed[left-1] <= smoothed[left] && smoothed[left] > limit {
+		left--
+	}
+
+	right := apex
+	for right < len(smoothed)-1 && smoothed[right+1] <= smoothed[right] && smoothed[right] > limit {
+		right++
+	}
+
+	return left, right
+}
+
+// halfHeight interpolates the retention time where the smoothed signal crosses
+// half of the apex height, between the positions from and to
+func halfHeight(rt, smoothed []float64, from, to int) float64 {
+
+	half := smoothed[from] / 2
+
+	step := 1
+	if to < from {
+		step = -1
+	}
+
+	for i := from; i != to; i += step {
+
+		j := i + step
+		if smoothed[j] <= half {
+			if smoothed[i] == smoothed[j] {
+				return rt[j]
+			}
+			return rt[i] + (rt[j]-rt[i])*(smoothed[i]-half)/(smoothed[i]-smoothed[j])
+		}
+	}
+
+	return rt[to]
+}
+
+// detectPeak finds the chromatographic peak eluting at the identification
+// retention time. The chromatogram is smoothed, the local maxima are delimited,
+// and the peak containing the retention time is kept, or the closest apex
+// within the tolerance window. The retention times are in minutes, as in the
+// mzML files
+func detectPeak(measured map[float64]float64, retentionTime, pTWin float64) (Peak, bool) {
+
+	var peak Peak
+
+	if len(measured) < 3 {
+		return peak, false
+	}
+
+	var rt []float64
+	for k := range measured {
+		rt = append(rt, k)
+	}
+	sort.Float64s(rt)
+
+	var intensities = make([]float64, len(rt))
+	for i, k := range rt {
+		intensities[i] = measured[k]
+	}
+
+	smoothed := smooth(intensities)
+
+	// maxima far below the highest one are noise
+	var highest float64
+	for _, i := range smoothed {
+		if i > highest {
+			highest = i
+		}
+	}
+
+	var selected = -1
+	var distance float64
+
+	for i := range smoothed {
+
+		if smoothed[i] <= 0 || smoothed[i] < highest*noiseFraction {
+			continue
+		}
+
+		if (i > 0 && smoothed[i-1] > smoothed[i]) || (i < len(smoothed)-1 && smoothed[i+1] > smoothed[i]) {
+			continue
+		}
+
+		left, right := boundaries(smoothed, i)
+
+		var d float64
+		if retentionTime < rt[left] {
+			d = rt[left] - retentionTime
+		} else if retentionTime > rt[right] {
+			d = retentionTime - rt[right]
+		}
+
+		if d > pTWin {
+			continue
+		}
+
+		// a peak containing the retention time is preferred to a neighbouring one
+		if selected < 0 || d < distance || (d == distance && smoothed[i] > smoothed[selected]) {
+			selected = i
+			distance = d
+		}
+	}
+
+	if selected < 0 {
+		return peak, false
+	}
+
+	left, right := boundaries(smoothed, selected)
+
+	peak.Apex = rt[selected] * 60
+	peak.Start = rt[left] * 60
+	peak.End = rt[right] * 60
+	peak.FWHM = (halfHeight(rt, smoothed, selected, right) - halfHeight(rt, smoothed, selected, left)) * 60
+
+	for i := left; i <= right; i++ {
+
+		if intensities[i] > peak.Intensity {
+			peak.Intensity = intensities[i]
+		}
+
+		if i < right {
+			peak.Area += (intensities[i] + intensities[i+1]) / 2 * (rt[i+1] - rt[i]) * 60
+		}
+	}
+
+	return peak, true
+}
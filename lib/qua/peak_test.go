@@ -0,0 +1,55 @@
+package qua
+
+import (
+	"math"
+	"testing"
+)
+
+// gaussian builds a chromatogram with one scan every 0.05 minutes
+func gaussian(measured map[float64]float64, apex, sigma, height float64) {
+	for i := 0; i <= 80; i++ {
+		rt := float64(i) * 0.05
+		measured[rt] += height * math.Exp(-(rt-apex)*(rt-apex)/(2*sigma*sigma))
+	}
+}
+
+func Test_detectPeak(t *testing.T) {
+
+	var measured = make(map[float64]float64)
+	gaussian(measured, 1.5, 0.1, 1000)
+
+	// a more intense co-eluting neighbour inside the tolerance window
+	gaussian(measured, 2.5, 0.1, 5000)
+
+	peak, ok := detectPeak(measured, 1.45, 1.5)
+	if !ok {
+		t.Fatalf("Peak was not detected")
+	}
+
+	if math.Abs(peak.Apex-90) > 3.1 {
+		t.Errorf("Apex retention time is incorrect, got %f, want %f", peak.Apex, 90.0)
+	}
+
+	if peak.Intensity > 1100 {
+		t.Errorf("The neighbouring peak was picked, got intensity %f", peak.Intensity)
+	}
+
+	if peak.Start >= peak.Apex || peak.End <= peak.Apex || peak.End > 2.5*60 {
+		t.Errorf("Peak boundaries are incorrect, got %f - %f", peak.Start, peak.End)
+	}
+
+	// the FWHM of a gaussian is 2.355 sigma
+	if math.Abs(peak.FWHM-2.355*0.1*60) > 3 {
+		t.Errorf("FWHM is incorrect, got %f, want %f", peak.FWHM, 2.355*0.1*60)
+	}
+
+	// the area of a gaussian is height * sigma * sqrt(2 pi)
+	area := 1000 * 0.1 * 60 * math.Sqrt(2*math.Pi)
+	if math.Abs(peak.Area-area)/area > 0.05 {
+		t.Errorf("Peak area is incorrect, got %f, want %f", peak.Area, area)
+	}
+
+	if _, ok := detectPeak(measured, 3.9, 0.1); ok {
+		t.Errorf("A peak was detected outside the tolerance window")
+	}
+}
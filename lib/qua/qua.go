@@ -44,7 +44,7 @@ func RunLabelFreeQuantification(p met.Quantify) {
 	var evi rep.Evidence
 	evi.RestoreGranular()
 
-	evi = peakIntensity(evi, p.Dir, p.Format, p.RTWin, p.PTWin, p.Tol, p.Isolated, p.Raw, p.Faims)
+	evi = peakIntensity(evi, p.Dir, p.Format, p.RTWin, p.PTWin, p.Tol, p.IMTol, p.MinIsoCorr, p.Isotopes, p.Isolated, p.Raw, p.Faims)
 
 	evi = calculateIntensities(evi)
 
@@ -85,9 +85,19 @@ func RunIsobaricLabelQuantification(p met.Quantify, mods bool) met.Quantify {
 	}
 
 	// read the annotation file
+	var references []string
 	p.LabelNames = make(map[string]string)
 	if len(p.Annot) > 0 {
 		p.LabelNames = uti.GetLabelNames(p.Annot)
+		references = uti.GetReferenceChannels(p.Annot)
+	}
+
+	checkNormalization(p.ChanNorm, references)
+
+	// build the reagent impurity correction matrix
+	p.ImpurityMatrix = nil
+	if len(p.Impurity) > 0 {
+		p.ImpurityMatrix = impurityMatrix(rep.LabelKit(p.Brand, p.Plex), readImpurityTable(p.Impurity))
 	}
 
 	logrus.Info("Calculating intensities and ion interference")
@@ -100,18 +110,12 @@ func RunIsobaricLabelQuantification(p met.Quantify, mods bool) met.Quantify {
 		logrus.Info("Processing ", sourceList[i])
 
 		if p.Raw {
-			//fileName = fmt.Sprintf("%s%s%s.raw", p.Dir, string(filepath.Separator), sourceList[i])
-			//stream := rawfilereader.Run(fileName, "")
-			//mz.ReadRaw(fileName, stream)
-
+			fileName = fmt.Sprintf("%s%s%s.raw", p.Dir, string(filepath.Separator), sourceList[i])
+			mz.IndexRaw(fileName)
 		} else {
 
 			fileName = fmt.Sprintf("%s%s%s.mzML", p.Dir, string(filepath.Separator), sourceList[i])
-			mz.Read(fileName)
-
-			for i := range mz.Spectra {
-				mz.Spectra[i].Decode()
-			}
+			mz.Index(fileName)
 		}
 
 		mappedPurity := calculateIonPurity(p.Dir, p.Format, mz, sourceMap[sourceList[i]])
@@ -124,7 +128,13 @@ func RunIsobaricLabelQuantification(p met.Quantify, mods bool) met.Quantify {
 			labels = prepareLabelStructureWithMS2(p.Dir, p.Format, p.Brand, p.Plex, p.Tol, mz)
 		}
 
-		labels = assignLabelNames(labels, p.LabelNames, p.Brand, p.Plex)
+		mz.Close()
+
+		if len(p.ImpurityMatrix) > 0 {
+			labels = correctImpurities(labels, p.ImpurityMatrix)
+		}
+
+		labels = assignLabelNames(labels, p.LabelNames)
 
 		mappedPSM := mapLabeledSpectra(labels, p.Purity, sourceMap[sourceList[i]])
 
@@ -159,7 +169,7 @@ func RunIsobaricLabelQuantification(p met.Quantify, mods bool) met.Quantify {
 
 	// classification and filtering based on quality filters
 	logrus.Info("Filtering spectra for label quantification")
-	spectrumMap, phosphoSpectrumMap := classification(evi, mods, p.BestPSM, p.RemoveLow, p.Purity, p.MinProb)
+	spectrumMap := classification(evi, p.BestPSM, p.RemoveLow, p.Purity, p.MinProb)
 
 	// assignment happens only for general PSMs
 	evi = assignUsage(evi, spectrumMap)
@@ -167,15 +177,32 @@ func RunIsobaricLabelQuantification(p met.Quantify, mods bool) met.Quantify {
 	// forces psms with no label to have 0 intensities
 	evi = correctUnlabelledSpectra(evi)
 
-	evi = rollUpPeptides(evi, spectrumMap, phosphoSpectrumMap)
+	evi = rollUpPeptides(evi, spectrumMap)
+
+	evi = rollUpPeptideIons(evi, spectrumMap)
 
-	evi = rollUpPeptideIons(evi, spectrumMap, phosphoSpectrumMap)
+	evi = rollUpProteins(evi, spectrumMap)
 
-	evi = rollUpProteins(evi, spectrumMap, phosphoSpectrumMap)
+	// site level quantification of the selected modifications
+	// the mapped modifications keep the phospho sites quantified when no sites are selected
+	sites := p.Sites
+	if mods && len(sites) == 0 {
+		sites = "Phospho"
+	}
 
-	// normalize to the total protein levels
-	logrus.Info("Calculating normalized protein levels")
-	evi = NormToTotalProteins(evi)
+	evi.Sites = nil
+	if len(sites) > 0 {
+		logrus.Info("Calculating modification site levels")
+		evi.Sites = rollUpSites(evi, spectrumMap, parseSiteModifications(sites), p.SiteProb)
+
+		if len(evi.Sites) == 0 {
+			msg.Custom(errors.New("no localized modification sites were found, the site quantification requires PTMProphet results"), "warning")
+		}
+	}
+
+	// normalize the channels
+	logrus.Info("Calculating normalized channel levels")
+	evi = normalizeLabels(evi, p.ChanNorm, references)
 
 	logrus.Info("Saving")
 
@@ -192,6 +219,9 @@ func RunIsobaricLabelQuantification(p met.Quantify, mods bool) met.Quantify {
 	// create Ion
 	rep.SerializeProteins(&evi.Proteins)
 
+	// create Sites
+	rep.SerializeSites(&evi.Sites)
+
 	return p
 }
 
@@ -276,317 +306,26 @@ func cleanPreviousData(evi rep.Evidence, brand, plex string) rep.Evidence {
 }
 
 // checks for custom names and assign the normal channel or the custom name to the CustomName
-func assignLabelNames(labels map[string]iso.Labels, labelNames map[string]string, brand, plex string) map[string]iso.Labels {
+func assignLabelNames(labels map[string]iso.Labels, labelNames map[string]string) map[string]iso.Labels {
 
 	for k, v := range labels {
-		v2 := v
-
-		if brand == "tmt" {
-
-			if len(labelNames["126"]) < 1 {
-				v2.Channel1.CustomName = "126"
-			} else {
-				v2.Channel1.CustomName = labelNames["126"]
-			}
-
-			if len(labelNames["127N"]) < 1 {
-				v2.Channel2.CustomName = "127N"
-			} else {
-				v2.Channel2.CustomName = labelNames["127N"]
-			}
-
-			if len(labelNames["127C"]) < 1 {
-				v2.Channel3.CustomName = "127C"
-			} else {
-				v2.Channel3.CustomName = labelNames["127C"]
-			}
-
-			if len(labelNames["128N"]) < 1 {
-				v2.Channel4.CustomName = "128N"
-			} else {
-				v2.Channel4.CustomName = labelNames["128N"]
-			}
-
-			if len(labelNames["128C"]) < 1 {
-				v2.Channel5.CustomName = "128C"
-			} else {
-				v2.Channel5.CustomName = labelNames["128C"]
-			}
-
-			if len(labelNames["129N"]) < 1 {
-				v2.Channel6.CustomName = "129N"
-			} else {
-				v2.Channel6.CustomName = labelNames["129N"]
-			}
-
-			if len(labelNames["129C"]) < 1 {
-				v2.Channel7.CustomName = "129C"
-			} else {
-				v2.Channel7.CustomName = labelNames["129C"]
-			}
-
-			if len(labelNames["130N"]) < 1 {
-				v2.Channel8.CustomName = "130N"
-			} else {
-				v2.Channel8.CustomName = labelNames["130N"]
-			}
-
-			if len(labelNames["130C"]) < 1 {
-				v2.Channel9.CustomName = "130C"
-			} else {
-				v2.Channel9.CustomName = labelNames["130C"]
-			}
-
-			if len(labelNames["131N"]) < 1 {
-				v2.Channel10.CustomName = "131N"
-			} else {
-				v2.Channel10.CustomName = labelNames["131N"]
-			}
-
-			if len(labelNames["131C"]) < 1 {
-				v2.Channel11.CustomName = "131C"
-			} else {
-				v2.Channel11.CustomName = labelNames["131C"]
-			}
-
-			if len(labelNames["132N"]) < 1 {
-				v2.Channel12.CustomName = "132N"
-			} else {
-				v2.Channel12.CustomName = labelNames["132N"]
-			}
-
-			if len(labelNames["132C"]) < 1 {
-				v2.Channel13.CustomName = "132C"
-			} else {
-				v2.Channel13.CustomName = labelNames["132C"]
-			}
-
-			if len(labelNames["133N"]) < 1 {
-				v2.Channel14.CustomName = "133N"
-			} else {
-				v2.Channel14.CustomName = labelNames["133N"]
-			}
-
-			if len(labelNames["133C"]) < 1 {
-				v2.Channel15.CustomName = "133C"
-			} else {
-				v2.Channel15.CustomName = labelNames["133C"]
-			}
-
-			if len(labelNames["134N"]) < 1 {
-				v2.Channel16.CustomName = "134N"
-			} else {
-				v2.Channel16.CustomName = labelNames["134N"]
-			}
-
-			if len(labelNames["134C"]) < 1 {
-				v2.Channel17.CustomName = "134C"
-			} else {
-				v2.Channel17.CustomName = labelNames["134C"]
-			}
-
-			if len(labelNames["135N"]) < 1 {
-				v2.Channel18.CustomName = "135N"
-			} else {
-				v2.Channel18.CustomName = labelNames["135N"]
-			}
-
-		} else if brand == "itraq" && plex == "4" {
-
-			if len(labelNames["114"]) < 1 {
-				v2.Channel1.CustomName = "114"
-			} else {
-				v2.Channel1.CustomName = labelNames["114"]
-			}
-
-			if len(labelNames["115"]) < 1 {
-				v2.Channel2.CustomName = "115"
-			} else {
-				v2.Channel2.CustomName = labelNames["115"]
-			}
-
-			if len(labelNames["116"]) < 1 {
-				v2.Channel3.CustomName = "116"
-			} else {
-				v2.Channel3.CustomName = labelNames["116"]
-			}
-
-			if len(labelNames["117"]) < 1 {
-				v2.Channel4.CustomName = "117"
-			} else {
-				v2.Channel4.CustomName = labelNames["117"]
-			}
-
-		} else if brand == "itraq" && plex == "8" {
-			if len(labelNames["113"]) < 1 {
-				v2.Channel1.CustomName = "113"
-			} else {
-				v2.Channel1.CustomName = labelNames["113"]
-			}
-
-			if len(labelNames["114"]) < 1 {
-				v2.Channel2.CustomName = "114"
-			} else {
-				v2.Channel2.CustomName = labelNames["114"]
-			}
-
-			if len(labelNames["115"]) < 1 {
-				v2.Channel3.CustomName = "115"
-			} else {
-				v2.Channel3.CustomName = labelNames["115"]
-			}
-
-			if len(labelNames["116"]) < 1 {
-				v2.Channel4.CustomName = "116"
-			} else {
-				v2.Channel4.CustomName = labelNames["116"]
-			}
-
-			if len(labelNames["117"]) < 1 {
-				v2.Channel5.CustomName = "117"
+		for i := range v.Channels {
+			if len(labelNames[v.Channels[i].Name]) < 1 {
+				v.Channels[i].CustomName = v.Channels[i].Name
 			} else {
-				v2.Channel5.CustomName = labelNames["117"]
-			}
-
-			if len(labelNames["118"]) < 1 {
-				v2.Channel6.CustomName = "118"
-			} else {
-				v2.Channel6.CustomName = labelNames["118"]
-			}
-
-			if len(labelNames["119"]) < 1 {
-				v2.Channel7.CustomName = "119"
-			} else {
-				v2.Channel7.CustomName = labelNames["119"]
-			}
-
-			if len(labelNames["121"]) < 1 {
-				v2.Channel8.CustomName = "121"
-			} else {
-				v2.Channel8.CustomName = labelNames["121"]
-			}
-
-		} else if brand == "xtag" {
-
-			if len(labelNames["xTag1"]) < 1 {
-				v2.Channel1.CustomName = "xTag1"
-			} else {
-				v2.Channel1.CustomName = labelNames["xTag1"]
-			}
-
-			if len(labelNames["xTag2"]) < 1 {
-				v2.Channel2.CustomName = "xTag2"
-			} else {
-				v2.Channel2.CustomName = labelNames["xTag2"]
-			}
-
-			if len(labelNames["xTag3"]) < 1 {
-				v2.Channel3.CustomName = "xTag3"
-			} else {
-				v2.Channel3.CustomName = labelNames["xTag3"]
-			}
-
-			if len(labelNames["xTag4"]) < 1 {
-				v2.Channel4.CustomName = "xTag4"
-			} else {
-				v2.Channel4.CustomName = labelNames["xTag4"]
-			}
-
-			if len(labelNames["xTag5"]) < 1 {
-				v2.Channel5.CustomName = "xTag5"
-			} else {
-				v2.Channel5.CustomName = labelNames["xTag5"]
-			}
-
-			if len(labelNames["xTag6"]) < 1 {
-				v2.Channel6.CustomName = "xTag6"
-			} else {
-				v2.Channel6.CustomName = labelNames["xTag6"]
-			}
-
-			if len(labelNames["xTag7"]) < 1 {
-				v2.Channel7.CustomName = "xTag7"
-			} else {
-				v2.Channel7.CustomName = labelNames["xTag7"]
-			}
-
-			if len(labelNames["xTag8"]) < 1 {
-				v2.Channel8.CustomName = "xTag8"
-			} else {
-				v2.Channel8.CustomName = labelNames["xTag8"]
-			}
-
-			if len(labelNames["xTag9"]) < 1 {
-				v2.Channel9.CustomName = "xTag9"
-			} else {
-				v2.Channel9.CustomName = labelNames["xTag9"]
-			}
-
-			if len(labelNames["xTag10"]) < 1 {
-				v2.Channel10.CustomName = "xTag10"
-			} else {
-				v2.Channel10.CustomName = labelNames["xTag10"]
-			}
-
-			if len(labelNames["xTag11"]) < 1 {
-				v2.Channel11.CustomName = "xTag11"
-			} else {
-				v2.Channel11.CustomName = labelNames["xTag11"]
-			}
-
-			if len(labelNames["xTag12"]) < 1 {
-				v2.Channel12.CustomName = "xTag12"
-			} else {
-				v2.Channel12.CustomName = labelNames["xTag12"]
-			}
-
-			if len(labelNames["xTag13"]) < 1 {
-				v2.Channel13.CustomName = "xTag13"
-			} else {
-				v2.Channel13.CustomName = labelNames["xTag13"]
-			}
-
-			if len(labelNames["xTag14"]) < 1 {
-				v2.Channel14.CustomName = "xTag14"
-			} else {
-				v2.Channel14.CustomName = labelNames["xTag14"]
-			}
-
-			if len(labelNames["xTag15"]) < 1 {
-				v2.Channel15.CustomName = "xTag15"
-			} else {
-				v2.Channel15.CustomName = labelNames["xTag15"]
-			}
-
-			if len(labelNames["xTag16"]) < 1 {
-				v2.Channel16.CustomName = "xTag16"
-			} else {
-				v2.Channel16.CustomName = labelNames["xTag16"]
-			}
-
-			if len(labelNames["xTag17"]) < 1 {
-				v2.Channel17.CustomName = "xTag17"
-			} else {
-				v2.Channel17.CustomName = labelNames["xTag17"]
-			}
-
-			if len(labelNames["xTag18"]) < 1 {
-				v2.Channel18.CustomName = "xTag18"
-			} else {
-				v2.Channel18.CustomName = labelNames["xTag18"]
+				v.Channels[i].CustomName = labelNames[v.Channels[i].Name]
 			}
 		}
 
-		labels[k] = v2
+		labels[k] = v
 	}
 
 	return labels
 }
 
-func classification(evi rep.Evidence, mods, best bool, remove, purity, probability float64) (map[id.SpectrumType]iso.Labels, map[id.SpectrumType]iso.Labels) {
+func classification(evi rep.Evidence, best bool, remove, purity, probability float64) map[id.SpectrumType]iso.Labels {
 
 	var spectrumMap = make(map[id.SpectrumType]iso.Labels)
-	var phosphoSpectrumMap = make(map[id.SpectrumType]iso.Labels)
 	var bestMap = make(map[id.SpectrumType]uint8)
 	var psmLabelSumList PairList
 	var quantCheckUp bool
@@ -597,37 +336,10 @@ func classification(evi rep.Evidence, mods, best bool, remove, purity, probabili
 
 			spectrumMap[i.SpectrumFileName()] = *i.Labels
 			bestMap[i.SpectrumFileName()] = 0
-
-			if mods && i.PTM != nil {
-				_, ok1 := i.PTM.LocalizedPTMSites["PTMProphet_STY79.9663"]
-				_, ok2 := i.PTM.LocalizedPTMSites["PTMProphet_STY79.96633"]
-				_, ok3 := i.PTM.LocalizedPTMSites["PTMProphet_STY79.966331"]
-				if ok1 || ok2 || ok3 {
-					phosphoSpectrumMap[i.SpectrumFileName()] = *i.Labels
-				}
-			}
-
 		}
 
 		if remove != 0 {
-			sum := i.Labels.Channel1.Intensity +
-				i.Labels.Channel2.Intensity +
-				i.Labels.Channel3.Intensity +
-				i.Labels.Channel4.Intensity +
-				i.Labels.Channel5.Intensity +
-				i.Labels.Channel6.Intensity +
-				i.Labels.Channel7.Intensity +
-				i.Labels.Channel8.Intensity +
-				i.Labels.Channel9.Intensity +
-				i.Labels.Channel10.Intensity +
-				i.Labels.Channel11.Intensity +
-				i.Labels.Channel12.Intensity +
-				i.Labels.Channel13.Intensity +
-				i.Labels.Channel14.Intensity +
-				i.Labels.Channel15.Intensity +
-				i.Labels.Channel16.Intensity +
-				i.Labels.Channel17.Intensity +
-				i.Labels.Channel18.Intensity
+			sum := i.Labels.Sum()
 			psmLabelSumList = append(psmLabelSumList, Pair{i.SpectrumFileName(), sum})
 
 			if sum > 0 {
@@ -659,24 +371,7 @@ func classification(evi rep.Evidence, mods, best bool, remove, purity, probabili
 				var bestPSM id.SpectrumType
 				var bestPSMInt float64
 				for _, i := range v {
-					tmtSum := i.Labels.Channel1.Intensity +
-						i.Labels.Channel2.Intensity +
-						i.Labels.Channel3.Intensity +
-						i.Labels.Channel4.Intensity +
-						i.Labels.Channel5.Intensity +
-						i.Labels.Channel6.Intensity +
-						i.Labels.Channel7.Intensity +
-						i.Labels.Channel8.Intensity +
-						i.Labels.Channel9.Intensity +
-						i.Labels.Channel10.Intensity +
-						i.Labels.Channel11.Intensity +
-						i.Labels.Channel12.Intensity +
-						i.Labels.Channel13.Intensity +
-						i.Labels.Channel14.Intensity +
-						i.Labels.Channel15.Intensity +
-						i.Labels.Channel16.Intensity +
-						i.Labels.Channel17.Intensity +
-						i.Labels.Channel18.Intensity
+					tmtSum := i.Labels.Sum()
 
 					if tmtSum > bestPSMInt {
 						bestPSM = i.SpectrumFileName()
@@ -692,7 +387,6 @@ func classification(evi rep.Evidence, mods, best bool, remove, purity, probabili
 	}
 
 	var toDelete = make(map[id.SpectrumType]uint8)
-	var toDeletePhospho = make(map[id.SpectrumType]uint8)
 
 	// 3rd check: remove the lower 3%
 	// Ignore all PSMs that fall under the lower 3% based on their summed TMT labels
@@ -703,7 +397,6 @@ func classification(evi rep.Evidence, mods, best bool, remove, purity, probabili
 
 		for i := 0; i <= lowerFiveInt; i++ {
 			toDelete[psmLabelSumList[i].Key] = 0
-			toDeletePhospho[psmLabelSumList[i].Key] = 0
 		}
 	}
 
@@ -714,88 +407,44 @@ func classification(evi rep.Evidence, mods, best bool, remove, purity, probabili
 		}
 	}
 
+	logrus.Info("Removing ", len(toDelete), " PSMs from isobaric quantification")
 	for i := range toDelete {
 		delete(spectrumMap, i)
 	}
 
-	for k := range phosphoSpectrumMap {
-		_, ok := bestMap[k]
-		if !ok {
-			toDeletePhospho[k] = 0
-		}
-	}
-
-	logrus.Info("Removing ", len(toDelete), " PSMs from isobaric quantification")
-	for i := range toDeletePhospho {
-		delete(phosphoSpectrumMap, i)
-	}
-
-	return spectrumMap, phosphoSpectrumMap
+	return spectrumMap
 }
 
 // calculateIonPurity verifies how much interference there is on the precursor scans for each fragment
 func calculateIonPurity(d, f string, mz mzn.MsData, evi []rep.PSMEvidence) []rep.PSMEvidence {
 
-	// index MS1 and MS2 spectra in a dictionary
-	var indexedMS1 = make(map[string]mzn.Spectrum)
+	// index the MS2 spectra headers, the MS1 peaks are loaded only for the precursors
 	var indexedMS2 = make(map[string]mzn.Spectrum)
 
-	var MS1Peaks = make(map[string][]float64)
-	var MS1Int = make(map[string][]float64)
-
 	for i := range mz.Spectra {
 
-		if mz.Spectra[i].Level == "1" {
-
-			// left-pad the spectrum index
-			paddedIndex := fmt.Sprintf("%05s", mz.Spectra[i].Index)
-
-			// left-pad the spectrum scan
-			paddedScan := fmt.Sprintf("%05s", mz.Spectra[i].Scan)
-
-			mz.Spectra[i].Index = paddedIndex
-			mz.Spectra[i].Scan = paddedScan
-
-			indexedMS1[paddedScan] = mz.Spectra[i]
-
-			MS1Peaks[paddedScan] = mz.Spectra[i].Mz.DecodedStream
-			MS1Int[paddedScan] = mz.Spectra[i].Intensity.DecodedStream
+		if mz.Spectra[i].Level == "2" {
 
-		} else if mz.Spectra[i].Level == "2" {
+			spec := mz.Spectra[i]
 
-			if mz.Spectra[i].Precursor.IsolationWindowLowerOffset == 0 && mz.Spectra[i].Precursor.IsolationWindowUpperOffset == 0 {
-				mz.Spectra[i].Precursor.IsolationWindowLowerOffset = mzDeltaWindow
-				mz.Spectra[i].Precursor.IsolationWindowUpperOffset = mzDeltaWindow
+			if spec.Precursor.IsolationWindowLowerOffset == 0 && spec.Precursor.IsolationWindowUpperOffset == 0 {
+				spec.Precursor.IsolationWindowLowerOffset = mzDeltaWindow
+				spec.Precursor.IsolationWindowUpperOffset = mzDeltaWindow
 			}
 
 			// left-pad the spectrum index
-			paddedIndex := fmt.Sprintf("%05s", mz.Spectra[i].Index)
+			spec.Index = fmt.Sprintf("%05s", spec.Index)
 
 			// left-pad the spectrum scan
-			paddedScan := fmt.Sprintf("%05s", mz.Spectra[i].Scan)
+			spec.Scan = fmt.Sprintf("%05s", spec.Scan)
 
 			// left-pad the precursor spectrum index
-			paddedPI := fmt.Sprintf("%05s", mz.Spectra[i].Precursor.ParentIndex)
+			spec.Precursor.ParentIndex = fmt.Sprintf("%05s", spec.Precursor.ParentIndex)
 
 			// left-pad the precursor spectrum scan
-			paddedPS := fmt.Sprintf("%05s", mz.Spectra[i].Precursor.ParentScan)
+			spec.Precursor.ParentScan = fmt.Sprintf("%05s", spec.Precursor.ParentScan)
 
-			mz.Spectra[i].Index = paddedIndex
-			mz.Spectra[i].Scan = paddedScan
-			mz.Spectra[i].Precursor.ParentIndex = paddedPI
-			mz.Spectra[i].Precursor.ParentScan = paddedPS
-
-			stream := MS1Peaks[paddedPS]
-
-			for j := range stream {
-				if stream[j] >= (mz.Spectra[i].Precursor.TargetIon-mz.Spectra[i].Precursor.IsolationWindowLowerOffset) && stream[j] <= (mz.Spectra[i].Precursor.TargetIon+mz.Spectra[i].Precursor.IsolationWindowUpperOffset) {
-					if MS1Int[mz.Spectra[i].Precursor.ParentScan][j] > mz.Spectra[i].Precursor.TargetIonIntensity {
-						mz.Spectra[i].Precursor.TargetIonIntensity = MS1Int[mz.Spectra[i].Precursor.ParentScan][j]
-					}
-				}
-			}
-
-			indexedMS2[paddedScan] = mz.Spectra[i]
+			indexedMS2[spec.Scan] = spec
 		}
 	}
 
@@ -807,7 +456,18 @@ func calculateIonPurity(d, f string, mz mzn.MsData, evi []rep.PSMEvidence) []rep
 		v2, ok := indexedMS2[split[1]]
 		if ok {
 
-			v1 := indexedMS1[v2.Precursor.ParentScan]
+			var v1 mzn.Spectrum
+			if idx, ok := mz.ByScan(v2.Precursor.ParentScan); ok && mz.Spectra[idx].Level == "1" {
+				v1 = mz.Load(idx)
+			}
+
+			for k := range v1.Mz.DecodedStream {
+				if v1.Mz.DecodedStream[k] >= (v2.Precursor.TargetIon-v2.Precursor.IsolationWindowLowerOffset) && v1.Mz.DecodedStream[k] <= (v2.Precursor.TargetIon+v2.Precursor.IsolationWindowUpperOffset) {
+					if v1.Intensity.DecodedStream[k] > v2.Precursor.TargetIonIntensity {
+						v2.Precursor.TargetIonIntensity = v1.Intensity.DecodedStream[k]
+					}
+				}
+			}
 
 			var ions = make(map[float64]float64)
 			var isolationWindowSummedInt float64
@@ -0,0 +1,275 @@
+package qua
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"philosopher/lib/id"
+	"philosopher/lib/iso"
+	"philosopher/lib/msg"
+	"philosopher/lib/obo"
+	"philosopher/lib/rep"
+)
+
+const (
+	// siteMassTolerance is the Dalton tolerance used to match the PTMProphet modifications to the selected ones
+	siteMassTolerance float64 = 0.01
+	// siteWindow is the number of residues reported on each side of a modification site
+	siteWindow int = 7
+)
+
+// siteModification is a modification selected for the site level quantification
+type siteModification struct {
+	name string
+	mass float64
+}
+
+// localizedResidue is a candidate residue of a PTMProphet localization
+type localizedResidue struct {
+	aminoAcid   string
+	position    int // peptide position, 1-based
+	probability float64
+}
+
+var ptmMass = regexp.MustCompile(`[-+]?\d+\.\d+$`)
+
+// parseSiteModifications resolves the comma separated list of modifications, given as
+// mass differences or UniMod names, e.g. Phospho,Acetyl,114.0429
+func parseSiteModifications(sites string) []siteModification {
+
+	var mods []siteModification
+	var terms []obo.Term
+
+	for _, i := range strings.Split(sites, ",") {
+
+		i = strings.TrimSpace(i)
+		if len(i) == 0 {
+			continue
+		}
+
+		mass, e := strconv.ParseFloat(i, 64)
+		if e == nil {
+			mods = append(mods, siteModification{name: fmt.Sprintf("%.4f", mass), mass: mass})
+			continue
+		}
+
+		if terms == nil {
+			terms = obo.NewUniModOntology().Terms
+		}
+
+		mod, ok := uniModSite(i, terms)
+		if !ok {
+			msg.Custom(errors.New("the modification "+i+" was not found in UniMod"), "fatal")
+		}
+
+		mods = append(mods, mod)
+	}
+
+	return mods
+}
+
+// uniModSite finds a modification by its UniMod name
+func uniModSite(name string, terms []obo.Term) (siteModification, bool) {
+
+	for _, i := range terms {
+		if strings.EqualFold(i.Name, name) {
+			return siteModification{name: i.Name, mass: i.MonoIsotopicMass}, true
+		}
+	}
+
+	return siteModification{}, false
+}
+
+// matchSiteModification finds the selected modification of a PTMProphet result, e.g. PTMProphet_STY79.9663
+func matchSiteModification(ptm string, mods []siteModification) (siteModification, bool) {
+
+	mass, e := strconv.ParseFloat(ptmMass.FindString(ptm), 64)
+	if e != nil {
+		return siteModification{}, false
+	}
+
+	for _, i := range mods {
+		if math.Abs(i.mass-mass) <= siteMassTolerance {
+			return i, true
+		}
+	}
+
+	return siteModification{}, false
+}
+
+// parseLocalization reads a PTMProphet peptide, e.g. AS(0.987)T(0.013)PEPK, and returns the
+// candidate residues ranked by localization probability
+func parseLocalization(peptide string) []localizedResidue {
+
+	var residues []localizedResidue
+	var position int
+
+	for i := 0; i < len(peptide); i++ {
+
+		c := peptide[i]
+
+		if c == '[' {
+			for i < len(peptide) && peptide[i] != ']' {
+				i++
+			}
+			continue
+		}
+
+		if c == '(' {
+			end := strings.IndexByte(peptide[i:], ')')
+			if end < 0 {
+				break
+			}
+
+			probability, e := strconv.ParseFloat(peptide[i+1:i+end], 64)
+			if e == nil && position > 0 {
+				residues = append(residues, localizedResidue{string(peptide[i-1]), position, probability})
+			}
+
+			i += end
+			continue
+		}
+
+		if c >= 'A' && c <= 'Z' {
+			position++
+		}
+	}
+
+	sort.SliceStable(residues, func(i, j int) bool { return residues[i].probability > residues[j].probability })
+
+	return residues
+}
+
+// localizedSites selects the most probable residues, as many as the number of modifications on the peptide
+func localizedSites(residues []localizedResidue, minProbability float64) []localizedResidue {
+
+	var total float64
+	for _, i := range residues {
+		total += i.probability
+	}
+
+	n := int(math.Round(total))
+	if n < 1 {
+		n = 1
+	}
+
+	var sites []localizedResidue
+	for i := 0; i < n && i < len(residues); i++ {
+		if residues[i].probability >= minProbability {
+			sites = append(sites, residues[i])
+		}
+	}
+
+	return sites
+}
+
+// sequenceWindow returns the protein residues around the site, padded with _ at the protein termini
+func sequenceWindow(sequence string, position int) string {
+
+	var window strings.Builder
+
+	for i := position - siteWindow; i <= position+siteWindow; i++ {
+		if i < 1 || i > len(sequence) {
+			window.WriteByte('_')
+		} else {
+			window.WriteByte(sequence[i-1])
+		}
+	}
+
+	return window.String()
+}
+
+// rollUpSites sums the PSM intensities to the localized modification sites
+func rollUpSites(evi rep.Evidence, spectrumMap map[id.SpectrumType]iso.Labels, mods []siteModification, minProbability float64) rep.SiteEvidenceList {
+
+	var sequences = make(map[string]string)
+	for _, i := range evi.Proteins {
+		sequences[i.PartHeader] = i.Sequence
+	}
+
+	var siteMap = make(map[string]*rep.SiteEvidence)
+
+	for _, i := range evi.PSM {
+
+		if i.PTM == nil {
+			continue
+		}
+
+		labels, ok := spectrumMap[i.SpectrumFileName()]
+		if !ok {
+			continue
+		}
+
+		start := i.ProteinStart
+		if start < 1 {
+			start = 1
+		}
+
+		for ptm, peptide := range i.PTM.LocalizedPTMMassDiff {
+
+			mod, ok := matchSiteModification(ptm, mods)
+			if !ok {
+				continue
+			}
+
+			for _, j := range localizedSites(parseLocalization(peptide), minProbability) {
+
+				position := start + j.position - 1
+				key := fmt.Sprintf("%s#%s#%d", i.Protein, mod.name, position)
+
+				site, ok := siteMap[key]
+				if !ok {
+
+					sequence, ok := sequences[i.Protein]
+					if !ok || len(sequence) < position {
+						sequence = strings.Repeat("_", start-1) + i.Peptide
+					}
+
+					site = &rep.SiteEvidence{
+						Protein:            i.Protein,
+						ProteinID:          i.ProteinID,
+						EntryName:          i.EntryName,
+						GeneName:           i.GeneName,
+						ProteinDescription: i.ProteinDescription,
+						Modification:       mod.name,
+						AminoAcid:          j.aminoAcid,
+						SequenceWindow:     sequenceWindow(sequence, position),
+						Position:           position,
+						MassDiff:           mod.mass,
+						IsUnique:           i.IsUnique,
+						IsDecoy:            i.IsDecoy,
+						Spectra:            make(map[id.SpectrumType]uint8),
+						Peptides:           make(map[string]uint8),
+						Labels:             &iso.Labels{},
+					}
+					siteMap[key] = site
+				}
+
+				// a site is unique only when all of its PSMs are
+				site.IsUnique = site.IsUnique && i.IsUnique
+
+				if j.probability > site.Probability {
+					site.Probability = j.probability
+				}
+
+				site.Spectra[i.SpectrumFileName()]++
+				site.Peptides[i.Peptide]++
+				site.Labels.Add(labels)
+			}
+		}
+	}
+
+	var sites rep.SiteEvidenceList
+	for _, v := range siteMap {
+		sites = append(sites, *v)
+	}
+
+	sort.Sort(sites)
+
+	return sites
+}
@@ -0,0 +1,111 @@
+package qua
+
+import (
+	"testing"
+
+	"philosopher/lib/id"
+	"philosopher/lib/iso"
+	"philosopher/lib/obo"
+	"philosopher/lib/rep"
+)
+
+func Test_matchSiteModification(t *testing.T) {
+
+	mods := []siteModification{{"Phospho", 79.966331}, {"GG", 114.042927}}
+
+	mod, ok := matchSiteModification("PTMProphet_STY79.9663", mods)
+	if !ok || mod.name != "Phospho" {
+		t.Errorf("Modification is incorrect, got %v", mod)
+	}
+
+	mod, ok = matchSiteModification("PTMProphet_K114.0429", mods)
+	if !ok || mod.name != "GG" {
+		t.Errorf("Modification is incorrect, got %v", mod)
+	}
+
+	if _, ok = matchSiteModification("PTMProphet_K42.0106", mods); ok {
+		t.Errorf("Acetylation should not match the selected modifications")
+	}
+
+	terms := []obo.Term{{Name: "Acetyl", MonoIsotopicMass: 42.010565}}
+	if mod, ok = uniModSite("acetyl", terms); !ok || mod.mass != 42.010565 {
+		t.Errorf("UniMod modification is incorrect, got %v", mod)
+	}
+}
+
+func Test_parseLocalization(t *testing.T) {
+
+	residues := parseLocalization("AS(0.250)T(0.750)PEPS(1.000)K")
+	if len(residues) != 3 {
+		t.Fatalf("Localized residues are incorrect, got %v", residues)
+	}
+
+	if residues[0].position != 7 || residues[1].position != 3 || residues[1].aminoAcid != "T" {
+		t.Errorf("Localized residues are incorrect, got %v", residues)
+	}
+
+	sites := localizedSites(residues, 0.75)
+	if len(sites) != 2 || sites[0].position != 7 || sites[1].position != 3 {
+		t.Errorf("Localized sites are incorrect, got %v", sites)
+	}
+
+	sites = localizedSites(residues, 0.9)
+	if len(sites) != 1 {
+		t.Errorf("Localized sites are incorrect, got %v", sites)
+	}
+}
+
+func Test_sequenceWindow(t *testing.T) {
+
+	if w := sequenceWindow("MASTPEPK", 3); w != "_____MASTPEPK__" {
+		t.Errorf("Sequence window is incorrect, got %s", w)
+	}
+}
+
+func Test_rollUpSites(t *testing.T) {
+
+	labels := iso.Labels{Channels: []iso.Channel{{Name: "126", Intensity: 10}, {Name: "127", Intensity: 20}}}
+
+	var evi rep.Evidence
+	evi.Proteins = rep.ProteinEvidenceList{{PartHeader: "sp|P1|PROT", Sequence: "MKASTPEPKR"}}
+
+	for _, i := range []string{"run.00001.00001.2", "run.00002.00002.2", "run.00003.00003.2"} {
+		evi.PSM = append(evi.PSM, rep.PSMEvidence{
+			Spectrum:     i,
+			Peptide:      "ASTPEPK",
+			Protein:      "sp|P1|PROT",
+			ProteinStart: 3,
+			Labels:       &labels,
+			PTM: &id.PTM{
+				LocalizedPTMSites:    map[string]int{"PTMProphet_STY79.9663": 2},
+				LocalizedPTMMassDiff: map[string]string{"PTMProphet_STY79.9663": "AS(0.900)T(0.100)PEPK"},
+			},
+		})
+	}
+
+	// the second PSM is shared with another protein
+	evi.PSM[0].IsUnique = true
+
+	spectrumMap := map[id.SpectrumType]iso.Labels{
+		evi.PSM[0].SpectrumFileName(): labels,
+		evi.PSM[1].SpectrumFileName(): labels,
+	}
+
+	sites := rollUpSites(evi, spectrumMap, []siteModification{{"Phospho", 79.966331}}, 0.75)
+	if len(sites) != 1 {
+		t.Fatalf("Sites are incorrect, got %v", sites)
+	}
+
+	s := sites[0]
+	if s.Position != 4 || s.AminoAcid != "S" || s.SequenceWindow != "____MKASTPEPKR_" || s.Probability != 0.9 {
+		t.Errorf("Site is incorrect, got %v", s)
+	}
+
+	if len(s.Spectra) != 2 || s.Labels.Channels[1].Intensity != 40 {
+		t.Errorf("Site intensities are incorrect, got %v", s.Labels)
+	}
+
+	if s.IsUnique {
+		t.Errorf("Site with a shared PSM should not be unique")
+	}
+}
@@ -1,10 +1,22 @@
 package qua
 
 import (
+	"math"
+	"strconv"
+	"strings"
+
+	"philosopher/lib/bio"
 	"philosopher/lib/id"
 	"philosopher/lib/rep"
 )
 
+// digestMinLength and digestMaxLength are the observable peptide lengths for
+// the emPAI when the search parameters do not define them
+const (
+	digestMinLength = 7
+	digestMaxLength = 50
+)
+
 // CalculateSpectralCounts add Spc to ions and proteins
 func CalculateSpectralCounts(e rep.Evidence) rep.Evidence {
 
@@ -65,6 +77,134 @@ func CalculateSpectralCounts(e rep.Evidence) rep.Evidence {
 	return e
 }
 
+// RunSpectralAbundances calculates the protein spectral abundances of the
+// workspace, they are reported with the protein spectral counts
+func RunSpectralAbundances() {
+
+	var e rep.Evidence
+	e.RestoreGranular()
+	e.RestoreSearch()
+
+	if len(e.Proteins) == 0 {
+		return
+	}
+
+	e = CalculateSpectralAbundances(e)
+
+	rep.SerializeProteins(&e.Proteins)
+}
+
+// CalculateSpectralAbundances adds the NSAF, dNSAF and emPAI to the proteins.
+// The NSAF divides the spectral count by the protein length, the dNSAF shares
+// the spectra of the shared peptides by the unique spectral counts of each
+// protein, and the emPAI compares the observed peptides with the peptides from
+// the in-silico digestion with the search enzyme
+func CalculateSpectralAbundances(e rep.Evidence) rep.Evidence {
+
+	var enzyme bio.Enzyme
+	if len(e.Parameters.SearchEnzymeName) == 0 || strings.Contains(strings.ToLower(e.Parameters.SearchEnzymeName), "trypsin") {
+		enzyme.Synth("trypsin")
+	} else {
+		enzyme.Synth(e.Parameters.SearchEnzymeName)
+		if len(enzyme.Name) == 0 {
+			enzyme.Synth("trypsin")
+		}
+	}
+
+	minLength, err := strconv.Atoi(e.Parameters.DigestMinLength)
+	if err != nil {
+		minLength = digestMinLength
+	}
+
+	maxLength, err := strconv.Atoi(e.Parameters.DigestMaxLength)
+	if err != nil {
+		maxLength = digestMaxLength
+	}
+
+	var uniqueSpC = make(map[string]float64)
+	for _, i := range e.Proteins {
+		uniqueSpC[i.PartHeader] = float64(i.UniqueSpC)
+	}
+
+	// the shared spectra are distributed by the unique spectral counts, or
+	// assigned to the razor protein when none of the proteins has unique spectra
+	var distributed = make(map[string]float64)
+	for _, i := range e.PSM {
+
+		if i.IsUnique || i.IsDecoy {
+			continue
+		}
+
+		var proteins = []string{i.Protein}
+		for j := range i.MappedProteins {
+			if j != i.Protein {
+				proteins = append(proteins, j)
+			}
+		}
+
+		var sum float64
+		for _, j := range proteins {
+			sum += uniqueSpC[j]
+		}
+
+		if sum > 0 {
+			for _, j := range proteins {
+				distributed[j] += uniqueSpC[j] / sum
+			}
+		} else if i.IsURazor {
+			distributed[i.Protein]++
+		} else {
+			for _, j := range proteins {
+				distributed[j] += 1 / float64(len(proteins))
+			}
+		}
+	}
+
+	var saf = make([]float64, len(e.Proteins))
+	var dsaf = make([]float64, len(e.Proteins))
+	var safSum, dsafSum float64
+
+	for i := range e.Proteins {
+
+		e.Proteins[i].NSAF = 0
+		e.Proteins[i].DNSAF = 0
+		e.Proteins[i].EmPAI = 0
+
+		length := e.Proteins[i].Length
+		if length == 0 {
+			length = len(e.Proteins[i].Sequence)
+		}
+
+		if length == 0 {
+			continue
+		}
+
+		saf[i] = float64(e.Proteins[i].TotalSpC) / float64(length)
+		dsaf[i] = (float64(e.Proteins[i].UniqueSpC) + distributed[e.Proteins[i].PartHeader]) / float64(length)
+		safSum += saf[i]
+		dsafSum += dsaf[i]
+
+		observable := len(enzyme.Digest(e.Proteins[i].Sequence, minLength, maxLength))
+		if observable > 0 {
+			observed := math.Min(float64(len(e.Proteins[i].TotalPeptides)), float64(observable))
+			e.Proteins[i].EmPAI = math.Pow(10, observed/float64(observable)) - 1
+		}
+	}
+
+	for i := range e.Proteins {
+
+		if safSum > 0 {
+			e.Proteins[i].NSAF = saf[i] / safSum
+		}
+
+		if dsafSum > 0 {
+			e.Proteins[i].DNSAF = dsaf[i] / dsafSum
+		}
+	}
+
+	return e
+}
+
 // func CalculateSpectralCounts(e rep.Evidence) rep.Evidence {
 
 // 	var uniqueIonPSM = make(map[string]string)
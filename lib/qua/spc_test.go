@@ -0,0 +1,46 @@
+package qua
+
+import (
+	"math"
+	"testing"
+
+	"philosopher/lib/rep"
+)
+
+func TestCalculateSpectralAbundances(t *testing.T) {
+
+	var e rep.Evidence
+
+	e.Proteins = rep.ProteinEvidenceList{
+		{PartHeader: "A", Sequence: "MAGKPEPTIDERLLKAAAAAAK", Length: 20, TotalSpC: 4, UniqueSpC: 3, TotalPeptides: map[string]int{"MAGKPEPTIDER": 1}},
+		{PartHeader: "B", Sequence: "MAGKPEPTIDERLLKAAAAAAK", Length: 10, TotalSpC: 2, UniqueSpC: 1, TotalPeptides: map[string]int{"MAGKPEPTIDER": 1}},
+	}
+
+	// three unique spectra on A, one on B, one shared spectrum, and a shared
+	// decoy spectrum that is not distributed
+	e.PSM = []rep.PSMEvidence{
+		{Protein: "A", IsUnique: true},
+		{Protein: "A", IsUnique: true},
+		{Protein: "A", IsUnique: true},
+		{Protein: "B", IsUnique: true},
+		{Protein: "A", MappedProteins: map[string]int{"B": 0}},
+		{Protein: "rev_A", IsDecoy: true, MappedProteins: map[string]int{"B": 0}},
+	}
+
+	e = CalculateSpectralAbundances(e)
+
+	// SAF 4/20 and 2/10
+	if math.Abs(e.Proteins[0].NSAF-0.5) > 1e-9 || math.Abs(e.Proteins[1].NSAF-0.5) > 1e-9 {
+		t.Errorf("NSAF is incorrect, got %f and %f, want %f", e.Proteins[0].NSAF, e.Proteins[1].NSAF, 0.5)
+	}
+
+	// the shared spectrum is split 3:1, dSAF 3.75/20 and 1.25/10
+	if math.Abs(e.Proteins[0].DNSAF-0.6) > 1e-9 {
+		t.Errorf("dNSAF is incorrect, got %f, want %f", e.Proteins[0].DNSAF, 0.6)
+	}
+
+	// one of the two tryptic peptides with at least 7 residues is observed
+	if math.Abs(e.Proteins[0].EmPAI-(math.Pow(10, 0.5)-1)) > 1e-9 {
+		t.Errorf("emPAI is incorrect, got %f, want %f", e.Proteins[0].EmPAI, math.Pow(10, 0.5)-1)
+	}
+}
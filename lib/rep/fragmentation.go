@@ -0,0 +1,219 @@
+package rep
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"philosopher/lib/bio"
+	"philosopher/lib/mod"
+	"philosopher/lib/psi"
+)
+
+// defaultFragmentTolerance is used when the search parameters do not define one, in ppm
+const defaultFragmentTolerance = 20.0
+
+// residueMasses holds the monoisotopic residue masses indexed by the one letter code
+var residueMasses = func() map[byte]float64 {
+
+	var masses = make(map[byte]float64)
+
+	for _, i := range "ACDEFGHIKLMNPQRSTVWY" {
+		masses[byte(i)] = bio.NewFromCode(string(i)).MonoIsotopeMass
+	}
+
+	return masses
+}()
+
+// fragmentTolerance returns the fragment tolerance from the search parameters and
+// whether it is expressed in ppm
+func fragmentTolerance(p SearchParametersEvidence) (float64, bool) {
+
+	tol, e := strconv.ParseFloat(p.FragmentMassTolerance, 64)
+	if e != nil || tol <= 0 {
+		return defaultFragmentTolerance, true
+	}
+
+	// MSFragger and Comet use 1 for ppm and 0 for Daltons
+	ppm := p.FragmentMassUnits == "1" || strings.EqualFold(p.FragmentMassUnits, "ppm")
+
+	return tol, ppm
+}
+
+// Fragment is a theoretical b or y ion of a peptide
+type Fragment struct {
+	Series string
+	Index  int
+	Charge int
+	Mz     float64
+}
+
+// TheoreticalFragments returns the b and y ions of a PSM including the assigned
+// modifications, up to charge 2. It returns nil when the peptide has a residue
+// without a known mass
+func TheoreticalFragments(psm PSMEvidence) []Fragment {
+
+	length := len(psm.Peptide)
+	if length < 2 {
+		return nil
+	}
+
+	// residue masses including the modifications
+	residues := make([]float64, length)
+	for i := 0; i < length; i++ {
+		mass, ok := residueMasses[psm.Peptide[i]]
+		if !ok {
+			return nil
+		}
+		residues[i] = mass
+	}
+
+	var nTerm, cTerm float64
+	for _, i := range psm.Modifications.IndexSlice {
+
+		if i.Type != mod.Assigned || i.Name == "Unknown" {
+			continue
+		}
+
+		site, _ := mzTabSite(i.AminoAcid)
+		switch {
+		case site == "N-term":
+			nTerm += i.MassDiff
+		case site == "C-term":
+			cTerm += i.MassDiff
+		case i.Position >= 1 && i.Position <= length:
+			residues[i.Position-1] += i.MassDiff
+		}
+	}
+
+	maxCharge := psm.AssumedCharge - 1
+	if maxCharge > 2 {
+		maxCharge = 2
+	}
+	if maxCharge < 1 {
+		maxCharge = 1
+	}
+
+	var fragments []Fragment
+
+	for _, series := range []string{"b", "y"} {
+		for z := 1; z <= int(maxCharge); z++ {
+			for n := 1; n < length; n++ {
+
+				var mass float64
+				if series == "b" {
+					mass = nTerm
+					for i := 0; i < n; i++ {
+						mass += residues[i]
+					}
+				} else {
+					mass = cTerm + 2*bio.Hydrogen + bio.Oxygen
+					for i := length - n; i < length; i++ {
+						mass += residues[i]
+					}
+				}
+
+				fragments = append(fragments, Fragment{series, n, z, (mass + float64(z)*bio.Proton) / float64(z)})
+			}
+		}
+	}
+
+	return fragments
+}
+
+// annotateFragments matches the theoretical b and y ions of a PSM against the
+// spectrum peaks and returns the annotated ions, or nil when nothing was matched
+func annotateFragments(psm PSMEvidence, mz, intensity []float64, tol float64, ppm bool) *psi.Fragmentation {
+
+	if len(mz) == 0 || len(mz) != len(intensity) {
+		return nil
+	}
+
+	fragments := TheoreticalFragments(psm)
+	if fragments == nil {
+		return nil
+	}
+
+	var frag psi.Fragmentation
+
+	for _, series := range []string{"b", "y"} {
+		for z := 1; z <= 2; z++ {
+
+			var index, mzs, ints, errs []string
+
+			for _, f := range fragments {
+
+				if f.Series != series || f.Charge != z {
+					continue
+				}
+
+				peak := closestPeak(mz, f.Mz)
+				delta := mz[peak] - f.Mz
+
+				limit := tol
+				if ppm {
+					limit = f.Mz * tol / 1e6
+				}
+
+				if math.Abs(delta) > limit {
+					continue
+				}
+
+				index = append(index, strconv.Itoa(f.Index))
+				mzs = append(mzs, fmt.Sprintf("%.4f", mz[peak]))
+				ints = append(ints, fmt.Sprintf("%.1f", intensity[peak]))
+				errs = append(errs, fmt.Sprintf("%.4f", delta))
+			}
+
+			if len(index) == 0 {
+				continue
+			}
+
+			ion := psi.IonType{
+				Charge: z,
+				Index:  strings.Join(index, " "),
+				FragmentArray: []psi.FragmentArray{
+					{MeasureRef: "Measure_MZ", Values: strings.Join(mzs, " ")},
+					{MeasureRef: "Measure_Int", Values: strings.Join(ints, " ")},
+					{MeasureRef: "Measure_Error", Values: strings.Join(errs, " ")},
+				},
+			}
+
+			if series == "b" {
+				ion.CVParam = []psi.CVParam{{CVRef: "PSI-MS", Accession: "MS:1001224", Name: "frag: b ion"}}
+			} else {
+				ion.CVParam = []psi.CVParam{{CVRef: "PSI-MS", Accession: "MS:1001220", Name: "frag: y ion"}}
+			}
+
+			frag.IonType = append(frag.IonType, ion)
+		}
+	}
+
+	if len(frag.IonType) == 0 {
+		return nil
+	}
+
+	return &frag
+}
+
+// closestPeak returns the index of the peak closest to the target m/z, the peaks
+// are expected to be sorted by m/z
+func closestPeak(mz []float64, target float64) int {
+
+	lo, hi := 0, len(mz)-1
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if mz[mid] < target {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+
+	if lo > 0 && math.Abs(mz[lo-1]-target) < math.Abs(mz[lo]-target) {
+		return lo - 1
+	}
+
+	return lo
+}
@@ -0,0 +1,34 @@
+package rep
+
+import (
+	"testing"
+)
+
+func Test_annotateFragments(t *testing.T) {
+
+	psm := PSMEvidence{Peptide: "PEPK", AssumedCharge: 2}
+
+	mz := []float64{98.0600, 120.5000, 147.1128, 500.0000}
+	intensity := []float64{1000, 50, 2000, 10}
+
+	f := annotateFragments(psm, mz, intensity, 20, true)
+	if f == nil {
+		t.Fatalf("annotateFragments() = nil, want b1 and y1")
+	}
+
+	if len(f.IonType) != 2 {
+		t.Fatalf("annotateFragments() ion types = %d, want 2", len(f.IonType))
+	}
+
+	if f.IonType[0].CVParam[0].Accession != "MS:1001224" || f.IonType[0].Index != "1" || f.IonType[0].FragmentArray[0].Values != "98.0600" {
+		t.Errorf("annotateFragments() b ions = %+v", f.IonType[0])
+	}
+
+	if f.IonType[1].CVParam[0].Accession != "MS:1001220" || f.IonType[1].Index != "1" || f.IonType[1].FragmentArray[1].Values != "2000.0" {
+		t.Errorf("annotateFragments() y ions = %+v", f.IonType[1])
+	}
+
+	if f := annotateFragments(psm, []float64{300}, []float64{1}, 20, true); f != nil {
+		t.Errorf("annotateFragments() = %+v, want nil", f)
+	}
+}
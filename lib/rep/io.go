@@ -49,6 +49,34 @@ func SerializeProteins(evi *ProteinEvidenceList) {
 	sys.Serialize(evi, sys.ProBin())
 }
 
+// SerializeSites creates an ev serial with the modification site data
+func SerializeSites(evi *SiteEvidenceList) {
+	sys.Serialize(evi, sys.SiteBin())
+}
+
+// SerializeSearch saves the search parameters and modifications used for the identifications
+func (evi *Evidence) SerializeSearch() {
+	search := SearchEvidence{Parameters: evi.Parameters, Mods: evi.Mods}
+	sys.Serialize(&search, sys.SearchBin())
+}
+
+// RestoreSearch restores the search parameters and modifications, if available
+func (evi *Evidence) RestoreSearch() {
+	var search SearchEvidence
+	sys.Restore(&search, sys.SearchBin(), true)
+	evi.Parameters = search.Parameters
+	evi.Mods = search.Mods
+}
+
+// RestoreSearchWithPath restores the search parameters and modifications of a workspace, if available
+func (evi *Evidence) RestoreSearchWithPath(p string) {
+	var search SearchEvidence
+	path := fmt.Sprintf("%s%s%s", p, string(filepath.Separator), sys.SearchBin())
+	sys.Restore(&search, path, true)
+	evi.Parameters = search.Parameters
+	evi.Mods = search.Mods
+}
+
 // RestoreGranular reads philosopher results files and restore the data sctructure
 func (evi *Evidence) RestoreGranular() {
 
@@ -85,6 +113,11 @@ func RestoreProtein(evi *ProteinEvidenceList) {
 	sys.Restore(evi, sys.ProBin(), false)
 }
 
+// RestoreSites restores the modification site data, if available
+func RestoreSites(evi *SiteEvidenceList) {
+	sys.Restore(evi, sys.SiteBin(), true)
+}
+
 // RestoreGranularWithPath reads philosopher results files and restore the data sctructure
 func (evi *Evidence) RestoreGranularWithPath(p string) {
 
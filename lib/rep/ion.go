@@ -16,6 +16,7 @@ import (
 	"philosopher/lib/bio"
 	"philosopher/lib/cla"
 	"philosopher/lib/id"
+	"philosopher/lib/iso"
 	"philosopher/lib/mod"
 	"philosopher/lib/uti"
 )
@@ -77,6 +78,8 @@ func (evi *Evidence) AssembleIonReport(ion id.PepIDList, decoyTag string) {
 		pr.MappedProteins[i.Protein] = 0
 		pr.Modifications = i.Modifications
 		pr.Probability = bestProb[pr.IonForm()]
+		pr.Qvalue = i.Qvalue
+		pr.PEP = i.PEP
 
 		// get the mapped proteins
 		for _, j := range psmPtMap[pr.IonForm()] {
@@ -103,10 +106,16 @@ func (evi *Evidence) AssembleIonReport(ion id.PepIDList, decoyTag string) {
 }
 
 // IonReport reports consist on ion reporting
-func (evi IonEvidenceList) IonReport(workspace, brand, decoyTag string, channels int, hasDecoys, hasLabels, hasPrefix, removeContam bool) {
+func (evi IonEvidenceList) IonReport(workspace, decoyTag string, kit iso.Kit, hasDecoys, hasLabels, hasPrefix, removeContam bool) {
 
 	var header string
 	var output string
+	var hasPeak bool
+	var hasIsotopes bool
+	var hasAligned bool
+	var hasFragments bool
+	var hasMS1Labels bool
+	var hasMedium bool
 
 	if hasPrefix {
 		output = fmt.Sprintf("%s%s%s_ion.tsv", workspace, string(filepath.Separator), path.Base(workspace))
@@ -130,6 +139,29 @@ func (evi IonEvidenceList) IonReport(workspace, brand, decoyTag string, channels
 			continue
 		}
 
+		if i.PeakArea > 0 {
+			hasPeak = true
+		}
+
+		if i.SummedIsotopeIntensity > 0 {
+			hasIsotopes = true
+		}
+
+		if i.AlignedRetentionTime > 0 {
+			hasAligned = true
+		}
+
+		if i.QuantifiedFragments > 0 {
+			hasFragments = true
+		}
+
+		if i.MS1Labels != nil {
+			hasMS1Labels = true
+			if i.MS1Labels.Medium > 0 {
+				hasMedium = true
+			}
+		}
+
 		// This inclusion is necessary to avoid unexistent observations from being included after using the filter --mods options
 		if i.Probability > 0 {
 			if !hasDecoys {
@@ -144,149 +176,41 @@ func (evi IonEvidenceList) IonReport(workspace, brand, decoyTag string, channels
 
 	header = "Peptide Sequence\tModified Sequence\tPrev AA\tNext AA\tPeptide Length\tM/Z\tCharge\tObserved Mass\tProbability\tExpectation\tSpectral Count\tIntensity\tAssigned Modifications\tObserved Modifications\tProtein\tProtein ID\tEntry Name\tGene\tProtein Description\tMapped Genes\tMapped Proteins"
 
+	if hasPeak {
+		header += "\tApex Retention Time\tPeak Start\tPeak End\tFWHM\tPeak Area\tPeak Intensity"
+	}
+
+	if hasIsotopes {
+		header += "\tIsotope Correlation\tSummed Isotope Intensity\tIsotope Interference"
+	}
+
+	if hasAligned {
+		header += "\tAligned Retention Time"
+	}
+
+	if hasFragments {
+		header += "\tQuantified Fragments\tCo-Elution Score"
+	}
+
+	if hasMS1Labels {
+		header += ms1LabelHeader(hasMedium)
+	}
+
 	var headerIndex int
 	for i := range printSet {
-		if printSet[i].Labels != nil && len(printSet[i].Labels.Channel1.Name) > 0 {
+		if printSet[i].Labels != nil && len(printSet[i].Labels.Channels) > 0 {
 			headerIndex = i
 			break
 		}
 	}
 
-	if brand == "tmt" {
-		switch channels {
-		case 6:
-			header = fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s",
-				header,
-				printSet[headerIndex].Labels.Channel1.CustomName,
-				printSet[headerIndex].Labels.Channel2.CustomName,
-				printSet[headerIndex].Labels.Channel5.CustomName,
-				printSet[headerIndex].Labels.Channel6.CustomName,
-				printSet[headerIndex].Labels.Channel9.CustomName,
-				printSet[headerIndex].Labels.Channel10.CustomName,
-			)
-		case 10:
-			header = fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
-				header,
-				printSet[headerIndex].Labels.Channel1.CustomName,
-				printSet[headerIndex].Labels.Channel2.CustomName,
-				printSet[headerIndex].Labels.Channel3.CustomName,
-				printSet[headerIndex].Labels.Channel4.CustomName,
-				printSet[headerIndex].Labels.Channel5.CustomName,
-				printSet[headerIndex].Labels.Channel6.CustomName,
-				printSet[headerIndex].Labels.Channel7.CustomName,
-				printSet[headerIndex].Labels.Channel8.CustomName,
-				printSet[headerIndex].Labels.Channel9.CustomName,
-				printSet[headerIndex].Labels.Channel10.CustomName,
-			)
-		case 11:
-			header = fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
-				header,
-				printSet[headerIndex].Labels.Channel1.CustomName,
-				printSet[headerIndex].Labels.Channel2.CustomName,
-				printSet[headerIndex].Labels.Channel3.CustomName,
-				printSet[headerIndex].Labels.Channel4.CustomName,
-				printSet[headerIndex].Labels.Channel5.CustomName,
-				printSet[headerIndex].Labels.Channel6.CustomName,
-				printSet[headerIndex].Labels.Channel7.CustomName,
-				printSet[headerIndex].Labels.Channel8.CustomName,
-				printSet[headerIndex].Labels.Channel9.CustomName,
-				printSet[headerIndex].Labels.Channel10.CustomName,
-				printSet[headerIndex].Labels.Channel11.CustomName,
-			)
-		case 16:
-			header = fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
-				header,
-				printSet[headerIndex].Labels.Channel1.CustomName,
-				printSet[headerIndex].Labels.Channel2.CustomName,
-				printSet[headerIndex].Labels.Channel3.CustomName,
-				printSet[headerIndex].Labels.Channel4.CustomName,
-				printSet[headerIndex].Labels.Channel5.CustomName,
-				printSet[headerIndex].Labels.Channel6.CustomName,
-				printSet[headerIndex].Labels.Channel7.CustomName,
-				printSet[headerIndex].Labels.Channel8.CustomName,
-				printSet[headerIndex].Labels.Channel9.CustomName,
-				printSet[headerIndex].Labels.Channel10.CustomName,
-				printSet[headerIndex].Labels.Channel11.CustomName,
-				printSet[headerIndex].Labels.Channel12.CustomName,
-				printSet[headerIndex].Labels.Channel13.CustomName,
-				printSet[headerIndex].Labels.Channel14.CustomName,
-				printSet[headerIndex].Labels.Channel15.CustomName,
-				printSet[headerIndex].Labels.Channel16.CustomName,
-			)
-		case 18:
-			header = fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
-				header,
-				printSet[headerIndex].Labels.Channel1.CustomName,
-				printSet[headerIndex].Labels.Channel2.CustomName,
-				printSet[headerIndex].Labels.Channel3.CustomName,
-				printSet[headerIndex].Labels.Channel4.CustomName,
-				printSet[headerIndex].Labels.Channel5.CustomName,
-				printSet[headerIndex].Labels.Channel6.CustomName,
-				printSet[headerIndex].Labels.Channel7.CustomName,
-				printSet[headerIndex].Labels.Channel8.CustomName,
-				printSet[headerIndex].Labels.Channel9.CustomName,
-				printSet[headerIndex].Labels.Channel10.CustomName,
-				printSet[headerIndex].Labels.Channel11.CustomName,
-				printSet[headerIndex].Labels.Channel12.CustomName,
-				printSet[headerIndex].Labels.Channel13.CustomName,
-				printSet[headerIndex].Labels.Channel14.CustomName,
-				printSet[headerIndex].Labels.Channel15.CustomName,
-				printSet[headerIndex].Labels.Channel16.CustomName,
-				printSet[headerIndex].Labels.Channel17.CustomName,
-				printSet[headerIndex].Labels.Channel18.CustomName,
-			)
-		default:
-			header += ""
-		}
-	} else if brand == "itraq" {
-		switch channels {
-		case 4:
-			header = fmt.Sprintf("%s\t%s\t%s\t%s\t%s",
-				header,
-				printSet[headerIndex].Labels.Channel1.CustomName,
-				printSet[headerIndex].Labels.Channel2.CustomName,
-				printSet[headerIndex].Labels.Channel3.CustomName,
-				printSet[headerIndex].Labels.Channel4.CustomName,
-			)
-		case 8:
-			header = fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
-				header,
-				printSet[headerIndex].Labels.Channel1.CustomName,
-				printSet[headerIndex].Labels.Channel2.CustomName,
-				printSet[headerIndex].Labels.Channel3.CustomName,
-				printSet[headerIndex].Labels.Channel4.CustomName,
-				printSet[headerIndex].Labels.Channel5.CustomName,
-				printSet[headerIndex].Labels.Channel6.CustomName,
-				printSet[headerIndex].Labels.Channel7.CustomName,
-				printSet[headerIndex].Labels.Channel8.CustomName,
-			)
-		default:
-			header += ""
-		}
-	} else if brand == "xtag" {
-		header = fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
-			header,
-			printSet[headerIndex].Labels.Channel1.CustomName,
-			printSet[headerIndex].Labels.Channel2.CustomName,
-			printSet[headerIndex].Labels.Channel3.CustomName,
-			printSet[headerIndex].Labels.Channel4.CustomName,
-			printSet[headerIndex].Labels.Channel5.CustomName,
-			printSet[headerIndex].Labels.Channel6.CustomName,
-			printSet[headerIndex].Labels.Channel7.CustomName,
-			printSet[headerIndex].Labels.Channel8.CustomName,
-			printSet[headerIndex].Labels.Channel9.CustomName,
-			printSet[headerIndex].Labels.Channel10.CustomName,
-			printSet[headerIndex].Labels.Channel11.CustomName,
-			printSet[headerIndex].Labels.Channel12.CustomName,
-			printSet[headerIndex].Labels.Channel13.CustomName,
-			printSet[headerIndex].Labels.Channel14.CustomName,
-			printSet[headerIndex].Labels.Channel15.CustomName,
-			printSet[headerIndex].Labels.Channel16.CustomName,
-			printSet[headerIndex].Labels.Channel17.CustomName,
-			printSet[headerIndex].Labels.Channel18.CustomName,
-		)
+	if len(kit.Channels) > 0 {
+		header = labelHeader(header, kit, printSet[headerIndex].Labels)
+		header = rawLabelHeader(header, kit, printSet[headerIndex].Labels)
 	}
 
+	header += "\tQ-Value\tPEP"
+
 	header += "\n"
 
 	_, e = io.WriteString(bw, header)
@@ -348,140 +272,51 @@ func (evi IonEvidenceList) IonReport(workspace, brand, decoyTag string, channels
 			strings.Join(mappedProteins, ","),
 		)
 
-		if brand == "tmt" {
-			switch channels {
-			case 6:
-				line = fmt.Sprintf("%s\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f",
-					line,
-					i.Labels.Channel1.Intensity,
-					i.Labels.Channel2.Intensity,
-					i.Labels.Channel5.Intensity,
-					i.Labels.Channel6.Intensity,
-					i.Labels.Channel9.Intensity,
-					i.Labels.Channel10.Intensity,
-				)
-			case 10:
-				line = fmt.Sprintf("%s\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f",
-					line,
-					i.Labels.Channel1.Intensity,
-					i.Labels.Channel2.Intensity,
-					i.Labels.Channel3.Intensity,
-					i.Labels.Channel4.Intensity,
-					i.Labels.Channel5.Intensity,
-					i.Labels.Channel6.Intensity,
-					i.Labels.Channel7.Intensity,
-					i.Labels.Channel8.Intensity,
-					i.Labels.Channel9.Intensity,
-					i.Labels.Channel10.Intensity,
-				)
-			case 11:
-				line = fmt.Sprintf("%s\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f",
-					line,
-					i.Labels.Channel1.Intensity,
-					i.Labels.Channel2.Intensity,
-					i.Labels.Channel3.Intensity,
-					i.Labels.Channel4.Intensity,
-					i.Labels.Channel5.Intensity,
-					i.Labels.Channel6.Intensity,
-					i.Labels.Channel7.Intensity,
-					i.Labels.Channel8.Intensity,
-					i.Labels.Channel9.Intensity,
-					i.Labels.Channel10.Intensity,
-					i.Labels.Channel11.Intensity,
-				)
-			case 16:
-				line = fmt.Sprintf("%s\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f",
-					line,
-					i.Labels.Channel1.Intensity,
-					i.Labels.Channel2.Intensity,
-					i.Labels.Channel3.Intensity,
-					i.Labels.Channel4.Intensity,
-					i.Labels.Channel5.Intensity,
-					i.Labels.Channel6.Intensity,
-					i.Labels.Channel7.Intensity,
-					i.Labels.Channel8.Intensity,
-					i.Labels.Channel9.Intensity,
-					i.Labels.Channel10.Intensity,
-					i.Labels.Channel11.Intensity,
-					i.Labels.Channel12.Intensity,
-					i.Labels.Channel13.Intensity,
-					i.Labels.Channel14.Intensity,
-					i.Labels.Channel15.Intensity,
-					i.Labels.Channel16.Intensity,
-				)
-			case 18:
-				line = fmt.Sprintf("%s\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f",
-					line,
-					i.Labels.Channel1.Intensity,
-					i.Labels.Channel2.Intensity,
-					i.Labels.Channel3.Intensity,
-					i.Labels.Channel4.Intensity,
-					i.Labels.Channel5.Intensity,
-					i.Labels.Channel6.Intensity,
-					i.Labels.Channel7.Intensity,
-					i.Labels.Channel8.Intensity,
-					i.Labels.Channel9.Intensity,
-					i.Labels.Channel10.Intensity,
-					i.Labels.Channel11.Intensity,
-					i.Labels.Channel12.Intensity,
-					i.Labels.Channel13.Intensity,
-					i.Labels.Channel14.Intensity,
-					i.Labels.Channel15.Intensity,
-					i.Labels.Channel16.Intensity,
-					i.Labels.Channel17.Intensity,
-					i.Labels.Channel18.Intensity,
-				)
-			default:
-				header += ""
-			}
-		} else if brand == "itraq" {
-			switch channels {
-			case 4:
-				line = fmt.Sprintf("%s\t%.4f\t%.4f\t%.4f\t%.4f",
-					line,
-					i.Labels.Channel1.Intensity,
-					i.Labels.Channel2.Intensity,
-					i.Labels.Channel3.Intensity,
-					i.Labels.Channel4.Intensity,
-				)
-			case 8:
-				line = fmt.Sprintf("%s\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f",
-					line,
-					i.Labels.Channel1.Intensity,
-					i.Labels.Channel2.Intensity,
-					i.Labels.Channel3.Intensity,
-					i.Labels.Channel4.Intensity,
-					i.Labels.Channel5.Intensity,
-					i.Labels.Channel6.Intensity,
-					i.Labels.Channel7.Intensity,
-					i.Labels.Channel8.Intensity,
-				)
-			default:
-				header += ""
-			}
-		} else if brand == "xtag" {
-			line = fmt.Sprintf("%s\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f",
+		if hasPeak {
+			line = fmt.Sprintf("%s\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f",
+				line,
+				i.ApexRetentionTime,
+				i.PeakStart,
+				i.PeakEnd,
+				i.FWHM,
+				i.PeakArea,
+				i.PeakIntensity,
+			)
+		}
+
+		if hasIsotopes {
+			line = fmt.Sprintf("%s\t%.4f\t%.4f\t%t",
 				line,
-				i.Labels.Channel1.Intensity,
-				i.Labels.Channel2.Intensity,
-				i.Labels.Channel3.Intensity,
-				i.Labels.Channel4.Intensity,
-				i.Labels.Channel5.Intensity,
-				i.Labels.Channel6.Intensity,
-				i.Labels.Channel7.Intensity,
-				i.Labels.Channel8.Intensity,
-				i.Labels.Channel9.Intensity,
-				i.Labels.Channel10.Intensity,
-				i.Labels.Channel11.Intensity,
-				i.Labels.Channel12.Intensity,
-				i.Labels.Channel13.Intensity,
-				i.Labels.Channel14.Intensity,
-				i.Labels.Channel15.Intensity,
-				i.Labels.Channel16.Intensity,
-				i.Labels.Channel17.Intensity,
-				i.Labels.Channel18.Intensity,
+				i.IsotopeCorrelation,
+				i.SummedIsotopeIntensity,
+				i.IsotopeInterference,
 			)
 		}
+
+		if hasAligned {
+			line = fmt.Sprintf("%s\t%.4f",
+				line,
+				i.AlignedRetentionTime,
+			)
+		}
+
+		if hasFragments {
+			line = fmt.Sprintf("%s\t%d\t%.4f",
+				line,
+				i.QuantifiedFragments,
+				i.CoElutionScore,
+			)
+		}
+
+		if hasMS1Labels {
+			line = ms1LabelLine(line, i.MS1Labels, hasMedium)
+		}
+
+		if len(kit.Channels) > 0 {
+			line = labelLine(line, kit, i.Labels)
+			line = rawLabelLine(line, kit, i.Labels)
+		}
+		line = fmt.Sprintf("%s\t%.6f\t%.6f", line, i.Qvalue, i.PEP)
 		line += "\n"
 
 		_, e = io.WriteString(bw, line)
@@ -1,57 +1,84 @@
 package rep
 
 import (
+	"errors"
 	"fmt"
-	"philosopher/lib/id"
+	"os"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"philosopher/lib/bio"
 	"philosopher/lib/dat"
+	"philosopher/lib/id"
+	"philosopher/lib/met"
+	"philosopher/lib/mod"
+	"philosopher/lib/msg"
+	"philosopher/lib/mzn"
+	"philosopher/lib/obo"
 	"philosopher/lib/psi"
+
+	"github.com/sirupsen/logrus"
 )
 
+// tmtReagents are the PSI-MS terms for the TMT channels reported on the PSMs, by channel name
+var tmtReagents = map[string]psi.CVParam{
+	"126":  {CVRef: "PSI-MS", Accession: "MS:1002616", Name: "TMT reagent 126"},
+	"127N": {CVRef: "PSI-MS", Accession: "MS:1002763", Name: "TMT reagent 127N"},
+	"127C": {CVRef: "PSI-MS", Accession: "MS:1002764", Name: "TMT reagent 127C"},
+	"128N": {CVRef: "PSI-MS", Accession: "MS:1002765", Name: "TMT reagent 128N"},
+	"128C": {CVRef: "PSI-MS", Accession: "MS:1002766", Name: "TMT reagent 128C"},
+	"129N": {CVRef: "PSI-MS", Accession: "MS:1002767", Name: "TMT reagent 129N"},
+	"129C": {CVRef: "PSI-MS", Accession: "MS:1002768", Name: "TMT reagent 129C"},
+	"130N": {CVRef: "PSI-MS", Accession: "MS:1002769", Name: "TMT reagent 130N"},
+	"130C": {CVRef: "PSI-MS", Accession: "MS:1002770", Name: "TMT reagent 130C"},
+	"131N": {CVRef: "PSI-MS", Accession: "MS:1002621", Name: "TMT reagent 131N"},
+}
+
 // MzIdentMLReport creates a MzIdentML structure to be encoded
-func (e Evidence) MzIdentMLReport(version, database string) {
+func (e Evidence) MzIdentMLReport(version, database, searchEngine string, f met.Filter) {
 
 	var mzid psi.MzIdentML
 
 	t := time.Now()
-	//var idCounter = 0
 
 	// collect source file names
-	var sourceMap = make(map[string]uint8)
+	var sourceMap = make(map[string]string)
 	var sources []string
 	for _, i := range e.PSM {
 		s := strings.Split(i.Spectrum, ".")
-		sourceMap[s[0]]++
-	}
-
-	for i := range sourceMap {
-		sources = append(sources, i)
+		if _, ok := sourceMap[s[0]]; !ok {
+			sourceMap[s[0]] = ""
+			sources = append(sources, s[0])
+		}
 	}
 
 	sort.Strings(sources)
 
+	for i, j := range sources {
+		sourceMap[j] = fmt.Sprintf("SD_%d", i+1)
+	}
+
 	// load the database
 	var dtb dat.Base
 	dtb.Restore()
 
-	// spectra evidence reference map
-	var specRef = make(map[id.SpectrumType]string)
-
-	// peptide evidence reference map
-	var pepRef = make(map[string]string)
+	var records = make(map[string]int)
+	for i, j := range dtb.Records {
+		records[j.PartHeader] = i
+		if _, ok := records[j.ID]; !ok {
+			records[j.ID] = i
+		}
+	}
 
-	// protein evidence reference map
-	var proRef = make(map[string]string)
+	o := obo.NewUniModOntology()
 
 	// Header
-	//mzid.Name = "foo"
 	mzid.ID = "Philosopher"
 	mzid.Version = "1.2.0"
-	mzid.CreationDate = t.Format(time.ANSIC)
+	mzid.CreationDate = t.Format("2006-01-02T15:04:05")
 	mzid.Xmlns = "http://psidev.info/psi/pi/mzIdentML/1.2"
 	mzid.XmlnsXsi = "http://www.w3.org/2001/XMLSchema-instance"
 	mzid.XsiSchemaLocation = "http://psidev.info/psi/pi/mzIdentML/1.2 http://www.psidev.info/files/mzIdentML1.2.0.xsd"
@@ -61,7 +88,6 @@ func (e Evidence) MzIdentMLReport(version, database string) {
 	mzid.CvList.CV = append(mzid.CvList.CV, psi.CV{ID: "UNIMOD", URI: "http://www.unimod.org/obo/unimod.obo", FullName: "UNIMOD"})
 	mzid.CvList.CV = append(mzid.CvList.CV, psi.CV{ID: "UO", URI: "https://raw.githubusercontent.com/bio-ontology-research-group/unit-ontology/master/unit.obo", FullName: "UNIT-ONTOLOGY"})
 	mzid.CvList.CV = append(mzid.CvList.CV, psi.CV{ID: "PRIDE", URI: "https://github.com/PRIDE-Utilities/pride-ontology/blob/master/pride_cv.obo", FullName: "PRIDE"})
-	mzid.CvList.Count = len(mzid.CvList.CV)
 
 	// AnalysisSoftwareList
 	aa := &psi.AnalysisSoftware{
@@ -69,8 +95,8 @@ func (e Evidence) MzIdentMLReport(version, database string) {
 		Name:    "Philosopher toolkit",
 		URI:     "https://philosopher.nesvilab.org",
 		Version: version,
-		ContactRole: psi.ContactRole{
-			ContactRef: "PS_DEV",
+		ContactRole: &psi.ContactRole{
+			ContactRef: "Nesvilab",
 			Role: psi.Role{
 				CVParam: psi.CVParam{
 					CVRef:     "PSI-MS",
@@ -80,22 +106,34 @@ func (e Evidence) MzIdentMLReport(version, database string) {
 			},
 		},
 		SoftwareName: psi.SoftwareName{
-			CVParam: psi.CVParam{
-				CVRef:     "PSI-MS",
-				Accession: "XXXX",
-				Name:      "Philosopher",
+			UserParam: &psi.UserParam{
+				Name: "Philosopher",
 			},
 		},
-		Customizations: psi.Customizations{
+		Customizations: &psi.Customizations{
 			Value: "No customizations",
 		},
 	}
 	mzid.AnalysisSoftwareList.AnalysisSoftware = append(mzid.AnalysisSoftwareList.AnalysisSoftware, *aa)
 
+	searchSoftware := "Philosopher"
+	if sn := searchEngineName(searchEngine); sn != nil {
+		searchSoftware = "SearchEngine"
+		se := &psi.AnalysisSoftware{
+			ID:      searchSoftware,
+			Name:    sn.Name,
+			Version: searchEngineVersion(searchEngine, e.Parameters),
+			SoftwareName: psi.SoftwareName{
+				CVParam: sn,
+			},
+		}
+		mzid.AnalysisSoftwareList.AnalysisSoftware = append(mzid.AnalysisSoftwareList.AnalysisSoftware, *se)
+	}
+
 	//Provider
 	provider := &psi.Provider{
 		ID: "PROVIDER",
-		ContactRole: psi.ContactRole{
+		ContactRole: &psi.ContactRole{
 			ContactRef: "Philosopher_Author_FVL",
 			Role: psi.Role{
 				CVParam: psi.CVParam{
@@ -106,10 +144,9 @@ func (e Evidence) MzIdentMLReport(version, database string) {
 			},
 		},
 	}
-	mzid.Provider = *provider
+	mzid.Provider = provider
 
 	// AuditCollection
-
 	auditCol := &psi.AuditCollection{
 		Person: psi.Person{
 			ID:        "Philosopher_Author_FVL",
@@ -131,7 +168,7 @@ func (e Evidence) MzIdentMLReport(version, database string) {
 			},
 			Affiliation: []psi.Affiliation{
 				{
-					OrganizationRef: "University of Michigan",
+					OrganizationRef: "Nesvilab",
 				},
 			},
 		},
@@ -166,945 +203,1275 @@ func (e Evidence) MzIdentMLReport(version, database string) {
 			},
 		},
 	}
-	mzid.AuditCollection = *auditCol
+	mzid.AuditCollection = auditCol
 
-	// SequenceCollection - DBSequence
-	idCounter := 0
+	// SequenceCollection - DBSequence, only the proteins referenced by the evidences are listed
+	var proRef = make(map[string]string)
 	var seqs []psi.DBSequence
-	for _, i := range dtb.Records {
 
-		idCounter++
+	dbRef := func(protein string) string {
 
-		db := &psi.DBSequence{
-			ID:                fmt.Sprintf("DB_%d", idCounter),
-			Accession:         i.ID,
-			SearchDatabaseRef: dtb.FileName,
-			CVParam: []psi.CVParam{
+		if ref, ok := proRef[protein]; ok {
+			return ref
+		}
+
+		ref := fmt.Sprintf("DBSeq_%d", len(seqs)+1)
+		db := psi.DBSequence{
+			ID:                ref,
+			Accession:         protein,
+			SearchDatabaseRef: "SDB_1",
+		}
+
+		if idx, ok := records[protein]; ok {
+			r := dtb.Records[idx]
+			db.Length = strconv.Itoa(len(r.Sequence))
+			db.Seq = &psi.Seq{Value: r.Sequence}
+			db.CVParam = []psi.CVParam{
 				{
 					CVRef:     "PSI-MS",
 					Accession: "MS:1001088",
 					Name:      "protein description",
-					Value:     i.Description,
-				},
-				{
-					CVRef:     "PSI-MS",
-					Accession: "MS:1001344",
-					Name:      "AA sequence",
+					Value:     r.Description,
 				},
-			},
-			Seq: psi.Seq{
-				Value: i.Sequence,
-			},
+			}
 		}
 
-		proRef[i.ID] = fmt.Sprintf("DB_%d", idCounter)
-		seqs = append(seqs, *db)
+		proRef[protein] = ref
+		seqs = append(seqs, db)
+
+		return ref
 	}
-	mzid.SequenceCollection.DBSequence = seqs
 
-	// SequenceCollection - Peptide
+	// SequenceCollection - Peptide, one for each combination of sequence and modifications
+	var pepRef = make(map[string]string)
 	var peps []psi.Peptide
-	for _, i := range e.Peptides {
 
-		p := psi.Peptide{
-			ID: i.Sequence,
-			PeptideSequence: psi.PeptideSequence{
-				Value: i.Sequence,
-			},
-		}
+	// SequenceCollection - PeptideEvidence, one for each peptide to protein mapping
+	var pevRef = make(map[string]string)
+	var pevs []psi.PeptideEvidence
 
-		for _, j := range i.Modifications.IndexSlice {
-			if j.Name != "Unknown" {
-				mod := psi.Modification{
-					//AvgMassDelta:          j.AverageMass,
-					//MonoIsotopicMassDelta: j.MonoIsotopicMass,
-					Residues: j.AminoAcid,
-					Location: strconv.Itoa(j.Position),
-					CVParam: []psi.CVParam{
-						{
-							CVRef:     "UNIMOD",
-							Accession: j.ID,
-							Name:      j.Name,
-						},
-					},
-				}
+	pevOf := func(peptide, protein string, i PSMEvidence) string {
 
-				if mod.Residues == "N-term" {
-					mod.Residues = ""
-				}
+		key := peptide + "#" + protein
+		if ref, ok := pevRef[key]; ok {
+			return ref
+		}
 
-				p.Modification = append(p.Modification, mod)
-			}
+		ref := fmt.Sprintf("PepEv_%d", len(pevs)+1)
+		pev := psi.PeptideEvidence{
+			ID:            ref,
+			DBSequenceRef: dbRef(protein),
+			PeptideRef:    peptide,
+			IsDecoy:       strconv.FormatBool(len(f.Tag) > 0 && strings.HasPrefix(protein, f.Tag)),
 		}
 
-		peps = append(peps, p)
-	}
-	mzid.SequenceCollection.Peptide = peps
+		if protein == i.Protein {
+			pev.IsDecoy = strconv.FormatBool(i.IsDecoy)
+			pev.Start = i.ProteinStart
+			pev.End = i.ProteinEnd
+			pev.Pre = mzIdentMLFlank(i.PrevAA)
+			pev.Post = mzIdentMLFlank(i.NextAA)
+		}
 
-	// SequenceCollection - PeptideEvidence
-	var pevs []psi.PeptideEvidence
-	idCounter = 0
-	for _, i := range e.PSM {
+		pevRef[key] = ref
+		pevs = append(pevs, pev)
 
-		idCounter++
+		return ref
+	}
 
-		evi := psi.PeptideEvidence{
-			DBSequenceRef: i.ProteinID,
-			ID:            fmt.Sprintf("PepEv_%d", idCounter),
-			IsDecoy:       strconv.FormatBool(i.IsDecoy),
-			PeptideRef:    i.Peptide,
-			Pre:           string(i.PrevAA),
-			Post:          string(i.NextAA),
-		}
+	// spectrum identification item reference for each spectrum
+	var specRef = make(map[id.SpectrumType]string)
+	var specPeptide = make(map[id.SpectrumType]string)
+	var specPSM = make(map[id.SpectrumType]int)
 
-		pepRef[i.Peptide] = fmt.Sprintf("PepEv_%d", idCounter)
-		pevs = append(pevs, evi)
+	// DataCollection - SpectrumIdentificationResult
+	sil := psi.SpectrumIdentificationList{
+		ID: "SIL_1",
 	}
-	mzid.SequenceCollection.PeptideEvidence = pevs
-
-	// AnalysisCollection
-	idCounter = 0
-	ac := &psi.AnalysisCollection{}
-	for _, i := range sources {
 
-		idCounter++
+	fragments, nativeIDs := e.mzIdentMLFragmentation(sources)
 
-		si := &psi.SpectrumIdentification{
-			SpectrumIdentificationListRef:     fmt.Sprintf("SIL_%d", idCounter),
-			ID:                                fmt.Sprintf("SpecIdent_%d", idCounter),
-			SpectrumIdentificationProtocolRef: fmt.Sprintf("SearchProtocol_%d", idCounter),
-			InputSpectra: []psi.InputSpectra{
-				{
-					SpectraDataRef: i,
-				},
-			},
-			SearchDatabaseRef: []psi.SearchDatabaseRef{
-				{
-					SearchDatabaseRef: dtb.FileName,
-				},
-			},
+	// the nativeID format of each spectra file is taken from its spectra
+	var sourceNativeIDs = make(map[string]string)
+	for _, i := range e.PSM {
+		source := strings.Split(i.Spectrum, ".")[0]
+		if v, ok := nativeIDs[i.SpectrumFileName()]; ok && len(sourceNativeIDs[source]) == 0 {
+			sourceNativeIDs[source] = v
 		}
-
-		ac.SpectrumIdentification = append(ac.SpectrumIdentification, *si)
 	}
-
-	ac.ProteinDetection = psi.ProteinDetection{
-		ProteinDetectionProtocolRef: "Philosopher_protocol",
-		ProteinDetectionListRef:     "Protein Groups",
-		ID:                          "Phi_1",
+	if len(fragments) > 0 {
+		sil.FragmentationTable = mzIdentMLFragmentationTable()
 	}
-	mzid.AnalysisCollection = *ac
 
-	// AnalysisProtocolCollection
-	apc := &psi.AnalysisProtocolCollection{
-		SpectrumIdentificationProtocol: []psi.SpectrumIdentificationProtocol{
-			{
-				AnalysisSoftwareRef: "DatabaseSearch_ID",
-				ID:                  "Search_Protocol_1",
-				SearchType: psi.SearchType{
-					CVParam: psi.CVParam{
-						CVRef:     "PSI-MS",
-						Accession: "MS:1001083",
-						Name:      "ms-ms search",
-					},
-				},
-				AdditionalSearchParams: psi.AdditionalSearchParams{
-					CVParam: []psi.CVParam{
-						{
-							CVRef:     "PSI-MS",
-							Accession: "MS:1001211",
-							Name:      "parent mass type mono",
-						},
-						{
-							CVRef:     "PSI-MS",
-							Accession: "MS:1001256",
-							Name:      "fragment mass type mono",
-						},
-						{
-							CVRef:     "PSI-MS",
-							Accession: "MS:1002492",
-							Name:      "consensus scoring",
-						},
-						{
-							CVRef:     "PSI-MS",
-							Accession: "MS:1002490",
-							Name:      "peptide-level scoring",
-						},
-						{
-							CVRef:     "PSI-MS",
-							Accession: "MS:1002497",
-							Name:      "group PSMs by sequence with modifications",
-						},
-						{
-							CVRef:     "PSI-MS",
-							Accession: "MS:1002491",
-							Name:      "modification localization scoring",
-						},
-					},
-					UserParam: []psi.UserParam{
-						{
-							Name:  "MSFragger",
-							Value: e.Parameters.MSFragger,
-						},
-						{
-							Name:  "database_name",
-							Value: e.Parameters.DatabaseName,
-						},
-						{
-							Name:  "precursor_mass_lower",
-							Value: e.Parameters.PrecursorMassLower,
-						},
-						{
-							Name:  "precursor_mass_upper",
-							Value: e.Parameters.PrecursorMassUpper,
-						},
-						{
-							Name:  "precursor_mass_units",
-							Value: e.Parameters.PrecursorMassUnits,
-						},
-						{
-							Name:  "precursor_true_tolerance",
-							Value: e.Parameters.PrecursorTrueTolerance,
-						},
-						{
-							Name:  "precursor_true_units",
-							Value: e.Parameters.PrecursorTrueUnits,
-						},
-						{
-							Name:  "fragment_mass_tolerance",
-							Value: e.Parameters.FragmentMassTolerance,
-						},
-						{
-							Name:  "fragment_mass_units",
-							Value: e.Parameters.FragmentMassUnits,
-						},
-						{
-							Name:  "calibrate_mass",
-							Value: e.Parameters.CalibrateMass,
-						},
-						{
-							Name:  "ms1_tolerance_mad",
-							Value: e.Parameters.Ms1ToleranceMad,
-						},
-						{
-							Name:  "ms2_tolerance_mad",
-							Value: e.Parameters.Ms2ToleranceMad,
-						},
-						{
-							Name:  "evaluate_mass_calibration",
-							Value: e.Parameters.EvaluateMassCalibration,
-						},
-						{
-							Name:  "isotope_error",
-							Value: e.Parameters.IsotopeError,
-						},
-						{
-							Name:  "mass_offsets",
-							Value: e.Parameters.MassOffsets,
-						},
-						{
-							Name:  "precursor_mass_mode",
-							Value: e.Parameters.PrecursorMassMode,
-						},
-						{
-							Name:  "shifted_ions",
-							Value: e.Parameters.ShiftedIons,
-						},
-						{
-							Name:  "shifted_ions_exclude_ranges",
-							Value: e.Parameters.ShiftedIonsExcludeRanges,
-						},
-						{
-							Name:  "fragment_ion_series",
-							Value: e.Parameters.FragmentIonSeries,
-						},
-						{
-							Name:  "search_enzyme_name",
-							Value: e.Parameters.SearchEnzymeName,
-						},
-						{
-							Name:  "search_enzyme_cutafter",
-							Value: e.Parameters.SearchEnzymeCutafter,
-						},
-						{
-							Name:  "search_enzyme_butnotafter",
-							Value: e.Parameters.SearchEnzymeButnotafter,
-						},
-						{
-							Name:  "num_enzyme_termini",
-							Value: e.Parameters.NumEnzymeTermini,
-						},
-						{
-							Name:  "allowed_missed_cleavage",
-							Value: e.Parameters.AllowedMissedCleavage,
-						},
-						{
-							Name:  "clip_nTerm_M",
-							Value: e.Parameters.ClipNTermM,
-						},
-						{
-							Name:  "allow_multiple_variable_mods_on_residue",
-							Value: e.Parameters.AllowMultipleVariableModsOnResidue,
-						},
-						{
-							Name:  "max_variable_mods_per_mod",
-							Value: e.Parameters.MaxVariableModsPerMod,
-						},
-						{
-							Name:  "max_variable_mods_combinations",
-							Value: e.Parameters.MaxVariableModsCombinations,
-						},
-						{
-							Name:  "output_format",
-							Value: e.Parameters.OutputFormat,
-						},
-						{
-							Name:  "output_report_topN",
-							Value: e.Parameters.OutputReportTopN,
-						},
-						{
-							Name:  "output_max_expect",
-							Value: e.Parameters.OutputMaxExpect,
-						},
-						{
-							Name:  "report_alternative_proteins",
-							Value: e.Parameters.ReportAlternativeProteins,
-						},
-						{
-							Name:  "override_charge",
-							Value: e.Parameters.OverrideCharge,
-						},
-						{
-							Name:  "precursor_charge",
-							Value: e.Parameters.PrecursorCharge,
-						},
-						{
-							Name:  "digest_min_length",
-							Value: e.Parameters.DigestMinLength,
-						},
-						{
-							Name:  "digest_max_length",
-							Value: e.Parameters.DigestMaxLength,
-						},
-						{
-							Name:  "digest_mass_range",
-							Value: e.Parameters.DigestMassRange,
-						},
-						{
-							Name:  "max_fragment_charge",
-							Value: e.Parameters.MaxFragmentCharge,
-						},
-						{
-							Name:  "track_zero_topN",
-							Value: e.Parameters.TrackZeroTopN,
-						},
-						{
-							Name:  "zero_bin_accept_expect",
-							Value: e.Parameters.ZeroBinAcceptExpect,
-						},
-						{
-							Name:  "zero_bin_mult_expect",
-							Value: e.Parameters.ZeroBinMultExpect,
-						},
-						{
-							Name:  "add_topN_complementary",
-							Value: e.Parameters.AddTopNComplementary,
-						},
-						{
-							Name:  "minimum_peaks",
-							Value: e.Parameters.MinimumPeaks,
-						},
-						{
-							Name:  "use_topN_peaks",
-							Value: e.Parameters.UseTopNPeaks,
-						},
-						{
-							Name:  "min_fragments_modelling",
-							Value: e.Parameters.MinFragmentsModelling,
-						},
-						{
-							Name:  "min_matched_fragments",
-							Value: e.Parameters.MinMatchedFragments,
-						},
-						{
-							Name:  "minimum_ratio",
-							Value: e.Parameters.MinimumRatio,
-						},
-						{
-							Name:  "clear_mz_range",
-							Value: e.Parameters.ClearMzRange,
-						},
-						{
-							Name:  "variable_mod_01",
-							Value: e.Parameters.VariableMod01,
-						},
-						{
-							Name:  "variable_mod_02",
-							Value: e.Parameters.VariableMod02,
-						},
-						{
-							Name:  "add_C_cysteine",
-							Value: e.Parameters.Cysteine,
-						},
-						{
-							Name:  "add_Cterm_peptide",
-							Value: e.Parameters.CTermPeptide,
-						},
-						{
-							Name:  "add_Cterm_protein",
-							Value: e.Parameters.CTermProtein,
-						},
-						{
-							Name:  "add_D_aspartic_acid",
-							Value: e.Parameters.AsparticAcid,
-						},
-						{
-							Name:  "add_E_glutamic_acid",
-							Value: e.Parameters.GlutamicAcid,
-						},
-						{
-							Name:  "add_F_phenylalanine",
-							Value: e.Parameters.Phenylalanine,
-						},
-						{
-							Name:  "add_G_glycine",
-							Value: e.Parameters.Glycine,
-						},
-						{
-							Name:  "add_H_histidine",
-							Value: e.Parameters.Histidine,
-						},
-						{
-							Name:  "add_I_isoleucine",
-							Value: e.Parameters.Isoleucine,
-						},
-						{
-							Name:  "add_K_lysine",
-							Value: e.Parameters.Lysine,
-						},
-						{
-							Name:  "add_L_leucine",
-							Value: e.Parameters.Leucine,
-						},
-						{
-							Name:  "add_M_methionine",
-							Value: e.Parameters.Methionine,
-						},
-						{
-							Name:  "add_N_asparagine",
-							Value: e.Parameters.Asparagine,
-						},
-						{
-							Name:  "add_Nterm_peptide",
-							Value: e.Parameters.NTermPeptide,
-						},
-						{
-							Name:  "add_Nterm_protein",
-							Value: e.Parameters.NTermProtein,
-						},
-						{
-							Name:  "add_P_proline",
-							Value: e.Parameters.Proline,
-						},
-						{
-							Name:  "add_Q_glutamine",
-							Value: e.Parameters.GlutamicAcid,
-						},
-						{
-							Name:  "add_R_arginine",
-							Value: e.Parameters.Arginine,
-						},
-						{
-							Name:  "add_S_serine",
-							Value: e.Parameters.Serine,
-						},
-						{
-							Name:  "add_T_threonine",
-							Value: e.Parameters.Threonine,
-						},
-						{
-							Name:  "add_V_valine",
-							Value: e.Parameters.Valine,
-						},
-						{
-							Name:  "add_W_tryptophan",
-							Value: e.Parameters.Tryptophan,
-						},
-						{
-							Name:  "add_Y_tyrosine",
-							Value: e.Parameters.Tyrosine,
-						},
-					},
-				},
-			},
-		},
-	}
+	for idx, j := range e.PSM {
 
-	mzid.AnalysisProtocolCollection = *apc
+		key := mzIdentMLPeptideKey(j)
+		ref, ok := pepRef[key]
+		if !ok {
+			ref = fmt.Sprintf("Pep_%d", len(peps)+1)
+			pepRef[key] = ref
+			peps = append(peps, mzIdentMLPeptide(ref, j, o.Terms))
+		}
 
-	// DataCollection
-	dta := psi.DataCollection{}
+		proteins := []string{j.Protein}
+		var alternatives []string
+		for k := range j.MappedProteins {
+			if k != j.Protein {
+				alternatives = append(alternatives, k)
+			}
+		}
+		sort.Strings(alternatives)
+		proteins = append(proteins, alternatives...)
 
-	for _, i := range sources {
-		sf := &psi.SourceFile{
-			ID:       i,
-			Location: i,
-			Name:     i,
+		var evidences []psi.PeptideEvidenceRef
+		for _, k := range proteins {
+			evidences = append(evidences, psi.PeptideEvidenceRef{PeptideEvidenceRef: pevOf(ref, k, j)})
 		}
 
-		dta.Inputs.SourceFile = append(dta.Inputs.SourceFile, *sf)
-	}
+		rank := j.HitRank
+		if rank == 0 {
+			rank = 1
+		}
 
-	// DataCollection - Input - SearchDatabase
-	sdb := &psi.SearchDatabase{
-		ID:                   database,
-		NumDatabaseSequences: len(dtb.Records),
-		Location:             database,
-		FileFormat: psi.FileFormat{
-			CVParam: psi.CVParam{
-				CVRef:     "PSI-MS",
-				Accession: "MS:1001348",
-				Name:      "FASTA format",
-			},
-		},
-		DatabaseName: psi.DatabaseName{
-			CVParam: psi.CVParam{
-				CVRef:     "PSI-MS",
-				Accession: "MS:1001073",
-				Name:      "database type amino acid",
-			},
-			UserParam: psi.UserParam{
-				Name: database,
-			},
-		},
-	}
-	mzid.DataCollection.Inputs.SearchDatabase = append(mzid.DataCollection.Inputs.SearchDatabase, *sdb)
+		charge := float64(j.AssumedCharge)
+		if charge == 0 {
+			charge = 1
+		}
 
-	// DataCollection - Input - SpectraData
-	for _, i := range sources {
-		sd := &psi.SpectraData{
-			Location: "./",
-			ID:       i,
-			Name:     i,
-			FileFormat: psi.FileFormat{
-				CVParam: psi.CVParam{
-					CVRef:     "PSI-MS",
-					Accession: "MS:1000584",
-					Name:      "mzML format",
+		sii := psi.SpectrumIdentificationItem{
+			ID:                       fmt.Sprintf("SII_%d", idx+1),
+			PassThreshold:            "true",
+			Rank:                     rank,
+			PeptideRef:               ref,
+			ChargeState:              j.AssumedCharge,
+			CalculatedMassToCharge:   (j.CalcNeutralPepMass + charge*bio.Proton) / charge,
+			ExperimentalMassToCharge: (j.PrecursorNeutralMass + charge*bio.Proton) / charge,
+			PeptideEvidenceRef:       evidences,
+			Fragmentation:            fragments[j.SpectrumFileName()],
+			CVParam:                  mzIdentMLScores(j, searchEngine),
+			UserParam: []psi.UserParam{
+				{
+					Name:  "entry name",
+					Value: j.EntryName,
+				},
+				{
+					Name:  "posterior error probability",
+					Value: fmt.Sprintf("%f", j.PEP),
 				},
 			},
 		}
-		mzid.DataCollection.Inputs.SpectraData = append(mzid.DataCollection.Inputs.SpectraData, *sd)
-	}
 
-	// DataCollection - AnalysisData
-	ad := &psi.AnalysisData{
-		SpectrumIdentificationList: []psi.SpectrumIdentificationList{
-			{
-				ID: "SIL_1",
-				FragmentationTable: psi.FragmentationTable{
-					Measure: []psi.Measure{
-						{
-							ID: "Measure_MZ",
-							CVParam: []psi.CVParam{
-								{
-									CVRef:         "PSI-MS",
-									Accession:     "MS:1001225",
-									Name:          "product ion m/z",
-									UnitCvRef:     "PSI-MS",
-									UnitAccession: "MS:1000040",
-									UnitName:      "m/z",
-								},
-							},
-						},
-						{
-							ID: "Measure_Int",
-							CVParam: []psi.CVParam{
-								{
-									CVRef:         "PSI-MS",
-									Accession:     "MS:1001226",
-									Name:          "product ion intensity",
-									UnitCvRef:     "PSI-MS",
-									UnitAccession: "MS:1000131",
-									UnitName:      "number of detector counts",
-								},
-							},
-						},
-						{
-							ID: "Measure_Error",
-							CVParam: []psi.CVParam{
-								{
-									CVRef:         "PSI-MS",
-									Accession:     "MS:1001227",
-									Name:          "product ion m/z error",
-									UnitCvRef:     "PSI-MS",
-									UnitAccession: "MS:1000040",
-									UnitName:      "m/z",
-								},
-							},
-						},
-					},
+		// isobaric quantification
+		if j.Labels != nil {
+			for _, k := range j.Labels.Channels {
+				param, ok := tmtReagents[k.Name]
+				if !ok {
+					continue
+				}
+				param.Value = fmt.Sprintf("%f", k.Intensity)
+				sii.CVParam = append(sii.CVParam, param)
+				sii.UserParam = append(sii.UserParam, psi.UserParam{Name: param.Name + " Label", Value: labelName(k)})
+			}
+		}
+
+		sir := psi.SpectrumIdentificationResult{
+			ID:                         fmt.Sprintf("SIR_%d", idx+1),
+			SpectraDataRef:             sourceMap[strings.Split(j.Spectrum, ".")[0]],
+			SpectrumID:                 mzIdentMLSpectrumID(j, nativeIDs),
+			SpectrumIdentificationItem: []psi.SpectrumIdentificationItem{sii},
+			CVParam: []psi.CVParam{
+				{
+					CVRef:     "PSI-MS",
+					Accession: "MS:1000796",
+					Name:      "spectrum title",
+					Value:     j.Spectrum,
+				},
+				{
+					CVRef:         "PSI-MS",
+					Accession:     "MS:1000894",
+					Name:          "retention time",
+					Value:         fmt.Sprintf("%f", j.RetentionTime),
+					UnitCvRef:     "UO",
+					UnitAccession: "UO:0000010",
+					UnitName:      "second",
 				},
 			},
-		},
-	}
+		}
 
-	// DataCollection - SpectrumIdentificationResult
-	idCounter = 0
-	for i := 0; i <= len(sources)-1; i++ {
-		for _, j := range e.PSM {
-			if strings.Contains(j.Spectrum, sources[i]) {
-
-				idCounter++
-
-				sir := &psi.SpectrumIdentificationResult{
-					SpectraDataRef: sources[i],
-					ID:             fmt.Sprintf("Spectrum_%d", idCounter),
-					SpectrumID:     fmt.Sprintf("%d", j.Index),
-					SpectrumIdentificationItem: []psi.SpectrumIdentificationItem{
-						{
-							PassThreshold:            "true",
-							Rank:                     j.HitRank,
-							PeptideRef:               j.Peptide,
-							CalculatedMassToCharge:   j.CalcNeutralPepMass,
-							ChargeState:              j.AssumedCharge,
-							ExperimentalMassToCharge: j.PrecursorNeutralMass,
-							ID:                       fmt.Sprintf("SII_%d", j.HitRank),
-							PeptideEvidenceRef: []psi.PeptideEvidenceRef{
-								{
-									PeptideEvidenceRef: pepRef[j.Peptide],
-								},
-							},
-							Fragmentation: psi.Fragmentation{
-								IonType: []psi.IonType{
-									{
-										CVParam: []psi.CVParam{
-											{},
-										},
-										UserParam: []psi.UserParam{
-											{},
-										},
-									},
-								},
-							},
-							CVParam: []psi.CVParam{
-								{
-									CVRef:     "PSI-MS",
-									Accession: "MS:1000796",
-									Name:      "spectrum title",
-									Value:     j.SpectrumFileName().Str(),
-								},
-								{
-									CVRef:     "PSI-MS",
-									Accession: "MS:1001192",
-									Name:      "Expect value",
-									Value:     fmt.Sprintf("%f", j.Expectation),
-								},
-								{
-									CVRef:     "PSI-MS",
-									Accession: "MS:1000882",
-									Name:      "protein",
-									Value:     j.ProteinID,
-								},
-								{
-									CVRef:     "PSI-MS",
-									Accession: "MS:1000886",
-									Name:      "protein name",
-									Value:     j.ProteinDescription,
-								},
-								{
-									CVRef:     "PSI-MS",
-									Accession: "MS:1000934",
-									Name:      "gene name",
-									Value:     j.GeneName,
-								},
-								{
-									CVRef:     "PSI-MS",
-									Accession: "MS:1000888",
-									Name:      "modified peptide sequence",
-									Value:     j.ModifiedPeptide,
-								},
-								{
-									CVRef:     "PSI-MS",
-									Accession: "MS:1000894",
-									Name:      "retention time",
-									Value:     fmt.Sprintf("%f", j.RetentionTime),
-								},
-								{
-									CVRef:     "PSI-MS",
-									Accession: "MS:1001976",
-									Name:      "delta M",
-									Value:     fmt.Sprintf("%f", j.Massdiff),
-								},
-								{
-									CVRef:     "PSI-MS",
-									Accession: "MS:1002357",
-									Name:      "PSM-level probability",
-									Value:     fmt.Sprintf("%f", j.Probability),
-								},
-								{
-									CVRef:     "PSI-MS",
-									Accession: "MS:1002252",
-									Name:      "Comet:xcorr",
-									Value:     fmt.Sprintf("%f", j.Xcorr),
-								},
-								{
-									CVRef:     "PSI-MS",
-									Accession: "MS:1002253",
-									Name:      "Comet:deltacn",
-									Value:     fmt.Sprintf("%f", j.DeltaCN),
-								},
-								{
-									CVRef:     "PSI-MS",
-									Accession: "MS:1002254",
-									Name:      "Comet:deltacnstar",
-									Value:     fmt.Sprintf("%f", j.DeltaCNStar),
-								},
-								{
-									CVRef:     "PSI-MS",
-									Accession: "MS:1002255",
-									Name:      "Comet:spscore",
-									Value:     fmt.Sprintf("%f", j.SPScore),
-								},
-								{
-									CVRef:     "PSI-MS",
-									Accession: "MS:1002256",
-									Name:      "Comet:sprank",
-									Value:     fmt.Sprintf("%f", j.SPRank),
-								},
-								{
-									CVRef:     "PSI-MS",
-									Accession: "MS:1001331",
-									Name:      "X! Tandem:hyperscore",
-									Value:     fmt.Sprintf("%f", j.Hyperscore),
-								},
-								{
-									CVRef:     "PSI-MS",
-									Accession: "MS:1002217",
-									Name:      "decoy peptide",
-									Value:     fmt.Sprintf("%v", j.IsDecoy),
-								},
-								{
-									CVRef:     "PSI-MS",
-									Accession: "MS:1001843",
-									Name:      "MS1 feature maximum intensity",
-									Value:     fmt.Sprintf("%f", j.Intensity),
-								},
-								{
-									CVRef:     "PSI-MS",
-									Accession: "MS:1001363",
-									Name:      "peptide unique to one protein",
-									Value:     fmt.Sprintf("%v", j.IsUnique),
-								},
-								{
-									CVRef:     "PSI-MS",
-									Accession: "MS:1003015",
-									Name:      "razor peptide",
-									Value:     fmt.Sprintf("%v", j.IsURazor),
-								},
-								{
-									CVRef:     "PSI-MS",
-									Accession: "MS:1002616",
-									Name:      "TMT reagent 126",
-									Value:     fmt.Sprintf("%f", j.Labels.Channel1.Intensity),
-								},
-								{
-									CVRef:     "PSI-MS",
-									Accession: "MS:1002763",
-									Name:      "TMT reagent 127N",
-									Value:     fmt.Sprintf("%f", j.Labels.Channel2.Intensity),
-								},
-								{
-									CVRef:     "PSI-MS",
-									Accession: "MS:1002764",
-									Name:      "TMT reagent 127C",
-									Value:     fmt.Sprintf("%f", j.Labels.Channel3.Intensity),
-								},
-								{
-									CVRef:     "PSI-MS",
-									Accession: "MS:1002765",
-									Name:      "TMT reagent 128N",
-									Value:     fmt.Sprintf("%f", j.Labels.Channel4.Intensity),
-								},
-								{
-									CVRef:     "PSI-MS",
-									Accession: "MS:1002766",
-									Name:      "TMT reagent 128C",
-									Value:     fmt.Sprintf("%f", j.Labels.Channel5.Intensity),
-								},
-								{
-									CVRef:     "PSI-MS",
-									Accession: "MS:1002767",
-									Name:      "TMT reagent 129N",
-									Value:     fmt.Sprintf("%f", j.Labels.Channel6.Intensity),
-								},
-								{
-									CVRef:     "PSI-MS",
-									Accession: "MS:1002768",
-									Name:      "TMT reagent 129C",
-									Value:     fmt.Sprintf("%f", j.Labels.Channel7.Intensity),
-								},
-								{
-									CVRef:     "PSI-MS",
-									Accession: "MS:1002769",
-									Name:      "TMT reagent 130N",
-									Value:     fmt.Sprintf("%f", j.Labels.Channel8.Intensity),
-								},
-								{
-									CVRef:     "PSI-MS",
-									Accession: "MS:1002770",
-									Name:      "TMT reagent 130C",
-									Value:     fmt.Sprintf("%f", j.Labels.Channel9.Intensity),
-								},
-								{
-									CVRef:     "PSI-MS",
-									Accession: "MS:1002621",
-									Name:      "TMT reagent 131",
-									Value:     fmt.Sprintf("%f", j.Labels.Channel10.Intensity),
-								},
-							},
-							UserParam: []psi.UserParam{
-								{
-									Name:  "entry name",
-									Value: j.EntryName,
-								},
-								{
-									Name:  "TMT reagent 126 Label",
-									Value: j.Labels.Channel1.Name,
-								},
-								{
-									Name:  "TMT reagent 17N Label",
-									Value: j.Labels.Channel2.Name,
-								},
-								{
-									Name:  "TMT reagent 127C Label",
-									Value: j.Labels.Channel3.Name,
-								},
-								{
-									Name:  "TMT reagent 128N Label",
-									Value: j.Labels.Channel4.Name,
-								},
-								{
-									Name:  "TMT reagent 128C Label",
-									Value: j.Labels.Channel5.Name,
-								},
-								{
-									Name:  "TMT reagent 129N Label",
-									Value: j.Labels.Channel6.Name,
-								},
-								{
-									Name:  "TMT reagent 129C Label",
-									Value: j.Labels.Channel7.Name,
-								},
-								{
-									Name:  "TMT reagent 130N Label",
-									Value: j.Labels.Channel8.Name,
-								},
-								{
-									Name:  "TMT reagent 130C Label",
-									Value: j.Labels.Channel9.Name,
-								},
-								{
-									Name:  "TMT reagent 131 Label",
-									Value: j.Labels.Channel10.Name,
-								},
-							},
-						},
-					},
-				}
+		specRef[j.SpectrumFileName()] = sii.ID
+		specPeptide[j.SpectrumFileName()] = ref
+		specPSM[j.SpectrumFileName()] = idx
 
-				specRef[j.SpectrumFileName()] = fmt.Sprintf("Spectrum_%d", idCounter)
-				ad.SpectrumIdentificationList[0].SpectrumIdentificationResult = append(ad.SpectrumIdentificationList[0].SpectrumIdentificationResult, *sir)
-			}
-		}
+		sil.SpectrumIdentificationResult = append(sil.SpectrumIdentificationResult, sir)
 	}
 
 	// DataCollection - ProteinDetectionList
-	idCounter = 0
+	var pdl *psi.ProteinDetectionList
 	if len(e.Proteins) > 0 {
-		pdl := &psi.ProteinDetectionList{
-			ID: "protein groups",
+
+		pdl = &psi.ProteinDetectionList{
+			ID: "PDL_1",
 		}
 
-		var groupsMap = make(map[int]uint8)
-		var groups []int
+		var groups = make(map[uint32][]ProteinEvidence)
+		var groupIDs []int
 
 		for _, i := range e.Proteins {
-			groupsMap[int(i.ProteinGroup)] = 0
+			if _, ok := groups[i.ProteinGroup]; !ok {
+				groupIDs = append(groupIDs, int(i.ProteinGroup))
+			}
+			groups[i.ProteinGroup] = append(groups[i.ProteinGroup], i)
 		}
 
-		for i := range groupsMap {
-			groups = append(groups, i)
-		}
+		sort.Ints(groupIDs)
+
+		var pdhCounter int
+		for _, i := range groupIDs {
+
+			pag := psi.ProteinAmbiguityGroup{
+				ID: fmt.Sprintf("PAG_%d", i),
+				CVParam: []psi.CVParam{
+					{
+						CVRef:     "PSI-MS",
+						Accession: "MS:1002415",
+						Name:      "protein group passes threshold",
+						Value:     "true",
+					},
+				},
+			}
+
+			for _, j := range groups[uint32(i)] {
 
-		sort.Ints(groups)
+				// the indistinguishable proteins share the same peptide hypotheses
+				members := []string{j.PartHeader}
+				var indistinguishable []string
+				for k := range j.IndiProtein {
+					if k != j.PartHeader {
+						indistinguishable = append(indistinguishable, k)
+					}
+				}
+				sort.Strings(indistinguishable)
+				members = append(members, indistinguishable...)
 
-		for _, i := range groups {
+				for n, k := range members {
 
-			idCounter++
+					var hypotheses = make(map[string][]psi.SpectrumIdentificationItemRef)
+					var order []string
 
-			pag := &psi.ProteinAmbiguityGroup{
-				ID: fmt.Sprintf("%d", i),
-			}
+					for _, l := range j.TotalPeptideIons {
+						for s := range l.Spectra {
+
+							sii, ok := specRef[s]
+							if !ok {
+								continue
+							}
+
+							pev := pevOf(specPeptide[s], k, e.PSM[specPSM[s]])
+							if _, ok := hypotheses[pev]; !ok {
+								order = append(order, pev)
+							}
+							hypotheses[pev] = append(hypotheses[pev], psi.SpectrumIdentificationItemRef{SpectrumIdentificationItemRef: sii})
+						}
+					}
+
+					if len(order) == 0 {
+						continue
+					}
 
-			for _, j := range e.Proteins {
-				if int(j.ProteinGroup) == i {
+					sort.Strings(order)
+					pdhCounter++
 
-					pdh := &psi.ProteinDetectionHypothesis{
-						ID:                j.ProteinSubGroup,
-						PassThreshold:     "true",
-						DBSquenceRef:      proRef[j.ProteinID],
-						PeptideHypothesis: []psi.PeptideHypothesis{},
+					pdh := psi.ProteinDetectionHypothesis{
+						ID:            fmt.Sprintf("PDH_%d", pdhCounter),
+						PassThreshold: "true",
+						DBSquenceRef:  dbRef(k),
 						CVParam: []psi.CVParam{
 							{
 								CVRef:     "PSI-MS",
-								Accession: "MS:1000796",
-								Name:      "spectrum title",
-								Value:     "",
+								Accession: "MS:1001093",
+								Name:      "sequence coverage",
+								Value:     fmt.Sprintf("%.2f", j.Coverage),
 							},
-						},
+							{
+								CVRef:     "PSI-MS",
+								Accession: "MS:1001097",
+								Name:      "distinct peptide sequences",
+								Value:     strconv.Itoa(len(j.TotalPeptides)),
+							},
+						},
 						UserParam: []psi.UserParam{
 							{
-								Name:  "original protein header",
-								Value: j.OriginalHeader,
+								Name:  "protein probability",
+								Value: fmt.Sprintf("%f", j.Probability),
 							},
 							{
-								Name:  "partial header",
-								Value: j.PartHeader,
+								Name:  "original protein header",
+								Value: j.OriginalHeader,
 							},
 						},
 					}
 
-					for _, k := range j.TotalPeptideIons {
-						peph := &psi.PeptideHypothesis{
-							PeptideEvidenceRef: pepRef[k.Sequence],
-						}
-						_ = k
-
-						for l := range k.Spectra {
-							siir := psi.SpectrumIdentificationItemRef{
-								SpectrumIdentificationItemRef: specRef[l],
-							}
-
-							peph.SpectrumIdentificationItemRef = append(peph.SpectrumIdentificationItemRef, siir)
-						}
+					if n == 0 {
+						pdh.CVParam = append(pdh.CVParam,
+							psi.CVParam{CVRef: "PSI-MS", Accession: "MS:1002403", Name: "group representative"},
+							psi.CVParam{CVRef: "PSI-MS", Accession: "MS:1002401", Name: "leading protein"})
+					} else {
+						pdh.CVParam = append(pdh.CVParam, psi.CVParam{CVRef: "PSI-MS", Accession: "MS:1002402", Name: "non-leading protein"})
+					}
 
-						pdh.PeptideHypothesis = append(pdh.PeptideHypothesis, *peph)
+					for _, l := range order {
+						refs := hypotheses[l]
+						sort.Slice(refs, func(a, b int) bool {
+							return refs[a].SpectrumIdentificationItemRef < refs[b].SpectrumIdentificationItemRef
+						})
+						pdh.PeptideHypothesis = append(pdh.PeptideHypothesis, psi.PeptideHypothesis{
+							PeptideEvidenceRef:            l,
+							SpectrumIdentificationItemRef: refs,
+						})
 					}
 
-					pag.ProteinDetectionHypothesis = append(pag.ProteinDetectionHypothesis, *pdh)
+					pag.ProteinDetectionHypothesis = append(pag.ProteinDetectionHypothesis, pdh)
 				}
 			}
 
-			pdl.ProteinAmbiguityGroup = append(pdl.ProteinAmbiguityGroup, *pag)
+			if len(pag.ProteinDetectionHypothesis) > 0 {
+				pdl.ProteinAmbiguityGroup = append(pdl.ProteinAmbiguityGroup, pag)
+			}
 		}
 
-		ad.ProteinDetectionList = *pdl
+		pdl.CVParam = []psi.CVParam{
+			{
+				CVRef:     "PSI-MS",
+				Accession: "MS:1002404",
+				Name:      "count of identified proteins",
+				Value:     strconv.Itoa(len(pdl.ProteinAmbiguityGroup)),
+			},
+		}
+	}
+
+	mzid.SequenceCollection.DBSequence = seqs
+	mzid.SequenceCollection.Peptide = peps
+	mzid.SequenceCollection.PeptideEvidence = pevs
+
+	// AnalysisCollection
+	si := psi.SpectrumIdentification{
+		ID:                                "SI_1",
+		SpectrumIdentificationListRef:     "SIL_1",
+		SpectrumIdentificationProtocolRef: "SIP_1",
+		SearchDatabaseRef: []psi.SearchDatabaseRef{
+			{
+				SearchDatabaseRef: "SDB_1",
+			},
+		},
+	}
+
+	for _, i := range sources {
+		si.InputSpectra = append(si.InputSpectra, psi.InputSpectra{SpectraDataRef: sourceMap[i]})
+	}
+
+	mzid.AnalysisCollection.SpectrumIdentification = append(mzid.AnalysisCollection.SpectrumIdentification, si)
+
+	if pdl != nil {
+		mzid.AnalysisCollection.ProteinDetection = &psi.ProteinDetection{
+			ID:                          "PD_1",
+			ProteinDetectionListRef:     "PDL_1",
+			ProteinDetectionProtocolRef: "PDP_1",
+			InputSpectrumIdentifications: []psi.InputSpectrumIdentifications{
+				{
+					SpectrumIdentificationListRef: "SIL_1",
+				},
+			},
+		}
 	}
 
-	mzid.DataCollection.AnalysisData = *ad
+	// AnalysisProtocolCollection
+	sip := psi.SpectrumIdentificationProtocol{
+		AnalysisSoftwareRef: searchSoftware,
+		ID:                  "SIP_1",
+		SearchType: psi.SearchType{
+			CVParam: psi.CVParam{
+				CVRef:     "PSI-MS",
+				Accession: "MS:1001083",
+				Name:      "ms-ms search",
+			},
+		},
+		AdditionalSearchParams: &psi.AdditionalSearchParams{
+			CVParam: []psi.CVParam{
+				{
+					CVRef:     "PSI-MS",
+					Accession: "MS:1001211",
+					Name:      "parent mass type mono",
+				},
+				{
+					CVRef:     "PSI-MS",
+					Accession: "MS:1001256",
+					Name:      "fragment mass type mono",
+				},
+				{
+					CVRef:     "PSI-MS",
+					Accession: "MS:1002492",
+					Name:      "consensus scoring",
+				},
+				{
+					CVRef:     "PSI-MS",
+					Accession: "MS:1002490",
+					Name:      "peptide-level scoring",
+				},
+				{
+					CVRef:     "PSI-MS",
+					Accession: "MS:1002497",
+					Name:      "group PSMs by sequence with modifications",
+				},
+				{
+					CVRef:     "PSI-MS",
+					Accession: "MS:1002491",
+					Name:      "modification localization scoring",
+				},
+			},
+			UserParam: []psi.UserParam{
+				{
+					Name:  "MSFragger",
+					Value: e.Parameters.MSFragger,
+				},
+				{
+					Name:  "database_name",
+					Value: e.Parameters.DatabaseName,
+				},
+				{
+					Name:  "precursor_mass_lower",
+					Value: e.Parameters.PrecursorMassLower,
+				},
+				{
+					Name:  "precursor_mass_upper",
+					Value: e.Parameters.PrecursorMassUpper,
+				},
+				{
+					Name:  "precursor_mass_units",
+					Value: e.Parameters.PrecursorMassUnits,
+				},
+				{
+					Name:  "precursor_true_tolerance",
+					Value: e.Parameters.PrecursorTrueTolerance,
+				},
+				{
+					Name:  "precursor_true_units",
+					Value: e.Parameters.PrecursorTrueUnits,
+				},
+				{
+					Name:  "fragment_mass_tolerance",
+					Value: e.Parameters.FragmentMassTolerance,
+				},
+				{
+					Name:  "fragment_mass_units",
+					Value: e.Parameters.FragmentMassUnits,
+				},
+				{
+					Name:  "calibrate_mass",
+					Value: e.Parameters.CalibrateMass,
+				},
+				{
+					Name:  "ms1_tolerance_mad",
+					Value: e.Parameters.Ms1ToleranceMad,
+				},
+				{
+					Name:  "ms2_tolerance_mad",
+					Value: e.Parameters.Ms2ToleranceMad,
+				},
+				{
+					Name:  "evaluate_mass_calibration",
+					Value: e.Parameters.EvaluateMassCalibration,
+				},
+				{
+					Name:  "isotope_error",
+					Value: e.Parameters.IsotopeError,
+				},
+				{
+					Name:  "mass_offsets",
+					Value: e.Parameters.MassOffsets,
+				},
+				{
+					Name:  "precursor_mass_mode",
+					Value: e.Parameters.PrecursorMassMode,
+				},
+				{
+					Name:  "shifted_ions",
+					Value: e.Parameters.ShiftedIons,
+				},
+				{
+					Name:  "shifted_ions_exclude_ranges",
+					Value: e.Parameters.ShiftedIonsExcludeRanges,
+				},
+				{
+					Name:  "fragment_ion_series",
+					Value: e.Parameters.FragmentIonSeries,
+				},
+				{
+					Name:  "search_enzyme_name",
+					Value: e.Parameters.SearchEnzymeName,
+				},
+				{
+					Name:  "search_enzyme_cutafter",
+					Value: e.Parameters.SearchEnzymeCutafter,
+				},
+				{
+					Name:  "search_enzyme_butnotafter",
+					Value: e.Parameters.SearchEnzymeButnotafter,
+				},
+				{
+					Name:  "num_enzyme_termini",
+					Value: e.Parameters.NumEnzymeTermini,
+				},
+				{
+					Name:  "allowed_missed_cleavage",
+					Value: e.Parameters.AllowedMissedCleavage,
+				},
+				{
+					Name:  "clip_nTerm_M",
+					Value: e.Parameters.ClipNTermM,
+				},
+				{
+					Name:  "allow_multiple_variable_mods_on_residue",
+					Value: e.Parameters.AllowMultipleVariableModsOnResidue,
+				},
+				{
+					Name:  "max_variable_mods_per_mod",
+					Value: e.Parameters.MaxVariableModsPerMod,
+				},
+				{
+					Name:  "max_variable_mods_combinations",
+					Value: e.Parameters.MaxVariableModsCombinations,
+				},
+				{
+					Name:  "output_format",
+					Value: e.Parameters.OutputFormat,
+				},
+				{
+					Name:  "output_report_topN",
+					Value: e.Parameters.OutputReportTopN,
+				},
+				{
+					Name:  "output_max_expect",
+					Value: e.Parameters.OutputMaxExpect,
+				},
+				{
+					Name:  "report_alternative_proteins",
+					Value: e.Parameters.ReportAlternativeProteins,
+				},
+				{
+					Name:  "override_charge",
+					Value: e.Parameters.OverrideCharge,
+				},
+				{
+					Name:  "precursor_charge",
+					Value: e.Parameters.PrecursorCharge,
+				},
+				{
+					Name:  "digest_min_length",
+					Value: e.Parameters.DigestMinLength,
+				},
+				{
+					Name:  "digest_max_length",
+					Value: e.Parameters.DigestMaxLength,
+				},
+				{
+					Name:  "digest_mass_range",
+					Value: e.Parameters.DigestMassRange,
+				},
+				{
+					Name:  "max_fragment_charge",
+					Value: e.Parameters.MaxFragmentCharge,
+				},
+				{
+					Name:  "track_zero_topN",
+					Value: e.Parameters.TrackZeroTopN,
+				},
+				{
+					Name:  "zero_bin_accept_expect",
+					Value: e.Parameters.ZeroBinAcceptExpect,
+				},
+				{
+					Name:  "zero_bin_mult_expect",
+					Value: e.Parameters.ZeroBinMultExpect,
+				},
+				{
+					Name:  "add_topN_complementary",
+					Value: e.Parameters.AddTopNComplementary,
+				},
+				{
+					Name:  "minimum_peaks",
+					Value: e.Parameters.MinimumPeaks,
+				},
+				{
+					Name:  "use_topN_peaks",
+					Value: e.Parameters.UseTopNPeaks,
+				},
+				{
+					Name:  "min_fragments_modelling",
+					Value: e.Parameters.MinFragmentsModelling,
+				},
+				{
+					Name:  "min_matched_fragments",
+					Value: e.Parameters.MinMatchedFragments,
+				},
+				{
+					Name:  "minimum_ratio",
+					Value: e.Parameters.MinimumRatio,
+				},
+				{
+					Name:  "clear_mz_range",
+					Value: e.Parameters.ClearMzRange,
+				},
+				{
+					Name:  "variable_mod_01",
+					Value: e.Parameters.VariableMod01,
+				},
+				{
+					Name:  "variable_mod_02",
+					Value: e.Parameters.VariableMod02,
+				},
+				{
+					Name:  "add_C_cysteine",
+					Value: e.Parameters.Cysteine,
+				},
+				{
+					Name:  "add_Cterm_peptide",
+					Value: e.Parameters.CTermPeptide,
+				},
+				{
+					Name:  "add_Cterm_protein",
+					Value: e.Parameters.CTermProtein,
+				},
+				{
+					Name:  "add_D_aspartic_acid",
+					Value: e.Parameters.AsparticAcid,
+				},
+				{
+					Name:  "add_E_glutamic_acid",
+					Value: e.Parameters.GlutamicAcid,
+				},
+				{
+					Name:  "add_F_phenylalanine",
+					Value: e.Parameters.Phenylalanine,
+				},
+				{
+					Name:  "add_G_glycine",
+					Value: e.Parameters.Glycine,
+				},
+				{
+					Name:  "add_H_histidine",
+					Value: e.Parameters.Histidine,
+				},
+				{
+					Name:  "add_I_isoleucine",
+					Value: e.Parameters.Isoleucine,
+				},
+				{
+					Name:  "add_K_lysine",
+					Value: e.Parameters.Lysine,
+				},
+				{
+					Name:  "add_L_leucine",
+					Value: e.Parameters.Leucine,
+				},
+				{
+					Name:  "add_M_methionine",
+					Value: e.Parameters.Methionine,
+				},
+				{
+					Name:  "add_N_asparagine",
+					Value: e.Parameters.Asparagine,
+				},
+				{
+					Name:  "add_Nterm_peptide",
+					Value: e.Parameters.NTermPeptide,
+				},
+				{
+					Name:  "add_Nterm_protein",
+					Value: e.Parameters.NTermProtein,
+				},
+				{
+					Name:  "add_P_proline",
+					Value: e.Parameters.Proline,
+				},
+				{
+					Name:  "add_Q_glutamine",
+					Value: e.Parameters.Glutamine,
+				},
+				{
+					Name:  "add_R_arginine",
+					Value: e.Parameters.Arginine,
+				},
+				{
+					Name:  "add_S_serine",
+					Value: e.Parameters.Serine,
+				},
+				{
+					Name:  "add_T_threonine",
+					Value: e.Parameters.Threonine,
+				},
+				{
+					Name:  "add_V_valine",
+					Value: e.Parameters.Valine,
+				},
+				{
+					Name:  "add_W_tryptophan",
+					Value: e.Parameters.Tryptophan,
+				},
+				{
+					Name:  "add_Y_tyrosine",
+					Value: e.Parameters.Tyrosine,
+				},
+			},
+		},
+		ModificationParams: mzIdentMLSearchModifications(e.Mods, o.Terms),
+		Enzymes:            mzIdentMLEnzymes(e.Parameters),
+		FragmentTolerance:  mzIdentMLFragmentTolerance(e.Parameters),
+		ParentTolerance:    mzIdentMLParentTolerance(e.Parameters),
+		Threshold: psi.Threshold{
+			CVParam: []psi.CVParam{
+				{
+					CVRef:     "PSI-MS",
+					Accession: "MS:1002350",
+					Name:      "PSM-level global FDR",
+					Value:     fmt.Sprintf("%v", f.PsmFDR),
+				},
+				{
+					CVRef:     "PSI-MS",
+					Accession: "MS:1001364",
+					Name:      "peptide sequence-level global FDR",
+					Value:     fmt.Sprintf("%v", f.PepFDR),
+				},
+			},
+		},
+	}
+
+	mzid.AnalysisProtocolCollection.SpectrumIdentificationProtocol = append(mzid.AnalysisProtocolCollection.SpectrumIdentificationProtocol, sip)
+
+	if pdl != nil {
+		mzid.AnalysisProtocolCollection.ProteinDetectionProtocol = &psi.ProteinDetectionProtocol{
+			ID:                  "PDP_1",
+			AnalysisSoftwareRef: "Philosopher",
+			AnalysisParams: &psi.AnalysisParams{
+				UserParam: []psi.UserParam{
+					{
+						Name:  "razor assignment",
+						Value: strconv.FormatBool(f.Razor),
+					},
+					{
+						Name:  "picked FDR",
+						Value: strconv.FormatBool(f.Picked),
+					},
+					{
+						Name:  "minimum protein probability",
+						Value: fmt.Sprintf("%v", f.ProtProb),
+					},
+				},
+			},
+			Threshold: psi.Threshold{
+				CVParam: []psi.CVParam{
+					{
+						CVRef:     "PSI-MS",
+						Accession: "MS:1002369",
+						Name:      "protein group-level global FDR",
+						Value:     fmt.Sprintf("%v", f.PtFDR),
+					},
+				},
+			},
+		}
+	}
+
+	// DataCollection - Input - SearchDatabase
+	sdb := &psi.SearchDatabase{
+		ID:                   "SDB_1",
+		Name:                 filepath.Base(database),
+		NumDatabaseSequences: len(dtb.Records),
+		Location:             database,
+		FileFormat: psi.FileFormat{
+			CVParam: psi.CVParam{
+				CVRef:     "PSI-MS",
+				Accession: "MS:1001348",
+				Name:      "FASTA format",
+			},
+		},
+		DatabaseName: psi.DatabaseName{
+			UserParam: &psi.UserParam{
+				Name: filepath.Base(database),
+			},
+		},
+		CVParam: []psi.CVParam{
+			{
+				CVRef:     "PSI-MS",
+				Accession: "MS:1001073",
+				Name:      "database type amino acid",
+			},
+		},
+	}
+
+	if len(f.Tag) > 0 {
+		sdb.CVParam = append(sdb.CVParam,
+			psi.CVParam{CVRef: "PSI-MS", Accession: "MS:1001283", Name: "decoy DB accession regexp", Value: "^" + f.Tag},
+			psi.CVParam{CVRef: "PSI-MS", Accession: "MS:1001195", Name: "decoy DB type reverse"})
+	}
+
+	mzid.DataCollection.Inputs.SearchDatabase = append(mzid.DataCollection.Inputs.SearchDatabase, *sdb)
+
+	// DataCollection - Input - SpectraData
+	for _, i := range sources {
+		sd := &psi.SpectraData{
+			ID:       sourceMap[i],
+			Location: i + ".mzML",
+			Name:     i,
+			FileFormat: psi.FileFormat{
+				CVParam: psi.CVParam{
+					CVRef:     "PSI-MS",
+					Accession: "MS:1000584",
+					Name:      "mzML format",
+				},
+			},
+			SpectrumIDFormat: psi.SpectrumIDFormat{
+				CVParam: nativeIDFormat(sourceNativeIDs[i]),
+			},
+		}
+		mzid.DataCollection.Inputs.SpectraData = append(mzid.DataCollection.Inputs.SpectraData, *sd)
+	}
+
+	// DataCollection - AnalysisData
+	mzid.DataCollection.AnalysisData.SpectrumIdentificationList = append(mzid.DataCollection.AnalysisData.SpectrumIdentificationList, sil)
+	mzid.DataCollection.AnalysisData.ProteinDetectionList = pdl
+
+	if e := mzid.Validate(); e != nil {
+		msg.Custom(errors.New("the mzIdentML report is not valid: "+e.Error()), "fatal")
+	}
 
 	// Burn!
 	mzid.Write()
 
 }
+
+// searchEngineName returns the PSI-MS term for the search engine
+func searchEngineName(searchEngine string) *psi.CVParam {
+
+	switch {
+	case strings.EqualFold(searchEngine, "MSFragger"):
+		return &psi.CVParam{CVRef: "PSI-MS", Accession: "MS:1003014", Name: "MSFragger"}
+	case strings.EqualFold(searchEngine, "Comet"):
+		return &psi.CVParam{CVRef: "PSI-MS", Accession: "MS:1002251", Name: "Comet"}
+	case strings.EqualFold(searchEngine, "X! Tandem"):
+		return &psi.CVParam{CVRef: "PSI-MS", Accession: "MS:1001476", Name: "X!Tandem"}
+	}
+
+	return nil
+}
+
+// searchEngineVersion returns the version reported by the detected search engine
+func searchEngineVersion(searchEngine string, p SearchParametersEvidence) string {
+
+	if strings.EqualFold(searchEngine, "MSFragger") && len(p.MSFragger) > 0 {
+		return p.MSFragger
+	}
+
+	return p.SearchEngineVersion
+}
+
+// mzIdentMLScores lists the scores and attributes of a PSM
+func mzIdentMLScores(j PSMEvidence, searchEngine string) []psi.CVParam {
+
+	params := []psi.CVParam{
+		{
+			CVRef:     "PSI-MS",
+			Accession: "MS:1002357",
+			Name:      "PSM-level probability",
+			Value:     fmt.Sprintf("%f", j.Probability),
+		},
+		{
+			CVRef:     "PSI-MS",
+			Accession: "MS:1002354",
+			Name:      "PSM-level q-value",
+			Value:     fmt.Sprintf("%f", j.Qvalue),
+		},
+		{
+			CVRef:     "PSI-MS",
+			Accession: "MS:1001976",
+			Name:      "delta M",
+			Value:     fmt.Sprintf("%f", j.Massdiff),
+		},
+		{
+			CVRef:     "PSI-MS",
+			Accession: "MS:1000888",
+			Name:      "modified peptide sequence",
+			Value:     j.ModifiedPeptide,
+		},
+		{
+			CVRef:     "PSI-MS",
+			Accession: "MS:1001363",
+			Name:      "peptide unique to one protein",
+			Value:     fmt.Sprintf("%v", j.IsUnique),
+		},
+		{
+			CVRef:     "PSI-MS",
+			Accession: "MS:1003015",
+			Name:      "razor peptide",
+			Value:     fmt.Sprintf("%v", j.IsURazor),
+		},
+	}
+
+	if strings.EqualFold(searchEngine, "Comet") {
+		params = append(params,
+			psi.CVParam{CVRef: "PSI-MS", Accession: "MS:1002252", Name: "Comet:xcorr", Value: fmt.Sprintf("%f", j.Xcorr)},
+			psi.CVParam{CVRef: "PSI-MS", Accession: "MS:1002253", Name: "Comet:deltacn", Value: fmt.Sprintf("%f", j.DeltaCN)},
+			psi.CVParam{CVRef: "PSI-MS", Accession: "MS:1002254", Name: "Comet:deltacnstar", Value: fmt.Sprintf("%f", j.DeltaCNStar)},
+			psi.CVParam{CVRef: "PSI-MS", Accession: "MS:1002255", Name: "Comet:spscore", Value: fmt.Sprintf("%f", j.SPScore)},
+			psi.CVParam{CVRef: "PSI-MS", Accession: "MS:1002256", Name: "Comet:sprank", Value: fmt.Sprintf("%f", j.SPRank)},
+			psi.CVParam{CVRef: "PSI-MS", Accession: "MS:1002257", Name: "Comet:expectation value", Value: fmt.Sprintf("%g", j.Expectation)},
+		)
+	} else {
+		params = append(params,
+			psi.CVParam{CVRef: "PSI-MS", Accession: "MS:1001331", Name: "X!Tandem:hyperscore", Value: fmt.Sprintf("%f", j.Hyperscore)},
+			psi.CVParam{CVRef: "PSI-MS", Accession: "MS:1001330", Name: "X!Tandem:expect", Value: fmt.Sprintf("%g", j.Expectation)},
+		)
+	}
+
+	if j.Intensity > 0 {
+		params = append(params, psi.CVParam{CVRef: "PSI-MS", Accession: "MS:1001843", Name: "MS1 feature maximum intensity", Value: fmt.Sprintf("%f", j.Intensity)})
+	}
+
+	return params
+}
+
+// mzIdentMLPeptideKey identifies a peptide by its sequence and modifications
+func mzIdentMLPeptideKey(j PSMEvidence) string {
+
+	var mods []string
+	for _, i := range j.Modifications.IndexSlice {
+		if i.Type == mod.Assigned && i.Name != "Unknown" && i.MassDiff != 0 {
+			mods = append(mods, fmt.Sprintf("%s%d%.4f", i.AminoAcid, i.Position, i.MassDiff))
+		}
+	}
+	sort.Strings(mods)
+
+	return j.Peptide + "#" + strings.Join(mods, ",")
+}
+
+// mzIdentMLPeptide creates the peptide element with the modifications mapped to UniMod
+func mzIdentMLPeptide(ref string, j PSMEvidence, terms []obo.Term) psi.Peptide {
+
+	p := psi.Peptide{
+		ID: ref,
+		PeptideSequence: psi.PeptideSequence{
+			Value: j.Peptide,
+		},
+	}
+
+	var mods []mod.Modification
+	for _, i := range j.Modifications.IndexSlice {
+		if i.Type == mod.Assigned && i.Name != "Unknown" && i.MassDiff != 0 {
+			mods = append(mods, i)
+		}
+	}
+
+	sort.Slice(mods, func(a, b int) bool {
+		return mzIdentMLLocation(mods[a], len(j.Peptide)) < mzIdentMLLocation(mods[b], len(j.Peptide))
+	})
+
+	for _, i := range mods {
+
+		site, _ := mzTabSite(i.AminoAcid)
+
+		m := psi.Modification{
+			Location:              strconv.Itoa(mzIdentMLLocation(i, len(j.Peptide))),
+			MonoIsotopicMassDelta: i.MassDiff,
+			CVParam:               []psi.CVParam{mzIdentMLModification(site, i.MassDiff, terms)},
+		}
+
+		if len(site) == 1 {
+			m.Residues = site
+		}
+
+		p.Modification = append(p.Modification, m)
+	}
+
+	return p
+}
+
+// mzIdentMLLocation returns the modification location, where 0 is the N-terminus and
+// the peptide length + 1 is the C-terminus
+func mzIdentMLLocation(m mod.Modification, length int) int {
+
+	site, _ := mzTabSite(m.AminoAcid)
+
+	switch site {
+	case "N-term":
+		return 0
+	case "C-term":
+		return length + 1
+	}
+
+	return m.Position
+}
+
+// mzIdentMLModification returns the UniMod term for a mass shift, or the unknown
+// modification term when there is no match
+func mzIdentMLModification(site string, massDiff float64, terms []obo.Term) psi.CVParam {
+
+	if t, ok := unimodTerm(terms, site, massDiff); ok {
+		return psi.CVParam{CVRef: "UNIMOD", Accession: t.ID, Name: t.Name}
+	}
+
+	return psi.CVParam{CVRef: "PSI-MS", Accession: "MS:1001460", Name: "unknown modification", Value: fmt.Sprintf("%.4f", massDiff)}
+}
+
+// mzIdentMLSearchModifications lists the fixed and variable modifications defined in the search
+func mzIdentMLSearchModifications(mods mod.Modifications, terms []obo.Term) *psi.ModificationParams {
+
+	var keys []string
+	for k, v := range mods.Index {
+		if v.Type == mod.Assigned && v.MassDiff != 0 {
+			keys = append(keys, k)
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	sort.Strings(keys)
+
+	var params psi.ModificationParams
+	for _, k := range keys {
+
+		m := mods.Index[k]
+		site, _ := mzTabSite(m.AminoAcid)
+
+		sm := psi.SearchModification{
+			FixedMod:  strconv.FormatBool(!m.Variable),
+			MassDelta: m.MassDiff,
+			Residues:  site,
+			CVParam:   []psi.CVParam{mzIdentMLModification(site, m.MassDiff, terms)},
+		}
+
+		switch site {
+		case "N-term":
+			sm.Residues = "."
+			sm.SpecificityRules = []psi.SpecificityRules{{CVParam: []psi.CVParam{{CVRef: "PSI-MS", Accession: "MS:1001189", Name: "modification specificity peptide N-term"}}}}
+		case "C-term":
+			sm.Residues = "."
+			sm.SpecificityRules = []psi.SpecificityRules{{CVParam: []psi.CVParam{{CVRef: "PSI-MS", Accession: "MS:1001190", Name: "modification specificity peptide C-term"}}}}
+		}
+
+		params.SearchModification = append(params.SearchModification, sm)
+	}
+
+	return &params
+}
+
+// mzIdentMLEnzymes describes the enzyme used in the search
+func mzIdentMLEnzymes(p SearchParametersEvidence) *psi.Enzymes {
+
+	if len(p.SearchEnzymeName) == 0 {
+		return nil
+	}
+
+	enzyme := psi.Enzyme{
+		ID:           "Enz_1",
+		SemiSpecific: p.NumEnzymeTermini == "1",
+		EnzymeName:   &psi.EnzymeName{},
+	}
+
+	if n, e := strconv.Atoi(p.AllowedMissedCleavage); e == nil {
+		enzyme.MissedCleavages = n
+	}
+
+	if strings.EqualFold(p.SearchEnzymeName, "trypsin") {
+		enzyme.EnzymeName.CVParam = []psi.CVParam{{CVRef: "PSI-MS", Accession: "MS:1001251", Name: "Trypsin"}}
+	} else {
+		enzyme.EnzymeName.UserParam = []psi.UserParam{{Name: p.SearchEnzymeName}}
+	}
+
+	return &psi.Enzymes{Enzyme: []psi.Enzyme{enzyme}}
+}
+
+// mzIdentMLTolerance creates the plus and minus tolerance terms
+func mzIdentMLTolerance(minus, plus, units string) []psi.CVParam {
+
+	unitAccession, unitName := "UO:0000221", "dalton"
+	if units == "1" || strings.EqualFold(units, "ppm") {
+		unitAccession, unitName = "UO:0000169", "parts per million"
+	}
+
+	return []psi.CVParam{
+		{CVRef: "PSI-MS", Accession: "MS:1001412", Name: "search tolerance plus value", Value: strings.TrimPrefix(plus, "+"), UnitCvRef: "UO", UnitAccession: unitAccession, UnitName: unitName},
+		{CVRef: "PSI-MS", Accession: "MS:1001413", Name: "search tolerance minus value", Value: strings.TrimPrefix(minus, "-"), UnitCvRef: "UO", UnitAccession: unitAccession, UnitName: unitName},
+	}
+}
+
+// mzIdentMLFragmentTolerance returns the fragment tolerance used in the search
+func mzIdentMLFragmentTolerance(p SearchParametersEvidence) *psi.FragmentTolerance {
+
+	if len(p.FragmentMassTolerance) == 0 {
+		return nil
+	}
+
+	return &psi.FragmentTolerance{CVParam: mzIdentMLTolerance(p.FragmentMassTolerance, p.FragmentMassTolerance, p.FragmentMassUnits)}
+}
+
+// mzIdentMLParentTolerance returns the precursor tolerance used in the search
+func mzIdentMLParentTolerance(p SearchParametersEvidence) *psi.ParentTolerance {
+
+	switch {
+	case len(p.PrecursorTrueTolerance) > 0:
+		return &psi.ParentTolerance{CVParam: mzIdentMLTolerance(p.PrecursorTrueTolerance, p.PrecursorTrueTolerance, p.PrecursorTrueUnits)}
+	case len(p.PrecursorMassLower) > 0 && len(p.PrecursorMassUpper) > 0:
+		return &psi.ParentTolerance{CVParam: mzIdentMLTolerance(p.PrecursorMassLower, p.PrecursorMassUpper, p.PrecursorMassUnits)}
+	}
+
+	return nil
+}
+
+// mzIdentMLFlank formats the flanking amino acids, using - for the protein termini
+func mzIdentMLFlank(aa byte) string {
+	if aa == 0 {
+		return "-"
+	}
+	return string(aa)
+}
+
+// mzIdentMLSpectrumID returns the spectrum native ID read from the mzML file, or the scan
+// number when the spectra file is not available
+func mzIdentMLSpectrumID(j PSMEvidence, nativeIDs map[id.SpectrumType]string) string {
+
+	if v, ok := nativeIDs[j.SpectrumFileName()]; ok && len(v) > 0 {
+		return v
+	}
+
+	return fmt.Sprintf("scan=%d", spectrumScan(j.Spectrum))
+}
+
+// nativeIDFormat returns the PSI-MS term for the format of a spectrum native ID, spectra
+// without a native ID are referenced by their scan numbers
+func nativeIDFormat(nativeID string) psi.CVParam {
+
+	switch {
+	case strings.HasPrefix(nativeID, "controllerType="):
+		return psi.CVParam{CVRef: "PSI-MS", Accession: "MS:1000768", Name: "Thermo nativeID format"}
+	case strings.HasPrefix(nativeID, "sample="):
+		return psi.CVParam{CVRef: "PSI-MS", Accession: "MS:1000770", Name: "WIFF nativeID format"}
+	case strings.HasPrefix(nativeID, "index="):
+		return psi.CVParam{CVRef: "PSI-MS", Accession: "MS:1000774", Name: "multiple peak list nativeID format"}
+	case strings.HasPrefix(nativeID, "spectrum="):
+		return psi.CVParam{CVRef: "PSI-MS", Accession: "MS:1000777", Name: "spectrum identifier nativeID format"}
+	case len(nativeID) == 0, strings.HasPrefix(nativeID, "scan="):
+		return psi.CVParam{CVRef: "PSI-MS", Accession: "MS:1000776", Name: "scan number only nativeID format"}
+	}
+
+	return psi.CVParam{CVRef: "PSI-MS", Accession: "MS:1000824", Name: "no nativeID format"}
+}
+
+// spectrumScan returns the scan number from a spectrum name
+func spectrumScan(spectrum string) int {
+
+	parts := strings.Split(spectrum, ".")
+	if len(parts) < 3 {
+		return 0
+	}
+
+	scan, _ := strconv.Atoi(parts[len(parts)-3])
+
+	return scan
+}
+
+// mzIdentMLFragmentation annotates the b and y ions of the PSMs from the spectra files
+// found in the workspace, and returns the spectra native IDs
+func (e Evidence) mzIdentMLFragmentation(sources []string) (map[id.SpectrumType]*psi.Fragmentation, map[id.SpectrumType]string) {
+
+	var fragments = make(map[id.SpectrumType]*psi.Fragmentation)
+	var nativeIDs = make(map[id.SpectrumType]string)
+
+	tol, ppm := fragmentTolerance(e.Parameters)
+
+	for _, i := range sources {
+
+		fileName := i + ".mzML"
+		if _, err := os.Stat(fileName); err != nil {
+			logrus.Warn("spectra file ", fileName, " not found, fragment ions will not be reported")
+			continue
+		}
+
+		var ms mzn.MsData
+		ms.Index(fileName)
+
+		for _, j := range e.PSM {
+
+			if !strings.HasPrefix(j.Spectrum, i+".") {
+				continue
+			}
+
+			idx, ok := ms.ByScan(strconv.Itoa(spectrumScan(j.Spectrum)))
+			if !ok {
+				continue
+			}
+
+			nativeIDs[j.SpectrumFileName()] = ms.Spectra[idx].NativeID
+
+			if ms.Spectra[idx].Level != "2" {
+				continue
+			}
+
+			spec := ms.Load(idx)
+
+			if f := annotateFragments(j, spec.Mz.DecodedStream, spec.Intensity.DecodedStream, tol, ppm); f != nil {
+				fragments[j.SpectrumFileName()] = f
+			}
+		}
+
+		ms.Close()
+	}
+
+	return fragments, nativeIDs
+}
+
+// mzIdentMLFragmentationTable defines the measures reported for the fragment ions
+func mzIdentMLFragmentationTable() *psi.FragmentationTable {
+	return &psi.FragmentationTable{
+		Measure: []psi.Measure{
+			{
+				ID: "Measure_MZ",
+				CVParam: []psi.CVParam{
+					{
+						CVRef:         "PSI-MS",
+						Accession:     "MS:1001225",
+						Name:          "product ion m/z",
+						UnitCvRef:     "PSI-MS",
+						UnitAccession: "MS:1000040",
+						UnitName:      "m/z",
+					},
+				},
+			},
+			{
+				ID: "Measure_Int",
+				CVParam: []psi.CVParam{
+					{
+						CVRef:         "PSI-MS",
+						Accession:     "MS:1001226",
+						Name:          "product ion intensity",
+						UnitCvRef:     "PSI-MS",
+						UnitAccession: "MS:1000131",
+						UnitName:      "number of detector counts",
+					},
+				},
+			},
+			{
+				ID: "Measure_Error",
+				CVParam: []psi.CVParam{
+					{
+						CVRef:         "PSI-MS",
+						Accession:     "MS:1001227",
+						Name:          "product ion m/z error",
+						UnitCvRef:     "PSI-MS",
+						UnitAccession: "MS:1000040",
+						UnitName:      "m/z",
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,26 @@
+package rep
+
+import (
+	"testing"
+)
+
+func Test_nativeIDFormat(t *testing.T) {
+
+	tests := []struct {
+		nativeID string
+		want     string
+	}{
+		{"controllerType=0 controllerNumber=1 scan=1234", "MS:1000768"},
+		{"sample=1 period=1 cycle=42 experiment=3", "MS:1000770"},
+		{"index=12", "MS:1000774"},
+		{"scan=1234", "MS:1000776"},
+		{"", "MS:1000776"},
+		{"frame=10", "MS:1000824"},
+	}
+
+	for _, tt := range tests {
+		if got := nativeIDFormat(tt.nativeID); got.Accession != tt.want {
+			t.Errorf("nativeIDFormat(%q) = %v, want %v", tt.nativeID, got.Accession, tt.want)
+		}
+	}
+}
@@ -0,0 +1,586 @@
+package rep
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"philosopher/lib/bio"
+	"philosopher/lib/iso"
+	"philosopher/lib/mod"
+	"philosopher/lib/msg"
+	"philosopher/lib/obo"
+)
+
+// mzTabMassTolerance is the maximum difference, in Daltons, for matching a mass shift to an UniMod term
+const mzTabMassTolerance = 0.002
+
+// MzTabReport creates a mzTab 1.0 summary file with the proteins, peptides and PSMs from the workspace
+func (evi Evidence) MzTabReport(workspace, version, searchEngine string, kit iso.Kit, hasDecoys, hasRazor, uniqueOnly, hasPrefix bool) {
+
+	var output string
+
+	if hasPrefix {
+		output = fmt.Sprintf("%s%s%s_report.mztab", workspace, string(filepath.Separator), path.Base(workspace))
+	} else {
+		output = fmt.Sprintf("%s%sreport.mztab", workspace, string(filepath.Separator))
+	}
+
+	file, e := os.Create(output)
+	if e != nil {
+		msg.WriteFile(errors.New("cannot create mzTab report"), "fatal")
+	}
+	defer file.Close()
+
+	bw := bufio.NewWriter(file)
+	defer bw.Flush()
+
+	o := obo.NewUniModOntology()
+
+	// ms runs are the spectral files the PSMs came from
+	var runMap = make(map[string]int)
+	var runs []string
+	for _, i := range evi.PSM {
+		source := strings.Split(i.Spectrum, ".")[0]
+		if _, ok := runMap[source]; !ok {
+			runMap[source] = 0
+			runs = append(runs, source)
+		}
+	}
+
+	sort.Strings(runs)
+	for i, j := range runs {
+		runMap[j] = i + 1
+	}
+
+	// isobaric channels have one study variable each, label-free has a single one
+	var variables []string
+	if len(kit.Channels) > 0 {
+		for _, i := range evi.PSM {
+			if i.Labels != nil && len(i.Labels.Channels) > 0 {
+				for _, j := range kit.Select(i.Labels) {
+					variables = append(variables, labelName(j))
+				}
+				break
+			}
+		}
+	} else {
+		for _, i := range evi.Peptides {
+			if i.Intensity > 0 {
+				variables = append(variables, "label-free")
+				break
+			}
+		}
+	}
+
+	evi.mzTabMetadata(bw, version, searchEngine, kit.Brand, runs, variables, o.Terms)
+
+	engine := mzTabSearchEngine(searchEngine)
+
+	// proteins
+	if len(evi.Proteins) > 0 {
+
+		header := "PRH\taccession\tdescription\ttaxid\tspecies\tdatabase\tdatabase_version\tsearch_engine\tbest_search_engine_score[1]\tambiguity_members\tmodifications\tprotein_coverage"
+		header += mzTabAbundanceHeader("protein", len(variables))
+		fmt.Fprintf(bw, "\n%s\n", header)
+
+		for _, i := range evi.Proteins {
+
+			if i.IsDecoy && !hasDecoys {
+				continue
+			}
+
+			var members []string
+			for j := range i.IndiProtein {
+				members = append(members, j)
+			}
+			sort.Strings(members)
+
+			var abundances []float64
+			if len(kit.Channels) > 0 {
+				labels := i.URazorLabels
+				if uniqueOnly || !hasRazor {
+					labels = i.UniqueLabels
+				}
+				abundances = labelAbundances(labels, kit)
+			} else if len(variables) > 0 {
+				if uniqueOnly || !hasRazor {
+					abundances = []float64{i.UniqueIntensity}
+				} else {
+					abundances = []float64{i.URazorIntensity}
+				}
+			}
+
+			line := fmt.Sprintf("PRT\t%s\t%s\tnull\t%s\t%s\tnull\t%s\t%s\t%s\tnull\t%s",
+				i.PartHeader,
+				mzTabString(i.Description),
+				mzTabString(i.Organism),
+				mzTabString(evi.Parameters.DatabaseName),
+				engine,
+				mzTabFloat(i.Probability),
+				mzTabString(strings.Join(members, ",")),
+				mzTabFloat(float64(i.Coverage)/100),
+			)
+
+			fmt.Fprintf(bw, "%s%s\n", line, mzTabAbundances(abundances, len(variables)))
+		}
+	}
+
+	// peptides are reported as ions, so each charge state has its own row
+	if len(evi.Ions) > 0 {
+
+		header := "PEH\tsequence\taccession\tunique\tdatabase\tdatabase_version\tsearch_engine\tbest_search_engine_score[1]\tmodifications\tretention_time\tretention_time_window\tcharge\tmass_to_charge\tspectra_ref"
+		header += mzTabAbundanceHeader("peptide", len(variables))
+		fmt.Fprintf(bw, "\n%s\n", header)
+
+		for _, i := range evi.Ions {
+
+			if i.IsDecoy && !hasDecoys {
+				continue
+			}
+
+			var spectra []string
+			for j := range i.Spectra {
+				spectra = append(spectra, mzTabSpectraRef(j.Spectrum, runMap))
+			}
+			sort.Strings(spectra)
+
+			var abundances []float64
+			if len(kit.Channels) > 0 {
+				abundances = labelAbundances(i.Labels, kit)
+			} else if len(variables) > 0 {
+				abundances = []float64{i.Intensity}
+			}
+
+			line := fmt.Sprintf("PEP\t%s\t%s\t%d\t%s\tnull\t%s\t%s\t%s\tnull\tnull\t%d\t%.4f\t%s",
+				i.Sequence,
+				i.Protein,
+				mzTabBool(i.IsUnique),
+				mzTabString(evi.Parameters.DatabaseName),
+				engine,
+				mzTabFloat(i.Probability),
+				mzTabModifications(i.Modifications, len(i.Sequence), o.Terms),
+				i.ChargeState,
+				i.MZ,
+				mzTabString(strings.Join(spectra, "|")),
+			)
+
+			fmt.Fprintf(bw, "%s%s\n", line, mzTabAbundances(abundances, len(variables)))
+		}
+	}
+
+	// PSMs, one row for each protein the peptide maps to
+	header := "PSH\tsequence\tPSM_ID\taccession\tunique\tdatabase\tdatabase_version\tsearch_engine\tsearch_engine_score[1]\tsearch_engine_score[2]\tmodifications\tretention_time\tcharge\texp_mass_to_charge\tcalc_mass_to_charge\tspectra_ref\tpre\tpost\tstart\tend"
+	fmt.Fprintf(bw, "\n%s\n", header)
+
+	for idx, i := range evi.PSM {
+
+		if i.IsDecoy && !hasDecoys {
+			continue
+		}
+
+		charge := float64(i.AssumedCharge)
+		if charge == 0 {
+			charge = 1
+		}
+
+		var proteins = []string{i.Protein}
+		var alternatives []string
+		for j := range i.MappedProteins {
+			if j != i.Protein {
+				alternatives = append(alternatives, j)
+			}
+		}
+		sort.Strings(alternatives)
+		proteins = append(proteins, alternatives...)
+
+		for n, j := range proteins {
+
+			start, end := "null", "null"
+			if n == 0 && i.ProteinStart > 0 {
+				start = strconv.Itoa(i.ProteinStart)
+				end = strconv.Itoa(i.ProteinEnd)
+			}
+
+			fmt.Fprintf(bw, "PSM\t%s\t%d\t%s\t%d\t%s\tnull\t%s\t%s\t%s\t%s\t%.4f\t%d\t%.4f\t%.4f\t%s\t%s\t%s\t%s\t%s\n",
+				i.Peptide,
+				idx+1,
+				j,
+				mzTabBool(i.IsUnique),
+				mzTabString(evi.Parameters.DatabaseName),
+				engine,
+				mzTabFloat(i.Probability),
+				mzTabFloat(i.Qvalue),
+				mzTabModifications(i.Modifications, len(i.Peptide), o.Terms),
+				i.RetentionTime,
+				i.AssumedCharge,
+				(i.PrecursorNeutralMass+charge*bio.Proton)/charge,
+				(i.CalcNeutralPepMass+charge*bio.Proton)/charge,
+				mzTabSpectraRef(i.Spectrum, runMap),
+				mzTabAminoAcid(i.PrevAA),
+				mzTabAminoAcid(i.NextAA),
+				start,
+				end,
+			)
+		}
+	}
+}
+
+// mzTabMetadata writes the MTD section
+func (evi Evidence) mzTabMetadata(bw *bufio.Writer, version, searchEngine, brand string, runs, variables []string, terms []obo.Term) {
+
+	mtd := func(key, value string) {
+		fmt.Fprintf(bw, "MTD\t%s\t%s\n", key, value)
+	}
+
+	mtd("mzTab-version", "1.0.0")
+	mtd("mzTab-mode", "Summary")
+	if len(variables) > 0 {
+		mtd("mzTab-type", "Quantification")
+	} else {
+		mtd("mzTab-type", "Identification")
+	}
+	mtd("description", "Philosopher results")
+
+	for i, j := range runs {
+		mtd(fmt.Sprintf("ms_run[%d]-location", i+1), fmt.Sprintf("file://%s.mzML", j))
+	}
+
+	mtd("software[1]", fmt.Sprintf("[, , Philosopher, %s]", version))
+	if engine := mzTabSearchEngine(searchEngine); engine != "null" {
+		mtd("software[2]", engine)
+	}
+
+	mtd("protein_search_engine_score[1]", "[, , protein probability, ]")
+	mtd("peptide_search_engine_score[1]", "[MS, MS:1002357, PSM-level probability, ]")
+	mtd("psm_search_engine_score[1]", "[MS, MS:1002357, PSM-level probability, ]")
+	mtd("psm_search_engine_score[2]", "[MS, MS:1002354, PSM-level q-value, ]")
+
+	// fixed and variable modifications defined in the search
+	var keys []string
+	for k, v := range evi.Mods.Index {
+		if v.Type == mod.Assigned && v.MassDiff != 0 {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var fixed, variable int
+	for _, k := range keys {
+
+		m := evi.Mods.Index[k]
+		site, position := mzTabSite(m.AminoAcid)
+
+		param := fmt.Sprintf("[, , CHEMMOD:%+.4f, ]", m.MassDiff)
+		if t, ok := unimodTerm(terms, site, m.MassDiff); ok {
+			param = fmt.Sprintf("[UNIMOD, %s, %s, ]", t.ID, t.Name)
+		}
+
+		var key string
+		if m.Variable {
+			variable++
+			key = fmt.Sprintf("variable_mod[%d]", variable)
+		} else {
+			fixed++
+			key = fmt.Sprintf("fixed_mod[%d]", fixed)
+		}
+
+		mtd(key, param)
+		mtd(key+"-site", site)
+		mtd(key+"-position", position)
+	}
+
+	if fixed == 0 {
+		mtd("fixed_mod[1]", "[MS, MS:1002453, No fixed modifications searched, ]")
+	}
+
+	if variable == 0 {
+		mtd("variable_mod[1]", "[MS, MS:1002454, No variable modifications searched, ]")
+	}
+
+	// search parameters are reported as custom parameters
+	var params = [][2]string{
+		{"database_name", evi.Parameters.DatabaseName},
+		{"search_enzyme_name", evi.Parameters.SearchEnzymeName},
+		{"search_enzyme_cutafter", evi.Parameters.SearchEnzymeCutafter},
+		{"search_enzyme_butnotafter", evi.Parameters.SearchEnzymeButnotafter},
+		{"num_enzyme_termini", evi.Parameters.NumEnzymeTermini},
+		{"allowed_missed_cleavage", evi.Parameters.AllowedMissedCleavage},
+		{"precursor_mass_lower", evi.Parameters.PrecursorMassLower},
+		{"precursor_mass_upper", evi.Parameters.PrecursorMassUpper},
+		{"precursor_mass_units", evi.Parameters.PrecursorMassUnits},
+		{"precursor_true_tolerance", evi.Parameters.PrecursorTrueTolerance},
+		{"precursor_true_units", evi.Parameters.PrecursorTrueUnits},
+		{"fragment_mass_tolerance", evi.Parameters.FragmentMassTolerance},
+		{"fragment_mass_units", evi.Parameters.FragmentMassUnits},
+		{"isotope_error", evi.Parameters.IsotopeError},
+		{"mass_offsets", evi.Parameters.MassOffsets},
+		{"fragment_ion_series", evi.Parameters.FragmentIonSeries},
+		{"digest_min_length", evi.Parameters.DigestMinLength},
+		{"digest_max_length", evi.Parameters.DigestMaxLength},
+	}
+
+	var custom int
+	for _, i := range params {
+		if len(i[1]) > 0 {
+			custom++
+			mtd(fmt.Sprintf("custom[%d]", custom), fmt.Sprintf("[, , %s, %s]", i[0], i[1]))
+		}
+	}
+
+	// quantification design
+	if len(variables) > 0 {
+
+		if len(brand) > 0 {
+			mtd("quantification_method", "[MS, MS:1002009, isobaric label quantitation analysis, ]")
+		} else {
+			mtd("quantification_method", "[MS, MS:1001834, LC-MS label-free quantitation analysis, ]")
+		}
+
+		for i, j := range variables {
+
+			if len(brand) > 0 {
+				mtd(fmt.Sprintf("assay[%d]-quantification_reagent", i+1), fmt.Sprintf("[, , %s %s, ]", strings.ToUpper(brand), j))
+			} else {
+				mtd(fmt.Sprintf("assay[%d]-quantification_reagent", i+1), "[MS, MS:1002038, unlabeled sample, ]")
+			}
+
+			var refs []string
+			for k := range runs {
+				refs = append(refs, fmt.Sprintf("ms_run[%d]", k+1))
+			}
+			mtd(fmt.Sprintf("assay[%d]-ms_run_ref", i+1), strings.Join(refs, ","))
+		}
+
+		for i, j := range variables {
+			mtd(fmt.Sprintf("study_variable[%d]-assay_refs", i+1), fmt.Sprintf("assay[%d]", i+1))
+			mtd(fmt.Sprintf("study_variable[%d]-description", i+1), j)
+		}
+	}
+}
+
+// unimodTerm finds the UniMod term with the closest mass to the mass shift on the given site
+func unimodTerm(terms []obo.Term, site string, massDiff float64) (obo.Term, bool) {
+
+	var term obo.Term
+	var found bool
+	var gap = mzTabMassTolerance
+
+	for _, i := range terms {
+
+		if _, ok := i.Sites[site]; !ok {
+			continue
+		}
+
+		if d := math.Abs(i.MonoIsotopicMass - massDiff); d <= gap {
+			gap = d
+			term = i
+			found = true
+		}
+	}
+
+	return term, found
+}
+
+// mzTabModifications formats the modifications of a peptide as position-accession pairs,
+// where the N-terminus is position 0 and the C-terminus is the peptide length + 1
+func mzTabModifications(mods mod.ModificationsSlice, length int, terms []obo.Term) string {
+
+	type site struct {
+		position int
+		value    string
+	}
+
+	var list []site
+	for _, i := range mods.IndexSlice {
+
+		if i.Type != mod.Assigned || i.Name == "Unknown" || i.MassDiff == 0 {
+			continue
+		}
+
+		aa, _ := mzTabSite(i.AminoAcid)
+
+		var position int
+		switch aa {
+		case "N-term":
+			position = 0
+		case "C-term":
+			position = length + 1
+		default:
+			position = i.Position
+		}
+
+		accession := fmt.Sprintf("CHEMMOD:%+.4f", i.MassDiff)
+		if strings.HasPrefix(i.ID, "UNIMOD:") {
+			accession = i.ID
+		} else if t, ok := unimodTerm(terms, aa, i.MassDiff); ok {
+			accession = t.ID
+		}
+
+		list = append(list, site{position, fmt.Sprintf("%d-%s", position, accession)})
+	}
+
+	if len(list) == 0 {
+		return "null"
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].position == list[j].position {
+			return list[i].value < list[j].value
+		}
+		return list[i].position < list[j].position
+	})
+
+	var values []string
+	for _, i := range list {
+		values = append(values, i.value)
+	}
+
+	return strings.Join(values, ",")
+}
+
+// mzTabSite converts the modification amino acid into a mzTab site and position
+func mzTabSite(aminoAcid string) (string, string) {
+
+	switch {
+	case strings.EqualFold(aminoAcid, "n-term"):
+		return "N-term", "Any N-term"
+	case strings.EqualFold(aminoAcid, "c-term"):
+		return "C-term", "Any C-term"
+	}
+
+	return aminoAcid, "Anywhere"
+}
+
+// mzTabSearchEngine returns the CV parameter for the search engine
+func mzTabSearchEngine(searchEngine string) string {
+
+	switch {
+	case strings.EqualFold(searchEngine, "MSFragger"):
+		return "[MS, MS:1003014, MSFragger, ]"
+	case strings.EqualFold(searchEngine, "Comet"):
+		return "[MS, MS:1002251, Comet, ]"
+	case strings.EqualFold(searchEngine, "X! Tandem"):
+		return "[MS, MS:1001476, X!Tandem, ]"
+	case len(searchEngine) > 0:
+		return fmt.Sprintf("[, , %s, ]", searchEngine)
+	}
+
+	return "null"
+}
+
+// mzTabSpectraRef converts a spectrum name into a reference to the scan on its ms run
+func mzTabSpectraRef(spectrum string, runs map[string]int) string {
+
+	parts := strings.Split(spectrum, ".")
+	if len(parts) < 3 {
+		return "null"
+	}
+
+	scan, e := strconv.Atoi(parts[len(parts)-3])
+	if e != nil {
+		return "null"
+	}
+
+	run, ok := runs[parts[0]]
+	if !ok {
+		return "null"
+	}
+
+	return fmt.Sprintf("ms_run[%d]:scan=%d", run, scan)
+}
+
+// mzTabAbundanceHeader creates the abundance columns for each study variable
+func mzTabAbundanceHeader(section string, variables int) string {
+
+	var header string
+	for i := 1; i <= variables; i++ {
+		header += fmt.Sprintf("\t%s_abundance_study_variable[%d]\t%s_abundance_stdev_study_variable[%d]\t%s_abundance_std_error_study_variable[%d]", section, i, section, i, section, i)
+	}
+
+	return header
+}
+
+// mzTabAbundances formats the abundance values for each study variable
+func mzTabAbundances(abundances []float64, variables int) string {
+
+	var line string
+	for i := 0; i < variables; i++ {
+		if i < len(abundances) && abundances[i] > 0 {
+			line += fmt.Sprintf("\t%.4f\tnull\tnull", abundances[i])
+		} else {
+			line += "\tnull\tnull\tnull"
+		}
+	}
+
+	return line
+}
+
+// labelAbundances returns the intensities of the kit channels
+func labelAbundances(l *iso.Labels, kit iso.Kit) []float64 {
+
+	if l == nil {
+		return nil
+	}
+
+	var abundances []float64
+	for _, i := range kit.Select(l) {
+		abundances = append(abundances, i.Intensity)
+	}
+
+	return abundances
+}
+
+// labelName returns the custom name of the channel when available
+func labelName(c iso.Channel) string {
+
+	if len(c.CustomName) > 0 {
+		return c.CustomName
+	}
+
+	return c.Name
+}
+
+// mzTabString replaces empty values by null
+func mzTabString(s string) string {
+
+	s = strings.TrimSpace(strings.Replace(s, "\t", " ", -1))
+	if len(s) == 0 {
+		return "null"
+	}
+
+	return s
+}
+
+// mzTabFloat formats a score, replacing invalid values by null
+func mzTabFloat(f float64) string {
+
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "null"
+	}
+
+	return strconv.FormatFloat(f, 'f', 4, 64)
+}
+
+// mzTabBool converts a boolean into the mzTab integer representation
+func mzTabBool(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// mzTabAminoAcid formats the flanking amino acids, using null when they are unknown
+func mzTabAminoAcid(aa byte) string {
+	if aa == 0 {
+		return "null"
+	}
+	return string(aa)
+}
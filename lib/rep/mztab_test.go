@@ -0,0 +1,49 @@
+package rep
+
+import (
+	"testing"
+
+	"philosopher/lib/mod"
+	"philosopher/lib/obo"
+)
+
+func Test_mzTabModifications(t *testing.T) {
+
+	terms := []obo.Term{
+		{ID: "UNIMOD:1", Name: "Acetyl", MonoIsotopicMass: 42.0106, Sites: map[string]uint8{"N-term": 1, "K": 1}},
+		{ID: "UNIMOD:4", Name: "Carbamidomethyl", MonoIsotopicMass: 57.0215, Sites: map[string]uint8{"C": 1}},
+		{ID: "UNIMOD:35", Name: "Oxidation", MonoIsotopicMass: 15.9949, Sites: map[string]uint8{"M": 1}},
+	}
+
+	mods := mod.ModificationsSlice{IndexSlice: []mod.Modification{
+		{Index: "C#5#160.0306", AminoAcid: "C", Position: 5, MassDiff: 57.0215, Type: mod.Assigned},
+		{Index: "M#3#147.0354", AminoAcid: "M", Position: 3, MassDiff: 15.9949, Type: mod.Assigned},
+		{Index: "n-term#43.0184", AminoAcid: "n-term", MassDiff: 42.0106, Type: mod.Assigned},
+		{Index: "K#6#136.1109", AminoAcid: "K", Position: 6, MassDiff: 8.0142, Type: mod.Assigned},
+		{Index: "0.9840", Name: "Unknown", MassDiff: 0.984, Type: mod.Observed},
+	}}
+
+	got := mzTabModifications(mods, 6, terms)
+	want := "0-UNIMOD:1,3-UNIMOD:35,5-UNIMOD:4,6-CHEMMOD:+8.0142"
+
+	if got != want {
+		t.Errorf("mzTabModifications() = %v, want %v", got, want)
+	}
+
+	if got := mzTabModifications(mod.ModificationsSlice{}, 6, terms); got != "null" {
+		t.Errorf("mzTabModifications() = %v, want null", got)
+	}
+}
+
+func Test_mzTabSpectraRef(t *testing.T) {
+
+	runs := map[string]int{"run_a": 1, "run_b": 2}
+
+	if got := mzTabSpectraRef("run_b.01234.01234.2", runs); got != "ms_run[2]:scan=1234" {
+		t.Errorf("mzTabSpectraRef() = %v, want ms_run[2]:scan=1234", got)
+	}
+
+	if got := mzTabSpectraRef("run_c.01234.01234.2", runs); got != "null" {
+		t.Errorf("mzTabSpectraRef() = %v, want null", got)
+	}
+}
@@ -14,6 +14,7 @@ import (
 
 	"philosopher/lib/cla"
 	"philosopher/lib/id"
+	"philosopher/lib/iso"
 	"philosopher/lib/mod"
 	"philosopher/lib/msg"
 )
@@ -29,10 +30,17 @@ func (evi *Evidence) AssemblePeptideReport(pep id.PepIDList, decoyTag string) {
 	var mappedGenes = make(map[string][]string)
 	var mappedProts = make(map[string][]string)
 	var bestProb = make(map[string]float64)
+	var bestQvalue = make(map[string]float64)
+	var bestPEP = make(map[string]float64)
 	var pepMods = make(map[string][]mod.Modification)
 
 	for _, i := range pep {
 		pepSeqMap[i.Peptide] = cla.IsDecoyPSM(i, decoyTag)
+
+		if v, ok := bestQvalue[i.Peptide]; !ok || i.Qvalue < v {
+			bestQvalue[i.Peptide] = i.Qvalue
+			bestPEP[i.Peptide] = i.PEP
+		}
 	}
 
 	for _, i := range evi.PSM {
@@ -82,6 +90,8 @@ func (evi *Evidence) AssemblePeptideReport(pep id.PepIDList, decoyTag string) {
 		pep.Sequence = k
 
 		pep.Probability = bestProb[k]
+		pep.Qvalue = bestQvalue[k]
+		pep.PEP = bestPEP[k]
 
 		for _, i := range spectra[k] {
 			pep.Spectra[i] = 0
@@ -126,10 +136,12 @@ func (evi *Evidence) AssemblePeptideReport(pep id.PepIDList, decoyTag string) {
 }
 
 // PeptideReport report consist on ion reporting
-func (evi PeptideEvidenceList) PeptideReport(workspace, brand, decoyTag string, channels int, hasDecoys, hasLabels, hasPrefix, removeContam bool) {
+func (evi PeptideEvidenceList) PeptideReport(workspace, decoyTag string, kit iso.Kit, hasDecoys, hasLabels, hasPrefix, removeContam bool) {
 
 	var header string
 	var output string
+	var hasMS1Labels bool
+	var hasMedium bool
 
 	if hasPrefix {
 		output = fmt.Sprintf("%s%s%s_peptide.tsv", workspace, string(filepath.Separator), path.Base(workspace))
@@ -160,153 +172,36 @@ func (evi PeptideEvidenceList) PeptideReport(workspace, brand, decoyTag string,
 		} else {
 			printSet = append(printSet, &evi[idx])
 		}
+
+		if i.MS1Labels != nil {
+			hasMS1Labels = true
+			if i.MS1Labels.Medium > 0 {
+				hasMedium = true
+			}
+		}
 	}
 
 	header = "Peptide\tPrev AA\tNext AA\tPeptide Length\tCharges\tProbability\tSpectral Count\tIntensity\tAssigned Modifications\tObserved Modifications\tProtein\tProtein ID\tEntry Name\tGene\tProtein Description\tMapped Genes\tMapped Proteins"
 
+	if hasMS1Labels {
+		header += ms1LabelHeader(hasMedium)
+	}
+
 	var headerIndex int
 	for i := range printSet {
-		if printSet[i].Labels != nil && len(printSet[i].Labels.Channel1.Name) > 0 {
+		if printSet[i].Labels != nil && len(printSet[i].Labels.Channels) > 0 {
 			headerIndex = i
 			break
 		}
 	}
 
-	if brand == "tmt" {
-		switch channels {
-		case 6:
-			header = fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s",
-				header,
-				printSet[headerIndex].Labels.Channel1.CustomName,
-				printSet[headerIndex].Labels.Channel2.CustomName,
-				printSet[headerIndex].Labels.Channel5.CustomName,
-				printSet[headerIndex].Labels.Channel6.CustomName,
-				printSet[headerIndex].Labels.Channel9.CustomName,
-				printSet[headerIndex].Labels.Channel10.CustomName,
-			)
-		case 10:
-			header = fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
-				header,
-				printSet[headerIndex].Labels.Channel1.CustomName,
-				printSet[headerIndex].Labels.Channel2.CustomName,
-				printSet[headerIndex].Labels.Channel3.CustomName,
-				printSet[headerIndex].Labels.Channel4.CustomName,
-				printSet[headerIndex].Labels.Channel5.CustomName,
-				printSet[headerIndex].Labels.Channel6.CustomName,
-				printSet[headerIndex].Labels.Channel7.CustomName,
-				printSet[headerIndex].Labels.Channel8.CustomName,
-				printSet[headerIndex].Labels.Channel9.CustomName,
-				printSet[headerIndex].Labels.Channel10.CustomName,
-			)
-		case 11:
-			header = fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
-				header,
-				printSet[headerIndex].Labels.Channel1.CustomName,
-				printSet[headerIndex].Labels.Channel2.CustomName,
-				printSet[headerIndex].Labels.Channel3.CustomName,
-				printSet[headerIndex].Labels.Channel4.CustomName,
-				printSet[headerIndex].Labels.Channel5.CustomName,
-				printSet[headerIndex].Labels.Channel6.CustomName,
-				printSet[headerIndex].Labels.Channel7.CustomName,
-				printSet[headerIndex].Labels.Channel8.CustomName,
-				printSet[headerIndex].Labels.Channel9.CustomName,
-				printSet[headerIndex].Labels.Channel10.CustomName,
-				printSet[headerIndex].Labels.Channel11.CustomName,
-			)
-		case 16:
-			header = fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
-				header,
-				printSet[headerIndex].Labels.Channel1.CustomName,
-				printSet[headerIndex].Labels.Channel2.CustomName,
-				printSet[headerIndex].Labels.Channel3.CustomName,
-				printSet[headerIndex].Labels.Channel4.CustomName,
-				printSet[headerIndex].Labels.Channel5.CustomName,
-				printSet[headerIndex].Labels.Channel6.CustomName,
-				printSet[headerIndex].Labels.Channel7.CustomName,
-				printSet[headerIndex].Labels.Channel8.CustomName,
-				printSet[headerIndex].Labels.Channel9.CustomName,
-				printSet[headerIndex].Labels.Channel10.CustomName,
-				printSet[headerIndex].Labels.Channel11.CustomName,
-				printSet[headerIndex].Labels.Channel12.CustomName,
-				printSet[headerIndex].Labels.Channel13.CustomName,
-				printSet[headerIndex].Labels.Channel14.CustomName,
-				printSet[headerIndex].Labels.Channel15.CustomName,
-				printSet[headerIndex].Labels.Channel16.CustomName,
-			)
-		case 18:
-			header = fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
-				header,
-				printSet[headerIndex].Labels.Channel1.CustomName,
-				printSet[headerIndex].Labels.Channel2.CustomName,
-				printSet[headerIndex].Labels.Channel3.CustomName,
-				printSet[headerIndex].Labels.Channel4.CustomName,
-				printSet[headerIndex].Labels.Channel5.CustomName,
-				printSet[headerIndex].Labels.Channel6.CustomName,
-				printSet[headerIndex].Labels.Channel7.CustomName,
-				printSet[headerIndex].Labels.Channel8.CustomName,
-				printSet[headerIndex].Labels.Channel9.CustomName,
-				printSet[headerIndex].Labels.Channel10.CustomName,
-				printSet[headerIndex].Labels.Channel11.CustomName,
-				printSet[headerIndex].Labels.Channel12.CustomName,
-				printSet[headerIndex].Labels.Channel13.CustomName,
-				printSet[headerIndex].Labels.Channel14.CustomName,
-				printSet[headerIndex].Labels.Channel15.CustomName,
-				printSet[headerIndex].Labels.Channel16.CustomName,
-				printSet[headerIndex].Labels.Channel17.CustomName,
-				printSet[headerIndex].Labels.Channel18.CustomName,
-			)
-		default:
-			header += ""
-		}
-	} else if brand == "itraq" {
-		switch channels {
-		case 4:
-			header = fmt.Sprintf("%s\t%s\t%s\t%s\t%s",
-				header,
-				printSet[headerIndex].Labels.Channel1.CustomName,
-				printSet[headerIndex].Labels.Channel2.CustomName,
-				printSet[headerIndex].Labels.Channel3.CustomName,
-				printSet[headerIndex].Labels.Channel4.CustomName,
-			)
-		case 8:
-			header = fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
-				header,
-				printSet[headerIndex].Labels.Channel1.CustomName,
-				printSet[headerIndex].Labels.Channel2.CustomName,
-				printSet[headerIndex].Labels.Channel3.CustomName,
-				printSet[headerIndex].Labels.Channel4.CustomName,
-				printSet[headerIndex].Labels.Channel5.CustomName,
-				printSet[headerIndex].Labels.Channel6.CustomName,
-				printSet[headerIndex].Labels.Channel7.CustomName,
-				printSet[headerIndex].Labels.Channel8.CustomName,
-			)
-		default:
-			header += ""
-		}
-	} else if brand == "xtag" {
-		header = fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
-			header,
-			printSet[headerIndex].Labels.Channel1.CustomName,
-			printSet[headerIndex].Labels.Channel2.CustomName,
-			printSet[headerIndex].Labels.Channel3.CustomName,
-			printSet[headerIndex].Labels.Channel4.CustomName,
-			printSet[headerIndex].Labels.Channel5.CustomName,
-			printSet[headerIndex].Labels.Channel6.CustomName,
-			printSet[headerIndex].Labels.Channel7.CustomName,
-			printSet[headerIndex].Labels.Channel8.CustomName,
-			printSet[headerIndex].Labels.Channel9.CustomName,
-			printSet[headerIndex].Labels.Channel10.CustomName,
-			printSet[headerIndex].Labels.Channel11.CustomName,
-			printSet[headerIndex].Labels.Channel12.CustomName,
-			printSet[headerIndex].Labels.Channel13.CustomName,
-			printSet[headerIndex].Labels.Channel14.CustomName,
-			printSet[headerIndex].Labels.Channel15.CustomName,
-			printSet[headerIndex].Labels.Channel16.CustomName,
-			printSet[headerIndex].Labels.Channel17.CustomName,
-			printSet[headerIndex].Labels.Channel18.CustomName,
-		)
+	if len(kit.Channels) > 0 {
+		header = labelHeader(header, kit, printSet[headerIndex].Labels)
+		header = rawLabelHeader(header, kit, printSet[headerIndex].Labels)
 	}
 
+	header += "\tQ-Value\tPEP"
+
 	header += "\n"
 
 	//_, e = io.WriteString(file, header)
@@ -371,140 +266,15 @@ func (evi PeptideEvidenceList) PeptideReport(workspace, brand, decoyTag string,
 			strings.Join(mappedProteins, ", "),
 		)
 
-		if brand == "tmt" {
-			switch channels {
-			case 6:
-				line = fmt.Sprintf("%s\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f",
-					line,
-					i.Labels.Channel1.Intensity,
-					i.Labels.Channel2.Intensity,
-					i.Labels.Channel5.Intensity,
-					i.Labels.Channel6.Intensity,
-					i.Labels.Channel9.Intensity,
-					i.Labels.Channel10.Intensity,
-				)
-			case 10:
-				line = fmt.Sprintf("%s\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f",
-					line,
-					i.Labels.Channel1.Intensity,
-					i.Labels.Channel2.Intensity,
-					i.Labels.Channel3.Intensity,
-					i.Labels.Channel4.Intensity,
-					i.Labels.Channel5.Intensity,
-					i.Labels.Channel6.Intensity,
-					i.Labels.Channel7.Intensity,
-					i.Labels.Channel8.Intensity,
-					i.Labels.Channel9.Intensity,
-					i.Labels.Channel10.Intensity,
-				)
-			case 11:
-				line = fmt.Sprintf("%s\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f",
-					line,
-					i.Labels.Channel1.Intensity,
-					i.Labels.Channel2.Intensity,
-					i.Labels.Channel3.Intensity,
-					i.Labels.Channel4.Intensity,
-					i.Labels.Channel5.Intensity,
-					i.Labels.Channel6.Intensity,
-					i.Labels.Channel7.Intensity,
-					i.Labels.Channel8.Intensity,
-					i.Labels.Channel9.Intensity,
-					i.Labels.Channel10.Intensity,
-					i.Labels.Channel11.Intensity,
-				)
-			case 16:
-				line = fmt.Sprintf("%s\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f",
-					line,
-					i.Labels.Channel1.Intensity,
-					i.Labels.Channel2.Intensity,
-					i.Labels.Channel3.Intensity,
-					i.Labels.Channel4.Intensity,
-					i.Labels.Channel5.Intensity,
-					i.Labels.Channel6.Intensity,
-					i.Labels.Channel7.Intensity,
-					i.Labels.Channel8.Intensity,
-					i.Labels.Channel9.Intensity,
-					i.Labels.Channel10.Intensity,
-					i.Labels.Channel11.Intensity,
-					i.Labels.Channel12.Intensity,
-					i.Labels.Channel13.Intensity,
-					i.Labels.Channel14.Intensity,
-					i.Labels.Channel15.Intensity,
-					i.Labels.Channel16.Intensity,
-				)
-			case 18:
-				line = fmt.Sprintf("%s\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f",
-					line,
-					i.Labels.Channel1.Intensity,
-					i.Labels.Channel2.Intensity,
-					i.Labels.Channel3.Intensity,
-					i.Labels.Channel4.Intensity,
-					i.Labels.Channel5.Intensity,
-					i.Labels.Channel6.Intensity,
-					i.Labels.Channel7.Intensity,
-					i.Labels.Channel8.Intensity,
-					i.Labels.Channel9.Intensity,
-					i.Labels.Channel10.Intensity,
-					i.Labels.Channel11.Intensity,
-					i.Labels.Channel12.Intensity,
-					i.Labels.Channel13.Intensity,
-					i.Labels.Channel14.Intensity,
-					i.Labels.Channel15.Intensity,
-					i.Labels.Channel16.Intensity,
-					i.Labels.Channel17.Intensity,
-					i.Labels.Channel18.Intensity,
-				)
-			default:
-				header += ""
-			}
-		} else if brand == "itraq" {
-			switch channels {
-			case 4:
-				line = fmt.Sprintf("%s\t%.4f\t%.4f\t%.4f\t%.4f",
-					line,
-					i.Labels.Channel1.Intensity,
-					i.Labels.Channel2.Intensity,
-					i.Labels.Channel3.Intensity,
-					i.Labels.Channel4.Intensity,
-				)
-			case 8:
-				line = fmt.Sprintf("%s\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f",
-					line,
-					i.Labels.Channel1.Intensity,
-					i.Labels.Channel2.Intensity,
-					i.Labels.Channel3.Intensity,
-					i.Labels.Channel4.Intensity,
-					i.Labels.Channel5.Intensity,
-					i.Labels.Channel6.Intensity,
-					i.Labels.Channel7.Intensity,
-					i.Labels.Channel8.Intensity,
-				)
-			default:
-				header += ""
-			}
-		} else if brand == "xtag" {
-			line = fmt.Sprintf("%s\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f",
-				line,
-				i.Labels.Channel1.Intensity,
-				i.Labels.Channel2.Intensity,
-				i.Labels.Channel3.Intensity,
-				i.Labels.Channel4.Intensity,
-				i.Labels.Channel5.Intensity,
-				i.Labels.Channel6.Intensity,
-				i.Labels.Channel7.Intensity,
-				i.Labels.Channel8.Intensity,
-				i.Labels.Channel9.Intensity,
-				i.Labels.Channel10.Intensity,
-				i.Labels.Channel11.Intensity,
-				i.Labels.Channel12.Intensity,
-				i.Labels.Channel13.Intensity,
-				i.Labels.Channel14.Intensity,
-				i.Labels.Channel15.Intensity,
-				i.Labels.Channel16.Intensity,
-				i.Labels.Channel17.Intensity,
-				i.Labels.Channel18.Intensity,
-			)
+		if hasMS1Labels {
+			line = ms1LabelLine(line, i.MS1Labels, hasMedium)
+		}
+
+		if len(kit.Channels) > 0 {
+			line = labelLine(line, kit, i.Labels)
+			line = rawLabelLine(line, kit, i.Labels)
 		}
+		line = fmt.Sprintf("%s\t%.6f\t%.6f", line, i.Qvalue, i.PEP)
 		line += "\n"
 
 		_, e = io.WriteString(bw, line)
@@ -13,6 +13,7 @@ import (
 
 	"philosopher/lib/dat"
 	"philosopher/lib/id"
+	"philosopher/lib/iso"
 	"philosopher/lib/mod"
 	"philosopher/lib/msg"
 )
@@ -51,6 +52,8 @@ func (evi *Evidence) AssembleProteinReport(pro id.ProtIDList, weight float64, de
 		rep.UniqueStrippedPeptides = len(i.UniqueStrippedPeptides)
 		rep.Probability = i.Probability
 		rep.TopPepProb = i.TopPepProb
+		rep.Qvalue = i.Qvalue
+		rep.PEP = i.PEP
 
 		rep.TotalPeptides = make(map[string]int)
 		rep.UniquePeptides = make(map[string]int)
@@ -238,10 +241,13 @@ func (evi *Evidence) AssembleProteinReport(pro id.ProtIDList, weight float64, de
 }
 
 // ProteinReport creates the TSV Protein report
-func (eviProteins ProteinEvidenceList) ProteinReport(workspace, brand, decoyTag string, channels int, hasDecoys, hasRazor, uniqueOnly, hasLabels, hasPrefix, removeContam bool) {
+func (eviProteins ProteinEvidenceList) ProteinReport(workspace, decoyTag string, kit iso.Kit, hasDecoys, hasRazor, uniqueOnly, hasLabels, hasPrefix, removeContam bool) {
 
 	var header string
 	var output string
+	var hasMS1Labels bool
+	var hasMedium bool
+	var hasAbundances bool
 
 	if hasPrefix {
 		output = fmt.Sprintf("%s%s%s_protein.tsv", workspace, string(filepath.Separator), path.Base(workspace))
@@ -272,153 +278,44 @@ func (eviProteins ProteinEvidenceList) ProteinReport(workspace, brand, decoyTag
 		} else {
 			printSet = append(printSet, &eviProteins[idx])
 		}
+
+		if i.NSAF > 0 {
+			hasAbundances = true
+		}
+
+		if i.MS1Labels != nil {
+			hasMS1Labels = true
+			if i.MS1Labels.Medium > 0 {
+				hasMedium = true
+			}
+		}
 	}
 
 	header = "Protein\tProtein ID\tEntry Name\tGene\tLength\tOrganism\tProtein Description\tProtein Existence\tCoverage\tProtein Probability\tTop Peptide Probability\tTotal Peptides\tUnique Peptides\tRazor Peptides\tTotal Spectral Count\tUnique Spectral Count\tRazor Spectral Count\tTotal Intensity\tUnique Intensity\tRazor Intensity\tRazor Assigned Modifications\tRazor Observed Modifications\tIndistinguishable Proteins"
 
+	if hasAbundances {
+		header += "\tNSAF\tdNSAF\temPAI"
+	}
+
+	if hasMS1Labels {
+		header += ms1LabelHeader(hasMedium)
+	}
+
 	var headerIndex int
 	for i := range printSet {
-		if printSet[i].UniqueLabels != nil && len(printSet[i].UniqueLabels.Channel1.Name) > 0 {
+		if printSet[i].UniqueLabels != nil && len(printSet[i].UniqueLabels.Channels) > 0 {
 			headerIndex = i
 			break
 		}
 	}
 
-	if brand == "tmt" {
-		switch channels {
-		case 6:
-			header = fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s",
-				header,
-				printSet[headerIndex].UniqueLabels.Channel1.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel2.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel5.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel6.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel9.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel10.CustomName,
-			)
-		case 10:
-			header = fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
-				header,
-				printSet[headerIndex].UniqueLabels.Channel1.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel2.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel3.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel4.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel5.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel6.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel7.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel8.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel9.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel10.CustomName,
-			)
-		case 11:
-			header = fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
-				header,
-				printSet[headerIndex].UniqueLabels.Channel1.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel2.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel3.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel4.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel5.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel6.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel7.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel8.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel9.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel10.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel11.CustomName,
-			)
-		case 16:
-			header = fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
-				header,
-				printSet[headerIndex].UniqueLabels.Channel1.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel2.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel3.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel4.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel5.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel6.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel7.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel8.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel9.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel10.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel11.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel12.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel13.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel14.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel15.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel16.CustomName,
-			)
-		case 18:
-			header = fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
-				header,
-				printSet[headerIndex].UniqueLabels.Channel1.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel2.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel3.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel4.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel5.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel6.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel7.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel8.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel9.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel10.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel11.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel12.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel13.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel14.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel15.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel16.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel17.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel18.CustomName,
-			)
-		default:
-			header += ""
-		}
-	} else if brand == "itraq" {
-		switch channels {
-		case 4:
-			header = fmt.Sprintf("%s\t%s\t%s\t%s\t%s",
-				header,
-				printSet[headerIndex].UniqueLabels.Channel1.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel2.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel3.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel4.CustomName,
-			)
-		case 8:
-			header = fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
-				header,
-				printSet[headerIndex].UniqueLabels.Channel1.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel2.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel3.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel4.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel5.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel6.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel7.CustomName,
-				printSet[headerIndex].UniqueLabels.Channel8.CustomName,
-			)
-		default:
-			header += ""
-		}
-	} else if brand == "xtag" {
-		header = fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
-			header,
-			printSet[headerIndex].UniqueLabels.Channel1.CustomName,
-			printSet[headerIndex].UniqueLabels.Channel2.CustomName,
-			printSet[headerIndex].UniqueLabels.Channel3.CustomName,
-			printSet[headerIndex].UniqueLabels.Channel4.CustomName,
-			printSet[headerIndex].UniqueLabels.Channel5.CustomName,
-			printSet[headerIndex].UniqueLabels.Channel6.CustomName,
-			printSet[headerIndex].UniqueLabels.Channel7.CustomName,
-			printSet[headerIndex].UniqueLabels.Channel8.CustomName,
-			printSet[headerIndex].UniqueLabels.Channel9.CustomName,
-			printSet[headerIndex].UniqueLabels.Channel10.CustomName,
-			printSet[headerIndex].UniqueLabels.Channel11.CustomName,
-			printSet[headerIndex].UniqueLabels.Channel12.CustomName,
-			printSet[headerIndex].UniqueLabels.Channel13.CustomName,
-			printSet[headerIndex].UniqueLabels.Channel14.CustomName,
-			printSet[headerIndex].UniqueLabels.Channel15.CustomName,
-			printSet[headerIndex].UniqueLabels.Channel16.CustomName,
-			printSet[headerIndex].UniqueLabels.Channel17.CustomName,
-			printSet[headerIndex].UniqueLabels.Channel18.CustomName,
-		)
+	if len(kit.Channels) > 0 {
+		header = labelHeader(header, kit, printSet[headerIndex].UniqueLabels)
+		header = rawLabelHeader(header, kit, printSet[headerIndex].UniqueLabels)
 	}
 
+	header += "\tQ-Value\tPEP"
+
 	header += "\n"
 
 	_, e = io.WriteString(bw, header)
@@ -440,49 +337,11 @@ func (eviProteins ProteinEvidenceList) ProteinReport(workspace, brand, decoyTag
 		sort.Strings(ip)
 
 		// change between Unique+Razor and Unique only based on parameter defined on labelquant
-		var reportIntensities [18]float64
+		var reportLabels *iso.Labels
 		if uniqueOnly || !hasRazor {
-			if i.UniqueLabels != nil {
-				reportIntensities[headerIndex] = i.UniqueLabels.Channel1.Intensity
-				reportIntensities[1] = i.UniqueLabels.Channel2.Intensity
-				reportIntensities[2] = i.UniqueLabels.Channel3.Intensity
-				reportIntensities[3] = i.UniqueLabels.Channel4.Intensity
-				reportIntensities[4] = i.UniqueLabels.Channel5.Intensity
-				reportIntensities[5] = i.UniqueLabels.Channel6.Intensity
-				reportIntensities[6] = i.UniqueLabels.Channel7.Intensity
-				reportIntensities[7] = i.UniqueLabels.Channel8.Intensity
-				reportIntensities[8] = i.UniqueLabels.Channel9.Intensity
-				reportIntensities[9] = i.UniqueLabels.Channel10.Intensity
-				reportIntensities[10] = i.UniqueLabels.Channel11.Intensity
-				reportIntensities[11] = i.UniqueLabels.Channel12.Intensity
-				reportIntensities[12] = i.UniqueLabels.Channel13.Intensity
-				reportIntensities[13] = i.UniqueLabels.Channel14.Intensity
-				reportIntensities[14] = i.UniqueLabels.Channel15.Intensity
-				reportIntensities[15] = i.UniqueLabels.Channel16.Intensity
-				reportIntensities[16] = i.UniqueLabels.Channel17.Intensity
-				reportIntensities[17] = i.UniqueLabels.Channel18.Intensity
-			}
+			reportLabels = i.UniqueLabels
 		} else {
-			if i.URazorLabels != nil {
-				reportIntensities[headerIndex] = i.URazorLabels.Channel1.Intensity
-				reportIntensities[1] = i.URazorLabels.Channel2.Intensity
-				reportIntensities[2] = i.URazorLabels.Channel3.Intensity
-				reportIntensities[3] = i.URazorLabels.Channel4.Intensity
-				reportIntensities[4] = i.URazorLabels.Channel5.Intensity
-				reportIntensities[5] = i.URazorLabels.Channel6.Intensity
-				reportIntensities[6] = i.URazorLabels.Channel7.Intensity
-				reportIntensities[7] = i.URazorLabels.Channel8.Intensity
-				reportIntensities[8] = i.URazorLabels.Channel9.Intensity
-				reportIntensities[9] = i.URazorLabels.Channel10.Intensity
-				reportIntensities[10] = i.URazorLabels.Channel11.Intensity
-				reportIntensities[11] = i.URazorLabels.Channel12.Intensity
-				reportIntensities[12] = i.URazorLabels.Channel13.Intensity
-				reportIntensities[13] = i.URazorLabels.Channel14.Intensity
-				reportIntensities[14] = i.URazorLabels.Channel15.Intensity
-				reportIntensities[15] = i.URazorLabels.Channel16.Intensity
-				reportIntensities[16] = i.URazorLabels.Channel17.Intensity
-				reportIntensities[17] = i.URazorLabels.Channel18.Intensity
-			}
+			reportLabels = i.URazorLabels
 		}
 
 		// append decoy tags on the gene and proteinID names
@@ -520,142 +379,25 @@ func (eviProteins ProteinEvidenceList) ProteinReport(workspace, brand, decoyTag
 			strings.Join(ip, ", "),   // Indistinguishable Proteins
 		)
 
-		if brand == "tmt" || brand == "itraq" {
-			switch channels {
-			case 2:
-				line = fmt.Sprintf("%s\t%.4f\t%.4f",
-					line,
-					reportIntensities[headerIndex],
-					reportIntensities[1],
-				)
-			case 4:
-				line = fmt.Sprintf("%s\t%.4f\t%.4f\t%.4f\t%.4f",
-					line,
-					reportIntensities[headerIndex],
-					reportIntensities[1],
-					reportIntensities[2],
-					reportIntensities[3],
-				)
-			case 6:
-				line = fmt.Sprintf("%s\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f",
-					line,
-					reportIntensities[headerIndex],
-					reportIntensities[1],
-					reportIntensities[4],
-					reportIntensities[5],
-					reportIntensities[8],
-					reportIntensities[9],
-				)
-			case 8:
-				line = fmt.Sprintf("%s\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f",
-					line,
-					reportIntensities[headerIndex],
-					reportIntensities[1],
-					reportIntensities[2],
-					reportIntensities[3],
-					reportIntensities[4],
-					reportIntensities[5],
-					reportIntensities[6],
-					reportIntensities[7],
-				)
-			case 10:
-				line = fmt.Sprintf("%s\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f",
-					line,
-					reportIntensities[headerIndex],
-					reportIntensities[1],
-					reportIntensities[2],
-					reportIntensities[3],
-					reportIntensities[4],
-					reportIntensities[5],
-					reportIntensities[6],
-					reportIntensities[7],
-					reportIntensities[8],
-					reportIntensities[9],
-				)
-			case 11:
-				line = fmt.Sprintf("%s\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f",
-					line,
-					reportIntensities[headerIndex],
-					reportIntensities[1],
-					reportIntensities[2],
-					reportIntensities[3],
-					reportIntensities[4],
-					reportIntensities[5],
-					reportIntensities[6],
-					reportIntensities[7],
-					reportIntensities[8],
-					reportIntensities[9],
-					reportIntensities[10],
-				)
-			case 16:
-				line = fmt.Sprintf("%s\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f",
-					line,
-					reportIntensities[headerIndex],
-					reportIntensities[1],
-					reportIntensities[2],
-					reportIntensities[3],
-					reportIntensities[4],
-					reportIntensities[5],
-					reportIntensities[6],
-					reportIntensities[7],
-					reportIntensities[8],
-					reportIntensities[9],
-					reportIntensities[10],
-					reportIntensities[11],
-					reportIntensities[12],
-					reportIntensities[13],
-					reportIntensities[14],
-					reportIntensities[15],
-				)
-			case 18:
-				line = fmt.Sprintf("%s\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f",
-					line,
-					reportIntensities[headerIndex],
-					reportIntensities[1],
-					reportIntensities[2],
-					reportIntensities[3],
-					reportIntensities[4],
-					reportIntensities[5],
-					reportIntensities[6],
-					reportIntensities[7],
-					reportIntensities[8],
-					reportIntensities[9],
-					reportIntensities[10],
-					reportIntensities[11],
-					reportIntensities[12],
-					reportIntensities[13],
-					reportIntensities[14],
-					reportIntensities[15],
-					reportIntensities[16],
-					reportIntensities[17],
-				)
-			default:
-				header += ""
-			}
-		} else if brand == "xtag" {
-			line = fmt.Sprintf("%s\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f",
+		if hasAbundances {
+			line = fmt.Sprintf("%s\t%.6f\t%.6f\t%.4f",
 				line,
-				reportIntensities[headerIndex],
-				reportIntensities[1],
-				reportIntensities[2],
-				reportIntensities[3],
-				reportIntensities[4],
-				reportIntensities[5],
-				reportIntensities[6],
-				reportIntensities[7],
-				reportIntensities[8],
-				reportIntensities[9],
-				reportIntensities[10],
-				reportIntensities[11],
-				reportIntensities[12],
-				reportIntensities[13],
-				reportIntensities[14],
-				reportIntensities[15],
-				reportIntensities[16],
-				reportIntensities[17],
+				i.NSAF,
+				i.DNSAF,
+				i.EmPAI,
 			)
 		}
 
+		if hasMS1Labels {
+			line = ms1LabelLine(line, i.MS1Labels, hasMedium)
+		}
+
+		if len(kit.Channels) > 0 {
+			line = labelLine(line, kit, reportLabels)
+			line = rawLabelLine(line, kit, reportLabels)
+		}
+
+		line = fmt.Sprintf("%s\t%.6f\t%.6f", line, i.Qvalue, i.PEP)
 		line += "\n"
 
 		_, e = io.WriteString(bw, line)
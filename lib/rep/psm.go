@@ -19,6 +19,7 @@ import (
 	"philosopher/lib/cla"
 	"philosopher/lib/dat"
 	"philosopher/lib/id"
+	"philosopher/lib/iso"
 )
 
 // AssemblePSMReport creates the PSM structure for reporting
@@ -58,6 +59,8 @@ func (evi *Evidence) AssemblePSMReport(pep id.PepIDList, decoyTag string) {
 		p.Massdiff = i.Massdiff
 		p.PTM = i.PTM
 		p.Probability = i.Probability
+		p.Qvalue = i.Qvalue
+		p.PEP = i.PEP
 		p.Expectation = i.Expectation
 		p.Xcorr = i.Xcorr
 		p.DeltaCN = i.DeltaCN
@@ -130,7 +133,7 @@ func (evi *Evidence) AssemblePSMReport(pep id.PepIDList, decoyTag string) {
 }
 
 // PSMReport report all psms from study that passed the FDR filter
-func (evi PSMEvidenceList) PSMReport(workspace, brand, decoyTag string, channels int, hasDecoys, isComet, hasLoc, hasIonMob, hasLabels, hasPrefix, removeContam bool) {
+func (evi PSMEvidenceList) PSMReport(workspace, decoyTag string, kit iso.Kit, hasDecoys, isComet, hasLoc, hasIonMob, hasLabels, hasPrefix, removeContam bool) {
 
 	var header string
 	var output string
@@ -138,6 +141,12 @@ func (evi PSMEvidenceList) PSMReport(workspace, brand, decoyTag string, channels
 	var modList []string
 	var hasCompVolt bool
 	var hasPurity bool
+	var hasPeak bool
+	var hasIsotopes bool
+	var hasAligned bool
+	var hasFragments bool
+	var hasMS1Labels bool
+	var hasMedium bool
 	var hasSpectralSim bool
 	var hasRtScore bool
 
@@ -196,6 +205,29 @@ func (evi PSMEvidenceList) PSMReport(workspace, brand, decoyTag string, channels
 			hasPurity = true
 		}
 
+		if evi[i].PeakArea > 0 {
+			hasPeak = true
+		}
+
+		if evi[i].SummedIsotopeIntensity > 0 {
+			hasIsotopes = true
+		}
+
+		if evi[i].AlignedRetentionTime > 0 {
+			hasAligned = true
+		}
+
+		if evi[i].QuantifiedFragments > 0 {
+			hasFragments = true
+		}
+
+		if evi[i].MS1Labels != nil {
+			hasMS1Labels = true
+			if evi[i].MS1Labels.Medium > 0 {
+				hasMedium = true
+			}
+		}
+
 		if evi[i].MSFraggerLoc != nil && len(evi[i].MSFraggerLoc.MSFragerLocalization) > 0 {
 			hasLoc = true
 		}
@@ -257,160 +289,44 @@ func (evi PSMEvidenceList) PSMReport(workspace, brand, decoyTag string, channels
 		header += "\tPurity"
 	}
 
-	header += "\tIs Unique\tProtein\tProtein ID\tEntry Name\tGene\tProtein Description\tMapped Genes\tMapped Proteins"
+	if hasPeak {
+		header += "\tApex Retention Time\tPeak Start\tPeak End\tFWHM\tPeak Area\tPeak Intensity"
+	}
 
-	var headerIndex int
-	for i := range printSet {
-		if printSet[i].Labels != nil && len(printSet[i].Labels.Channel1.Name) > 0 {
-			headerIndex = i
-			break
-		}
+	if hasIsotopes {
+		header += "\tIsotope Correlation\tSummed Isotope Intensity\tIsotope Interference"
 	}
 
-	if brand == "tmt" {
+	if hasAligned {
+		header += "\tAligned Retention Time"
+	}
 
-		header += "\tQuan Usage"
+	if hasFragments {
+		header += "\tQuantified Fragments\tCo-Elution Score"
+	}
 
-		switch channels {
-		case 6:
-			header = fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s",
-				header,
-				printSet[headerIndex].Labels.Channel1.CustomName,
-				printSet[headerIndex].Labels.Channel2.CustomName,
-				printSet[headerIndex].Labels.Channel5.CustomName,
-				printSet[headerIndex].Labels.Channel6.CustomName,
-				printSet[headerIndex].Labels.Channel9.CustomName,
-				printSet[headerIndex].Labels.Channel10.CustomName,
-			)
-		case 10:
-			header = fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
-				header,
-				printSet[headerIndex].Labels.Channel1.CustomName,
-				printSet[headerIndex].Labels.Channel2.CustomName,
-				printSet[headerIndex].Labels.Channel3.CustomName,
-				printSet[headerIndex].Labels.Channel4.CustomName,
-				printSet[headerIndex].Labels.Channel5.CustomName,
-				printSet[headerIndex].Labels.Channel6.CustomName,
-				printSet[headerIndex].Labels.Channel7.CustomName,
-				printSet[headerIndex].Labels.Channel8.CustomName,
-				printSet[headerIndex].Labels.Channel9.CustomName,
-				printSet[headerIndex].Labels.Channel10.CustomName,
-			)
-		case 11:
-			header = fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
-				header,
-				printSet[headerIndex].Labels.Channel1.CustomName,
-				printSet[headerIndex].Labels.Channel2.CustomName,
-				printSet[headerIndex].Labels.Channel3.CustomName,
-				printSet[headerIndex].Labels.Channel4.CustomName,
-				printSet[headerIndex].Labels.Channel5.CustomName,
-				printSet[headerIndex].Labels.Channel6.CustomName,
-				printSet[headerIndex].Labels.Channel7.CustomName,
-				printSet[headerIndex].Labels.Channel8.CustomName,
-				printSet[headerIndex].Labels.Channel9.CustomName,
-				printSet[headerIndex].Labels.Channel10.CustomName,
-				printSet[headerIndex].Labels.Channel11.CustomName,
-			)
-		case 16:
-			header = fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
-				header,
-				printSet[headerIndex].Labels.Channel1.CustomName,
-				printSet[headerIndex].Labels.Channel2.CustomName,
-				printSet[headerIndex].Labels.Channel3.CustomName,
-				printSet[headerIndex].Labels.Channel4.CustomName,
-				printSet[headerIndex].Labels.Channel5.CustomName,
-				printSet[headerIndex].Labels.Channel6.CustomName,
-				printSet[headerIndex].Labels.Channel7.CustomName,
-				printSet[headerIndex].Labels.Channel8.CustomName,
-				printSet[headerIndex].Labels.Channel9.CustomName,
-				printSet[headerIndex].Labels.Channel10.CustomName,
-				printSet[headerIndex].Labels.Channel11.CustomName,
-				printSet[headerIndex].Labels.Channel12.CustomName,
-				printSet[headerIndex].Labels.Channel13.CustomName,
-				printSet[headerIndex].Labels.Channel14.CustomName,
-				printSet[headerIndex].Labels.Channel15.CustomName,
-				printSet[headerIndex].Labels.Channel16.CustomName,
-			)
-		case 18:
-			header = fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
-				header,
-				printSet[headerIndex].Labels.Channel1.CustomName,
-				printSet[headerIndex].Labels.Channel2.CustomName,
-				printSet[headerIndex].Labels.Channel3.CustomName,
-				printSet[headerIndex].Labels.Channel4.CustomName,
-				printSet[headerIndex].Labels.Channel5.CustomName,
-				printSet[headerIndex].Labels.Channel6.CustomName,
-				printSet[headerIndex].Labels.Channel7.CustomName,
-				printSet[headerIndex].Labels.Channel8.CustomName,
-				printSet[headerIndex].Labels.Channel9.CustomName,
-				printSet[headerIndex].Labels.Channel10.CustomName,
-				printSet[headerIndex].Labels.Channel11.CustomName,
-				printSet[headerIndex].Labels.Channel12.CustomName,
-				printSet[headerIndex].Labels.Channel13.CustomName,
-				printSet[headerIndex].Labels.Channel14.CustomName,
-				printSet[headerIndex].Labels.Channel15.CustomName,
-				printSet[headerIndex].Labels.Channel16.CustomName,
-				printSet[headerIndex].Labels.Channel17.CustomName,
-				printSet[headerIndex].Labels.Channel18.CustomName,
-			)
-		default:
-			header += ""
-		}
-	} else if brand == "itraq" {
+	if hasMS1Labels {
+		header += ms1LabelHeader(hasMedium)
+	}
 
-		header += "\tQuan Usage"
+	header += "\tIs Unique\tProtein\tProtein ID\tEntry Name\tGene\tProtein Description\tMapped Genes\tMapped Proteins"
 
-		switch channels {
-		case 4:
-			header = fmt.Sprintf("%s\t%s\t%s\t%s\t%s",
-				header,
-				printSet[headerIndex].Labels.Channel1.CustomName,
-				printSet[headerIndex].Labels.Channel2.CustomName,
-				printSet[headerIndex].Labels.Channel3.CustomName,
-				printSet[headerIndex].Labels.Channel4.CustomName,
-			)
-		case 8:
-			header = fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
-				header,
-				printSet[headerIndex].Labels.Channel1.CustomName,
-				printSet[headerIndex].Labels.Channel2.CustomName,
-				printSet[headerIndex].Labels.Channel3.CustomName,
-				printSet[headerIndex].Labels.Channel4.CustomName,
-				printSet[headerIndex].Labels.Channel5.CustomName,
-				printSet[headerIndex].Labels.Channel6.CustomName,
-				printSet[headerIndex].Labels.Channel7.CustomName,
-				printSet[headerIndex].Labels.Channel8.CustomName,
-			)
-		default:
-			header += ""
+	var headerIndex int
+	for i := range printSet {
+		if printSet[i].Labels != nil && len(printSet[i].Labels.Channels) > 0 {
+			headerIndex = i
+			break
 		}
-	} else if brand == "xtag" {
+	}
 
+	if len(kit.Channels) > 0 {
 		header += "\tQuan Usage"
-
-		header = fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
-			header,
-			printSet[headerIndex].Labels.Channel1.CustomName,
-			printSet[headerIndex].Labels.Channel2.CustomName,
-			printSet[headerIndex].Labels.Channel3.CustomName,
-			printSet[headerIndex].Labels.Channel4.CustomName,
-			printSet[headerIndex].Labels.Channel5.CustomName,
-			printSet[headerIndex].Labels.Channel6.CustomName,
-			printSet[headerIndex].Labels.Channel7.CustomName,
-			printSet[headerIndex].Labels.Channel8.CustomName,
-			printSet[headerIndex].Labels.Channel9.CustomName,
-			printSet[headerIndex].Labels.Channel10.CustomName,
-			printSet[headerIndex].Labels.Channel11.CustomName,
-			printSet[headerIndex].Labels.Channel12.CustomName,
-			printSet[headerIndex].Labels.Channel13.CustomName,
-			printSet[headerIndex].Labels.Channel14.CustomName,
-			printSet[headerIndex].Labels.Channel15.CustomName,
-			printSet[headerIndex].Labels.Channel16.CustomName,
-			printSet[headerIndex].Labels.Channel17.CustomName,
-			printSet[headerIndex].Labels.Channel18.CustomName,
-		)
+		header = labelHeader(header, kit, printSet[headerIndex].Labels)
+		header = rawLabelHeader(header, kit, printSet[headerIndex].Labels)
 	}
 
+	header += "\tQ-Value\tPEP"
+
 	header += "\n"
 
 	_, e = io.WriteString(bw, header)
@@ -559,6 +475,46 @@ func (evi PSMEvidenceList) PSMReport(workspace, brand, decoyTag string, channels
 			)
 		}
 
+		if hasPeak {
+			line = fmt.Sprintf("%s\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f",
+				line,
+				i.ApexRetentionTime,
+				i.PeakStart,
+				i.PeakEnd,
+				i.FWHM,
+				i.PeakArea,
+				i.PeakIntensity,
+			)
+		}
+
+		if hasIsotopes {
+			line = fmt.Sprintf("%s\t%.4f\t%.4f\t%t",
+				line,
+				i.IsotopeCorrelation,
+				i.SummedIsotopeIntensity,
+				i.IsotopeInterference,
+			)
+		}
+
+		if hasAligned {
+			line = fmt.Sprintf("%s\t%.4f",
+				line,
+				i.AlignedRetentionTime,
+			)
+		}
+
+		if hasFragments {
+			line = fmt.Sprintf("%s\t%d\t%.4f",
+				line,
+				i.QuantifiedFragments,
+				i.CoElutionScore,
+			)
+		}
+
+		if hasMS1Labels {
+			line = ms1LabelLine(line, i.MS1Labels, hasMedium)
+		}
+
 		line = fmt.Sprintf("%s\t%t\t%s\t%s\t%s\t%s\t%s\t%s\t%s",
 			line,
 			i.IsUnique,
@@ -571,148 +527,12 @@ func (evi PSMEvidenceList) PSMReport(workspace, brand, decoyTag string, channels
 			strings.Join(mappedProteins, ", "),
 		)
 
-		if brand == "tmt" {
-			switch channels {
-			case 6:
-				line = fmt.Sprintf("%s\t%t\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f",
-					line,
-					i.Labels.IsUsed,
-					i.Labels.Channel1.Intensity,
-					i.Labels.Channel2.Intensity,
-					i.Labels.Channel5.Intensity,
-					i.Labels.Channel6.Intensity,
-					i.Labels.Channel9.Intensity,
-					i.Labels.Channel10.Intensity,
-				)
-			case 10:
-				line = fmt.Sprintf("%s\t%t\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f",
-					line,
-					i.Labels.IsUsed,
-					i.Labels.Channel1.Intensity,
-					i.Labels.Channel2.Intensity,
-					i.Labels.Channel3.Intensity,
-					i.Labels.Channel4.Intensity,
-					i.Labels.Channel5.Intensity,
-					i.Labels.Channel6.Intensity,
-					i.Labels.Channel7.Intensity,
-					i.Labels.Channel8.Intensity,
-					i.Labels.Channel9.Intensity,
-					i.Labels.Channel10.Intensity,
-				)
-			case 11:
-				line = fmt.Sprintf("%s\t%t\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f",
-					line,
-					i.Labels.IsUsed,
-					i.Labels.Channel1.Intensity,
-					i.Labels.Channel2.Intensity,
-					i.Labels.Channel3.Intensity,
-					i.Labels.Channel4.Intensity,
-					i.Labels.Channel5.Intensity,
-					i.Labels.Channel6.Intensity,
-					i.Labels.Channel7.Intensity,
-					i.Labels.Channel8.Intensity,
-					i.Labels.Channel9.Intensity,
-					i.Labels.Channel10.Intensity,
-					i.Labels.Channel11.Intensity,
-				)
-			case 16:
-				line = fmt.Sprintf("%s\t%t\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f",
-					line,
-					i.Labels.IsUsed,
-					i.Labels.Channel1.Intensity,
-					i.Labels.Channel2.Intensity,
-					i.Labels.Channel3.Intensity,
-					i.Labels.Channel4.Intensity,
-					i.Labels.Channel5.Intensity,
-					i.Labels.Channel6.Intensity,
-					i.Labels.Channel7.Intensity,
-					i.Labels.Channel8.Intensity,
-					i.Labels.Channel9.Intensity,
-					i.Labels.Channel10.Intensity,
-					i.Labels.Channel11.Intensity,
-					i.Labels.Channel12.Intensity,
-					i.Labels.Channel13.Intensity,
-					i.Labels.Channel14.Intensity,
-					i.Labels.Channel15.Intensity,
-					i.Labels.Channel16.Intensity,
-				)
-			case 18:
-				line = fmt.Sprintf("%s\t%t\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f",
-					line,
-					i.Labels.IsUsed,
-					i.Labels.Channel1.Intensity,
-					i.Labels.Channel2.Intensity,
-					i.Labels.Channel3.Intensity,
-					i.Labels.Channel4.Intensity,
-					i.Labels.Channel5.Intensity,
-					i.Labels.Channel6.Intensity,
-					i.Labels.Channel7.Intensity,
-					i.Labels.Channel8.Intensity,
-					i.Labels.Channel9.Intensity,
-					i.Labels.Channel10.Intensity,
-					i.Labels.Channel11.Intensity,
-					i.Labels.Channel12.Intensity,
-					i.Labels.Channel13.Intensity,
-					i.Labels.Channel14.Intensity,
-					i.Labels.Channel15.Intensity,
-					i.Labels.Channel16.Intensity,
-					i.Labels.Channel17.Intensity,
-					i.Labels.Channel18.Intensity,
-				)
-			default:
-				header += ""
-			}
-		} else if brand == "itraq" {
-			switch channels {
-			case 4:
-				line = fmt.Sprintf("%s\t%t\t%.4f\t%.4f\t%.4f\t%.4f",
-					line,
-					i.Labels.IsUsed,
-					i.Labels.Channel1.Intensity,
-					i.Labels.Channel2.Intensity,
-					i.Labels.Channel3.Intensity,
-					i.Labels.Channel4.Intensity,
-				)
-			case 8:
-				line = fmt.Sprintf("%s\t%t\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f",
-					line,
-					i.Labels.IsUsed,
-					i.Labels.Channel1.Intensity,
-					i.Labels.Channel2.Intensity,
-					i.Labels.Channel3.Intensity,
-					i.Labels.Channel4.Intensity,
-					i.Labels.Channel5.Intensity,
-					i.Labels.Channel6.Intensity,
-					i.Labels.Channel7.Intensity,
-					i.Labels.Channel8.Intensity,
-				)
-			default:
-				header += ""
-			}
-		} else if brand == "xtag" {
-			line = fmt.Sprintf("%s\t%t\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f",
-				line,
-				i.Labels.IsUsed,
-				i.Labels.Channel1.Intensity,
-				i.Labels.Channel2.Intensity,
-				i.Labels.Channel3.Intensity,
-				i.Labels.Channel4.Intensity,
-				i.Labels.Channel5.Intensity,
-				i.Labels.Channel6.Intensity,
-				i.Labels.Channel7.Intensity,
-				i.Labels.Channel8.Intensity,
-				i.Labels.Channel9.Intensity,
-				i.Labels.Channel10.Intensity,
-				i.Labels.Channel11.Intensity,
-				i.Labels.Channel12.Intensity,
-				i.Labels.Channel13.Intensity,
-				i.Labels.Channel14.Intensity,
-				i.Labels.Channel15.Intensity,
-				i.Labels.Channel16.Intensity,
-				i.Labels.Channel17.Intensity,
-				i.Labels.Channel18.Intensity,
-			)
+		if len(kit.Channels) > 0 {
+			line = fmt.Sprintf("%s\t%t", line, i.Labels.IsUsed)
+			line = labelLine(line, kit, i.Labels)
+			line = rawLabelLine(line, kit, i.Labels)
 		}
+		line = fmt.Sprintf("%s\t%.6f\t%.6f", line, i.Qvalue, i.PEP)
 		line += "\n"
 
 		_, e = io.WriteString(bw, line)
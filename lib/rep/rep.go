@@ -8,6 +8,10 @@ import (
 	"philosopher/lib/iso"
 	"philosopher/lib/met"
 	"philosopher/lib/mod"
+	"philosopher/lib/msg"
+	"philosopher/lib/tmt"
+	"philosopher/lib/trq"
+	"philosopher/lib/xta"
 
 	"github.com/sirupsen/logrus"
 )
@@ -22,12 +26,20 @@ type Evidence struct {
 	Proteins        ProteinEvidenceList
 	Mods            mod.Modifications
 	Modifications   ModificationEvidence
+	Sites           SiteEvidenceList
 	CombinedProtein CombinedProteinEvidenceList
 	CombinedPeptide CombinedPeptideEvidenceList
 }
 
+// SearchEvidence holds the search definitions that are not part of the granular evidences
+type SearchEvidence struct {
+	Parameters SearchParametersEvidence
+	Mods       mod.Modifications
+}
+
 // SearchParametersEvidence ...
 type SearchParametersEvidence struct {
+	SearchEngineVersion                string
 	MSFragger                          string
 	DatabaseName                       string
 	NumThreads                         string
@@ -130,6 +142,7 @@ type PSMEvidence struct {
 	NumberOfMissedCleavages          uint8
 	AssumedCharge                    uint8
 	HitRank                          uint8
+	QuantifiedFragments              uint8
 	Index                            uint32
 	UncalibratedPrecursorNeutralMass float64
 	PrecursorNeutralMass             float64
@@ -138,6 +151,8 @@ type PSMEvidence struct {
 	RawMassdiff                      float64
 	Massdiff                         float64
 	Probability                      float64
+	Qvalue                           float64
+	PEP                              float64
 	Expectation                      float64
 	Xcorr                            float64
 	DeltaCN                          float64
@@ -149,6 +164,16 @@ type PSMEvidence struct {
 	SpectralSim                      float64
 	Rtscore                          float64
 	Intensity                        float64
+	ApexRetentionTime                float64
+	AlignedRetentionTime             float64
+	PeakStart                        float64
+	PeakEnd                          float64
+	FWHM                             float64
+	PeakArea                         float64
+	PeakIntensity                    float64
+	IsotopeCorrelation               float64
+	SummedIsotopeIntensity           float64
+	CoElutionScore                   float64
 	IonMobility                      float64
 	Purity                           float64
 	PrevAA                           byte
@@ -156,9 +181,11 @@ type PSMEvidence struct {
 	IsDecoy                          bool
 	IsUnique                         bool
 	IsURazor                         bool
+	IsotopeInterference              bool
 	PTM                              *id.PTM
 	MSFraggerLoc                     *id.MSFraggerLoc
 	Labels                           *iso.Labels
+	MS1Labels                        *MS1Labels
 	Modifications                    mod.ModificationsSlice
 	MappedProteins                   map[string]int
 	MappedGenes                      map[string]struct{}
@@ -180,6 +207,122 @@ func (e IonEvidence) IonForm() id.IonFormType {
 	return id.IonFormType{e.Sequence, float32(t), e.ChargeState}
 }
 
+// MS1Labels holds the MS1 intensities of the light, medium and heavy partners
+// of a SILAC or dimethyl labelled feature, and their ratios to the light one
+type MS1Labels struct {
+	Light       float64
+	Medium      float64
+	Heavy       float64
+	MediumLight float64
+	HeavyLight  float64
+}
+
+// ms1LabelHeader returns the MS1 label report columns, the medium partner is
+// only reported by the triplex schemes
+func ms1LabelHeader(hasMedium bool) string {
+
+	if hasMedium {
+		return "\tLight Intensity\tMedium Intensity\tHeavy Intensity\tM/L Ratio\tH/L Ratio"
+	}
+
+	return "\tLight Intensity\tHeavy Intensity\tH/L Ratio"
+}
+
+// ms1LabelLine appends the MS1 label columns to a report line
+func ms1LabelLine(line string, l *MS1Labels, hasMedium bool) string {
+
+	if l == nil {
+		l = &MS1Labels{}
+	}
+
+	if hasMedium {
+		return fmt.Sprintf("%s\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f", line, l.Light, l.Medium, l.Heavy, l.MediumLight, l.HeavyLight)
+	}
+
+	return fmt.Sprintf("%s\t%.4f\t%.4f\t%.4f", line, l.Light, l.Heavy, l.HeavyLight)
+}
+
+// LabelKit returns the isobaric reagents used by the quantification, empty for label-free data
+func LabelKit(brand, plex string) iso.Kit {
+
+	switch brand {
+	case "tmt":
+		if len(plex) == 0 {
+			return iso.Kit{}
+		}
+		kit, e := tmt.Kit(plex)
+		if e != nil {
+			msg.Custom(e, "fatal")
+		}
+		return kit
+	case "itraq":
+		return trq.Kit(plex)
+	case "xtag":
+		return xta.Kit(plex)
+	}
+
+	return iso.Kit{}
+}
+
+// labelHeader appends the channel names to a report header, using the custom names of the labels
+func labelHeader(header string, kit iso.Kit, l *iso.Labels) string {
+
+	for _, i := range kit.Select(l) {
+		header += fmt.Sprintf("\t%s", channelHeader(i.CustomName, kit))
+	}
+
+	return header
+}
+
+// channelHeader adds the normalization method to the channel name when the intensities are ratios or log2 values
+func channelHeader(name string, kit iso.Kit) string {
+
+	switch kit.Normalization {
+	case "", "none", "total":
+		return name
+	}
+
+	return fmt.Sprintf("%s (%s)", name, kit.Normalization)
+}
+
+// labelLine appends the channel intensities to a report line
+func labelLine(line string, kit iso.Kit, l *iso.Labels) string {
+
+	for _, i := range kit.Select(l) {
+		line += fmt.Sprintf("\t%.4f", i.Intensity)
+	}
+
+	return line
+}
+
+// rawLabelHeader appends the uncorrected channel names when the intensities were corrected for the reagent impurities
+func rawLabelHeader(header string, kit iso.Kit, l *iso.Labels) string {
+
+	if !kit.Corrected {
+		return header
+	}
+
+	for _, i := range kit.Select(l) {
+		header += fmt.Sprintf("\t%s Raw", i.CustomName)
+	}
+
+	return header
+}
+
+// rawLabelLine appends the uncorrected channel intensities when the intensities were corrected for the reagent impurities
+func rawLabelLine(line string, kit iso.Kit, l *iso.Labels) string {
+
+	if !kit.Corrected {
+		return line
+	}
+
+	for _, i := range kit.Select(l) {
+		line += fmt.Sprintf("\t%.4f", i.RawIntensity)
+	}
+
+	return line
+}
+
 // PSMEvidenceList ...
 type PSMEvidenceList []PSMEvidence
 
@@ -206,6 +349,7 @@ type IonEvidence struct {
 	ProteinDescription       string
 	ChargeState              uint8
 	NumberOfEnzymaticTermini uint8
+	QuantifiedFragments      uint8
 	PrevAA                   byte
 	NextAA                   byte
 	MZ                       float64
@@ -214,14 +358,27 @@ type IonEvidence struct {
 	Weight                   float64
 	GroupWeight              float64
 	Intensity                float64
+	ApexRetentionTime        float64
+	AlignedRetentionTime     float64
+	PeakStart                float64
+	PeakEnd                  float64
+	FWHM                     float64
+	PeakArea                 float64
+	PeakIntensity            float64
+	IsotopeCorrelation       float64
+	SummedIsotopeIntensity   float64
+	CoElutionScore           float64
 	Probability              float64
+	Qvalue                   float64
+	PEP                      float64
 	Expectation              float64
 	SummedLabelIntensity     float64
 	IsUnique                 bool
 	IsURazor                 bool
 	IsDecoy                  bool
+	IsotopeInterference      bool
 	Labels                   *iso.Labels
-	PhosphoLabels            *iso.Labels
+	MS1Labels                *MS1Labels
 	Modifications            mod.ModificationsSlice
 	Spectra                  map[id.SpectrumType]int
 	MappedProteins           map[string]int
@@ -257,6 +414,8 @@ type PeptideEvidence struct {
 	UnModifiedObservations int
 	Intensity              float64
 	Probability            float64
+	Qvalue                 float64
+	PEP                    float64
 	PrevAA                 byte
 	NextAA                 byte
 	IsUnique               bool
@@ -267,7 +426,7 @@ type PeptideEvidence struct {
 	MappedProteins         map[string]int
 	MappedGenes            map[string]struct{}
 	Labels                 *iso.Labels
-	PhosphoLabels          *iso.Labels
+	MS1Labels              *MS1Labels
 	Modifications          mod.ModificationsSlice
 }
 
@@ -307,8 +466,13 @@ type ProteinEvidence struct {
 	TotalIntensity         float64
 	UniqueIntensity        float64
 	URazorIntensity        float64 // Unique + razor
+	NSAF                   float64
+	DNSAF                  float64 // shared spectra distributed by the unique counts
+	EmPAI                  float64
 	Probability            float64
 	TopPepProb             float64
+	Qvalue                 float64
+	PEP                    float64
 	IsDecoy                bool
 	IsContaminant          bool
 	SupportingSpectra      map[id.SpectrumType]int
@@ -320,9 +484,7 @@ type ProteinEvidence struct {
 	TotalLabels            *iso.Labels
 	UniqueLabels           *iso.Labels
 	URazorLabels           *iso.Labels // Unique + razor
-	PhosphoTotalLabels     *iso.Labels
-	PhosphoUniqueLabels    *iso.Labels
-	PhosphoURazorLabels    *iso.Labels // Unique + razor
+	MS1Labels              *MS1Labels
 	Modifications          mod.ModificationsSlice
 }
 
@@ -333,38 +495,81 @@ func (a ProteinEvidenceList) Len() int           { return len(a) }
 func (a ProteinEvidenceList) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a ProteinEvidenceList) Less(i, j int) bool { return a[i].ProteinGroup < a[j].ProteinGroup }
 
+// SiteEvidence groups the isobaric quantification of a localized modification site
+type SiteEvidence struct {
+	Protein            string
+	ProteinID          string
+	EntryName          string
+	GeneName           string
+	ProteinDescription string
+	Modification       string
+	AminoAcid          string
+	SequenceWindow     string
+	Position           int // protein position, 1-based
+	MassDiff           float64
+	Probability        float64 // best localization probability
+	IsUnique           bool
+	IsDecoy            bool
+	Spectra            map[id.SpectrumType]uint8
+	Peptides           map[string]uint8
+	Labels             *iso.Labels
+}
+
+// SiteEvidenceList list
+type SiteEvidenceList []SiteEvidence
+
+func (a SiteEvidenceList) Len() int      { return len(a) }
+func (a SiteEvidenceList) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a SiteEvidenceList) Less(i, j int) bool {
+	if a[i].Protein != a[j].Protein {
+		return a[i].Protein < a[j].Protein
+	}
+	if a[i].Position != a[j].Position {
+		return a[i].Position < a[j].Position
+	}
+	return a[i].Modification < a[j].Modification
+}
+
 // CombinedProteinEvidence represents all combined proteins detected
 type CombinedProteinEvidence struct {
-	GroupNumber            uint32
-	SiblingID              string
-	ProteinName            string
-	ProteinID              string
-	EntryName              string
-	Organism               string
-	GeneNames              string
-	ProteinExistence       string
-	Description            string
-	IndiProtein            []string
-	Names                  []string
-	Length                 int
-	UniqueStrippedPeptides int
-	Coverage               float32
-	ProteinProbability     float64
-	TopPepProb             float64
-	SupportingSpectra      map[string]string
-	TotalSpc               map[string]int
-	UniqueSpc              map[string]int
-	UrazorSpc              map[string]int
-	TotalPeptides          map[string]map[string]bool
-	UniquePeptides         map[string]map[string]bool
-	UrazorPeptides         map[string]map[string]bool
-	TotalIntensity         map[string]float64
-	UniqueIntensity        map[string]float64
-	UrazorIntensity        map[string]float64
-	TotalLabels            map[string]iso.Labels
-	UniqueLabels           map[string]iso.Labels
-	URazorLabels           map[string]iso.Labels // Unique + razor
-	PeptideIons            []id.PeptideIonIdentification
+	GroupNumber              uint32
+	SiblingID                string
+	ProteinName              string
+	ProteinID                string
+	EntryName                string
+	Organism                 string
+	GeneNames                string
+	ProteinExistence         string
+	Description              string
+	IndiProtein              []string
+	Names                    []string
+	Length                   int
+	UniqueStrippedPeptides   int
+	Coverage                 float32
+	ProteinProbability       float64
+	TopPepProb               float64
+	SupportingSpectra        map[string]string
+	TotalSpc                 map[string]int
+	UniqueSpc                map[string]int
+	UrazorSpc                map[string]int
+	NSAF                     map[string]float64
+	DNSAF                    map[string]float64
+	EmPAI                    map[string]float64
+	TotalPeptides            map[string]map[string]bool
+	UniquePeptides           map[string]map[string]bool
+	UrazorPeptides           map[string]map[string]bool
+	TotalIntensity           map[string]float64
+	UniqueIntensity          map[string]float64
+	UrazorIntensity          map[string]float64
+	MaxLFQIntensity          map[string]float64
+	ProcessedIntensity       map[string]float64 // normalized and imputed
+	ProcessedMaxLFQIntensity map[string]float64
+	TotalLabels              map[string]iso.Labels
+	UniqueLabels             map[string]iso.Labels
+	URazorLabels             map[string]iso.Labels // Unique + razor
+	IRSFactors               map[string]float64    // internal reference scaling
+	TransferredIons          map[string]int        // match-between-runs
+	PeptideIons              []id.PeptideIonIdentification
 }
 
 // CombinedProteinEvidenceList is a list of Combined Protein Evidences
@@ -387,6 +592,8 @@ type CombinedPeptideEvidence struct {
 	AssignedMassDiffs  map[string]uint8
 	Spc                map[string]int
 	Intensity          map[string]float64
+	Labels             map[string]iso.Labels
+	IRSFactors         map[string]float64 // internal reference scaling
 }
 
 // CombinedPeptideEvidenceList is a list of Combined Peptide Evidences
@@ -454,8 +661,6 @@ func Run(m met.Data) {
 	var isComet bool
 	var hasLoc bool
 	var hasLabels bool
-	var isoBrand string
-	var isoChannels int
 
 	if len(m.Comet.Param) > 0 {
 		isComet = true
@@ -465,17 +670,9 @@ func Run(m met.Data) {
 		hasLoc = true
 	}
 
-	if m.Quantify.Brand == "tmt" {
-		isoBrand = "tmt"
-	} else if m.Quantify.Brand == "itraq" {
-		isoBrand = "itraq"
-	} else if m.Quantify.Brand == "xtag" {
-		isoBrand = "xtag"
-	}
-
-	if len(m.Quantify.Plex) > 0 {
-		isoChannels, _ = strconv.Atoi(m.Quantify.Plex)
-	}
+	kit := LabelKit(m.Quantify.Brand, m.Quantify.Plex)
+	kit.Normalization = m.Quantify.ChanNorm
+	kit.Corrected = len(m.Quantify.ImpurityMatrix) > 0
 
 	if len(m.Quantify.Annot) > 0 {
 		hasLabels = true
@@ -486,27 +683,35 @@ func Run(m met.Data) {
 		var repoPSM PSMEvidenceList
 		RestorePSM(&repoPSM)
 		// PSM
-		repoPSM.PSMReport(m.Home, isoBrand, m.Database.Tag, isoChannels, m.Report.Decoys, isComet, hasLoc, m.Report.IonMob, hasLabels, m.Report.Prefix, m.Report.RemoveContam)
+		repoPSM.PSMReport(m.Home, m.Database.Tag, kit, m.Report.Decoys, isComet, hasLoc, m.Report.IonMob, hasLabels, m.Report.Prefix, m.Report.RemoveContam)
 	}
 	{
 		var repoIons IonEvidenceList
 		RestoreIon(&repoIons)
 		// Ion
-		repoIons.IonReport(m.Home, isoBrand, m.Database.Tag, isoChannels, m.Report.Decoys, hasLabels, m.Report.Prefix, m.Report.RemoveContam)
+		repoIons.IonReport(m.Home, m.Database.Tag, kit, m.Report.Decoys, hasLabels, m.Report.Prefix, m.Report.RemoveContam)
 	}
 	{
 		// Peptide
 		var repoPeptides PeptideEvidenceList
 		RestorePeptide(&repoPeptides)
-		repoPeptides.PeptideReport(m.Home, isoBrand, m.Database.Tag, isoChannels, m.Report.Decoys, hasLabels, m.Report.Prefix, m.Report.RemoveContam)
+		repoPeptides.PeptideReport(m.Home, m.Database.Tag, kit, m.Report.Decoys, hasLabels, m.Report.Prefix, m.Report.RemoveContam)
 	}
 	// Protein
 	if len(m.Filter.Pox) > 0 || m.Filter.Inference {
 		var repoProteins ProteinEvidenceList
 		RestoreProtein(&repoProteins)
-		repoProteins.ProteinReport(m.Home, isoBrand, m.Database.Tag, isoChannels, m.Report.Decoys, m.Filter.Razor, m.Quantify.Unique, hasLabels, m.Report.Prefix, m.Report.RemoveContam)
+		repoProteins.ProteinReport(m.Home, m.Database.Tag, kit, m.Report.Decoys, m.Filter.Razor, m.Quantify.Unique, hasLabels, m.Report.Prefix, m.Report.RemoveContam)
 		repoProteins.ProteinFastaReport(m.Home, m.Report.Decoys)
 	}
+	{
+		// Modification sites
+		var repoSites SiteEvidenceList
+		RestoreSites(&repoSites)
+		if len(repoSites) > 0 {
+			repoSites.SiteReport(m.Home, m.Database.Tag, kit, m.Report.Decoys, m.Report.Prefix, m.Report.RemoveContam)
+		}
+	}
 
 	// Modifications
 	repo := New()
@@ -525,13 +730,21 @@ func Run(m met.Data) {
 
 	// MSstats
 	if m.Report.MSstats {
-		repo.MetaMSstatsReport(m.Home, isoBrand, isoChannels, m.Report.Decoys, m.Report.Prefix)
+		repo.MetaMSstatsReport(m.Home, kit, m.Report.Decoys, m.Report.Prefix)
 	}
 
 	// MzID
 	if m.Report.MZID {
 		repo.RestoreGranular()
-		repo.MzIdentMLReport(m.Version, m.Database.Annot)
+		repo.RestoreSearch()
+		repo.MzIdentMLReport(m.Version, m.Database.Annot, m.SearchEngine, m.Filter)
+	}
+
+	// mzTab
+	if m.Report.MzTab {
+		repo.RestoreGranular()
+		repo.RestoreSearch()
+		repo.MzTabReport(m.Home, m.Version, m.SearchEngine, kit, m.Report.Decoys, m.Filter.Razor, m.Quantify.Unique, m.Report.Prefix)
 	}
 
 }
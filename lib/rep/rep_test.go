@@ -0,0 +1,43 @@
+package rep
+
+import (
+	"testing"
+
+	"philosopher/lib/iso"
+)
+
+func Test_rawLabelLine(t *testing.T) {
+
+	kit := iso.NewKit("tmt", "2", []string{"126", "127C"}, []float64{126.127726, 127.131081})
+	l := &iso.Labels{Channels: []iso.Channel{
+		{Name: "126", CustomName: "A", Intensity: 100, RawIntensity: 95},
+		{Name: "127C", CustomName: "B", Intensity: 100, RawIntensity: 105},
+	}}
+
+	if got := rawLabelLine("x", kit, l); got != "x" {
+		t.Errorf("Raw intensities should only be reported after the impurity correction, got %q", got)
+	}
+
+	kit.Corrected = true
+
+	if got := rawLabelHeader("x", kit, l); got != "x\tA Raw\tB Raw" {
+		t.Errorf("Raw header is incorrect, got %q", got)
+	}
+
+	if got := rawLabelLine("x", kit, l); got != "x\t95.0000\t105.0000" {
+		t.Errorf("Raw line is incorrect, got %q", got)
+	}
+}
+
+func Test_searchEngineVersion(t *testing.T) {
+
+	p := SearchParametersEvidence{SearchEngineVersion: "2019.01 rev. 5", MSFragger: "MSFragger-3.4"}
+
+	if v := searchEngineVersion("Comet", p); v != "2019.01 rev. 5" {
+		t.Errorf("Comet version is incorrect, got %s", v)
+	}
+
+	if v := searchEngineVersion("MSFragger", p); v != "MSFragger-3.4" {
+		t.Errorf("MSFragger version is incorrect, got %s", v)
+	}
+}
@@ -0,0 +1,117 @@
+package rep
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"philosopher/lib/iso"
+	"philosopher/lib/msg"
+)
+
+// SiteReport report all localized modification sites with their channel intensities
+func (evi SiteEvidenceList) SiteReport(workspace, decoyTag string, kit iso.Kit, hasDecoys, hasPrefix, removeContam bool) {
+
+	var output string
+
+	if hasPrefix {
+		output = fmt.Sprintf("%s%s%s_site.tsv", workspace, string(filepath.Separator), path.Base(workspace))
+	} else {
+		output = fmt.Sprintf("%s%ssite.tsv", workspace, string(filepath.Separator))
+	}
+
+	file, e := os.Create(output)
+	bw := bufio.NewWriter(file)
+	if e != nil {
+		msg.WriteFile(errors.New("site output file"), "fatal")
+	}
+	defer file.Close()
+	defer bw.Flush()
+
+	// building the printing set tat may or not contain decoys
+	var printSet []*SiteEvidence
+	for idx, i := range evi {
+
+		if removeContam && (strings.HasPrefix(i.Protein, "contam_") || strings.HasPrefix(i.Protein, "Cont_")) {
+			continue
+		}
+
+		if !hasDecoys {
+			if !i.IsDecoy {
+				printSet = append(printSet, &evi[idx])
+			}
+		} else {
+			printSet = append(printSet, &evi[idx])
+		}
+	}
+
+	header := "Protein\tProtein ID\tEntry Name\tGene\tProtein Description\tModification\tMass Difference\tAmino Acid\tPosition\tSequence Window\tLocalization Probability\tSpectral Count\tPeptides\tIs Unique"
+
+	var headerIndex int
+	for i := range printSet {
+		if printSet[i].Labels != nil && len(printSet[i].Labels.Channels) > 0 {
+			headerIndex = i
+			break
+		}
+	}
+
+	if len(kit.Channels) > 0 && len(printSet) > 0 {
+		header = labelHeader(header, kit, printSet[headerIndex].Labels)
+	}
+
+	header += "\n"
+
+	_, e = io.WriteString(bw, header)
+	if e != nil {
+		msg.WriteToFile(errors.New("cannot print sites to file"), "fatal")
+	}
+
+	for _, i := range printSet {
+
+		var peptides []string
+		for j := range i.Peptides {
+			peptides = append(peptides, j)
+		}
+		sort.Strings(peptides)
+
+		// append decoy tags on the gene and proteinID names
+		if i.IsDecoy {
+			i.ProteinID = decoyTag + i.ProteinID
+			i.GeneName = decoyTag + i.GeneName
+			i.EntryName = decoyTag + i.EntryName
+		}
+
+		line := fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%.4f\t%s\t%d\t%s\t%.4f\t%d\t%s\t%t",
+			i.Protein,
+			i.ProteinID,
+			i.EntryName,
+			i.GeneName,
+			i.ProteinDescription,
+			i.Modification,
+			i.MassDiff,
+			i.AminoAcid,
+			i.Position,
+			i.SequenceWindow,
+			i.Probability,
+			len(i.Spectra),
+			strings.Join(peptides, ", "),
+			i.IsUnique,
+		)
+
+		if len(kit.Channels) > 0 {
+			line = labelLine(line, kit, i.Labels)
+		}
+		line += "\n"
+
+		_, e = io.WriteString(bw, line)
+		if e != nil {
+			msg.WriteToFile(errors.New("cannot print sites to file"), "fatal")
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package rsc
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"philosopher/lib/id"
+	"philosopher/lib/uti"
+)
+
+// featureNames are the PSM attributes used by the rescoring models
+var featureNames = []string{
+	"hyperscore",
+	"nextscore",
+	"delta hyperscore",
+	"-log10 expect",
+	"xcorr",
+	"deltacn",
+	"absolute delta mass",
+	"charge 1",
+	"charge 2",
+	"charge 3",
+	"charge 4+",
+	"missed cleavages",
+	"enzymatic termini",
+	"peptide length",
+	"rtscore",
+	"spectral similarity",
+	"ion mobility",
+}
+
+// features extracts the feature vector from a PSM
+func features(p *id.PeptideIdentification) []float64 {
+
+	var expect float64
+	if p.Expectation > 0 {
+		expect = -math.Log10(p.Expectation)
+	}
+
+	var charge [4]float64
+	switch {
+	case p.AssumedCharge >= 4:
+		charge[3] = 1
+	case p.AssumedCharge > 0:
+		charge[p.AssumedCharge-1] = 1
+	}
+
+	return []float64{
+		p.Hyperscore,
+		p.Nextscore,
+		p.Hyperscore - p.Nextscore,
+		expect,
+		p.Xcorr,
+		p.DeltaCN,
+		math.Abs(p.Massdiff),
+		charge[0],
+		charge[1],
+		charge[2],
+		charge[3],
+		float64(p.NumberofMissedCleavages),
+		float64(p.NumberOfEnzymaticTermini),
+		float64(len(p.Peptide)),
+		p.Rtscore,
+		p.SpectralSim,
+		p.IonMobility,
+	}
+}
+
+// standardize scales every feature to zero mean and unit variance. Constant features
+// are set to zero so they do not contribute to the models.
+func standardize(x [][]float64) {
+
+	if len(x) == 0 {
+		return
+	}
+
+	n := float64(len(x))
+
+	for j := range x[0] {
+
+		var mean, variance float64
+		for i := range x {
+			mean += x[i][j]
+		}
+		mean /= n
+
+		for i := range x {
+			variance += (x[i][j] - mean) * (x[i][j] - mean)
+		}
+		sd := math.Sqrt(variance / n)
+
+		for i := range x {
+			if sd > 0 {
+				x[i][j] = (x[i][j] - mean) / sd
+			} else {
+				x[i][j] = 0
+			}
+		}
+	}
+}
+
+// Model is a linear discriminant function, the last weight is the bias
+type Model struct {
+	Weights []float64
+}
+
+// Score calculates the discriminant score for a feature vector
+func (m Model) Score(x []float64) float64 {
+
+	s := m.Weights[len(x)]
+	for i := range x {
+		s += m.Weights[i] * x[i]
+	}
+
+	return s
+}
+
+// train fits a linear model on the positive (label true) and negative examples. The
+// svm model minimizes the hinge loss and the logistic model minimizes the log loss,
+// both with a L2 penalty. Classes are weighted so they have the same importance.
+func train(x [][]float64, label []bool, model string, init Model) Model {
+
+	const epochs = 100
+	const lambda = 0.001
+	const rate = 0.5
+
+	var positives, negatives float64
+	for _, i := range label {
+		if i {
+			positives++
+		} else {
+			negatives++
+		}
+	}
+
+	if positives == 0 || negatives == 0 {
+		return init
+	}
+
+	w := make([]float64, len(init.Weights))
+	copy(w, init.Weights)
+
+	m := Model{Weights: w}
+	grad := make([]float64, len(w))
+	svm := !strings.EqualFold(model, "logistic")
+
+	for epoch := 1; epoch <= epochs; epoch++ {
+
+		for j := range grad {
+			grad[j] = 0
+		}
+
+		for i := range x {
+
+			y := -1.0
+			c := 0.5 / negatives
+			if label[i] {
+				y = 1
+				c = 0.5 / positives
+			}
+
+			margin := y * m.Score(x[i])
+
+			var g float64
+			if svm {
+				if margin < 1 {
+					g = -y
+				}
+			} else {
+				g = -y / (1 + math.Exp(margin))
+			}
+
+			if g == 0 {
+				continue
+			}
+
+			for j := range x[i] {
+				grad[j] += c * g * x[i][j]
+			}
+			grad[len(x[i])] += c * g
+		}
+
+		// the bias is not regularized
+		for j := 0; j < len(w)-1; j++ {
+			grad[j] += lambda * w[j]
+		}
+
+		step := rate / math.Sqrt(float64(epoch))
+		for j := range w {
+			w[j] -= step * grad[j]
+		}
+	}
+
+	return m
+}
+
+// qValues estimates the q-value of each score using the target-decoy approach
+func qValues(scores []float64, decoy []bool) []float64 {
+
+	targets, decoys := scoreCounts(scores, decoy)
+
+	var keys []float64
+	for k := range targets {
+		keys = append(keys, k)
+	}
+	for k := range decoys {
+		if _, ok := targets[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Sort(sort.Reverse(sort.Float64Slice(keys)))
+
+	// the FDR of each score counts all the PSMs scored at or above it
+	var scoreMap = make(map[float64]float64)
+	var t, d float64
+	for _, k := range keys {
+		t += targets[k]
+		d += decoys[k]
+
+		scoreMap[k] = 1
+		if t > 0 {
+			scoreMap[k] = math.Min(d/t, 1)
+		}
+	}
+
+	qMap := uti.QValueMap(scoreMap)
+
+	var q = make([]float64, len(scores))
+	for i := range scores {
+		q[i] = qMap[scores[i]]
+	}
+
+	return q
+}
+
+// posteriorErrorProbabilities estimates the PEP of each score from the target and decoy counts
+func posteriorErrorProbabilities(scores []float64, decoy []bool) []float64 {
+
+	pepMap := uti.PEPMap(scoreCounts(scores, decoy))
+
+	var pep = make([]float64, len(scores))
+	for i := range scores {
+		pep[i] = pepMap[scores[i]]
+	}
+
+	return pep
+}
+
+// scoreCounts returns the number of targets and decoys with each score
+func scoreCounts(scores []float64, decoy []bool) (map[float64]float64, map[float64]float64) {
+
+	var targets = make(map[float64]float64)
+	var decoys = make(map[float64]float64)
+
+	for i := range scores {
+		if decoy[i] {
+			decoys[scores[i]]++
+		} else {
+			targets[scores[i]]++
+		}
+	}
+
+	return targets, decoys
+}
+
+// countAccepted returns the number of targets accepted at the given FDR level
+func countAccepted(q []float64, decoy []bool, fdr float64) int {
+
+	var n int
+	for i := range q {
+		if !decoy[i] && q[i] <= fdr {
+			n++
+		}
+	}
+
+	return n
+}
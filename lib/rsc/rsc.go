@@ -0,0 +1,243 @@
+// Package rsc implements a semi-supervised rescoring of the peptide-spectrum matches
+package rsc
+
+import (
+	"errors"
+	"hash/fnv"
+	"sort"
+	"strings"
+
+	"philosopher/lib/cla"
+	"philosopher/lib/id"
+	"philosopher/lib/met"
+	"philosopher/lib/msg"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Run executes the rescoring and stores the new discriminant scores and posterior
+// probabilities in the workspace identifications
+func Run(m met.Data) met.Data {
+
+	// get the database tag from database command
+	if len(m.Rescore.Tag) == 0 {
+		m.Rescore.Tag = m.Database.Tag
+	}
+
+	_, searchEngine := id.ReadIdentificationInput(m.Rescore.Pex, "", m.Rescore.Tag, "", m.Temp, false)
+	m.SearchEngine = searchEngine
+
+	var pepXML id.PepXML
+	pepXML.Restore()
+
+	list := id.ToPepIDListPtrs(pepXML.PeptideIdentification)
+	if len(list) == 0 {
+		msg.NoPSMFound(errors.New("there are no PSMs to rescore"), "fatal")
+	}
+
+	scores, peps := rescore(list, m.Rescore.Tag, m.Rescore.Model, m.Rescore.FDR, m.Rescore.Folds, m.Rescore.Iterations)
+
+	for i := range list {
+		list[i].DiscriminantValue = scores[i]
+		list[i].PEP = peps[i]
+		list[i].Probability = 1 - peps[i]
+	}
+
+	sort.Sort(list)
+
+	var ser id.PepXML4Serialiazation
+	ser.FileName = pepXML.FileName
+	ser.SpectraFile = pepXML.SpectraFile
+	ser.SearchEngine = pepXML.SearchEngine
+	ser.SearchEngineVersion = pepXML.SearchEngineVersion
+	ser.DecoyTag = pepXML.DecoyTag
+	ser.Database = pepXML.Database
+	ser.Prophet = "rescore"
+	ser.SearchParameters = pepXML.SearchParameters
+	ser.Models = pepXML.Models
+	ser.Modifications = pepXML.Modifications
+	ser.PeptideIdentification = list
+	ser.Serialize()
+
+	return m
+}
+
+// rescore trains the linear models with cross-validation and returns the discriminant
+// score and the posterior error probability of each PSM
+func rescore(list id.PepIDListPtrs, decoyTag, model string, fdr float64, folds, iterations int) ([]float64, []float64) {
+
+	if folds < 2 {
+		folds = 2
+	}
+
+	var x = make([][]float64, len(list))
+	var decoy = make([]bool, len(list))
+	var fold = make([]int, len(list))
+
+	for i := range list {
+		x[i] = features(list[i])
+		decoy[i] = cla.IsDecoyPSM(*list[i], decoyTag)
+		fold[i] = foldOf(list[i], folds)
+	}
+
+	standardize(x)
+
+	init, name := bestFeature(x, decoy, fdr)
+
+	logrus.WithFields(logrus.Fields{
+		"feature": name,
+		"psms":    countAccepted(qValues(scoreAll(init, x), decoy), decoy, fdr),
+	}).Info("Initial direction")
+
+	var scores = make([]float64, len(list))
+
+	for k := 0; k < folds; k++ {
+
+		var trainX [][]float64
+		var trainDecoy []bool
+		var test []int
+
+		for i := range x {
+			if fold[i] == k {
+				test = append(test, i)
+			} else {
+				trainX = append(trainX, x[i])
+				trainDecoy = append(trainDecoy, decoy[i])
+			}
+		}
+
+		m := init
+		for it := 0; it < iterations; it++ {
+
+			q := qValues(scoreAll(m, trainX), trainDecoy)
+
+			// the positive set are the confident targets, the negative set are all decoys,
+			// the remaining targets are left out of the training
+			var setX [][]float64
+			var label []bool
+			var positives int
+			for i := range trainX {
+				if trainDecoy[i] {
+					setX = append(setX, trainX[i])
+					label = append(label, false)
+				} else if q[i] <= fdr {
+					setX = append(setX, trainX[i])
+					label = append(label, true)
+					positives++
+				}
+			}
+
+			if positives == 0 {
+				logrus.Warn("no confident PSMs were found for training the fold ", k+1)
+				break
+			}
+
+			m = train(setX, label, model, m)
+		}
+
+		// scores from different folds are brought to the same scale using the training set,
+		// the FDR threshold is set to 0 and the median decoy to -1
+		trainScores := scoreAll(m, trainX)
+		threshold, median := normalizationPoints(trainScores, qValues(trainScores, trainDecoy), trainDecoy, fdr)
+
+		scale := threshold - median
+		if scale <= 0 {
+			scale = 1
+		}
+
+		for _, i := range test {
+			scores[i] = (m.Score(x[i]) - threshold) / scale
+		}
+	}
+
+	q := qValues(scores, decoy)
+	peps := posteriorErrorProbabilities(scores, decoy)
+
+	logrus.WithFields(logrus.Fields{
+		"model": model,
+		"folds": folds,
+		"psms":  countAccepted(q, decoy, fdr),
+	}).Info("Rescored PSMs")
+
+	return scores, peps
+}
+
+// bestFeature selects the single feature, and its direction, that accepts the largest
+// number of targets at the given FDR level
+func bestFeature(x [][]float64, decoy []bool, fdr float64) (Model, string) {
+
+	var best Model
+	var name string
+	var max = -1
+
+	for j := range featureNames {
+		for _, sign := range []float64{1, -1} {
+
+			m := Model{Weights: make([]float64, len(featureNames)+1)}
+			m.Weights[j] = sign
+
+			n := countAccepted(qValues(scoreAll(m, x), decoy), decoy, fdr)
+			if n > max {
+				max = n
+				best = m
+				name = featureNames[j]
+				if sign < 0 {
+					name = "-" + name
+				}
+			}
+		}
+	}
+
+	return best, name
+}
+
+// normalizationPoints returns the lowest score accepted at the FDR level and the median decoy score
+func normalizationPoints(scores, q []float64, decoy []bool, fdr float64) (float64, float64) {
+
+	var threshold float64
+	var accepted bool
+	var decoys []float64
+
+	for i := range scores {
+		if decoy[i] {
+			decoys = append(decoys, scores[i])
+		} else if q[i] <= fdr && (!accepted || scores[i] < threshold) {
+			threshold = scores[i]
+			accepted = true
+		}
+	}
+
+	var median float64
+	if len(decoys) > 0 {
+		sort.Float64s(decoys)
+		median = decoys[len(decoys)/2]
+	}
+
+	return threshold, median
+}
+
+// scoreAll calculates the discriminant score of every feature vector
+func scoreAll(m Model, x [][]float64) []float64 {
+
+	var scores = make([]float64, len(x))
+	for i := range x {
+		scores[i] = m.Score(x[i])
+	}
+
+	return scores
+}
+
+// foldOf assigns a PSM to a cross-validation fold. All the charge states searched for the
+// same scan are placed in the same fold
+func foldOf(p *id.PeptideIdentification, folds int) int {
+
+	spectrum := p.Spectrum
+	if i := strings.LastIndex(spectrum, "."); i > 0 {
+		spectrum = spectrum[:i]
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(spectrum + "#" + p.SpectrumFile))
+
+	return int(h.Sum32() % uint32(folds))
+}
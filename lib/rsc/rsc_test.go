@@ -0,0 +1,85 @@
+package rsc
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"philosopher/lib/id"
+)
+
+func Test_qValues(t *testing.T) {
+
+	scores := []float64{5, 4, 3, 2, 1}
+	decoy := []bool{false, false, true, false, true}
+
+	got := qValues(scores, decoy)
+	want := []float64{0, 0, 1.0 / 3.0, 1.0 / 3.0, 2.0 / 3.0}
+
+	for i := range want {
+		if got[i] < want[i]-1e-9 || got[i] > want[i]+1e-9 {
+			t.Errorf("qValues() = %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func Test_rescore(t *testing.T) {
+
+	r := rand.New(rand.NewSource(7))
+
+	// correct targets are separated from the decoys by two independent scores, so their
+	// combination is a better discriminant than any of them alone
+	var list id.PepIDListPtrs
+	for i := 0; i < 3000; i++ {
+
+		p := &id.PeptideIdentification{
+			Spectrum:            fmt.Sprintf("run.%05d.%05d.2", i, i),
+			SpectrumFile:        "run.pep.xml",
+			Peptide:             "PEPTIDEK",
+			Protein:             "sp|P1",
+			AssumedCharge:       2,
+			Hyperscore:          r.NormFloat64(),
+			SpectralSim:         r.NormFloat64(),
+			Expectation:         1,
+			AlternativeProteins: map[string]int{},
+		}
+
+		switch {
+		case i%3 == 0:
+			p.Protein = "rev_sp|P1"
+		case i%3 == 1:
+			p.Hyperscore += 1.5
+			p.SpectralSim += 1.5
+		}
+
+		list = append(list, p)
+	}
+
+	var x = make([][]float64, len(list))
+	var decoy = make([]bool, len(list))
+	for i := range list {
+		x[i] = features(list[i])
+		decoy[i] = i%3 == 0
+	}
+	standardize(x)
+
+	init, _ := bestFeature(x, decoy, 0.05)
+	before := countAccepted(qValues(scoreAll(init, x), decoy), decoy, 0.05)
+
+	for _, model := range []string{"svm", "logistic"} {
+
+		scores, peps := rescore(list, "rev_", model, 0.05, 3, 5)
+		after := countAccepted(qValues(scores, decoy), decoy, 0.05)
+
+		if after <= before {
+			t.Errorf("rescore(%s) accepted %d PSMs, the best single feature accepted %d", model, after, before)
+		}
+
+		for i := range peps {
+			if peps[i] < 0 || peps[i] > 1 {
+				t.Fatalf("rescore(%s) PEP out of range: %f", model, peps[i])
+			}
+		}
+	}
+}
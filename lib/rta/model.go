@@ -0,0 +1,268 @@
+package rta
+
+import (
+	"math"
+	"sort"
+
+	"philosopher/lib/uti"
+)
+
+const (
+	// Loess fits a locally weighted linear regression
+	Loess = "loess"
+
+	// Linear fits a piecewise-linear mapping through binned medians
+	Linear = "linear"
+)
+
+// minPoints is the number of shared ions needed to fit a model
+const minPoints = 10
+
+// linearBins is the number of knots of the piecewise-linear model
+const linearBins = 10
+
+// loessKnots is the number of points where the LOESS curve is evaluated
+const loessKnots = 50
+
+// loessIterations is the number of robustness iterations of the LOESS fit
+const loessIterations = 2
+
+// Model maps the retention times of a run to the retention time scale of a
+// reference run. The curve is stored as knots that are linearly interpolated,
+// the retention times are in seconds
+type Model struct {
+	Run            string
+	Reference      string
+	Method         string
+	X              []float64
+	Y              []float64
+	Points         int
+	Deviation      float64
+	RetentionTimes []float64
+	Residuals      []float64
+}
+
+// Fit builds the model mapping x onto y, where each pair is an ion identified
+// in both runs. The span is the fraction of points used by each LOESS local
+// regression and is ignored by the piecewise-linear method
+func Fit(x, y []float64, method string, span float64) (Model, bool) {
+
+	var m Model
+
+	if len(x) < minPoints || len(x) != len(y) {
+		return m, false
+	}
+
+	type pair struct{ x, y float64 }
+	var pairs = make([]pair, len(x))
+	for i := range x {
+		pairs[i] = pair{x[i], y[i]}
+	}
+
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].x < pairs[j].x })
+
+	var xs = make([]float64, len(pairs))
+	var ys = make([]float64, len(pairs))
+	for i := range pairs {
+		xs[i] = pairs[i].x
+		ys[i] = pairs[i].y
+	}
+
+	m.Method = method
+	m.Points = len(xs)
+
+	if method == Loess {
+		m.X, m.Y = loess(xs, ys, span)
+	} else {
+		m.Method = Linear
+		m.X, m.Y = binnedMedians(xs, ys)
+	}
+
+	var deviations []float64
+	for i := range xs {
+		r := m.Predict(xs[i]) - ys[i]
+		m.RetentionTimes = append(m.RetentionTimes, xs[i])
+		m.Residuals = append(m.Residuals, r)
+		deviations = append(deviations, math.Abs(r))
+	}
+	m.Deviation = uti.Median(deviations)
+
+	return m, true
+}
+
+// Predict maps a retention time of the run to the reference run, the curve is
+// extrapolated with the slope of its first and last segments
+func (m Model) Predict(x float64) float64 {
+
+	if len(m.X) == 0 {
+		return x
+	}
+
+	if len(m.X) == 1 {
+		return x + m.Y[0] - m.X[0]
+	}
+
+	i := sort.SearchFloat64s(m.X, x)
+	if i == 0 {
+		i = 1
+	} else if i >= len(m.X) {
+		i = len(m.X) - 1
+	}
+
+	if m.X[i] == m.X[i-1] {
+		return x + m.Y[i] - m.X[i]
+	}
+
+	slope := (m.Y[i] - m.Y[i-1]) / (m.X[i] - m.X[i-1])
+
+	return m.Y[i-1] + slope*(x-m.X[i-1])
+}
+
+// binnedMedians splits the sorted points in equally populated bins and takes
+// the median of each bin as a knot
+func binnedMedians(x, y []float64) ([]float64, []float64) {
+
+	var kx, ky []float64
+
+	bins := linearBins
+	if len(x)/bins < 3 {
+		bins = len(x) / 3
+	}
+
+	for b := 0; b < bins; b++ {
+		from := b * len(x) / bins
+		to := (b + 1) * len(x) / bins
+		kx = append(kx, uti.Median(x[from:to]))
+		ky = append(ky, uti.Median(y[from:to]))
+	}
+
+	return kx, ky
+}
+
+// loess evaluates a robust locally weighted linear regression on evenly spaced
+// knots, the sorted points are weighted by a tricube function of their distance
+// and by bisquare robustness weights of their residuals
+func loess(x, y []float64, span float64) ([]float64, []float64) {
+
+	n := len(x)
+
+	k := int(math.Ceil(span * float64(n)))
+	if k < minPoints {
+		k = minPoints
+	}
+	if k > n {
+		k = n
+	}
+
+	knots := loessKnots
+	if x[n-1] == x[0] {
+		knots = 1
+	}
+
+	var kx = make([]float64, knots)
+	for i := range kx {
+		if knots == 1 {
+			kx[i] = x[0]
+		} else {
+			kx[i] = x[0] + (x[n-1]-x[0])*float64(i)/float64(knots-1)
+		}
+	}
+
+	var robustness = make([]float64, n)
+	for i := range robustness {
+		robustness[i] = 1
+	}
+
+	var ky = make([]float64, knots)
+
+	for iteration := 0; iteration <= loessIterations; iteration++ {
+
+		for i := range kx {
+			ky[i] = localRegression(x, y, robustness, kx[i], k)
+		}
+
+		if iteration == loessIterations {
+			break
+		}
+
+		m := Model{X: kx, Y: ky}
+
+		var residuals = make([]float64, n)
+		var absolute = make([]float64, n)
+		for i := range x {
+			residuals[i] = y[i] - m.Predict(x[i])
+			absolute[i] = math.Abs(residuals[i])
+		}
+
+		s := uti.Median(absolute)
+		if s == 0 {
+			break
+		}
+
+		for i := range residuals {
+			u := residuals[i] / (6 * s)
+			if math.Abs(u) >= 1 {
+				robustness[i] = 0
+			} else {
+				robustness[i] = (1 - u*u) * (1 - u*u)
+			}
+		}
+	}
+
+	return kx, ky
+}
+
+// localRegression fits a weighted line on the k points nearest to x0 and
+// returns its value at x0
+func localRegression(x, y, robustness []float64, x0 float64, k int) float64 {
+
+	n := len(x)
+
+	// grow the window from the insertion point towards the nearest side
+	lo := sort.SearchFloat64s(x, x0)
+	hi := lo
+	for hi-lo < k {
+		if lo == 0 {
+			hi++
+		} else if hi == n {
+			lo--
+		} else if x0-x[lo-1] <= x[hi]-x0 {
+			lo--
+		} else {
+			hi++
+		}
+	}
+
+	maxDistance := math.Max(x0-x[lo], x[hi-1]-x0) * 1.0001
+	if maxDistance == 0 {
+		maxDistance = 1
+	}
+
+	var sw, swx, swy, swxx, swxy float64
+	for i := lo; i < hi; i++ {
+
+		d := math.Abs(x[i]-x0) / maxDistance
+		w := (1 - d*d*d)
+		w = w * w * w * robustness[i]
+
+		sw += w
+		swx += w * x[i]
+		swy += w * y[i]
+		swxx += w * x[i] * x[i]
+		swxy += w * x[i] * y[i]
+	}
+
+	if sw == 0 {
+		return uti.Median(y[lo:hi])
+	}
+
+	denominator := sw*swxx - swx*swx
+	if math.Abs(denominator) < 1e-12 {
+		return swy / sw
+	}
+
+	slope := (sw*swxy - swx*swy) / denominator
+	intercept := (swy - slope*swx) / sw
+
+	return intercept + slope*x0
+}
@@ -0,0 +1,53 @@
+package rta
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFit(t *testing.T) {
+
+	var x, y []float64
+
+	// a curved drift between the runs with a few outliers
+	for i := 0; i < 200; i++ {
+		rt := 300 + float64(i)*15
+		x = append(x, rt)
+		y = append(y, rt+60*math.Sin(rt/1000))
+	}
+	for _, i := range []int{20, 90, 150} {
+		y[i] += 600
+	}
+
+	for _, method := range []string{Loess, Linear} {
+
+		m, ok := Fit(x, y, method, 0.3)
+		if !ok {
+			t.Fatalf("Fit() with the %s method failed", method)
+		}
+
+		if m.Points != 200 {
+			t.Errorf("Model points are incorrect, got %d, want %d", m.Points, 200)
+		}
+
+		tolerance := 3.0
+		if method == Linear {
+			tolerance = 10
+		}
+
+		for _, rt := range []float64{600, 1500, 2500} {
+			want := rt + 60*math.Sin(rt/1000)
+			if p := m.Predict(rt); math.Abs(p-want) > tolerance {
+				t.Errorf("%s prediction is incorrect, got %f, want %f", method, p, want)
+			}
+		}
+
+		if m.Deviation > tolerance {
+			t.Errorf("%s median absolute residual is too high, got %f", method, m.Deviation)
+		}
+	}
+
+	if _, ok := Fit(x[:5], y[:5], Loess, 0.3); ok {
+		t.Errorf("Fit() should fail with less than %d points", minPoints)
+	}
+}
@@ -0,0 +1,101 @@
+package rta
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"philosopher/lib/msg"
+	"philosopher/lib/sys"
+)
+
+// PlotResiduals plots the residuals of each alignment model against the run
+// retention time, and the fitted calibration curves
+func (a Alignment) PlotResiduals() {
+
+	outfile := fmt.Sprintf("%s%srt-alignment.html", sys.MetaDir(), string(filepath.Separator))
+
+	file, e := os.Create(outfile)
+	if e != nil {
+		msg.WriteFile(errors.New("could not create output for the retention time alignment"), "fatal")
+	}
+	defer file.Close()
+
+	var runs []string
+	for k := range a.Models {
+		runs = append(runs, k)
+	}
+	sort.Strings(runs)
+
+	var residualTraces []string
+	var curveTraces []string
+
+	for i := range runs {
+		residualTraces = append(residualTraces, fmt.Sprintf("trace%d", i+1))
+		curveTraces = append(curveTraces, fmt.Sprintf("curve%d", i+1))
+	}
+
+	io.WriteString(file, "<head>\n")
+	io.WriteString(file, "  <script src=\"https://cdn.plot.ly/plotly-latest.min.js\"></script>\n")
+	io.WriteString(file, "</head>\n")
+	io.WriteString(file, "<body>\n")
+	io.WriteString(file, "<div id=\"residuals\" style=\"width: 1024px; height: 768px;\"></div>\n")
+	io.WriteString(file, "<div id=\"curves\" style=\"width: 1024px; height: 768px;\"></div>\n")
+	io.WriteString(file, "<script>\n")
+
+	for i, run := range runs {
+
+		model := a.Models[run]
+
+		var xvar []string
+		var yvar []string
+		for j := range model.RetentionTimes {
+			xvar = append(xvar, fmt.Sprintf("%.2f", model.RetentionTimes[j]/60))
+			yvar = append(yvar, fmt.Sprintf("%.2f", model.Residuals[j]))
+		}
+
+		var kx []string
+		var ky []string
+		for j := range model.X {
+			kx = append(kx, fmt.Sprintf("%.2f", model.X[j]/60))
+			ky = append(ky, fmt.Sprintf("%.2f", model.Y[j]/60))
+		}
+
+		io.WriteString(file, fmt.Sprintf("var trace%d = {", i+1))
+		io.WriteString(file, fmt.Sprintf("x: [%s],", strings.Join(xvar, ",")))
+		io.WriteString(file, fmt.Sprintf("y: [%s],", strings.Join(yvar, ",")))
+		io.WriteString(file, fmt.Sprintf("name: '%s',", run))
+		io.WriteString(file, "mode: 'markers',")
+		io.WriteString(file, "type: 'scatter',")
+		io.WriteString(file, "marker: {size: 3},")
+		io.WriteString(file, "};\n")
+
+		io.WriteString(file, fmt.Sprintf("var curve%d = {", i+1))
+		io.WriteString(file, fmt.Sprintf("x: [%s],", strings.Join(kx, ",")))
+		io.WriteString(file, fmt.Sprintf("y: [%s],", strings.Join(ky, ",")))
+		io.WriteString(file, fmt.Sprintf("name: '%s',", run))
+		io.WriteString(file, "mode: 'lines',")
+		io.WriteString(file, "type: 'scatter',")
+		io.WriteString(file, "};\n")
+	}
+
+	io.WriteString(file, fmt.Sprintf("var residuals = [%s];\n", strings.Join(residualTraces, ", ")))
+	io.WriteString(file, fmt.Sprintf("var curves = [%s];\n", strings.Join(curveTraces, ", ")))
+	io.WriteString(file, fmt.Sprintf("var residualsLayout = {title: 'Retention Time Alignment Residuals (reference %s, %s)', xaxis: {title: 'retention time (min)'}, yaxis: {title: 'residual (s)'}};\n", a.Reference, a.Method))
+	io.WriteString(file, fmt.Sprintf("var curvesLayout = {title: 'Retention Time Calibration Curves (reference %s)', xaxis: {title: 'run retention time (min)'}, yaxis: {title: 'reference retention time (min)'}};\n", a.Reference))
+	io.WriteString(file, "Plotly.newPlot('residuals', residuals, residualsLayout);\n")
+	io.WriteString(file, "Plotly.newPlot('curves', curves, curvesLayout);\n")
+	io.WriteString(file, "</script>\n")
+	_, e = io.WriteString(file, "</body>")
+
+	if e != nil {
+		msg.Custom(errors.New("there was an error trying to plot the retention time alignment"), "fatal")
+	}
+
+	// copy to work directory
+	sys.CopyFile(outfile, filepath.Base(outfile))
+}
@@ -0,0 +1,232 @@
+// Package rta implements the retention time alignment between runs
+package rta
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"philosopher/lib/id"
+	"philosopher/lib/met"
+	"philosopher/lib/msg"
+	"philosopher/lib/rep"
+	"philosopher/lib/uti"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Alignment holds the retention time models of all runs in a workspace
+type Alignment struct {
+	Reference string
+	Method    string
+	Models    map[string]Model
+}
+
+// Run aligns the retention times of each run in the workspace to a reference
+// run using the confidently identified ions, and stores the aligned retention
+// times on the PSMs and ions
+func Run(m met.Data) met.Data {
+
+	var e rep.Evidence
+	e.RestoreGranular()
+
+	if len(e.PSM) < 1 || len(e.Ions) < 1 {
+		msg.Custom(errors.New("the workspace has no PSMs or ions, run the filter first"), "fatal")
+	}
+
+	runs := ionRetentionTimes(e, m.Align.MinProb)
+
+	if len(runs) < 2 {
+		msg.Custom(errors.New("the retention time alignment needs at least 2 runs in the workspace"), "fatal")
+	}
+
+	reference := m.Align.Reference
+	if len(reference) == 0 {
+		reference = referenceRun(runs)
+	} else if _, ok := runs[reference]; !ok {
+		msg.Custom(errors.New("the reference run was not found in the workspace"), "fatal")
+	}
+
+	logrus.Info("Aligning ", len(runs), " runs to ", reference)
+
+	a := Alignment{Reference: reference, Method: m.Align.Method, Models: make(map[string]Model)}
+
+	for run, ions := range runs {
+
+		if run == reference {
+			continue
+		}
+
+		var x, y []float64
+		for k, v := range ions {
+			if r, ok := runs[reference][k]; ok {
+				x = append(x, v)
+				y = append(y, r)
+			}
+		}
+
+		model, ok := Fit(x, y, m.Align.Method, m.Align.Span)
+		if !ok {
+			msg.Custom(errors.New("not enough shared ions to align "+run+", its retention times are left unaligned"), "warning")
+			continue
+		}
+
+		model.Run = run
+		model.Reference = reference
+		a.Models[run] = model
+
+		logrus.Info(fmt.Sprintf("%s: %d shared ions, median absolute residual %.2f s", run, model.Points, model.Deviation))
+	}
+
+	e = a.alignEvidence(e)
+
+	e.SerializeGranular()
+	a.PlotResiduals()
+
+	m.Align.Models = a.calibrations()
+
+	return m
+}
+
+// ionRetentionTimes collects the retention time of each confident ion in each
+// run, taken from its best scoring PSM or from the chromatographic apex when
+// the ion was quantified
+func ionRetentionTimes(e rep.Evidence, minProb float64) map[string]map[id.IonFormType]float64 {
+
+	var confident = make(map[id.IonFormType]bool)
+	for _, i := range e.Ions {
+		if !i.IsDecoy && i.Probability >= minProb {
+			confident[i.IonForm()] = true
+		}
+	}
+
+	var runs = make(map[string]map[id.IonFormType]float64)
+	var best = make(map[string]map[id.IonFormType]float64)
+
+	for _, i := range e.PSM {
+
+		ion := i.IonForm()
+		if !confident[ion] {
+			continue
+		}
+
+		run := runName(i.Spectrum)
+		if _, ok := runs[run]; !ok {
+			runs[run] = make(map[id.IonFormType]float64)
+			best[run] = make(map[id.IonFormType]float64)
+		}
+
+		if p, ok := best[run][ion]; ok && p >= i.Probability {
+			continue
+		}
+
+		runs[run][ion] = retentionTime(i)
+		best[run][ion] = i.Probability
+	}
+
+	return runs
+}
+
+// referenceRun picks the run with the most confident ions
+func referenceRun(runs map[string]map[id.IonFormType]float64) string {
+
+	var names []string
+	for k := range runs {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var reference string
+	for _, i := range names {
+		if len(runs[i]) > len(runs[reference]) {
+			reference = i
+		}
+	}
+
+	return reference
+}
+
+// retentionTime returns the chromatographic apex of a quantified PSM, or its
+// MS2 retention time otherwise. The models are fitted and applied on it
+func retentionTime(psm rep.PSMEvidence) float64 {
+
+	if psm.ApexRetentionTime > 0 {
+		return psm.ApexRetentionTime
+	}
+
+	return psm.RetentionTime
+}
+
+// alignEvidence sets the aligned retention time of the PSMs, and of the ions as
+// the median over their PSMs
+func (a Alignment) alignEvidence(e rep.Evidence) rep.Evidence {
+
+	var ions = make(map[id.IonFormType][]float64)
+
+	for i := range e.PSM {
+
+		run := runName(e.PSM[i].Spectrum)
+
+		if run == a.Reference {
+			e.PSM[i].AlignedRetentionTime = retentionTime(e.PSM[i])
+		} else if model, ok := a.Models[run]; ok {
+			e.PSM[i].AlignedRetentionTime = model.Predict(retentionTime(e.PSM[i]))
+		} else {
+			e.PSM[i].AlignedRetentionTime = 0
+			continue
+		}
+
+		ions[e.PSM[i].IonForm()] = append(ions[e.PSM[i].IonForm()], e.PSM[i].AlignedRetentionTime)
+	}
+
+	for i := range e.Ions {
+		e.Ions[i].AlignedRetentionTime = uti.Median(ions[e.Ions[i].IonForm()])
+	}
+
+	return e
+}
+
+// runName returns the source file name from a spectrum name
+func runName(spectrum string) string {
+	return strings.Split(spectrum, ".")[0]
+}
+
+// calibrations returns the models in the form stored on the workspace meta data
+func (a Alignment) calibrations() map[string]met.Calibration {
+
+	var c = make(map[string]met.Calibration)
+	for k, v := range a.Models {
+		c[k] = met.Calibration{
+			Reference: v.Reference,
+			Method:    v.Method,
+			X:         v.X,
+			Y:         v.Y,
+			Points:    v.Points,
+			Deviation: v.Deviation,
+		}
+	}
+
+	return c
+}
+
+// Restore reads the alignment models stored on the workspace meta data
+func Restore(m met.Data) Alignment {
+
+	a := Alignment{Method: m.Align.Method, Models: make(map[string]Model)}
+
+	for k, v := range m.Align.Models {
+		a.Reference = v.Reference
+		a.Models[k] = Model{
+			Run:       k,
+			Reference: v.Reference,
+			Method:    v.Method,
+			X:         v.X,
+			Y:         v.Y,
+			Points:    v.Points,
+			Deviation: v.Deviation,
+		}
+	}
+
+	return a
+}
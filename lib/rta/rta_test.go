@@ -0,0 +1,65 @@
+package rta
+
+import (
+	"math"
+	"testing"
+
+	"philosopher/lib/met"
+	"philosopher/lib/rep"
+)
+
+func TestAlignment_alignEvidence(t *testing.T) {
+
+	var e rep.Evidence
+	var x, y []float64
+
+	// the run elutes 60 seconds later than the reference, its PSMs were
+	// identified 20 seconds after the chromatographic apex
+	for i := 0; i < 20; i++ {
+		apex := 600 + float64(i)*60
+		x = append(x, apex+60)
+		y = append(y, apex)
+	}
+
+	m, ok := Fit(x, y, Linear, 0)
+	if !ok {
+		t.Fatalf("Fit() failed with %d points", len(x))
+	}
+
+	a := Alignment{Reference: "ref", Models: map[string]Model{"run": m}}
+
+	e.PSM = rep.PSMEvidenceList{
+		{Spectrum: "run.01000.01000.2", Peptide: "PEPTIDE", RetentionTime: 1280, ApexRetentionTime: 1260},
+		{Spectrum: "ref.01000.01000.2", Peptide: "PEPTIDE", RetentionTime: 1220, ApexRetentionTime: 1200},
+		{Spectrum: "run.02000.02000.2", Peptide: "PEPTIDER", RetentionTime: 1860},
+	}
+
+	e = a.alignEvidence(e)
+
+	for i, want := range []float64{1200, 1200, 1800} {
+		if math.Abs(e.PSM[i].AlignedRetentionTime-want) > 1e-6 {
+			t.Errorf("Aligned retention time is incorrect, got %f, want %f", e.PSM[i].AlignedRetentionTime, want)
+		}
+	}
+}
+
+func TestRestore(t *testing.T) {
+
+	var x, y []float64
+	for i := 0; i < 20; i++ {
+		x = append(x, float64(i)*60+30)
+		y = append(y, float64(i)*60)
+	}
+
+	m, _ := Fit(x, y, Loess, 0.3)
+	m.Reference = "ref"
+	a := Alignment{Reference: "ref", Method: Loess, Models: map[string]Model{"run": m}}
+
+	var d met.Data
+	d.Align.Models = a.calibrations()
+	restored := Restore(d)
+
+	if restored.Reference != "ref" || restored.Models["run"].Predict(630) != m.Predict(630) {
+		t.Errorf("Restored alignment is incorrect, got %v", restored)
+	}
+}
@@ -158,6 +158,18 @@ func ProBin() string {
 	return p
 }
 
+// SiteBin file
+func SiteBin() string {
+	p := fmt.Sprintf("%s%ssite.bin", MetaDir(), string(filepath.Separator))
+	return p
+}
+
+// SearchBin file
+func SearchBin() string {
+	p := fmt.Sprintf("%s%ssearch.bin", MetaDir(), string(filepath.Separator))
+	return p
+}
+
 // DBBin file
 func DBBin() string {
 	p := fmt.Sprintf("%s%sdb.bin", MetaDir(), string(filepath.Separator))
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"philosopher/lib/msg"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -114,6 +115,38 @@ func GetLabelNames(annot string) map[string]string {
 	return labels
 }
 
+// GetReferenceChannels lists the channels marked as reference, bridge or pool on the third column of the annotation file
+func GetReferenceChannels(annot string) []string {
+
+	var channels []string
+
+	file, e := os.Open(annot)
+	if e != nil {
+		msg.ReadFile(e, "fatal")
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+
+		names := strings.Fields(scanner.Text())
+		if len(names) < 3 {
+			continue
+		}
+
+		switch strings.ToLower(names[2]) {
+		case "reference", "bridge", "pool":
+			channels = append(channels, names[0])
+		}
+	}
+
+	if e = scanner.Err(); e != nil {
+		msg.ReadFile(e, "fatal")
+	}
+
+	return channels
+}
+
 // FindFile locates a file based on a name pattern
 func FindFile(targetDir string, pattern string) string {
 
@@ -205,3 +238,172 @@ func RemoveDuplicateStrings(slice []string) []string {
 	copy(list2, list)
 	return list2
 }
+
+// Mean returns the average of the values, zero for an empty list
+func Mean(values []float64) float64 {
+
+	if len(values) == 0 {
+		return 0
+	}
+
+	var sum float64
+	for _, i := range values {
+		sum += i
+	}
+
+	return sum / float64(len(values))
+}
+
+// Median returns the median of the values, zero for an empty list
+func Median(values []float64) float64 {
+
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// QValueMap converts the FDR estimated for each score block into a monotone q-value,
+// the lowest FDR at which an identification with that score is accepted
+func QValueMap(scoreMap map[float64]float64) map[float64]float64 {
+
+	var keys []float64
+	for k := range scoreMap {
+		keys = append(keys, k)
+	}
+
+	sort.Float64s(keys)
+
+	var qValues = make(map[float64]float64)
+	var q = 1.0
+
+	for _, k := range keys {
+		if scoreMap[k] < q {
+			q = scoreMap[k]
+		}
+		qValues[k] = q
+	}
+
+	return qValues
+}
+
+// PEPMap estimates the posterior error probability for each score block. The decoy
+// rate is fitted with an isotonic regression (pool adjacent violators) so that
+// worse scores never get a lower error probability, and then converted to the
+// ratio between the expected number of incorrect and correct target hits.
+func PEPMap(targets, decoys map[float64]float64) map[float64]float64 {
+
+	var keys []float64
+	for k := range targets {
+		keys = append(keys, k)
+	}
+
+	for k := range decoys {
+		if _, ok := targets[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Sort(sort.Reverse(sort.Float64Slice(keys)))
+
+	type block struct {
+		rate   float64
+		weight float64
+		keys   []float64
+	}
+
+	var blocks []block
+	for _, k := range keys {
+
+		weight := targets[k] + decoys[k]
+		if weight == 0 {
+			continue
+		}
+
+		b := block{rate: decoys[k] / weight, weight: weight, keys: []float64{k}}
+
+		// merge the blocks that violate the monotonicity
+		for len(blocks) > 0 && blocks[len(blocks)-1].rate > b.rate {
+			last := blocks[len(blocks)-1]
+			blocks = blocks[:len(blocks)-1]
+
+			b.rate = (last.rate*last.weight + b.rate*b.weight) / (last.weight + b.weight)
+			b.weight += last.weight
+			b.keys = append(last.keys, b.keys...)
+		}
+
+		blocks = append(blocks, b)
+	}
+
+	var peps = make(map[float64]float64)
+	for _, b := range blocks {
+
+		pep := 1.0
+		if b.rate < 0.5 {
+			pep = b.rate / (1 - b.rate)
+		}
+
+		for _, k := range b.keys {
+			peps[k] = pep
+		}
+	}
+
+	return peps
+}
+
+// Solve resolves the linear system by Gaussian elimination with partial pivoting, the
+// system is modified in place and the unknowns without a pivot are left at zero
+func Solve(a [][]float64, b []float64) []float64 {
+
+	n := len(b)
+
+	for c := 0; c < n; c++ {
+
+		pivot := c
+		for r := c + 1; r < n; r++ {
+			if math.Abs(a[r][c]) > math.Abs(a[pivot][c]) {
+				pivot = r
+			}
+		}
+		a[c], a[pivot] = a[pivot], a[c]
+		b[c], b[pivot] = b[pivot], b[c]
+
+		if a[c][c] == 0 {
+			continue
+		}
+
+		for r := c + 1; r < n; r++ {
+			f := a[r][c] / a[c][c]
+			for k := c; k < n; k++ {
+				a[r][k] -= f * a[c][k]
+			}
+			b[r] -= f * b[c]
+		}
+	}
+
+	var x = make([]float64, n)
+	for r := n - 1; r >= 0; r-- {
+
+		if a[r][r] == 0 {
+			continue
+		}
+
+		sum := b[r]
+		for k := r + 1; k < n; k++ {
+			sum -= a[r][k] * x[k]
+		}
+		x[r] = sum / a[r][r]
+	}
+
+	return x
+}
@@ -1,6 +1,7 @@
 package uti_test
 
 import (
+	"math"
 	"philosopher/lib/tes"
 	"philosopher/lib/uti"
 	"testing"
@@ -21,3 +22,80 @@ func TestUti(t *testing.T) {
 	}
 
 }
+
+func TestMedian(t *testing.T) {
+
+	values := []float64{4, 1, 3, 2}
+
+	if m := uti.Median(values); m != 2.5 {
+		t.Errorf("Median is incorrect, got %f, want %f", m, 2.5)
+	}
+
+	if values[0] != 4 {
+		t.Errorf("Median should not sort the values, got %v", values)
+	}
+
+	if m := uti.Median(values[:3]); m != 3 {
+		t.Errorf("Median is incorrect, got %f, want %f", m, 3.0)
+	}
+
+	if m := uti.Mean(values); m != 2.5 {
+		t.Errorf("Mean is incorrect, got %f, want %f", m, 2.5)
+	}
+
+	if uti.Median(nil) != 0 || uti.Mean(nil) != 0 {
+		t.Errorf("Median and mean of an empty list should be zero")
+	}
+}
+
+func TestQValueMap(t *testing.T) {
+
+	scoreMap := map[float64]float64{1.0: 0.0, 0.9: 0.02, 0.8: 0.01, 0.5: 0.2}
+
+	got := uti.QValueMap(scoreMap)
+
+	want := map[float64]float64{1.0: 0.0, 0.9: 0.01, 0.8: 0.01, 0.5: 0.2}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("QValueMap() score %.2f = %f, want %f", k, got[k], v)
+		}
+	}
+}
+
+func TestPEPMap(t *testing.T) {
+
+	targets := map[float64]float64{1.0: 10, 0.9: 4, 0.8: 6, 0.5: 1}
+	decoys := map[float64]float64{0.9: 1, 0.5: 3}
+
+	got := uti.PEPMap(targets, decoys)
+
+	if got[1.0] != 0 {
+		t.Errorf("PEPMap() score 1.00 = %f, want 0", got[1.0])
+	}
+
+	// 0.9 and 0.8 violate the monotonicity and are pooled together
+	if got[0.9] != got[0.8] || got[0.8] != (1.0/11.0)/(10.0/11.0) {
+		t.Errorf("PEPMap() pooled blocks = %f and %f, want %f", got[0.9], got[0.8], 0.1)
+	}
+
+	if got[0.5] != 1 {
+		t.Errorf("PEPMap() score 0.50 = %f, want 1", got[0.5])
+	}
+}
+
+func TestSolve(t *testing.T) {
+
+	// the first row needs a pivot swap
+	a := [][]float64{{0, 2, 1}, {1, 1, 0}, {2, 0, 3}}
+	b := []float64{7, 3, 11}
+
+	x := uti.Solve(a, b)
+
+	want := []float64{1, 2, 3}
+	for i := range want {
+		if math.Abs(x[i]-want[i]) > 1e-9 {
+			t.Errorf("Solve() = %v, want %v", x, want)
+			break
+		}
+	}
+}